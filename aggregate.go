@@ -0,0 +1,85 @@
+package merkletree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+// WeightedLeafHash returns the aggregate-tree leaf committing to a sub-tree root
+// and its weight: H(subRoot || uint64(weight)), with the weight big-endian.
+func WeightedLeafHash(subRoot []byte, weight uint64, h func() hash.Hash) ([]byte, error) {
+	var w [8]byte
+	binary.BigEndian.PutUint64(w[:], weight)
+
+	hasher := h()
+	if _, err := hasher.Write(append(append([]byte(nil), subRoot...), w[:]...)); err != nil {
+		return nil, err
+	}
+	return hasher.Sum(nil), nil
+}
+
+// AggregateRoot builds a super-tree over the roots of trees, each paired with the
+// weight at the same index, and returns its root.
+func AggregateRoot(trees []*MerkleTree, weights []uint64, h func() hash.Hash) ([]byte, error) {
+	agg, _, err := buildAggregate(trees, weights, h)
+	if err != nil {
+		return nil, err
+	}
+	return agg.MerkleRoot(), nil
+}
+
+// AggregateProof returns the proof of the weighted leaf of trees[index] in the
+// super-tree built by AggregateRoot.
+func AggregateProof(trees []*MerkleTree, weights []uint64, index int, h func() hash.Hash) (*MerkleProof, error) {
+	if index < 0 || index >= len(trees) {
+		return nil, fmt.Errorf("error: tree index %d out of range", index)
+	}
+
+	agg, leaves, err := buildAggregate(trees, weights, h)
+	if err != nil {
+		return nil, err
+	}
+	return agg.GetProof(leaves[index])
+}
+
+// VerifyWeightedProof verifies that leafHash is in a sub-tree with root subRoot
+// (subProof), and that this sub-tree was committed with weight under aggRoot
+// (aggProof). Both levels are hashed with h.
+func VerifyWeightedProof(leafHash []byte, subProof *MerkleProof, subRoot []byte, weight uint64, aggProof *MerkleProof, aggRoot []byte, h func() hash.Hash) (bool, error) {
+	ok, err := VerifyAnonymousProof(leafHash, subProof, subRoot, h)
+	if err != nil || !ok {
+		return false, err
+	}
+
+	weighted, err := WeightedLeafHash(subRoot, weight, h)
+	if err != nil {
+		return false, err
+	}
+	return VerifyAnonymousProof(weighted, aggProof, aggRoot, h)
+}
+
+func buildAggregate(trees []*MerkleTree, weights []uint64, h func() hash.Hash) (*MerkleTree, []Content, error) {
+	if len(trees) != len(weights) {
+		return nil, nil, fmt.Errorf("error: %d trees but %d weights", len(trees), len(weights))
+	}
+	if len(trees) == 0 {
+		return nil, nil, errors.New("error: cannot aggregate no trees")
+	}
+
+	leaves := make([]Content, len(trees))
+	for i, t := range trees {
+		weighted, err := WeightedLeafHash(t.MerkleRoot(), weights[i], h)
+		if err != nil {
+			return nil, nil, err
+		}
+		leaves[i] = HashContent(weighted)
+	}
+
+	agg, err := NewTreeWithHashStrategy(leaves, h)
+	if err != nil {
+		return nil, nil, err
+	}
+	return agg, leaves, nil
+}
@@ -0,0 +1,48 @@
+package merkletree
+
+import (
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+func Test_AggregateRoot(t *testing.T) {
+	leaves := testLeaves(10)
+	var trees []*MerkleTree
+	for _, part := range [][]Content{leaves[:3], leaves[3:7], leaves[7:]} {
+		tree, err := NewTree(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		trees = append(trees, tree)
+	}
+	weights := []uint64{100, 250, 7}
+
+	aggRoot, err := AggregateRoot(trees, weights, sha3.NewLegacyKeccak256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// prove leaves[4] through the second, weighted sub-tree
+	subProof, err := trees[1].GetProof(leaves[4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	aggProof, err := AggregateProof(trees, weights, 1, sha3.NewLegacyKeccak256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafHash, _ := leaves[4].CalculateHash()
+
+	ok, err := VerifyWeightedProof(leafHash, subProof, trees[1].MerkleRoot(), 250, aggProof, aggRoot, sha3.NewLegacyKeccak256)
+	if err != nil || !ok {
+		t.Fatal("weighted proof does not verify")
+	}
+	if ok, _ := VerifyWeightedProof(leafHash, subProof, trees[1].MerkleRoot(), 251, aggProof, aggRoot, sha3.NewLegacyKeccak256); ok {
+		t.Fatal("wrong weight must not verify")
+	}
+
+	if _, err := AggregateRoot(trees, weights[:2], sha3.NewLegacyKeccak256); err == nil {
+		t.Fatal("expected error for mismatched weights")
+	}
+}
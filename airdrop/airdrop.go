@@ -0,0 +1,158 @@
+// Package airdrop builds token distributions claimable with merkle proofs, in
+// the format of Uniswap's MerkleDistributor: recipient i with amount a has the
+// leaf keccak256(abi.encodePacked(uint256 i, address account, uint256 a)), the
+// leaves form a keccak256 tree with sorted pairs, and the claims file maps each
+// account to its index, amount and proof.
+package airdrop
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/smartbch/merkletree"
+)
+
+// Recipient is an account and the amount it may claim.
+type Recipient struct {
+	Address common.Address
+	Amount  *big.Int
+}
+
+// Claim is what an account submits to the distributor contract.
+type Claim struct {
+	Index  uint64        `json:"index"`
+	Amount *hexutil.Big  `json:"amount"`
+	Proof  []common.Hash `json:"proof"`
+}
+
+// Distribution is the claims file of a distribution. Claims are keyed by the
+// checksummed address of each account.
+type Distribution struct {
+	MerkleRoot common.Hash       `json:"merkleRoot"`
+	TokenTotal *hexutil.Big      `json:"tokenTotal"`
+	Claims     map[string]*Claim `json:"claims"`
+}
+
+// New builds the distribution of recipients. As in Uniswap's parse-balance-map,
+// indexes follow the order of the checksummed addresses, every amount must be
+// positive and an account may appear only once.
+func New(recipients []Recipient) (*Distribution, error) {
+	byAddress := make(map[string]*big.Int, len(recipients))
+	for _, r := range recipients {
+		if r.Amount == nil || r.Amount.Sign() <= 0 || r.Amount.BitLen() > 256 {
+			return nil, fmt.Errorf("error: invalid amount for %s", r.Address.Hex())
+		}
+		key := r.Address.Hex()
+		if _, ok := byAddress[key]; ok {
+			return nil, fmt.Errorf("error: duplicate address %s", key)
+		}
+		byAddress[key] = r.Amount
+	}
+	if len(byAddress) == 0 {
+		return nil, errors.New("error: no recipients")
+	}
+	addresses := make([]string, 0, len(byAddress))
+	for key := range byAddress {
+		addresses = append(addresses, key)
+	}
+	sort.Strings(addresses)
+
+	total := new(big.Int)
+	leaves := make([]merkletree.Content, len(addresses))
+	for i, key := range addresses {
+		amount := byAddress[key]
+		total.Add(total, amount)
+		leaf := LeafHash(uint64(i), common.HexToAddress(key), amount)
+		leaves[i] = merkletree.HashContent(leaf[:])
+	}
+	tree, err := merkletree.NewTree(leaves)
+	if err != nil {
+		return nil, err
+	}
+
+	d := &Distribution{
+		MerkleRoot: common.BytesToHash(tree.MerkleRoot()),
+		TokenTotal: (*hexutil.Big)(total),
+		Claims:     make(map[string]*Claim, len(addresses)),
+	}
+	for i, key := range addresses {
+		_, proof, _, err := tree.GetSolidityProof(leaves[i])
+		if err != nil {
+			return nil, err
+		}
+		claim := &Claim{
+			Index:  uint64(i),
+			Amount: (*hexutil.Big)(new(big.Int).Set(byAddress[key])),
+			Proof:  make([]common.Hash, len(proof)),
+		}
+		for j, p := range proof {
+			claim.Proof[j] = p
+		}
+		d.Claims[key] = claim
+	}
+	return d, nil
+}
+
+// ClaimOf returns the claim of account, or nil if it has none.
+func (d *Distribution) ClaimOf(account common.Address) *Claim {
+	return d.Claims[account.Hex()]
+}
+
+// WriteJSON writes the claims file of d to w.
+func (d *Distribution) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
+
+// ReadJSON reads a claims file from r and checks every claim against its root.
+func ReadJSON(r io.Reader) (*Distribution, error) {
+	var d Distribution
+	if err := json.NewDecoder(r).Decode(&d); err != nil {
+		return nil, err
+	}
+	for key, claim := range d.Claims {
+		if !common.IsHexAddress(key) || claim.Amount == nil {
+			return nil, fmt.Errorf("error: invalid claim of %s", key)
+		}
+		if !VerifyClaim(d.MerkleRoot, claim.Index, common.HexToAddress(key), claim.Amount.ToInt(), claim.Proof) {
+			return nil, fmt.Errorf("error: claim of %s does not verify", key)
+		}
+	}
+	return &d, nil
+}
+
+// LeafHash returns keccak256(abi.encodePacked(uint256 index, address account,
+// uint256 amount)), the leaf of a claim.
+func LeafHash(index uint64, account common.Address, amount *big.Int) common.Hash {
+	return crypto.Keccak256Hash(
+		math.U256Bytes(new(big.Int).SetUint64(index)),
+		account[:],
+		math.U256Bytes(new(big.Int).Set(amount)),
+	)
+}
+
+// VerifyClaim checks a claim the way MerkleDistributor.claim does.
+func VerifyClaim(root common.Hash, index uint64, account common.Address, amount *big.Int, proof []common.Hash) bool {
+	if amount.Sign() < 0 || amount.BitLen() > 256 {
+		return false
+	}
+	computed := LeafHash(index, account, amount)
+	for _, p := range proof {
+		if bytes.Compare(computed[:], p[:]) <= 0 {
+			computed = crypto.Keccak256Hash(computed[:], p[:])
+		} else {
+			computed = crypto.Keccak256Hash(p[:], computed[:])
+		}
+	}
+	return computed == root
+}
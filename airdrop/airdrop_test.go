@@ -0,0 +1,121 @@
+package airdrop
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+func testRecipients(n int) []Recipient {
+	rs := make([]Recipient, n)
+	for i := range rs {
+		rs[i] = Recipient{
+			Address: common.BytesToAddress(crypto.Keccak256([]byte{byte(i)})),
+			Amount:  big.NewInt(int64(100 * (i + 1))),
+		}
+	}
+	return rs
+}
+
+func Test_LeafHash(t *testing.T) {
+	account := common.HexToAddress("0xf39Fd6e51aad88F6F4ce6aB8827279cffFb92266")
+	// abi.encodePacked(uint256(1), account, uint256(1000))
+	packed := append(common.LeftPadBytes([]byte{1}, 32), account[:]...)
+	packed = append(packed, common.LeftPadBytes(big.NewInt(1000).Bytes(), 32)...)
+	if LeafHash(1, account, big.NewInt(1000)) != crypto.Keccak256Hash(packed) {
+		t.Fatal("leaf differs from abi.encodePacked")
+	}
+}
+
+func Test_Distribution(t *testing.T) {
+	rs := testRecipients(7)
+	d, err := New(rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.TokenTotal.ToInt().Int64() != 2800 || len(d.Claims) != 7 {
+		t.Fatalf("unexpected total %v over %d claims", d.TokenTotal, len(d.Claims))
+	}
+
+	seen := make(map[uint64]bool)
+	var last string
+	for _, r := range rs {
+		claim := d.ClaimOf(r.Address)
+		if claim == nil || claim.Amount.ToInt().Cmp(r.Amount) != 0 {
+			t.Fatal("missing claim")
+		}
+		if !VerifyClaim(d.MerkleRoot, claim.Index, r.Address, r.Amount, claim.Proof) {
+			t.Fatalf("claim of %s does not verify", r.Address.Hex())
+		}
+		if VerifyClaim(d.MerkleRoot, claim.Index, r.Address, big.NewInt(1), claim.Proof) {
+			t.Fatal("claim verifies with another amount")
+		}
+		if VerifyClaim(d.MerkleRoot, claim.Index+1, r.Address, r.Amount, claim.Proof) {
+			t.Fatal("claim verifies with another index")
+		}
+		seen[claim.Index] = true
+	}
+	// indexes follow the sorted checksummed addresses
+	for i := uint64(0); i < 7; i++ {
+		for key, claim := range d.Claims {
+			if claim.Index == i {
+				if key < last {
+					t.Fatal("indexes out of address order")
+				}
+				last = key
+			}
+		}
+	}
+	if len(seen) != 7 {
+		t.Fatal("expected distinct indexes")
+	}
+
+	var buf bytes.Buffer
+	if err := d.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadJSON(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if read.MerkleRoot != d.MerkleRoot || read.TokenTotal.ToInt().Cmp(d.TokenTotal.ToInt()) != 0 {
+		t.Fatal("claims file changed in a round trip")
+	}
+
+	tampered := bytes.Replace(buf.Bytes(), []byte(`"amount": "0x64"`), []byte(`"amount": "0x65"`), 1)
+	if _, err := ReadJSON(bytes.NewReader(tampered)); err == nil {
+		t.Fatal("expected error for a tampered claims file")
+	}
+}
+
+func Test_DistributionErrors(t *testing.T) {
+	if _, err := New(nil); err == nil {
+		t.Fatal("expected error without recipients")
+	}
+	rs := testRecipients(3)
+	if _, err := New(append(rs, rs[1])); err == nil {
+		t.Fatal("expected error for a duplicate address")
+	}
+	rs[2].Amount = big.NewInt(0)
+	if _, err := New(rs); err == nil {
+		t.Fatal("expected error for an amount of zero")
+	}
+	rs[2].Amount = new(big.Int).Lsh(big.NewInt(1), 256)
+	if _, err := New(rs); err == nil {
+		t.Fatal("expected error for an amount past uint256")
+	}
+
+	// a single recipient has an empty proof
+	d, err := New(testRecipients(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, claim := range d.Claims {
+		if len(claim.Proof) != 0 {
+			t.Fatal("expected an empty proof")
+		}
+	}
+}
@@ -0,0 +1,244 @@
+package airdrop
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// LoadOption configures LoadCSV and LoadJSON.
+type LoadOption func(*loader)
+
+type loader struct {
+	addressField string
+	amountField  string
+	decimals     int
+}
+
+// AddressField sets the name of the address column, "address" by default.
+func AddressField(name string) LoadOption {
+	return func(l *loader) {
+		l.addressField = name
+	}
+}
+
+// AmountField sets the name of the amount column, "amount" by default.
+func AmountField(name string) LoadOption {
+	return func(l *loader) {
+		l.amountField = name
+	}
+}
+
+// Decimals makes amounts count whole tokens of n decimals: "1.5" with 18
+// decimals is 1500000000000000000 base units. Amounts are taken as base units by
+// default. Hex amounts with a 0x prefix are always base units.
+func Decimals(n int) LoadOption {
+	return func(l *loader) {
+		l.decimals = n
+	}
+}
+
+func newLoader(opts []LoadOption) *loader {
+	l := &loader{addressField: "address", amountField: "amount"}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// LoadCSV reads a balance snapshot as CSV with a header row from r and hands each
+// recipient to fn as it is parsed, so snapshots of any size are read in constant
+// memory. Columns other than the address and amount ones are ignored. It stops
+// with the first error of fn.
+func LoadCSV(r io.Reader, fn func(Recipient) error, opts ...LoadOption) error {
+	l := newLoader(opts)
+	cr := csv.NewReader(r)
+	cr.ReuseRecord = true
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	addressCol, amountCol := -1, -1
+	for i, name := range header {
+		switch strings.TrimSpace(name) {
+		case l.addressField:
+			addressCol = i
+		case l.amountField:
+			amountCol = i
+		}
+	}
+	if addressCol < 0 || amountCol < 0 {
+		return fmt.Errorf("error: CSV header lacks the %q or %q column", l.addressField, l.amountField)
+	}
+
+	for {
+		record, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		line, _ := cr.FieldPos(0)
+		if addressCol >= len(record) || amountCol >= len(record) {
+			return fmt.Errorf("error: line %d: missing column", line)
+		}
+		rec, err := l.recipient(record[addressCol], record[amountCol])
+		if err != nil {
+			return fmt.Errorf("error: line %d: %w", line, err)
+		}
+		if err := fn(rec); err != nil {
+			return err
+		}
+	}
+}
+
+// LoadJSON reads a balance snapshot as JSON from r and hands each recipient to fn
+// as it is parsed, so snapshots of any size are read in constant memory. The
+// snapshot is either an array of objects with an address and an amount field,
+// or an object mapping addresses to amounts as read by Uniswap's
+// parse-balance-map. Amounts are strings or numbers. It stops with the first
+// error of fn.
+func LoadJSON(r io.Reader, fn func(Recipient) error, opts ...LoadOption) error {
+	l := newLoader(opts)
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	switch tok {
+	case json.Delim('['):
+		for i := 0; dec.More(); i++ {
+			var fields map[string]interface{}
+			if err := dec.Decode(&fields); err != nil {
+				return err
+			}
+			address, _ := fields[l.addressField].(string)
+			amount, ok := jsonAmount(fields[l.amountField])
+			if address == "" || !ok {
+				return fmt.Errorf("error: entry %d lacks the %q or %q field", i, l.addressField, l.amountField)
+			}
+			rec, err := l.recipient(address, amount)
+			if err != nil {
+				return fmt.Errorf("error: entry %d: %w", i, err)
+			}
+			if err := fn(rec); err != nil {
+				return err
+			}
+		}
+	case json.Delim('{'):
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			address, _ := tok.(string)
+			var value interface{}
+			if err := dec.Decode(&value); err != nil {
+				return err
+			}
+			amount, ok := jsonAmount(value)
+			if !ok {
+				return fmt.Errorf("error: %s: invalid amount", address)
+			}
+			rec, err := l.recipient(address, amount)
+			if err != nil {
+				return fmt.Errorf("error: %s: %w", address, err)
+			}
+			if err := fn(rec); err != nil {
+				return err
+			}
+		}
+	default:
+		return errors.New("error: snapshot must be a JSON array or object")
+	}
+	_, err = dec.Token()
+	return err
+}
+
+// NewFromCSV builds the distribution of the snapshot read by LoadCSV.
+func NewFromCSV(r io.Reader, opts ...LoadOption) (*Distribution, error) {
+	var rs []Recipient
+	if err := LoadCSV(r, func(rec Recipient) error {
+		rs = append(rs, rec)
+		return nil
+	}, opts...); err != nil {
+		return nil, err
+	}
+	return New(rs)
+}
+
+// NewFromJSON builds the distribution of the snapshot read by LoadJSON.
+func NewFromJSON(r io.Reader, opts ...LoadOption) (*Distribution, error) {
+	var rs []Recipient
+	if err := LoadJSON(r, func(rec Recipient) error {
+		rs = append(rs, rec)
+		return nil
+	}, opts...); err != nil {
+		return nil, err
+	}
+	return New(rs)
+}
+
+func jsonAmount(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case json.Number:
+		return v.String(), true
+	}
+	return "", false
+}
+
+func (l *loader) recipient(address, amount string) (Recipient, error) {
+	address = strings.TrimSpace(address)
+	if !common.IsHexAddress(address) {
+		return Recipient{}, fmt.Errorf("invalid address %q", address)
+	}
+	value, err := l.parseAmount(strings.TrimSpace(amount))
+	if err != nil {
+		return Recipient{}, err
+	}
+	return Recipient{Address: common.HexToAddress(address), Amount: value}, nil
+}
+
+// parseAmount returns amount in base units.
+func (l *loader) parseAmount(amount string) (*big.Int, error) {
+	if strings.HasPrefix(amount, "0x") || strings.HasPrefix(amount, "0X") {
+		value, ok := new(big.Int).SetString(amount[2:], 16)
+		if !ok {
+			return nil, fmt.Errorf("invalid amount %q", amount)
+		}
+		return value, nil
+	}
+
+	whole, frac, _ := strings.Cut(amount, ".")
+	if len(frac) > l.decimals {
+		// trailing zeros past the decimals do not change the amount
+		if strings.TrimRight(frac[l.decimals:], "0") != "" {
+			return nil, fmt.Errorf("amount %q has more than %d decimals", amount, l.decimals)
+		}
+		frac = frac[:l.decimals]
+	}
+	digits := whole + frac + strings.Repeat("0", l.decimals-len(frac))
+	if whole == "" || strings.ContainsAny(digits, "+-") {
+		return nil, fmt.Errorf("invalid amount %q", amount)
+	}
+	value, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid amount %q", amount)
+	}
+	return value, nil
+}
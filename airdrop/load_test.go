@@ -0,0 +1,120 @@
+package airdrop
+
+import (
+	"errors"
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const (
+	addr1 = "0x1111111111111111111111111111111111111111"
+	addr2 = "0x2222222222222222222222222222222222222222"
+)
+
+func collect(t *testing.T, load func(func(Recipient) error) error) []Recipient {
+	t.Helper()
+	var rs []Recipient
+	if err := load(func(r Recipient) error {
+		rs = append(rs, r)
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	return rs
+}
+
+func checkRecipients(t *testing.T, rs []Recipient, amounts ...string) {
+	t.Helper()
+	if len(rs) != len(amounts) {
+		t.Fatalf("expected %d recipients, got %d", len(amounts), len(rs))
+	}
+	for i, want := range amounts {
+		if rs[i].Amount.String() != want {
+			t.Fatalf("recipient %d: expected %s, got %s", i, want, rs[i].Amount)
+		}
+	}
+}
+
+func Test_LoadCSV(t *testing.T) {
+	snapshot := "holder,note,balance\n" + addr1 + ",a,1.5\n" + addr2 + ",b,0x10\n"
+	rs := collect(t, func(fn func(Recipient) error) error {
+		return LoadCSV(strings.NewReader(snapshot), fn, AddressField("holder"), AmountField("balance"), Decimals(18))
+	})
+	checkRecipients(t, rs, "1500000000000000000", "16")
+	if rs[1].Address.Hex() != addr2 {
+		t.Fatal("unexpected address")
+	}
+
+	rs = collect(t, func(fn func(Recipient) error) error {
+		return LoadCSV(strings.NewReader("address,amount\n"+addr1+",42\n"), fn)
+	})
+	checkRecipients(t, rs, "42")
+
+	for _, bad := range []string{
+		"address,amount\n" + addr1 + ",1.5\n",
+		"address,amount\n0x12,1\n",
+		"address,amount\n" + addr1 + ",-1\n",
+		"address,amount\n" + addr1 + ",abc\n",
+		"address\n" + addr1 + "\n",
+	} {
+		if err := LoadCSV(strings.NewReader(bad), func(Recipient) error { return nil }); err == nil {
+			t.Fatalf("expected error for %q", bad)
+		}
+	}
+
+	stop := errors.New("stop")
+	if err := LoadCSV(strings.NewReader(snapshot), func(Recipient) error { return stop },
+		AddressField("holder"), AmountField("balance"), Decimals(1)); err != stop {
+		t.Fatalf("expected the error of fn, got %v", err)
+	}
+}
+
+func Test_LoadJSON(t *testing.T) {
+	array := `[{"account":"` + addr1 + `","value":"2.25"},{"account":"` + addr2 + `","value":3}]`
+	rs := collect(t, func(fn func(Recipient) error) error {
+		return LoadJSON(strings.NewReader(array), fn, AddressField("account"), AmountField("value"), Decimals(2))
+	})
+	checkRecipients(t, rs, "225", "300")
+
+	// the balance map of Uniswap's parse-balance-map
+	balanceMap := `{"` + addr1 + `":"0x0a","` + addr2 + `":"1.50"}`
+	rs = collect(t, func(fn func(Recipient) error) error {
+		return LoadJSON(strings.NewReader(balanceMap), fn, Decimals(1))
+	})
+	checkRecipients(t, rs, "10", "15")
+
+	for _, bad := range []string{
+		`"snapshot"`,
+		`[{"address":"` + addr1 + `"}]`,
+		`{"` + addr1 + `":true}`,
+		`[{"address":"` + addr1 + `","amount":"1"}`,
+	} {
+		if err := LoadJSON(strings.NewReader(bad), func(Recipient) error { return nil }); err == nil {
+			t.Fatalf("expected error for %s", bad)
+		}
+	}
+}
+
+func Test_NewFromSnapshot(t *testing.T) {
+	fromCSV, err := NewFromCSV(strings.NewReader("address,amount\n" + addr1 + ",5\n" + addr2 + ",7\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromJSON, err := NewFromJSON(strings.NewReader(`{"` + addr2 + `":7,"` + addr1 + `":5}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	direct, err := New([]Recipient{
+		{Address: common.HexToAddress(addr1), Amount: big.NewInt(5)},
+		{Address: common.HexToAddress(addr2), Amount: big.NewInt(7)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fromCSV.MerkleRoot != direct.MerkleRoot || fromJSON.MerkleRoot != direct.MerkleRoot {
+		t.Fatal("expected the same distribution from CSV and JSON")
+	}
+}
@@ -0,0 +1,135 @@
+package merkletree
+
+import (
+	"errors"
+	"math/bits"
+)
+
+// AppendFast appends c as the last leaf of a tree kept in insertion order and
+// returns the new root. The tree caches the roots of the perfect subtrees along
+// its right edge, one per set bit of the leaf count; the new leaf is merged with
+// the cached roots it completes, as in a binary counter, and the nodes above the
+// remaining roots are made anew, so no other node is read or hashed and at most
+// two hashes per level are computed. Trees with sorted leaves, where a new leaf
+// can land anywhere, and trees duplicating odd nodes, whose right edge is not
+// made of perfect subtrees, are refused. The duplicate policy and the leaf size
+// check of the tree apply as in AddLeaf.
+//
+// Any change other than an append drops the cache; the next AppendFast finds the
+// subtree roots again by walking down the right edge of the tree.
+func (m *MerkleTree) AppendFast(c Content) ([]byte, error) {
+	if !m.unsortedLeaves {
+		return nil, errors.New("error: AppendFast needs a tree kept in insertion order")
+	}
+	if m.duplicateOdd {
+		return nil, errors.New("error: AppendFast does not support duplicated odd nodes")
+	}
+	if len(m.Leafs) == 0 {
+		if err := m.AddLeaf(c); err != nil {
+			return nil, err
+		}
+		return m.merkleRoot, nil
+	}
+	leaf, err := m.newLeaf(c, nil)
+	if err != nil {
+		return nil, err
+	}
+	if leaf == nil {
+		// dropped as a duplicate
+		return m.merkleRoot, nil
+	}
+
+	frontier := append(m.rightEdge(), nil)
+
+	// links are applied once every hash is known, so that a failing pair hasher
+	// leaves the tree as it was
+	type link struct {
+		parent, left, right *Node
+	}
+	var links []link
+	pair := func(left, right *Node, level int) (*Node, error) {
+		n := &Node{Left: left, Right: right, Tree: m, epoch: m.epoch}
+		if left == right {
+			// promoted without a partner
+			n.Hash = left.Hash
+		} else {
+			hashBz, err := m.hashPair(level, left.Hash, right.Hash)
+			if err != nil {
+				return nil, err
+			}
+			n.Hash = hashBz
+		}
+		links = append(links, link{n, left, right})
+		return n, nil
+	}
+
+	// the leaf completes the perfect subtrees of the lowest set bits
+	acc, k := leaf, 0
+	for ; frontier[k] != nil; k++ {
+		if acc, err = pair(frontier[k], acc, k+1); err != nil {
+			return nil, err
+		}
+		frontier[k] = nil
+	}
+	frontier[k] = acc
+
+	// above it, the last node of a level is promoted until it meets the root of
+	// the next perfect subtree
+	for level, i := k, k+1; i < len(frontier); i++ {
+		if frontier[i] == nil {
+			continue
+		}
+		for ; level < i; level++ {
+			if acc, err = pair(acc, acc, level+1); err != nil {
+				return nil, err
+			}
+		}
+		if acc, err = pair(frontier[i], acc, i+1); err != nil {
+			return nil, err
+		}
+		level = i + 1
+	}
+
+	for _, l := range links {
+		m.keepLink(l.left)
+		m.keepLink(l.right)
+		l.left.Parent, l.right.Parent = l.parent, l.parent
+		l.left.single, l.right.single = false, false
+		if l.left == l.right {
+			l.left.single = true
+		}
+	}
+	m.proofCache.invalidate()
+	m.Leafs = append(m.Leafs, leaf)
+	m.Root = acc
+	m.merkleRoot = acc.Hash
+	m.commit()
+	m.frontier, m.frontierRoot, m.frontierVersion = frontier, acc, m.version
+	return m.merkleRoot, nil
+}
+
+// rightEdge returns the roots of the perfect subtrees along the right edge of the
+// tree, indexed by their height and nil for the heights that are not set bits of
+// the leaf count. It returns a copy of the cache if the tree has not changed since
+// the last append, and is not a clone of the tree that made it.
+func (m *MerkleTree) rightEdge() []*Node {
+	if m.frontierRoot != nil && m.frontierRoot == m.Root && m.frontierVersion == m.version {
+		return append([]*Node(nil), m.frontier...)
+	}
+
+	count := len(m.Leafs)
+	frontier := make([]*Node, bits.Len(uint(count)))
+	for n := m.Root; ; {
+		for !n.leaf && n.Left == n.Right {
+			n = n.Left
+		}
+		height := bits.Len(uint(count)) - 1
+		if count == 1<<uint(height) {
+			frontier[height] = n
+			return frontier
+		}
+		// the node pairs the largest perfect subtree with the rest
+		frontier[height] = n.Left
+		n, count = n.Right, count-1<<uint(height)
+	}
+}
@@ -0,0 +1,84 @@
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_AppendFast(t *testing.T) {
+	leaves := testLeaves(130)
+	layouts := [][]Option{
+		{WithInsertionOrder()},
+		{WithInsertionOrder(), WithLevelNumbers()},
+		{WithSortLeaves(false)},
+		{WithRFC6962()},
+	}
+	for _, opts := range layouts {
+		tree, err := NewTreeWithOptions(leaves[:1], opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for n := 2; n <= 101; n++ {
+			root, err := tree.AppendFast(leaves[n-1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(root, tree.MerkleRoot()) {
+				t.Fatal("AppendFast returned another root than the tree has")
+			}
+			checkSameTree(t, tree, leaves[:n], opts)
+		}
+
+		// proofs of the appended tree are those of a full build
+		want, _ := NewTreeWithOptions(leaves[:101], opts...)
+		for _, i := range []int{0, 63, 64, 99, 100} {
+			proof, _ := tree.GetProofByIndex(i)
+			wantProof, _ := want.GetProofByIndex(i)
+			if len(proof.Siblings) != len(wantProof.Siblings) {
+				t.Fatalf("proof of leaf %d differs from a full build", i)
+			}
+			for k := range proof.Siblings {
+				if !bytes.Equal(proof.Siblings[k], wantProof.Siblings[k]) || proof.Path[k] != wantProof.Path[k] {
+					t.Fatalf("proof of leaf %d differs from a full build", i)
+				}
+			}
+		}
+
+		// other changes drop the cached subtree roots, and appends go on from the
+		// changed tree
+		cs := append([]Content(nil), leaves[:101]...)
+		tree.Snapshot()
+		if err := tree.UpdateLeaf(98, leaves[120]); err != nil {
+			t.Fatal(err)
+		}
+		cs[98] = leaves[120]
+		if _, err := tree.RemoveLeafByIndex(5); err != nil {
+			t.Fatal(err)
+		}
+		cs = append(cs[:5], cs[6:]...)
+		for _, c := range leaves[101:110] {
+			if _, err := tree.AppendFast(c); err != nil {
+				t.Fatal(err)
+			}
+			cs = append(cs, c)
+			checkSameTree(t, tree, cs, opts)
+		}
+
+		// a clone appends on its own nodes
+		clone := tree.Clone()
+		if _, err := clone.AppendFast(leaves[110]); err != nil {
+			t.Fatal(err)
+		}
+		checkSameTree(t, tree, cs, opts)
+		checkSameTree(t, clone, append(cs, leaves[110]), opts)
+	}
+
+	sorted, _ := NewTree(leaves[:4])
+	if _, err := sorted.AppendFast(leaves[4]); err == nil {
+		t.Fatal("expected error appending to a tree with sorted leaves")
+	}
+	duplicated, _ := NewTreeWithOptions(leaves[:4], WithInsertionOrder(), WithOddNodePolicy(DuplicateLast))
+	if _, err := duplicated.AppendFast(leaves[4]); err == nil {
+		t.Fatal("expected error appending to a tree duplicating odd nodes")
+	}
+}
@@ -0,0 +1,57 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+// ComputeRootArena computes the same root as a tree built from hashes, without
+// allocating. hashes must already be sorted in ascending order, as leaves are in a
+// tree. Intermediate pairs are concatenated in scratch, which must hold at least
+// 2 * hash size bytes, and intermediate hashes are written over the contents of
+// hashes, so the caller's leaf hashes are destroyed. The returned root aliases
+// one of them.
+//
+// h is called once per invocation; callers in tight loops can hand out a single
+// reused hash.Hash, which is Reset before every use.
+func ComputeRootArena(hashes [][]byte, scratch []byte, h func() hash.Hash) ([]byte, error) {
+	if len(hashes) == 0 {
+		return nil, errors.New("error: cannot compute root of no hashes")
+	}
+
+	hasher := h()
+	n := len(hashes)
+	for n > 1 {
+		next := 0
+		for i := 0; i < n; i += 2 {
+			if i+1 == n {
+				// single node, promoted without hashing
+				hashes[next] = hashes[i]
+				next++
+				continue
+			}
+
+			a, b := hashes[i], hashes[i+1]
+			if bytes.Compare(a, b) > 0 {
+				a, b = b, a
+			}
+			if len(a)+len(b) > len(scratch) {
+				return nil, fmt.Errorf("error: scratch holds %d bytes, need %d", len(scratch), len(a)+len(b))
+			}
+			l := copy(scratch, a)
+			l += copy(scratch[l:], b)
+
+			hasher.Reset()
+			if _, err := hasher.Write(scratch[:l]); err != nil {
+				return nil, err
+			}
+			// both inputs now live in scratch, so slot i can take the result
+			hashes[next] = hasher.Sum(hashes[i][:0])
+			next++
+		}
+		n = next
+	}
+	return hashes[0], nil
+}
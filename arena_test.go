@@ -0,0 +1,87 @@
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"hash"
+	"testing"
+)
+
+// arenaInputs returns sorted leaf hashes of n test leaves with a reusable copy.
+func arenaInputs(n int) (orig, hashes [][]byte) {
+	tree, _ := NewTreeWithHashStrategy(testLeaves(n), sha256.New)
+	for _, leaf := range tree.Leafs {
+		orig = append(orig, leaf.Hash)
+		hashes = append(hashes, make([]byte, 0, sha256.Size))
+	}
+	return orig, hashes
+}
+
+func resetArena(orig, hashes, backing [][]byte) {
+	copy(hashes, backing)
+	for i := range hashes {
+		hashes[i] = append(hashes[i][:0], orig[i]...)
+	}
+}
+
+func Test_ComputeRootArena(t *testing.T) {
+	scratch := make([]byte, 2*sha256.Size)
+	for n := 1; n <= 33; n++ {
+		leaves := testLeaves(n)
+		tree, err := NewTreeWithHashStrategy(leaves, sha256.New)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var hashes [][]byte
+		for _, leaf := range tree.Leafs {
+			hashes = append(hashes, append([]byte(nil), leaf.Hash...))
+		}
+		root, err := ComputeRootArena(hashes, scratch, sha256.New)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(root, tree.MerkleRoot()) {
+			t.Fatalf("%d leaves: arena root mismatch", n)
+		}
+	}
+
+	if _, err := ComputeRootArena([][]byte{{1}, {2}}, nil, sha256.New); err == nil {
+		t.Fatal("expected error for short scratch")
+	}
+}
+
+func Test_ComputeRootArenaAllocs(t *testing.T) {
+	orig, hashes := arenaInputs(100)
+	backing := append([][]byte(nil), hashes...)
+	scratch := make([]byte, 2*sha256.Size)
+	hasher := sha256.New()
+	reuse := func() hash.Hash { return hasher }
+
+	allocs := testing.AllocsPerRun(100, func() {
+		resetArena(orig, hashes, backing)
+		if _, err := ComputeRootArena(hashes, scratch, reuse); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if allocs != 0 {
+		t.Fatalf("expected no allocations, got %v", allocs)
+	}
+}
+
+func BenchmarkComputeRootArena(b *testing.B) {
+	orig, hashes := arenaInputs(1024)
+	backing := append([][]byte(nil), hashes...)
+	scratch := make([]byte, 2*sha256.Size)
+	hasher := sha256.New()
+	reuse := func() hash.Hash { return hasher }
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resetArena(orig, hashes, backing)
+		if _, err := ComputeRootArena(hashes, scratch, reuse); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
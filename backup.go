@@ -0,0 +1,233 @@
+package merkletree
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+)
+
+// backupVersion is the version byte of the backup format.
+const backupVersion byte = 1
+
+// maxBackupDepth bounds the depth of the nodes of a dump, well beyond that of
+// any tree that fits in memory, so that a crafted dump cannot exhaust the stack.
+const maxBackupDepth = 64
+
+// node flags of the backup format
+const (
+	backupLeaf     byte = 1 << iota // node is a leaf
+	backupSingle                    // node was promoted without a partner
+	backupPromoted                  // node wraps a single promoted child
+)
+
+// WriteTo streams a compact binary dump of the tree to w: a version byte, the name
+// of the hash function prefixed with its uvarint length, empty if it is not
+// registered, the options of the tree as MarshalFull writes them, then every node
+// in pre-order as a flags byte, a uvarint hash length and the hash. Leaf content
+// is not written. It implements io.WriterTo.
+func (m *MerkleTree) WriteTo(w io.Writer) (int64, error) {
+	if m.Root == nil {
+		return 0, ErrEmptyTree
+	}
+
+	// the count is taken below the buffer, so it holds what reached w
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	bw.WriteByte(backupVersion)
+	name, _ := HashName(m.hashStrategy)
+	var l [binary.MaxVarintLen64]byte
+	bw.Write(l[:binary.PutUvarint(l[:], uint64(len(name)))])
+	bw.WriteString(name)
+	var layout bytes.Buffer
+	m.writeLayout(&layout)
+	bw.Write(layout.Bytes())
+	m.Root.writeTo(bw)
+	// bw keeps the first error of w and returns it from Flush
+	err := bw.Flush()
+	return cw.n, err
+}
+
+func (n *Node) writeTo(bw *bufio.Writer) {
+	var flags byte
+	if n.leaf {
+		flags |= backupLeaf
+	}
+	if n.single {
+		flags |= backupSingle
+	}
+	if !n.leaf && n.Left == n.Right {
+		flags |= backupPromoted
+	}
+
+	var l [binary.MaxVarintLen64]byte
+	bw.WriteByte(flags)
+	bw.Write(l[:binary.PutUvarint(l[:], uint64(len(n.Hash)))])
+	bw.Write(n.Hash)
+
+	switch {
+	case n.leaf:
+	case n.Left == n.Right:
+		n.Left.writeTo(bw)
+	default:
+		n.Left.writeTo(bw)
+		n.Right.writeTo(bw)
+	}
+}
+
+// ReadTreeFrom restores a tree written by WriteTo without recomputing any hash,
+// with the options it was built with. The leaves of the returned tree carry no
+// content; VerifyTree checks the internal hashes against the restored leaf
+// hashes. h may be nil for dumps that name a registered hash function; otherwise
+// it must match the one named. opts supply what the dump cannot hold, such as the
+// key of WithHMACKey, and are applied after the recorded options. Nothing past
+// the dump is read from r, so it may be followed by other data; readers that are
+// not io.ByteReaders are read a byte at a time, and are better wrapped in a
+// bufio.Reader when nothing else is read from them.
+func ReadTreeFrom(r io.Reader, h func() hash.Hash, opts ...Option) (*MerkleTree, error) {
+	br, ok := r.(io.ByteReader)
+	if !ok {
+		br = &byteReader{r: r}
+	}
+
+	version, err := br.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if version != backupVersion {
+		return nil, fmt.Errorf("error: unsupported backup version %d", version)
+	}
+	if h, err = readHashName(br, h); err != nil {
+		return nil, err
+	}
+	if h == nil {
+		return nil, errors.New("error: backup does not name its hash function")
+	}
+	layout, flags, err := readLayout(br)
+	if err != nil {
+		return nil, err
+	}
+
+	t := newConfiguredTree(append(append(layout, opts...), WithHashStrategy(h)))
+	if err := checkLayout(t, flags); err != nil {
+		return nil, err
+	}
+	root, err := readNode(br, t, 0)
+	if err != nil {
+		return nil, err
+	}
+	t.Root = root
+	t.merkleRoot = root.Hash
+	return t, nil
+}
+
+// readHashName reads the name of the hash function of a dump, prefixed with its
+// uvarint length, and returns the strategy to restore it with: h if set, checked
+// against the name.
+func readHashName(br io.ByteReader, h func() hash.Hash) (func() hash.Hash, error) {
+	nameLen, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	if nameLen > 255 {
+		return nil, fmt.Errorf("error: implausible hash name length %d", nameLen)
+	}
+	name := make([]byte, nameLen)
+	for i := range name {
+		if name[i], err = br.ReadByte(); err != nil {
+			return nil, err
+		}
+	}
+	if nameLen == 0 {
+		return h, nil
+	}
+
+	if h != nil {
+		if got, ok := HashName(h); ok && got != string(name) {
+			return nil, fmt.Errorf("error: tree was written with %s, not %s", name, got)
+		}
+		return h, nil
+	}
+	return HashStrategyByName(string(name))
+}
+
+// readNode restores the subtree at depth at the current position, appending its
+// leaves to t.Leafs in order.
+func readNode(br io.ByteReader, t *MerkleTree, depth int) (*Node, error) {
+	if depth > maxBackupDepth {
+		return nil, fmt.Errorf("error: backup nodes nested deeper than %d levels", maxBackupDepth)
+	}
+	flags, err := br.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	hashLen, err := binary.ReadUvarint(br)
+	if err != nil {
+		return nil, err
+	}
+	if hashLen > 1024 {
+		return nil, fmt.Errorf("error: implausible hash length %d", hashLen)
+	}
+	hashBz := make([]byte, hashLen)
+	for i := range hashBz {
+		if hashBz[i], err = br.ReadByte(); err != nil {
+			return nil, err
+		}
+	}
+
+	n := &Node{
+		Tree:   t,
+		Hash:   hashBz,
+		leaf:   flags&backupLeaf != 0,
+		single: flags&backupSingle != 0,
+	}
+	switch {
+	case n.leaf:
+		t.Leafs = append(t.Leafs, n)
+	case flags&backupPromoted != 0:
+		child, err := readNode(br, t, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		child.Parent = n
+		n.Left, n.Right = child, child
+	default:
+		if n.Left, err = readNode(br, t, depth+1); err != nil {
+			return nil, err
+		}
+		if n.Right, err = readNode(br, t, depth+1); err != nil {
+			return nil, err
+		}
+		n.Left.Parent, n.Right.Parent = n, n
+	}
+	return n, nil
+}
+
+// byteReader reads r a byte at a time, so that it consumes no more of r than was
+// asked for.
+type byteReader struct {
+	r io.Reader
+	b [1]byte
+}
+
+func (br *byteReader) ReadByte() (byte, error) {
+	if _, err := io.ReadFull(br.r, br.b[:]); err != nil {
+		return 0, err
+	}
+	return br.b[0], nil
+}
+
+// countingWriter counts the bytes written to w.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	n, err := cw.w.Write(p)
+	cw.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,174 @@
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+var _ io.WriterTo = (*MerkleTree)(nil)
+
+func Test_WriteToReadTreeFrom(t *testing.T) {
+	for _, n := range []int{1, 2, 5, 8, 13} {
+		tree, err := NewTree(testLeaves(n))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var buf bytes.Buffer
+		written, err := tree.WriteTo(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if written != int64(buf.Len()) {
+			t.Fatalf("WriteTo reported %d bytes, wrote %d", written, buf.Len())
+		}
+
+		restored, err := ReadTreeFrom(&buf, sha3.NewLegacyKeccak256)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(restored.MerkleRoot(), tree.MerkleRoot()) {
+			t.Fatalf("%d leaves: restored root mismatch", n)
+		}
+		if ok, err := restored.VerifyTree(); err != nil || !ok {
+			t.Fatalf("%d leaves: restored tree does not verify", n)
+		}
+		if len(restored.Leafs) != n || len(restored.SingleNodes()) != len(tree.SingleNodes()) {
+			t.Fatalf("%d leaves: restored tree has a different shape", n)
+		}
+	}
+
+	tree, _ := NewTree(testLeaves(4))
+	var buf bytes.Buffer
+	tree.WriteTo(&buf)
+	if _, err := ReadTreeFrom(bytes.NewReader(buf.Bytes()[:buf.Len()-1]), sha3.NewLegacyKeccak256); err == nil {
+		t.Fatal("expected error for truncated dump")
+	}
+
+	// the bytes after a dump are left to the caller
+	var stream bytes.Buffer
+	tree.WriteTo(&stream)
+	stream.WriteString("trailer")
+	r := io.MultiReader(&stream)
+	if _, err := ReadTreeFrom(r, nil); err != nil {
+		t.Fatal(err)
+	}
+	if rest, _ := io.ReadAll(r); string(rest) != "trailer" {
+		t.Fatalf("expected the trailer after the dump, got %q", rest)
+	}
+
+	// an endless chain of promoted nodes is cut off
+	name, _ := HashName(tree.hashStrategy)
+	header := bytes.NewBuffer(append([]byte{backupVersion, byte(len(name))}, name...))
+	tree.writeLayout(header)
+	if _, err := ReadTreeFrom(io.MultiReader(header, &endlessNodes{}), nil); err == nil {
+		t.Fatal("expected error for nodes nested too deep")
+	}
+
+	// a failing writer gets only the bytes it accepted counted
+	fw := &failingWriter{limit: 10}
+	if written, err := tree.WriteTo(fw); err == nil || written != 10 {
+		t.Fatalf("expected 10 bytes written and an error, got %d, %v", written, err)
+	}
+}
+
+// endlessNodes reads as promoted nodes with a one-byte hash, forever.
+type endlessNodes struct {
+	pos int
+}
+
+func (e *endlessNodes) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = []byte{backupPromoted, 1, 0xaa}[e.pos%3]
+		e.pos++
+	}
+	return len(p), nil
+}
+
+// failingWriter accepts limit bytes and fails from then on.
+type failingWriter struct {
+	limit int
+}
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.limit {
+		n := w.limit
+		w.limit = 0
+		return n, io.ErrShortWrite
+	}
+	w.limit -= len(p)
+	return len(p), nil
+}
+
+func Test_ReadTreeFromNamedHash(t *testing.T) {
+	tree, _ := NewTreeWithHashStrategy(testLeaves(6), sha256.New)
+	var buf bytes.Buffer
+	if _, err := tree.WriteTo(&buf); err != nil {
+		t.Fatal(err)
+	}
+	dump := buf.Bytes()
+
+	restored, err := ReadTreeFrom(bytes.NewReader(dump), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := restored.VerifyTree(); err != nil || !ok {
+		t.Fatal("tree restored with the named hash does not verify")
+	}
+	if _, err := ReadTreeFrom(bytes.NewReader(dump), sha3.NewLegacyKeccak256); err == nil {
+		t.Fatal("expected error for a mismatched hash function")
+	}
+}
+
+func Test_ReadTreeFromOptions(t *testing.T) {
+	key := []byte("key")
+	modes := []struct {
+		name string
+		opts []Option
+		// restore holds the options the dump cannot carry
+		restore []Option
+	}{
+		{"insertion order", []Option{WithInsertionOrder()}, nil},
+		{"duplicate last", []Option{WithOddNodePolicy(DuplicateLast)}, nil},
+		{"rfc6962", []Option{WithRFC6962()}, nil},
+		{"hmac key", []Option{WithHMACKey(key)}, []Option{WithHMACKey(key)}},
+	}
+	for _, mode := range modes {
+		tree, err := NewTreeWithOptions(testLeaves(7), mode.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		if _, err := tree.WriteTo(&buf); err != nil {
+			t.Fatal(err)
+		}
+		dump := buf.Bytes()
+
+		restored, err := ReadTreeFrom(bytes.NewReader(dump), nil, mode.restore...)
+		if err != nil {
+			t.Fatalf("%s: %v", mode.name, err)
+		}
+		if ok, err := restored.VerifyTree(); err != nil || !ok {
+			t.Fatalf("%s: restored tree does not verify", mode.name)
+		}
+		if len(restored.Leafs) != len(tree.Leafs) {
+			t.Fatalf("%s: restored %d leaves", mode.name, len(restored.Leafs))
+		}
+		proof, err := restored.GetProofByIndex(6)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := VerifyProofWithOptions(tree.MerkleRoot(), proof, mode.opts...); err != nil || !ok {
+			t.Fatalf("%s: proof of the restored tree does not verify", mode.name)
+		}
+		if mode.restore != nil {
+			if _, err := ReadTreeFrom(bytes.NewReader(dump), nil); err == nil {
+				t.Fatalf("%s: expected error without the restore options", mode.name)
+			}
+		}
+	}
+}
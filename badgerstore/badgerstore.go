@@ -0,0 +1,65 @@
+// Package badgerstore is a merkletree.NodeStore backed by Badger. It is a module
+// of its own so that the merkletree module does not depend on Badger.
+package badgerstore
+
+import (
+	"github.com/dgraph-io/badger/v3"
+	"github.com/smartbch/merkletree"
+)
+
+// Store keeps tree nodes in a Badger database.
+type Store struct {
+	db *badger.DB
+}
+
+var _ merkletree.NodeStore = (*Store)(nil)
+
+// Open opens or creates the database in the directory path.
+func Open(path string) (*Store, error) {
+	db, err := badger.Open(badger.DefaultOptions(path).WithLogger(nil))
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// New returns a store in db, which remains owned by the caller.
+func New(db *badger.DB) *Store {
+	return &Store{db: db}
+}
+
+// Get implements merkletree.NodeStore.
+func (s *Store) Get(hash []byte) ([]byte, error) {
+	var value []byte
+	err := s.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(hash)
+		if err != nil {
+			return err
+		}
+		value, err = item.ValueCopy(nil)
+		return err
+	})
+	if err == badger.ErrKeyNotFound {
+		return nil, merkletree.ErrNodeNotFound
+	}
+	return value, err
+}
+
+// Put implements merkletree.NodeStore.
+func (s *Store) Put(hash, value []byte) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(hash, value)
+	})
+}
+
+// Delete implements merkletree.NodeStore.
+func (s *Store) Delete(hash []byte) error {
+	return s.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(hash)
+	})
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
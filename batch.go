@@ -0,0 +1,181 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// ApplyBatch adds, updates and removes many leaves at once and recomputes every
+// internal node it changes a single time. updates maps positions in Leafs to
+// their new content and removals lists positions to drop; both refer to the tree
+// before the batch and may not name the same position twice. Leaves keep the
+// shape of the tree up to the first position the batch inserts or removes a leaf
+// at: there only the ancestors of updated leaves are rehashed, after it every
+// level is rebuilt as in AddLeaf. Updates behave as in UpdateLeaf and adds as in
+// AddLeaf, and the duplicate policy of the tree covers the leaves of the batch as
+// well. An invalid batch leaves the tree unchanged.
+func (m *MerkleTree) ApplyBatch(adds []Content, updates map[int]Content, removals []int) error {
+	changed := make(map[int]bool, len(updates)+len(removals))
+	for _, i := range removals {
+		if i < 0 || i >= len(m.Leafs) {
+			return fmt.Errorf("error: leaf index %d out of range [0, %d)", i, len(m.Leafs))
+		}
+		if changed[i] {
+			return fmt.Errorf("error: leaf index %d removed twice", i)
+		}
+		changed[i] = true
+	}
+	updated := make([]int, 0, len(updates))
+	for i := range updates {
+		if i < 0 || i >= len(m.Leafs) {
+			return fmt.Errorf("error: leaf index %d out of range [0, %d)", i, len(m.Leafs))
+		}
+		if changed[i] {
+			return fmt.Errorf("error: leaf index %d both updated and removed", i)
+		}
+		changed[i] = true
+		updated = append(updated, i)
+	}
+	sort.Ints(updated)
+	changedLeafs := make(map[*Node]bool, len(changed))
+	for i := range changed {
+		changedLeafs[m.Leafs[i]] = true
+	}
+
+	// replaced maps the new leaves of updates to the position they update
+	replaced := make(map[*Node]int, len(updates))
+	var added []*Node
+	acc := newLeafAccumulator(m, len(updates)+len(adds))
+	for k, c := range append(updatesInOrder(updates, updated), adds...) {
+		kept := len(acc.leafs)
+		if err := acc.add(c); err != nil {
+			return err
+		}
+		if len(acc.leafs) == kept {
+			continue
+		}
+		leaf := acc.leafs[kept]
+		if m.duplicatePolicy != AllowDuplicates {
+			if dup := m.findLeafByHash(leaf.Hash); dup != nil && !changedLeafs[dup] {
+				if m.duplicatePolicy == ErrorOnDuplicate {
+					return ErrDuplicateLeaf
+				}
+				continue
+			}
+		}
+		if k < len(updated) {
+			replaced[leaf] = updated[k]
+		} else {
+			added = append(added, leaf)
+		}
+	}
+
+	leafs := m.batchLeafs(changed, replaced, added)
+	if len(leafs) == 0 && m.emptyRoot == nil {
+		return errors.New("error: cannot remove the last leaf of a tree without an empty root")
+	}
+
+	// up to start the tree keeps its shape
+	start := 0
+	for start < len(leafs) && start < len(m.Leafs) {
+		if i, ok := replaced[leafs[start]]; leafs[start] != m.Leafs[start] && (!ok || i != start) {
+			break
+		}
+		start++
+	}
+	if start == len(leafs) && start == len(m.Leafs) && start > 0 {
+		start = math.MaxInt
+	}
+
+	m.proofCache.invalidate()
+	var dirty []int
+	for _, i := range updated {
+		if i >= start {
+			break
+		}
+		if leafs[i] != m.Leafs[i] {
+			m.swapLeaf(i, leafs[i])
+			dirty = append(dirty, i)
+		}
+	}
+	if err := m.rehashAncestors(dirty, start); err != nil {
+		return err
+	}
+	m.Leafs = leafs
+	if start == math.MaxInt {
+		m.merkleRoot = m.Root.Hash
+		m.commit()
+		return nil
+	}
+	return m.rebuildFrom(start)
+}
+
+// updatesInOrder returns the contents of updates at the positions of indexes.
+func updatesInOrder(updates map[int]Content, indexes []int) []Content {
+	cs := make([]Content, len(indexes))
+	for k, i := range indexes {
+		cs[k] = updates[i]
+	}
+	return cs
+}
+
+// batchLeafs returns the leaves of the tree after a batch: the leaves that were
+// not changed, the new leaves of updates in place of the ones they update and
+// the added leaves, in the order of the tree.
+func (m *MerkleTree) batchLeafs(changed map[int]bool, replaced map[*Node]int, added []*Node) []*Node {
+	newLeafs := make([]*Node, len(m.Leafs))
+	for leaf, i := range replaced {
+		newLeafs[i] = leaf
+	}
+
+	leafs := make([]*Node, 0, len(m.Leafs)-len(changed)+len(replaced)+len(added))
+	if m.unsortedLeaves {
+		for i, leaf := range m.Leafs {
+			if !changed[i] {
+				leafs = append(leafs, leaf)
+			} else if newLeafs[i] != nil {
+				leafs = append(leafs, newLeafs[i])
+			}
+		}
+		return append(leafs, added...)
+	}
+
+	// an update stays in place if it still sorts between the leaves before it and
+	// the next unchanged one, the others are merged into the sorted leaves with the
+	// added ones
+	nextKept := make([][]byte, len(m.Leafs))
+	var next []byte
+	for i := len(m.Leafs) - 1; i >= 0; i-- {
+		nextKept[i] = next
+		if !changed[i] {
+			next = m.Leafs[i].Hash
+		}
+	}
+	var moved []*Node
+	for i, leaf := range m.Leafs {
+		switch {
+		case !changed[i]:
+			leafs = append(leafs, leaf)
+		case newLeafs[i] == nil:
+		case (len(leafs) == 0 || bytes.Compare(leafs[len(leafs)-1].Hash, newLeafs[i].Hash) <= 0) &&
+			(nextKept[i] == nil || bytes.Compare(newLeafs[i].Hash, nextKept[i]) <= 0):
+			leafs = append(leafs, newLeafs[i])
+		default:
+			moved = append(moved, newLeafs[i])
+		}
+	}
+	moved = sortLeafs(append(moved, added...))
+
+	merged := make([]*Node, 0, len(leafs)+len(moved))
+	for len(leafs) > 0 || len(moved) > 0 {
+		if len(moved) == 0 || (len(leafs) > 0 && bytes.Compare(leafs[0].Hash, moved[0].Hash) <= 0) {
+			merged, leafs = append(merged, leafs[0]), leafs[1:]
+		} else {
+			merged, moved = append(merged, moved[0]), moved[1:]
+		}
+	}
+	return merged
+}
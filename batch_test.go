@@ -0,0 +1,112 @@
+package merkletree
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func Test_ApplyBatch(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	next := 0
+	newLeaf := func() Content {
+		next++
+		return TestLeaf{Bz: []byte(fmt.Sprintf("batch-%d", next))}
+	}
+
+	for _, opts := range incrementalOptions {
+		tree, err := NewTreeWithOptions(testLeaves(30), opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for round := 0; round < 20; round++ {
+			before := make([]Content, len(tree.Leafs))
+			for i, leaf := range tree.Leafs {
+				before[i] = leaf.C
+			}
+
+			// pick distinct positions for updates and removals
+			perm := rnd.Perm(len(before))
+			nUpdates, nRemovals := rnd.Intn(5), rnd.Intn(4)
+			updates := make(map[int]Content)
+			for _, i := range perm[:nUpdates] {
+				updates[i] = newLeaf()
+			}
+			removals := perm[nUpdates : nUpdates+nRemovals]
+			var adds []Content
+			for k := rnd.Intn(5); k > 0; k-- {
+				adds = append(adds, newLeaf())
+			}
+
+			if err := tree.ApplyBatch(adds, updates, removals); err != nil {
+				t.Fatal(err)
+			}
+			removed := make(map[int]bool)
+			for _, i := range removals {
+				removed[i] = true
+			}
+			var after []Content
+			for i, c := range before {
+				if u, ok := updates[i]; ok {
+					after = append(after, u)
+				} else if !removed[i] {
+					after = append(after, c)
+				}
+			}
+			checkSameTree(t, tree, append(after, adds...), opts)
+		}
+	}
+}
+
+func Test_ApplyBatchErrors(t *testing.T) {
+	leaves := testLeaves(6)
+	tree, _ := NewTreeWithOptions(leaves[:4], WithInsertionOrder())
+	root := tree.MerkleRoot()
+	for _, bad := range []struct {
+		updates  map[int]Content
+		removals []int
+	}{
+		{removals: []int{4}},
+		{removals: []int{1, 1}},
+		{updates: map[int]Content{-1: leaves[4]}},
+		{updates: map[int]Content{2: leaves[4]}, removals: []int{2}},
+	} {
+		if err := tree.ApplyBatch(nil, bad.updates, bad.removals); err == nil {
+			t.Fatal("expected error for an invalid batch")
+		}
+	}
+	if err := tree.ApplyBatch(nil, nil, []int{0, 1, 2, 3}); err == nil {
+		t.Fatal("expected error removing every leaf")
+	}
+	checkSameTree(t, tree, leaves[:4], []Option{WithInsertionOrder()})
+	if err := tree.ApplyBatch(nil, nil, nil); err != nil || !bytes.Equal(tree.MerkleRoot(), root) {
+		t.Fatal("expected an empty batch to keep the root")
+	}
+
+	tree, _ = NewTreeWithOptions(leaves[:4], WithDuplicatePolicy(ErrorOnDuplicate))
+	if err := tree.ApplyBatch([]Content{leaves[1]}, nil, nil); err != ErrDuplicateLeaf {
+		t.Fatalf("expected ErrDuplicateLeaf, got %v", err)
+	}
+	// the duplicate goes away with the leaf it duplicated
+	index, _ := tree.GetIndexOf(leaves[1])
+	if err := tree.ApplyBatch([]Content{leaves[1]}, nil, []int{index}); err != nil {
+		t.Fatal(err)
+	}
+	checkSameTree(t, tree, leaves[:4], nil)
+
+	tree, _ = NewTreeWithOptions(leaves[:4], WithDuplicatePolicy(DedupeDuplicates))
+	if err := tree.ApplyBatch([]Content{leaves[1], leaves[4], leaves[4]}, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	checkSameTree(t, tree, leaves[:5], nil)
+
+	tree, _ = NewTreeWithOptions(leaves[:2], WithEmptyRoot(ZeroRoot(32)))
+	if err := tree.ApplyBatch(nil, nil, []int{0, 1}); err != nil || tree.Root != nil {
+		t.Fatal("expected an empty tree")
+	}
+	if err := tree.ApplyBatch(leaves[2:5], nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	checkSameTree(t, tree, leaves[2:5], nil)
+}
@@ -0,0 +1,57 @@
+package merkletree
+
+import (
+	"encoding/hex"
+	"fmt"
+)
+
+// WithBitcoinMerkle builds the merkle tree of a Bitcoin or Bitcoin Cash block:
+// leaves keep the order of the transactions, nodes are double-SHA256(left ||
+// right) and the last node of an odd level is paired with itself. The contents
+// must be txids in internal byte order, e.g. HashContent values; see
+// NewBitcoinTreeFromTxids for txids as displayed by explorers and RPCs.
+//
+// Proofs from GetProofByIndex are SPV branches and verify with
+// VerifyIndexedProof given this option. Duplicating the odd node means a list of
+// transactions with its trailing ones repeated has the same root (CVE-2012-2459),
+// so callers validating blocks must reject duplicate txids themselves.
+func WithBitcoinMerkle() Option {
+	return func(m *MerkleTree) {
+		WithHashStrategy(NewDoubleSHA256)(m)
+		WithInsertionOrder()(m)
+		WithOddNodePolicy(DuplicateLast)(m)
+	}
+}
+
+// NewBitcoinTreeFromTxids builds the merkle tree of a block from its txids in
+// display order, the byte-reversed hex used by explorers and RPCs, in the order
+// of the transactions in the block. DisplayHash formats the root the same way.
+func NewBitcoinTreeFromTxids(txids []string) (*MerkleTree, error) {
+	cs := make([]Content, len(txids))
+	for i, txid := range txids {
+		b, err := hex.DecodeString(txid)
+		if err != nil {
+			return nil, fmt.Errorf("error: invalid txid at index %d: %v", i, err)
+		}
+		if len(b) != 32 {
+			return nil, fmt.Errorf("error: txid at index %d is %d bytes, not 32", i, len(b))
+		}
+		cs[i] = HashContent(reverseBytes(b))
+	}
+	return NewTreeWithOptions(cs, WithBitcoinMerkle())
+}
+
+// DisplayHash returns h as hex in reversed byte order, the way Bitcoin displays
+// txids and block merkle roots.
+func DisplayHash(h []byte) string {
+	return hex.EncodeToString(reverseBytes(h))
+}
+
+// reverseBytes returns a reversed copy of b.
+func reverseBytes(b []byte) []byte {
+	r := make([]byte, len(b))
+	for i, c := range b {
+		r[len(b)-1-i] = c
+	}
+	return r
+}
@@ -0,0 +1,51 @@
+package merkletree
+
+import "testing"
+
+func Test_BitcoinMerkle(t *testing.T) {
+	// block 100000
+	txids := []string{
+		"8c14f0db3df150123e6f3dbbf30f8b955a8249b62ac1d1ff16284aefa3d06d87",
+		"fff2525b8931402dd09222c50775608f75787bd2b87e56995a7bdd30f79702c4",
+		"6359f0868171b1d194cbee1af2f16ea598ae8fad666d9b012c8ed2b79a236ec4",
+		"e9a66845e05d5abc0ad04ec80f774a7e585c6e8db975962d069a522137b80c1d",
+	}
+	tree, err := NewBitcoinTreeFromTxids(txids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := DisplayHash(tree.MerkleRoot()); got != "f3e94742aca4b5ef85488dc37c06c3282295ffec960994b2c0d5ac2a25a95766" {
+		t.Fatalf("unexpected merkle root %s", got)
+	}
+
+	for i := range txids {
+		proof, err := tree.GetProofByIndex(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ok, err := VerifyIndexedProof(tree.MerkleRoot(), i, len(txids), proof, WithBitcoinMerkle())
+		if err != nil || !ok {
+			t.Fatalf("branch of tx %d does not verify", i)
+		}
+	}
+
+	// a block with only its coinbase has the coinbase txid as merkle root
+	single, err := NewBitcoinTreeFromTxids(txids[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if DisplayHash(single.MerkleRoot()) != txids[0] {
+		t.Fatal("single transaction root is not its txid")
+	}
+
+	// an odd count duplicates the last txid, so repeating it gives the same root
+	odd, _ := NewBitcoinTreeFromTxids(txids[:3])
+	repeated, _ := NewBitcoinTreeFromTxids(append(txids[:3:3], txids[2]))
+	if DisplayHash(odd.MerkleRoot()) != DisplayHash(repeated.MerkleRoot()) {
+		t.Fatal("odd node was not duplicated")
+	}
+
+	if _, err := NewBitcoinTreeFromTxids([]string{"00"}); err == nil {
+		t.Fatal("expected error for a short txid")
+	}
+}
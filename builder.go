@@ -0,0 +1,94 @@
+package merkletree
+
+import (
+	"context"
+	"errors"
+)
+
+// Builder builds a tree from contents added one at a time, hashing each as it
+// arrives, so that large inputs read from a database cursor or a channel need not
+// be collected in a slice first. Only the leaf hashes and contents are kept until
+// Build. A Builder is not safe for concurrent use.
+type Builder struct {
+	t     *MerkleTree
+	acc   *leafAccumulator
+	built bool
+}
+
+// NewBuilder returns a builder of a tree configured by opts, as in
+// NewTreeWithOptions.
+func NewBuilder(opts ...Option) (*Builder, error) {
+	t := newConfiguredTree(opts)
+	if err := t.checkOptions(); err != nil {
+		return nil, err
+	}
+	return &Builder{t: t, acc: newLeafAccumulator(t, 0)}, nil
+}
+
+// Add hashes c into the next leaf. With ErrorOnDuplicate it returns
+// ErrDuplicateLeaf for a content whose leaf hash was already added.
+func (b *Builder) Add(c Content) error {
+	if b.built {
+		return errors.New("error: builder already built its tree")
+	}
+	return b.acc.add(c)
+}
+
+// AddFrom adds every content received from ch until ch is closed. It stops with
+// the error of ctx once ctx is done.
+func (b *Builder) AddFrom(ctx context.Context, ch <-chan Content) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case c, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if err := b.Add(c); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Len returns the number of leaves added so far, not counting dropped
+// duplicates.
+func (b *Builder) Len() int {
+	return len(b.acc.leafs)
+}
+
+// Build returns the tree over the contents added. The builder cannot be used
+// afterwards.
+func (b *Builder) Build() (*MerkleTree, error) {
+	return b.BuildCtx(context.Background())
+}
+
+// BuildCtx is Build stopping with the error of ctx once ctx is done.
+func (b *Builder) BuildCtx(ctx context.Context) (*MerkleTree, error) {
+	if b.built {
+		return nil, errors.New("error: builder already built its tree")
+	}
+	t := b.t
+	if len(b.acc.leafs) == 0 {
+		if t.emptyRoot == nil {
+			return nil, ErrEmptyTree
+		}
+		b.built = true
+		t.merkleRoot = t.emptyRoot
+		return t, nil
+	}
+
+	t.building = newBuildProgress(t.progress, b.acc.count)
+	defer func() { t.building = nil }()
+	leafs := b.acc.finish()
+	root, err := buildIntermediate(ctx, leafs, t)
+	if err != nil {
+		return nil, err
+	}
+	b.built = true
+	t.Root = root
+	t.Leafs = leafs
+	t.merkleRoot = root.Hash
+	return t, nil
+}
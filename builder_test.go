@@ -0,0 +1,72 @@
+package merkletree
+
+import (
+	"bytes"
+	"context"
+	"testing"
+)
+
+func Test_Builder(t *testing.T) {
+	leaves := testLeaves(9)
+	for _, opts := range [][]Option{nil, {WithInsertionOrder()}, {WithOddNodePolicy(DuplicateLast)}} {
+		b, err := NewBuilder(opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, leaf := range leaves {
+			if err := b.Add(leaf); err != nil {
+				t.Fatal(err)
+			}
+		}
+		tree, err := b.Build()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := NewTreeWithOptions(leaves, opts...)
+		if !bytes.Equal(tree.MerkleRoot(), want.MerkleRoot()) {
+			t.Fatal("built tree differs from the tree over the same slice")
+		}
+		if ok, err := tree.VerifyTree(); err != nil || !ok {
+			t.Fatal("built tree does not verify")
+		}
+		if err := b.Add(leaves[0]); err == nil {
+			t.Fatal("expected error adding to a built tree")
+		}
+	}
+
+	ch := make(chan Content)
+	go func() {
+		for _, leaf := range leaves {
+			ch <- leaf
+		}
+		close(ch)
+	}()
+	b, _ := NewBuilder()
+	if err := b.AddFrom(context.Background(), ch); err != nil {
+		t.Fatal(err)
+	}
+	if b.Len() != len(leaves) {
+		t.Fatalf("%d leaves added, want %d", b.Len(), len(leaves))
+	}
+	tree, _ := b.Build()
+	want, _ := NewTree(leaves)
+	if !bytes.Equal(tree.MerkleRoot(), want.MerkleRoot()) {
+		t.Fatal("tree built from a channel has the wrong root")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	b, _ = NewBuilder()
+	if err := b.AddFrom(ctx, make(chan Content)); err != context.Canceled {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if _, err := b.Build(); err != ErrEmptyTree {
+		t.Fatalf("got %v, want ErrEmptyTree", err)
+	}
+
+	b, _ = NewBuilder(WithDuplicatePolicy(ErrorOnDuplicate))
+	b.Add(leaves[0])
+	if err := b.Add(leaves[0]); err != ErrDuplicateLeaf {
+		t.Fatalf("got %v, want ErrDuplicateLeaf", err)
+	}
+}
@@ -0,0 +1,202 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const bundleBinaryVersion byte = 1
+
+// ProofBundle is a compressed set of proofs against the same root. Leaf and sibling
+// hashes are stored once in Hashes and the proofs refer to them by index, so the
+// upper-level hashes shared by most proofs of a large distribution are not
+// repeated for every leaf.
+type ProofBundle struct {
+	Root   []byte
+	Hashes [][]byte
+	Proofs []BundledProof
+}
+
+// BundledProof is a proof inside a ProofBundle. Leaf and Siblings are indexes into
+// the bundle's Hashes and Path holds the directions of MerkleProof.Path.
+type BundledProof struct {
+	Leaf     uint64
+	Siblings []uint64
+	Path     []int64
+}
+
+// BundleProofs compresses proofs into a bundle. All proofs must carry the same Root.
+func BundleProofs(proofs []*Proof) (*ProofBundle, error) {
+	if len(proofs) == 0 {
+		return nil, errors.New("error: no proofs to bundle")
+	}
+
+	b := &ProofBundle{Root: proofs[0].Root}
+	refs := make(map[string]uint64)
+	ref := func(h []byte) uint64 {
+		i, ok := refs[string(h)]
+		if !ok {
+			i = uint64(len(b.Hashes))
+			refs[string(h)] = i
+			b.Hashes = append(b.Hashes, h)
+		}
+		return i
+	}
+
+	for i, p := range proofs {
+		if !bytes.Equal(p.Root, b.Root) {
+			return nil, fmt.Errorf("error: proof %d has a different root", i)
+		}
+		bp := BundledProof{Leaf: ref(p.LeafHash), Path: p.Path}
+		for _, sibling := range p.Siblings {
+			bp.Siblings = append(bp.Siblings, ref(sibling))
+		}
+		b.Proofs = append(b.Proofs, bp)
+	}
+	return b, nil
+}
+
+// Expand restores the proofs of the bundle in the order they were bundled.
+func (b *ProofBundle) Expand() ([]*Proof, error) {
+	lookup := func(i uint64) ([]byte, error) {
+		if i >= uint64(len(b.Hashes)) {
+			return nil, fmt.Errorf("error: hash reference %d out of range", i)
+		}
+		return b.Hashes[i], nil
+	}
+
+	proofs := make([]*Proof, 0, len(b.Proofs))
+	for _, bp := range b.Proofs {
+		leaf, err := lookup(bp.Leaf)
+		if err != nil {
+			return nil, err
+		}
+		p := &Proof{LeafHash: leaf, Root: b.Root, Path: bp.Path}
+		for _, i := range bp.Siblings {
+			sibling, err := lookup(i)
+			if err != nil {
+				return nil, err
+			}
+			p.Siblings = append(p.Siblings, sibling)
+		}
+		proofs = append(proofs, p)
+	}
+	return proofs, nil
+}
+
+// MarshalBinary encodes the bundle as a version byte, the root, the hash table and
+// the proofs. Every length, count and hash reference is a uvarint.
+func (b *ProofBundle) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(bundleBinaryVersion)
+	writeBytes(&buf, b.Root)
+	writeUvarint(&buf, uint64(len(b.Hashes)))
+	for _, h := range b.Hashes {
+		writeBytes(&buf, h)
+	}
+
+	writeUvarint(&buf, uint64(len(b.Proofs)))
+	for _, bp := range b.Proofs {
+		writeUvarint(&buf, bp.Leaf)
+		writeUvarint(&buf, uint64(len(bp.Siblings)))
+		for _, i := range bp.Siblings {
+			writeUvarint(&buf, i)
+		}
+		writeUvarint(&buf, uint64(len(bp.Path)))
+		for _, direction := range bp.Path {
+			if direction != 0 && direction != 1 {
+				return nil, fmt.Errorf("error: invalid direction %d", direction)
+			}
+			buf.WriteByte(byte(direction))
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a bundle encoded by MarshalBinary.
+func (b *ProofBundle) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	version, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if version != bundleBinaryVersion {
+		return fmt.Errorf("error: unsupported bundle version %d", version)
+	}
+
+	var decoded ProofBundle
+	if decoded.Root, err = readBytes(r); err != nil {
+		return err
+	}
+
+	count, err := readCount(r)
+	if err != nil {
+		return err
+	}
+	for i := uint64(0); i < count; i++ {
+		h, err := readBytes(r)
+		if err != nil {
+			return err
+		}
+		decoded.Hashes = append(decoded.Hashes, h)
+	}
+
+	if count, err = readCount(r); err != nil {
+		return err
+	}
+	for i := uint64(0); i < count; i++ {
+		var bp BundledProof
+		if bp.Leaf, err = binary.ReadUvarint(r); err != nil {
+			return err
+		}
+
+		siblings, err := readCount(r)
+		if err != nil {
+			return err
+		}
+		for j := uint64(0); j < siblings; j++ {
+			ref, err := binary.ReadUvarint(r)
+			if err != nil {
+				return err
+			}
+			bp.Siblings = append(bp.Siblings, ref)
+		}
+
+		directions, err := readCount(r)
+		if err != nil {
+			return err
+		}
+		for j := uint64(0); j < directions; j++ {
+			direction, err := r.ReadByte()
+			if err != nil {
+				return err
+			}
+			if direction > 1 {
+				return fmt.Errorf("error: invalid direction %d", direction)
+			}
+			bp.Path = append(bp.Path, int64(direction))
+		}
+		decoded.Proofs = append(decoded.Proofs, bp)
+	}
+	if r.Len() != 0 {
+		return errors.New("error: trailing data after bundle")
+	}
+
+	*b = decoded
+	return nil
+}
+
+// readCount reads a uvarint element count, rejecting counts larger than the rest
+// of the input since every element takes at least one byte.
+func readCount(r *bytes.Reader) (uint64, error) {
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return 0, err
+	}
+	if count > uint64(r.Len()) {
+		return 0, errors.New("error: count exceeds input size")
+	}
+	return count, nil
+}
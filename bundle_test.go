@@ -0,0 +1,64 @@
+package merkletree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_ProofBundle(t *testing.T) {
+	tree, err := NewTree(testLeaves(100))
+	if err != nil {
+		t.Fatal(err)
+	}
+	all, err := tree.AllProofs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	proofs := make([]*Proof, len(all))
+	naive := 0
+	for i := range proofs {
+		proofs[i] = all[i]
+		bin, _ := proofs[i].MarshalBinary()
+		naive += len(bin)
+	}
+
+	bundle, err := BundleProofs(proofs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bin, err := bundle.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bin)*2 > naive {
+		t.Fatalf("bundle of %d bytes is not much smaller than %d bytes of proofs", len(bin), naive)
+	}
+
+	var decoded ProofBundle
+	if err := decoded.UnmarshalBinary(bin); err != nil {
+		t.Fatal(err)
+	}
+	expanded, err := decoded.Expand()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(expanded, proofs) {
+		t.Fatal("expanded proofs differ from the bundled ones")
+	}
+	for _, p := range expanded {
+		if ok, err := p.Verify(); err != nil || !ok {
+			t.Fatal("expanded proof does not verify")
+		}
+	}
+
+	other, _ := NewTree(testLeaves(3))
+	foreign, _ := other.GetProof(testLeaves(3)[0])
+	if _, err := BundleProofs(append(proofs, foreign)); err == nil {
+		t.Fatal("expected error for proofs with different roots")
+	}
+
+	decoded.Proofs[0].Siblings[0] = uint64(len(decoded.Hashes))
+	if _, err := decoded.Expand(); err == nil {
+		t.Fatal("expected error for an out of range reference")
+	}
+}
@@ -0,0 +1,152 @@
+package merkletree
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"hash"
+	"sync"
+)
+
+// defaultTreeCacheCapacity is the capacity of a zero TreeCache.
+const defaultTreeCacheCapacity = 64
+
+// TreeCache memoizes trees by the set of leaf hashes and the hash strategy they
+// were built with, so that rebuilding an identical leaf set is free. A TreeCache
+// is safe for concurrent use.
+//
+// The cache holds at most its capacity of trees, each with all of its nodes, and
+// evicts the least recently used one when a new tree would exceed it. The zero
+// value is ready to use with a capacity of 64 trees; NewTreeCache sets another.
+// Reset empties the cache, and Delete drops a single leaf set.
+//
+// Trees returned by GetOrBuild are shared between all callers asking for the same
+// leaf set. Treat them as read-only; build a private tree from the same content
+// before calling RebuildTree or RebuildTreeWith on it.
+type TreeCache struct {
+	mu       sync.Mutex
+	capacity int
+	trees    map[string]*list.Element
+	// lru holds the cached trees, most recently used first
+	lru list.List
+}
+
+// treeCacheEntry is an element of TreeCache.lru.
+type treeCacheEntry struct {
+	key  string
+	tree *MerkleTree
+}
+
+// NewTreeCache returns an empty cache holding at most capacity trees.
+func NewTreeCache(capacity int) *TreeCache {
+	if capacity < 1 {
+		capacity = 1
+	}
+	return &TreeCache{capacity: capacity}
+}
+
+// GetOrBuild returns the cached tree for the leaf set of cs, building and caching
+// it first if this leaf set has not been seen with hashStrategy before.
+func (c *TreeCache) GetOrBuild(cs []Content, hashStrategy func() hash.Hash) (*MerkleTree, error) {
+	t := &MerkleTree{
+		hashStrategy: hashStrategy,
+	}
+	leafs, err := newLeafs(context.Background(), cs, t)
+	if err != nil {
+		return nil, err
+	}
+	key := leafSetKey(leafs, hashStrategy)
+
+	if cached := c.get(key); cached != nil {
+		return cached, nil
+	}
+
+	root, err := buildIntermediate(context.Background(), leafs, t)
+	if err != nil {
+		return nil, err
+	}
+	t.Root = root
+	t.Leafs = leafs
+	t.merkleRoot = root.Hash
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.trees[key]; ok {
+		// another caller built the same tree concurrently
+		c.lru.MoveToFront(e)
+		return e.Value.(*treeCacheEntry).tree, nil
+	}
+	if c.trees == nil {
+		c.trees = make(map[string]*list.Element)
+	}
+	capacity := c.capacity
+	if capacity == 0 {
+		capacity = defaultTreeCacheCapacity
+	}
+	for c.lru.Len() >= capacity {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.trees, oldest.Value.(*treeCacheEntry).key)
+	}
+	c.trees[key] = c.lru.PushFront(&treeCacheEntry{key: key, tree: t})
+	return t, nil
+}
+
+// get returns the cached tree with key, marking it used, or nil.
+func (c *TreeCache) get(key string) *MerkleTree {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.trees[key]
+	if !ok {
+		return nil
+	}
+	c.lru.MoveToFront(e)
+	return e.Value.(*treeCacheEntry).tree
+}
+
+// Delete drops the tree cached for the leaf set of cs with hashStrategy, if any.
+func (c *TreeCache) Delete(cs []Content, hashStrategy func() hash.Hash) error {
+	leafs, err := newLeafs(context.Background(), cs, &MerkleTree{hashStrategy: hashStrategy})
+	if err != nil {
+		return err
+	}
+	key := leafSetKey(leafs, hashStrategy)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.trees[key]; ok {
+		c.lru.Remove(e)
+		delete(c.trees, key)
+	}
+	return nil
+}
+
+// Reset drops every cached tree.
+func (c *TreeCache) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.trees = nil
+	c.lru.Init()
+}
+
+// Len returns the number of cached trees.
+func (c *TreeCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+// leafSetKey digests the sorted leaf hashes together with a fingerprint of the
+// hash strategy, since the leaf hashes alone do not depend on it.
+func leafSetKey(leafs []*Node, hashStrategy func() hash.Hash) string {
+	h := sha256.New()
+	h.Write(hashFingerprint(hashStrategy))
+	var l [4]byte
+	for _, leaf := range leafs {
+		binary.BigEndian.PutUint32(l[:], uint32(len(leaf.Hash)))
+		h.Write(l[:])
+		h.Write(leaf.Hash)
+	}
+	return string(h.Sum(nil))
+}
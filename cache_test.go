@@ -0,0 +1,88 @@
+package merkletree
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+func Test_TreeCache(t *testing.T) {
+	var cache TreeCache
+	leaves := testLeaves(6)
+
+	first, err := cache.GetOrBuild(leaves, sha3.NewLegacyKeccak256)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// same leaf set in a different order hits the cache
+	reversed := make([]Content, len(leaves))
+	for i, leaf := range leaves {
+		reversed[len(leaves)-1-i] = leaf
+	}
+	second, err := cache.GetOrBuild(reversed, sha3.NewLegacyKeccak256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first != second {
+		t.Fatal("expected the cached tree instance")
+	}
+
+	other, err := cache.GetOrBuild(leaves, sha256.New)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if other == first {
+		t.Fatal("trees built with different hash strategies must not share a cache entry")
+	}
+
+	smaller, err := cache.GetOrBuild(leaves[:5], sha3.NewLegacyKeccak256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if smaller == first {
+		t.Fatal("different leaf sets must not share a cache entry")
+	}
+
+	if err := cache.Delete(leaves, sha256.New); err != nil {
+		t.Fatal(err)
+	}
+	if cache.Len() != 2 {
+		t.Fatalf("expected 2 cached trees after a delete, got %d", cache.Len())
+	}
+	cache.Reset()
+	if again, _ := cache.GetOrBuild(leaves, sha3.NewLegacyKeccak256); again == first || cache.Len() != 1 {
+		t.Fatal("expected an empty cache after a reset")
+	}
+}
+
+func Test_TreeCacheEviction(t *testing.T) {
+	cache := NewTreeCache(2)
+	leaves := testLeaves(6)
+	a, _ := cache.GetOrBuild(leaves[:1], sha3.NewLegacyKeccak256)
+	b, _ := cache.GetOrBuild(leaves[:2], sha3.NewLegacyKeccak256)
+
+	// using a makes b the least recently used tree, which c evicts
+	if again, _ := cache.GetOrBuild(leaves[:1], sha3.NewLegacyKeccak256); again != a {
+		t.Fatal("expected the cached tree instance")
+	}
+	cache.GetOrBuild(leaves[:3], sha3.NewLegacyKeccak256)
+	if cache.Len() != 2 {
+		t.Fatalf("expected the cache to hold 2 trees, got %d", cache.Len())
+	}
+	if again, _ := cache.GetOrBuild(leaves[:1], sha3.NewLegacyKeccak256); again != a {
+		t.Fatal("expected the recently used tree to stay cached")
+	}
+	if again, _ := cache.GetOrBuild(leaves[:2], sha3.NewLegacyKeccak256); again == b {
+		t.Fatal("expected the least recently used tree to be evicted")
+	}
+
+	var zero TreeCache
+	for n := 1; n <= defaultTreeCacheCapacity+5; n++ {
+		zero.GetOrBuild(testLeaves(n), sha3.NewLegacyKeccak256)
+	}
+	if zero.Len() != defaultTreeCacheCapacity {
+		t.Fatalf("expected the zero cache to hold %d trees, got %d", defaultTreeCacheCapacity, zero.Len())
+	}
+}
@@ -0,0 +1,112 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"io"
+)
+
+// ChunkedTree is a tree over a stream split into chunks of a fixed size, so that
+// a large file can be distributed in chunks each verified on arrival. Leaf i
+// holds the hash of chunk i, of bytes [i*ChunkSize, (i+1)*ChunkSize) of the
+// stream, under the hash function of the tree; only the last chunk may be
+// shorter. Leaves keep the order of the stream.
+type ChunkedTree struct {
+	*MerkleTree
+	// ChunkSize is the size of every chunk but the last
+	ChunkSize int
+	// Size is the number of bytes read from the stream
+	Size int64
+}
+
+// NewChunkedTree reads r to its end and builds the tree over its chunks of
+// chunkSize bytes, configured by opts as in NewTreeWithOptions plus
+// WithInsertionOrder. Only the chunk hashes are kept in memory. An empty stream
+// gives the empty root of the tree, if it has one.
+func NewChunkedTree(r io.Reader, chunkSize int, opts ...Option) (*ChunkedTree, error) {
+	if chunkSize <= 0 {
+		return nil, errors.New("error: chunk size must be positive")
+	}
+	b, err := NewBuilder(append(opts, WithInsertionOrder())...)
+	if err != nil {
+		return nil, err
+	}
+
+	var size int64
+	buf := make([]byte, chunkSize)
+	for {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			size += int64(n)
+			hashBz, err := chunkHash(b.t, buf[:n])
+			if err != nil {
+				return nil, err
+			}
+			if err := b.Add(HashContent(hashBz)); err != nil {
+				return nil, err
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	t, err := b.Build()
+	if err != nil {
+		return nil, err
+	}
+	return &ChunkedTree{MerkleTree: t, ChunkSize: chunkSize, Size: size}, nil
+}
+
+// ChunkCount returns the number of chunks of the stream.
+func (c *ChunkedTree) ChunkCount() int {
+	return len(c.Leafs)
+}
+
+// GetChunkProof returns the proof of chunk i, see VerifyChunk.
+func (c *ChunkedTree) GetChunkProof(i int) (*MerkleProof, error) {
+	proof, err := c.GetProofByIndex(i)
+	if err != nil {
+		return nil, err
+	}
+	// Streams often repeat chunks, e.g. runs of zeros, and a node equal to its
+	// sibling gets its direction from its hash rather than its position. The
+	// sibling is the same either way, so the directions are taken from i.
+	proof.Path, _ = expectedPathLevels(uint64(i), uint64(len(c.Leafs)), c.duplicateOdd)
+	return proof, nil
+}
+
+// VerifyChunk checks that proof shows chunk as chunk index of the chunkCount
+// chunks of a stream with the given root. opts must be those the tree was built
+// with.
+func VerifyChunk(root []byte, index, chunkCount int, chunk []byte, proof *MerkleProof, opts ...Option) (bool, error) {
+	if proof == nil {
+		return false, errors.New("error: nil proof")
+	}
+	opts = append(opts, WithInsertionOrder())
+	t := newConfiguredTree(opts)
+	hashBz, err := chunkHash(t, chunk)
+	if err != nil {
+		return false, err
+	}
+	leafHash, err := t.leafHash(HashContent(hashBz))
+	if err != nil {
+		return false, err
+	}
+	if !bytes.Equal(leafHash, proof.LeafHash) {
+		return false, nil
+	}
+	return VerifyIndexedProof(root, index, chunkCount, proof, opts...)
+}
+
+// chunkHash returns the hash of chunk under the hash function of m.
+func chunkHash(m *MerkleTree, chunk []byte) ([]byte, error) {
+	h := m.hashStrategy()
+	if _, err := h.Write(chunk); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
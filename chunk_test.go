@@ -0,0 +1,79 @@
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"testing"
+	"testing/iotest"
+)
+
+func Test_ChunkedTree(t *testing.T) {
+	data := make([]byte, 10*1024+100)
+	for i := range data {
+		data[i] = byte(i*7 + i/1024)
+	}
+	for _, opts := range [][]Option{nil, {WithHashStrategy(sha256.New)}, {WithRFC6962()}} {
+		// a reader returning one byte at a time still fills whole chunks
+		tree, err := NewChunkedTree(iotest.OneByteReader(bytes.NewReader(data)), 1024, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tree.ChunkCount() != 11 || tree.Size != int64(len(data)) {
+			t.Fatalf("unexpected %d chunks over %d bytes", tree.ChunkCount(), tree.Size)
+		}
+
+		for i := 0; i < tree.ChunkCount(); i++ {
+			end := (i + 1) * 1024
+			if end > len(data) {
+				end = len(data)
+			}
+			chunk := data[i*1024 : end]
+			proof, err := tree.GetChunkProof(i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := VerifyChunk(tree.MerkleRoot(), i, tree.ChunkCount(), chunk, proof, opts...); err != nil || !ok {
+				t.Fatalf("chunk %d does not verify", i)
+			}
+			if ok, _ := VerifyChunk(tree.MerkleRoot(), i, tree.ChunkCount(), chunk[1:], proof, opts...); ok {
+				t.Fatal("altered chunk verifies")
+			}
+			if i > 0 {
+				if ok, _ := VerifyChunk(tree.MerkleRoot(), i-1, tree.ChunkCount(), chunk, proof, opts...); ok {
+					t.Fatal("chunk verifies at another index")
+				}
+			}
+		}
+	}
+
+	// repeated chunks keep their own leaves
+	tree, err := NewChunkedTree(bytes.NewReader(make([]byte, 4096)), 1024)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tree.ChunkCount() != 4 {
+		t.Fatalf("expected 4 chunks, got %d", tree.ChunkCount())
+	}
+	for i := 0; i < 4; i++ {
+		proof, _ := tree.GetChunkProof(i)
+		if ok, err := VerifyChunk(tree.MerkleRoot(), i, 4, make([]byte, 1024), proof); err != nil || !ok {
+			t.Fatalf("repeated chunk %d does not verify", i)
+		}
+	}
+
+	if _, err := NewChunkedTree(bytes.NewReader(nil), 1024); err != ErrEmptyTree {
+		t.Fatalf("expected ErrEmptyTree, got %v", err)
+	}
+	tree, err = NewChunkedTree(bytes.NewReader(nil), 1024, WithEmptyRoot(ZeroRoot(32)))
+	if err != nil || !bytes.Equal(tree.MerkleRoot(), ZeroRoot(32)) {
+		t.Fatal("expected the empty root for an empty stream")
+	}
+	if _, err := NewChunkedTree(bytes.NewReader(data), 0); err == nil {
+		t.Fatal("expected error for a chunk size of zero")
+	}
+	readErr := errors.New("read failed")
+	if _, err := NewChunkedTree(iotest.ErrReader(readErr), 1024); err != readErr {
+		t.Fatalf("expected the read error, got %v", err)
+	}
+}
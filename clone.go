@@ -0,0 +1,58 @@
+package merkletree
+
+// Clone returns an independent copy of the tree: its nodes, leaves and root
+// history are copied, so changes to either tree leave the other untouched. The
+// contents of the leaves are shared, as are the snapshots of retained versions,
+// which never change. The copy starts with an empty proof cache and without root
+// change callbacks.
+func (m *MerkleTree) Clone() *MerkleTree {
+	c := *m
+	t := &c
+	t.building = nil
+	t.rootListeners = nil
+	if m.proofCache != nil {
+		t.proofCache = &proofCache{}
+	}
+	if m.rootHistory != nil {
+		t.rootHistory = &rootHistory{
+			records: append([]RootRecord(nil), m.rootHistory.records...),
+			next:    m.rootHistory.next,
+			full:    m.rootHistory.full,
+		}
+	}
+	t.versions = append([]*Snapshot(nil), m.versions...)
+
+	copies := make(map[*Node]*Node, 2*len(m.Leafs))
+	var clone func(n, parent *Node) *Node
+	clone = func(n, parent *Node) *Node {
+		if cn, ok := copies[n]; ok {
+			return cn
+		}
+		cn := &Node{
+			Tree:   t,
+			Parent: parent,
+			leaf:   n.leaf,
+			single: n.single,
+			Hash:   append([]byte(nil), n.Hash...),
+			C:      n.C,
+			epoch:  t.epoch,
+		}
+		copies[n] = cn
+		if !n.leaf {
+			cn.Left = clone(n.Left, cn)
+			cn.Right = clone(n.Right, cn)
+		}
+		return cn
+	}
+
+	t.Root = nil
+	if m.Root != nil {
+		t.Root = clone(m.Root, nil)
+	}
+	t.Leafs = make([]*Node, len(m.Leafs))
+	for i, leaf := range m.Leafs {
+		t.Leafs[i] = copies[leaf]
+	}
+	t.merkleRoot = append([]byte(nil), m.merkleRoot...)
+	return t
+}
@@ -0,0 +1,43 @@
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_Clone(t *testing.T) {
+	leaves := testLeaves(9)
+	for _, opts := range incrementalOptions {
+		tree, err := NewTreeWithOptions(leaves[:7], append(opts, WithProofCache())...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		clone := tree.Clone()
+		checkSameTree(t, clone, leaves[:7], opts)
+		for i := range tree.Leafs {
+			if clone.Leafs[i] == tree.Leafs[i] || !bytes.Equal(clone.Leafs[i].Hash, tree.Leafs[i].Hash) {
+				t.Fatal("expected copied leaves in the same order")
+			}
+		}
+
+		// changes to the clone do not reach the original, nor the other way round
+		if err := clone.AddLeaf(leaves[7]); err != nil {
+			t.Fatal(err)
+		}
+		if err := clone.UpdateContent(leaves[0], leaves[8]); err != nil {
+			t.Fatal(err)
+		}
+		checkSameTree(t, tree, leaves[:7], opts)
+		if _, err := tree.RemoveLeaf(leaves[1]); err != nil {
+			t.Fatal(err)
+		}
+		cs := append([]Content{leaves[8]}, leaves[1:8]...)
+		checkSameTree(t, clone, cs, opts)
+	}
+
+	tree, _ := NewTreeWithOptions(nil, WithEmptyRoot(ZeroRoot(32)))
+	clone := tree.Clone()
+	if clone.Root != nil || !bytes.Equal(clone.MerkleRoot(), ZeroRoot(32)) {
+		t.Fatal("expected an empty clone")
+	}
+}
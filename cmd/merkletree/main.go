@@ -0,0 +1,396 @@
+// Command merkletree builds trees from files of leaves and produces and checks
+// their proofs.
+//
+// Usage:
+//
+//	merkletree root   -in FILE
+//	merkletree prove  -in FILE -leaf LEAF
+//	merkletree verify -proof FILE [-root ROOT] | -claims FILE
+//	merkletree export -in FILE -out FILE
+//	merkletree import -in FILE [-out FILE]
+//	merkletree serve  -in FILE [-addr ADDR]
+//
+// An input file is either a list of 0x-prefixed hex leaf hashes, one per line,
+// or, if its name ends in .csv or -csv is set, a balance snapshot turned into an
+// airdrop distribution. Proofs of hex leaves are printed in their canonical JSON
+// form, proofs of snapshot accounts as claims. export writes a binary backup of
+// a tree of hex leaves or the claims file of a snapshot, and import restores a
+// backup and writes its leaves back as hex. serve answers proof requests for a
+// tree of hex leaves over HTTP, see package proofserver.
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/smartbch/merkletree"
+	"github.com/smartbch/merkletree/airdrop"
+	"github.com/smartbch/merkletree/merkleproof"
+	"github.com/smartbch/merkletree/proofserver"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+// errInvalid is returned by verify for a proof that does not hold.
+var errInvalid = errors.New("invalid")
+
+var commands = map[string]func(*config, []string) error{
+	"root":   cmdRoot,
+	"prove":  cmdProve,
+	"verify": cmdVerify,
+	"export": cmdExport,
+	"import": cmdImport,
+	"serve":  cmdServe,
+}
+
+// run executes the command in args and returns the exit status.
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 || commands[args[0]] == nil {
+		fmt.Fprintln(stderr, "usage: merkletree root|prove|verify|export|import|serve [flags]")
+		return 2
+	}
+	c := &config{stdin: stdin, stdout: stdout}
+	if err := commands[args[0]](c, args[1:]); err != nil {
+		if err == flag.ErrHelp {
+			return 2
+		}
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+// config holds the flags shared by the commands.
+type config struct {
+	stdin  io.Reader
+	stdout io.Writer
+	fs     *flag.FlagSet
+
+	in       string
+	hashName string
+	ordered  bool
+	csv      bool
+	address  string
+	amount   string
+	decimals int
+}
+
+// flags returns the flag set of cmd with the flags every command takes.
+func (c *config) flags(cmd string) *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd, flag.ContinueOnError)
+	fs.SetOutput(c.stdout)
+	fs.StringVar(&c.in, "in", "-", "input `file`, - for standard input")
+	fs.StringVar(&c.hashName, "hash", "keccak256", "hash function of the tree")
+	fs.BoolVar(&c.ordered, "ordered", false, "keep leaves in file order and hash pairs positionally")
+	fs.BoolVar(&c.csv, "csv", false, "read the input as a CSV balance snapshot")
+	fs.StringVar(&c.address, "address-column", "address", "address column of a snapshot")
+	fs.StringVar(&c.amount, "amount-column", "amount", "amount column of a snapshot")
+	fs.IntVar(&c.decimals, "decimals", 0, "decimals of snapshot amounts")
+	c.fs = fs
+	return fs
+}
+
+func (c *config) isCSV() bool {
+	return c.csv || strings.HasSuffix(strings.ToLower(c.in), ".csv")
+}
+
+func (c *config) open(name string) (io.ReadCloser, error) {
+	if name == "-" {
+		return io.NopCloser(c.stdin), nil
+	}
+	return os.Open(name)
+}
+
+func (c *config) hashStrategy() (func() hash.Hash, error) {
+	return merkletree.HashStrategyByName(c.hashName)
+}
+
+// loadTree builds the tree over the hex leaves of the input.
+func (c *config) loadTree() (*merkletree.MerkleTree, error) {
+	h, err := c.hashStrategy()
+	if err != nil {
+		return nil, err
+	}
+	f, err := c.open(c.in)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var leaves []merkletree.Content
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		leaf, err := hexutil.Decode(text)
+		if err != nil {
+			return nil, fmt.Errorf("error: line %d: %w", line, err)
+		}
+		leaves = append(leaves, merkletree.HashContent(leaf))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	opts := []merkletree.Option{merkletree.WithHashStrategy(h)}
+	if c.ordered {
+		opts = append(opts, merkletree.WithInsertionOrder())
+	}
+	return merkletree.NewTreeWithOptions(leaves, opts...)
+}
+
+// loadDistribution builds the airdrop distribution of the snapshot input.
+func (c *config) loadDistribution() (*airdrop.Distribution, error) {
+	f, err := c.open(c.in)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return airdrop.NewFromCSV(f,
+		airdrop.AddressField(c.address), airdrop.AmountField(c.amount), airdrop.Decimals(c.decimals))
+}
+
+func (c *config) printJSON(v interface{}) error {
+	enc := json.NewEncoder(c.stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+func cmdRoot(c *config, args []string) error {
+	if err := c.flags("root").Parse(args); err != nil {
+		return err
+	}
+	if c.isCSV() {
+		d, err := c.loadDistribution()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.stdout, d.MerkleRoot.Hex())
+		return nil
+	}
+	tree, err := c.loadTree()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintln(c.stdout, hexutil.Encode(tree.MerkleRoot()))
+	return nil
+}
+
+func cmdProve(c *config, args []string) error {
+	fs := c.flags("prove")
+	leaf := fs.String("leaf", "", "hex leaf hash, or account address of a snapshot")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if c.isCSV() {
+		if !common.IsHexAddress(*leaf) {
+			return fmt.Errorf("error: invalid address %q", *leaf)
+		}
+		d, err := c.loadDistribution()
+		if err != nil {
+			return err
+		}
+		claim := d.ClaimOf(common.HexToAddress(*leaf))
+		if claim == nil {
+			return fmt.Errorf("error: %s has no claim", *leaf)
+		}
+		return c.printJSON(claim)
+	}
+
+	leafHash, err := hexutil.Decode(*leaf)
+	if err != nil {
+		return fmt.Errorf("error: invalid leaf: %w", err)
+	}
+	tree, err := c.loadTree()
+	if err != nil {
+		return err
+	}
+	proof, err := tree.GetProof(merkletree.HashContent(leafHash))
+	if err != nil {
+		return err
+	}
+	return c.printJSON(proof)
+}
+
+func cmdVerify(c *config, args []string) error {
+	fs := c.flags("verify")
+	proofFile := fs.String("proof", "", "proof `file` in canonical JSON form")
+	claimsFile := fs.String("claims", "", "claims `file` of a distribution to check entirely")
+	root := fs.String("root", "", "trusted root, instead of the one in the proof")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *claimsFile != "" {
+		f, err := c.open(*claimsFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		d, err := airdrop.ReadJSON(f)
+		if err != nil {
+			return err
+		}
+		if *root != "" && !strings.EqualFold(*root, d.MerkleRoot.Hex()) {
+			return errInvalid
+		}
+		fmt.Fprintf(c.stdout, "valid: %d claims\n", len(d.Claims))
+		return nil
+	}
+
+	if *proofFile == "" {
+		return errors.New("error: verify needs -proof or -claims")
+	}
+	h, err := c.hashStrategy()
+	if err != nil {
+		return err
+	}
+	f, err := c.open(*proofFile)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	proof, err := merkleproof.ParseProofJSON(data)
+	if err != nil {
+		return err
+	}
+	if *root != "" {
+		if proof.Root, err = hexutil.Decode(*root); err != nil {
+			return fmt.Errorf("error: invalid root: %w", err)
+		}
+	}
+
+	var ok bool
+	if c.ordered {
+		ok, err = proof.VerifyPositional(h)
+	} else {
+		ok, err = proof.VerifyWithHashStrategy(h)
+	}
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errInvalid
+	}
+	fmt.Fprintln(c.stdout, "valid")
+	return nil
+}
+
+func cmdExport(c *config, args []string) error {
+	fs := c.flags("export")
+	out := fs.String("out", "-", "output `file`, - for standard output")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	w, closeOut, err := c.create(*out)
+	if err != nil {
+		return err
+	}
+	defer closeOut()
+
+	if c.isCSV() {
+		d, err := c.loadDistribution()
+		if err != nil {
+			return err
+		}
+		if err := d.WriteJSON(w); err != nil {
+			return err
+		}
+		return closeOut()
+	}
+	tree, err := c.loadTree()
+	if err != nil {
+		return err
+	}
+	if _, err := tree.WriteTo(w); err != nil {
+		return err
+	}
+	return closeOut()
+}
+
+func cmdImport(c *config, args []string) error {
+	fs := c.flags("import")
+	out := fs.String("out", "-", "output `file` of hex leaves, - for standard output")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	f, err := c.open(c.in)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	// the backup records the layout of the tree, so -ordered does not apply
+	tree, err := merkletree.ReadTreeFrom(f, nil)
+	if err != nil {
+		return err
+	}
+	if ok, err := tree.VerifyTree(); err != nil || !ok {
+		return errors.New("error: backup does not verify")
+	}
+
+	w, closeOut, err := c.create(*out)
+	if err != nil {
+		return err
+	}
+	defer closeOut()
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "# root %s\n", hexutil.Encode(tree.MerkleRoot()))
+	for _, leaf := range tree.Leafs {
+		fmt.Fprintln(bw, hexutil.Encode(leaf.Hash))
+	}
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return closeOut()
+}
+
+func cmdServe(c *config, args []string) error {
+	fs := c.flags("serve")
+	addr := fs.String("addr", ":8080", "TCP `address` to listen on")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	tree, err := c.loadTree()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(c.stdout, "serving root %s on %s\n", hexutil.Encode(tree.MerkleRoot()), *addr)
+	return proofserver.ListenAndServe(*addr, tree.Freeze())
+}
+
+// create opens the output name, returning a function that closes it. Calls of
+// the function after the first return nil, so it can be both deferred and
+// called for its error.
+func (c *config) create(name string) (io.Writer, func() error, error) {
+	if name == "-" {
+		return c.stdout, func() error { return nil }, nil
+	}
+	f, err := os.Create(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	var closed bool
+	return f, func() error {
+		if closed {
+			return nil
+		}
+		closed = true
+		return f.Close()
+	}, nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/smartbch/merkletree/airdrop"
+)
+
+// runCmd runs the command line args with stdin and returns its exit status and
+// output.
+func runCmd(stdin string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	status := run(args, strings.NewReader(stdin), &stdout, &stderr)
+	return status, stdout.String(), stderr.String()
+}
+
+func writeLeaves(t *testing.T, dir string, n int) (string, []string) {
+	t.Helper()
+	var leaves []string
+	for i := 0; i < n; i++ {
+		leaves = append(leaves, fmt.Sprintf("0x%x", crypto.Keccak256([]byte{byte(i)})))
+	}
+	name := filepath.Join(dir, "leaves.txt")
+	content := "# test leaves\n" + strings.Join(leaves, "\n") + "\n\n"
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return name, leaves
+}
+
+func Test_RootProveVerify(t *testing.T) {
+	dir := t.TempDir()
+	in, leaves := writeLeaves(t, dir, 7)
+
+	for _, mode := range [][]string{nil, {"-ordered"}, {"-hash", "sha256"}} {
+		status, root, stderr := runCmd("", append([]string{"root", "-in", in}, mode...)...)
+		if status != 0 {
+			t.Fatal(stderr)
+		}
+		root = strings.TrimSpace(root)
+
+		status, proof, stderr := runCmd("", append([]string{"prove", "-in", in, "-leaf", leaves[3]}, mode...)...)
+		if status != 0 {
+			t.Fatal(stderr)
+		}
+		status, out, stderr := runCmd(proof, append([]string{"verify", "-proof", "-", "-root", root}, mode...)...)
+		if status != 0 || strings.TrimSpace(out) != "valid" {
+			t.Fatalf("proof does not verify: %s", stderr)
+		}
+		if status, _, _ := runCmd(proof, append([]string{"verify", "-proof", "-", "-root", leaves[0]}, mode...)...); status != 1 {
+			t.Fatal("proof verifies against another root")
+		}
+	}
+
+	if status, _, _ := runCmd("", "prove", "-in", in, "-leaf", "0x1234"); status != 1 {
+		t.Fatal("expected failure proving a missing leaf")
+	}
+	if status, _, _ := runCmd("not hex\n", "root"); status != 1 {
+		t.Fatal("expected failure for an invalid leaf")
+	}
+	if status, _, _ := runCmd("", "serve", "-in", in, "-addr", "256.0.0.1:http"); status != 1 {
+		t.Fatal("expected failure listening on an invalid address")
+	}
+	if status, _, _ := runCmd("", "frobnicate"); status != 2 {
+		t.Fatal("expected usage for an unknown command")
+	}
+}
+
+func Test_ExportImport(t *testing.T) {
+	dir := t.TempDir()
+	in, leaves := writeLeaves(t, dir, 5)
+	backup := filepath.Join(dir, "tree.bin")
+
+	for _, mode := range [][]string{nil, {"-ordered"}} {
+		if status, _, stderr := runCmd("", append([]string{"export", "-in", in, "-out", backup}, mode...)...); status != 0 {
+			t.Fatal(stderr)
+		}
+		// the backup records the layout, whatever the flags of import
+		status, out, stderr := runCmd("", "import", "-in", backup)
+		if status != 0 {
+			t.Fatal(stderr)
+		}
+		if status, again, stderr := runCmd("", "import", "-in", backup, "-ordered"); status != 0 || again != out {
+			t.Fatalf("expected -ordered to leave the import alone: %s", stderr)
+		}
+		_, root, _ := runCmd("", append([]string{"root", "-in", in}, mode...)...)
+		if !strings.HasPrefix(out, "# root "+strings.TrimSpace(root)+"\n") {
+			t.Fatal("restored tree has another root")
+		}
+		if len(strings.Split(strings.TrimSpace(out), "\n")) != len(leaves)+1 {
+			t.Fatal("expected every leaf written back")
+		}
+
+		// the written leaves build the same tree again
+		_, again, _ := runCmd(out, append([]string{"root"}, mode...)...)
+		if again != root {
+			t.Fatal("reimported leaves build another root")
+		}
+	}
+}
+
+func Test_Snapshot(t *testing.T) {
+	dir := t.TempDir()
+	snapshot := filepath.Join(dir, "snapshot.csv")
+	holder := "0x1111111111111111111111111111111111111111"
+	csv := "holder,balance\n" + holder + ",1.5\n0x2222222222222222222222222222222222222222,2\n"
+	if err := os.WriteFile(snapshot, []byte(csv), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	flags := []string{"-in", snapshot, "-address-column", "holder", "-amount-column", "balance", "-decimals", "1"}
+
+	status, root, stderr := runCmd("", append([]string{"root"}, flags...)...)
+	if status != 0 {
+		t.Fatal(stderr)
+	}
+	status, out, stderr := runCmd("", append([]string{"prove", "-leaf", holder}, flags...)...)
+	if status != 0 {
+		t.Fatal(stderr)
+	}
+	var claim airdrop.Claim
+	if err := json.Unmarshal([]byte(out), &claim); err != nil {
+		t.Fatal(err)
+	}
+	if claim.Amount.ToInt().Int64() != 15 {
+		t.Fatalf("unexpected claim %s", out)
+	}
+
+	claims := filepath.Join(dir, "claims.json")
+	if status, _, stderr := runCmd("", append([]string{"export", "-out", claims}, flags...)...); status != 0 {
+		t.Fatal(stderr)
+	}
+	status, out, stderr = runCmd("", "verify", "-claims", claims, "-root", strings.TrimSpace(root))
+	if status != 0 || strings.TrimSpace(out) != "valid: 2 claims" {
+		t.Fatalf("claims file does not verify: %s", stderr)
+	}
+}
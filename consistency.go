@@ -0,0 +1,222 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// The tree pairs nodes level by level and promotes the last node of an odd level,
+// which gives every tree the shape RFC 6962 prescribes: the left subtree of the
+// root holds the largest power of two leaves smaller than the leaf count. The
+// consistency proofs below follow RFC 6962 section 2.1.2 on that shape, with the
+// tree's own node hashing.
+
+// ConsistencyProof returns the RFC 6962 proof that the tree over the first oldSize
+// leaves of Leafs is a prefix of the tree over the first newSize leaves. This is
+// meant for append-only logs built with WithInsertionOrder: when leaves are sorted
+// by hash, an older version of the tree is only such a prefix if every leaf added
+// since sorts after the old ones.
+func (m *MerkleTree) ConsistencyProof(oldSize, newSize int) ([][]byte, error) {
+	if oldSize <= 0 || oldSize > newSize || newSize > len(m.Leafs) {
+		return nil, fmt.Errorf("error: invalid consistency range %d to %d for %d leaves", oldSize, newSize, len(m.Leafs))
+	}
+	if m.duplicateOdd {
+		return nil, errors.New("error: consistency proofs require promoted odd nodes")
+	}
+	if m.levelTag != nil {
+		return nil, errors.New("error: consistency proofs do not support level tags")
+	}
+	if oldSize == newSize {
+		return nil, nil
+	}
+
+	hashes := make([][]byte, newSize)
+	for i := range hashes {
+		hashes[i] = m.Leafs[i].Hash
+	}
+	return m.consistencySubproof(oldSize, hashes, true)
+}
+
+// InclusionProof returns the RFC 6962 audit path of the leaf at index in the tree
+// over the first treeSize leaves of Leafs, ordered from the leaf up. At the full
+// size it is the path of GetProofByIndex; earlier sizes recompute the subtrees
+// the path needs. Like ConsistencyProof it is meant for append-only logs built
+// with WithInsertionOrder.
+func (m *MerkleTree) InclusionProof(index, treeSize int) ([][]byte, error) {
+	if index < 0 || index >= treeSize || treeSize > len(m.Leafs) {
+		return nil, fmt.Errorf("error: invalid inclusion of leaf %d in %d of %d leaves", index, treeSize, len(m.Leafs))
+	}
+	if m.duplicateOdd {
+		return nil, errors.New("error: inclusion proofs require promoted odd nodes")
+	}
+	if m.levelTag != nil {
+		return nil, errors.New("error: inclusion proofs do not support level tags")
+	}
+	if treeSize == len(m.Leafs) {
+		proof, err := m.GetProofByIndex(index)
+		if err != nil {
+			return nil, err
+		}
+		return proof.Siblings, nil
+	}
+
+	hashes := make([][]byte, treeSize)
+	for i := range hashes {
+		hashes[i] = m.Leafs[i].Hash
+	}
+	return m.auditPath(index, hashes)
+}
+
+// auditPath is PATH(m, D[n]) of RFC 6962.
+func (m *MerkleTree) auditPath(index int, hashes [][]byte) ([][]byte, error) {
+	if len(hashes) == 1 {
+		return nil, nil
+	}
+	k := SplitPoint(len(hashes))
+	if index < k {
+		path, err := m.auditPath(index, hashes[:k])
+		if err != nil {
+			return nil, err
+		}
+		right, err := m.subtreeRoot(hashes[k:])
+		if err != nil {
+			return nil, err
+		}
+		return append(path, right), nil
+	}
+
+	path, err := m.auditPath(index-k, hashes[k:])
+	if err != nil {
+		return nil, err
+	}
+	left, err := m.subtreeRoot(hashes[:k])
+	if err != nil {
+		return nil, err
+	}
+	return append(path, left), nil
+}
+
+// consistencySubproof is SUBPROOF(m, D[n], b) of RFC 6962.
+func (m *MerkleTree) consistencySubproof(oldSize int, hashes [][]byte, complete bool) ([][]byte, error) {
+	if oldSize == len(hashes) {
+		if complete {
+			return nil, nil
+		}
+		root, err := m.subtreeRoot(hashes)
+		if err != nil {
+			return nil, err
+		}
+		return [][]byte{root}, nil
+	}
+
+	k := SplitPoint(len(hashes))
+	if oldSize <= k {
+		proof, err := m.consistencySubproof(oldSize, hashes[:k], complete)
+		if err != nil {
+			return nil, err
+		}
+		right, err := m.subtreeRoot(hashes[k:])
+		if err != nil {
+			return nil, err
+		}
+		return append(proof, right), nil
+	}
+
+	proof, err := m.consistencySubproof(oldSize-k, hashes[k:], false)
+	if err != nil {
+		return nil, err
+	}
+	left, err := m.subtreeRoot(hashes[:k])
+	if err != nil {
+		return nil, err
+	}
+	return append(proof, left), nil
+}
+
+// subtreeRoot returns the root of the tree over the leaf hashes, which must not be
+// empty.
+func (m *MerkleTree) subtreeRoot(hashes [][]byte) ([]byte, error) {
+	if len(hashes) == 1 {
+		return hashes[0], nil
+	}
+	k := SplitPoint(len(hashes))
+	left, err := m.subtreeRoot(hashes[:k])
+	if err != nil {
+		return nil, err
+	}
+	right, err := m.subtreeRoot(hashes[k:])
+	if err != nil {
+		return nil, err
+	}
+	return m.hashPair(0, left, right)
+}
+
+// SplitPoint returns the largest power of two smaller than n, for n > 1: the leaf
+// count of the left subtree of the root of an RFC 6962 tree of n leaves.
+func SplitPoint(n int) int {
+	k := 1
+	for k<<1 < n {
+		k <<= 1
+	}
+	return k
+}
+
+// VerifyConsistencyProof checks a proof returned by ConsistencyProof that oldRoot,
+// the root over oldSize leaves, is a prefix of newRoot, the root over newSize
+// leaves. opts must describe the node hashing of the tree, as given to
+// NewTreeWithOptions.
+func VerifyConsistencyProof(oldSize, newSize int, oldRoot, newRoot []byte, proof [][]byte, opts ...Option) (bool, error) {
+	if oldSize <= 0 || oldSize > newSize {
+		return false, fmt.Errorf("error: invalid consistency range %d to %d", oldSize, newSize)
+	}
+	if oldSize == newSize {
+		return len(proof) == 0 && bytes.Equal(oldRoot, newRoot), nil
+	}
+	t := newConfiguredTree(opts)
+	if t.duplicateOdd {
+		return false, errors.New("error: consistency proofs require promoted odd nodes")
+	}
+	if t.levelTag != nil {
+		return false, errors.New("error: consistency proofs do not support level tags")
+	}
+
+	// RFC 6962 section 2.1.4.2
+	if oldSize&(oldSize-1) == 0 {
+		proof = append([][]byte{oldRoot}, proof...)
+	}
+	if len(proof) == 0 {
+		return false, nil
+	}
+
+	fn, sn := oldSize-1, newSize-1
+	for fn&1 == 1 {
+		fn, sn = fn>>1, sn>>1
+	}
+
+	fr, sr := proof[0], proof[0]
+	for _, c := range proof[1:] {
+		if sn == 0 {
+			return false, nil
+		}
+
+		var err error
+		if fn&1 == 1 || fn == sn {
+			if fr, err = t.hashPair(0, c, fr); err != nil {
+				return false, err
+			}
+			if sr, err = t.hashPair(0, c, sr); err != nil {
+				return false, err
+			}
+			for fn&1 == 0 && fn != 0 {
+				fn, sn = fn>>1, sn>>1
+			}
+		} else {
+			if sr, err = t.hashPair(0, sr, c); err != nil {
+				return false, err
+			}
+		}
+		fn, sn = fn>>1, sn>>1
+	}
+	return sn == 0 && bytes.Equal(fr, oldRoot) && bytes.Equal(sr, newRoot), nil
+}
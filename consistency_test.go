@@ -0,0 +1,96 @@
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_ConsistencyProof(t *testing.T) {
+	const n = 13
+	for _, sortPairs := range []bool{true, false} {
+		opts := []Option{WithSortPairs(sortPairs)}
+		tree, err := NewTreeWithOptions(testLeaves(n), opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// roots of every prefix of the sorted leaves
+		roots := make([][]byte, n+1)
+		var prefix []Content
+		for i, leaf := range tree.Leafs {
+			prefix = append(prefix, hashLeaf(leaf.Hash))
+			older, err := NewTreeWithOptions(prefix, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			roots[i+1] = older.MerkleRoot()
+		}
+
+		for oldSize := 1; oldSize <= n; oldSize++ {
+			for newSize := oldSize; newSize <= n; newSize++ {
+				proof, err := tree.ConsistencyProof(oldSize, newSize)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ok, err := VerifyConsistencyProof(oldSize, newSize, roots[oldSize], roots[newSize], proof, opts...)
+				if err != nil || !ok {
+					t.Fatalf("sortPairs=%v: %d to %d does not verify", sortPairs, oldSize, newSize)
+				}
+
+				if oldSize < newSize {
+					if ok, _ := VerifyConsistencyProof(oldSize, newSize, roots[oldSize], bytes.Repeat([]byte{1}, 32), proof, opts...); ok {
+						t.Fatalf("%d to %d verifies against a wrong new root", oldSize, newSize)
+					}
+					if len(proof) > 0 {
+						proof[0] = bytes.Repeat([]byte{2}, 32)
+						if ok, _ := VerifyConsistencyProof(oldSize, newSize, roots[oldSize], roots[newSize], proof, opts...); ok {
+							t.Fatalf("%d to %d verifies with a tampered proof", oldSize, newSize)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	tree, _ := NewTree(testLeaves(3))
+	if _, err := tree.ConsistencyProof(2, 4); err == nil {
+		t.Fatal("expected error for a range beyond the tree")
+	}
+}
+
+func Test_InclusionProof(t *testing.T) {
+	const n = 13
+	opts := []Option{WithInsertionOrder()}
+	leaves := testLeaves(n)
+	tree, err := NewTreeWithOptions(leaves, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// every audit path matches the proof of the tree over that prefix
+	for size := 1; size <= n; size++ {
+		older, _ := NewTreeWithOptions(leaves[:size], opts...)
+		for index := 0; index < size; index++ {
+			path, err := tree.InclusionProof(index, size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _ := older.GetProofByIndex(index)
+			if len(path) != len(want.Siblings) {
+				t.Fatalf("leaf %d of %d: got %d hashes, want %d", index, size, len(path), len(want.Siblings))
+			}
+			for i := range path {
+				if !bytes.Equal(path[i], want.Siblings[i]) {
+					t.Fatalf("leaf %d of %d: hash %d differs", index, size, i)
+				}
+			}
+		}
+	}
+
+	if _, err := tree.InclusionProof(3, 3); err == nil {
+		t.Fatal("expected error for a leaf past the size")
+	}
+	if _, err := tree.InclusionProof(0, n+1); err == nil {
+		t.Fatal("expected error for a size past the tree")
+	}
+}
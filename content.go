@@ -0,0 +1,96 @@
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"hash"
+
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// ByteContent is a leaf holding raw bytes, hashed with keccak256.
+type ByteContent []byte
+
+// CalculateHash hashes the bytes with keccak256
+func (b ByteContent) CalculateHash() ([]byte, error) {
+	return gethcrypto.Keccak256(b), nil
+}
+
+// Equals tests the bytes for equality
+func (b ByteContent) Equals(other Content) (bool, error) {
+	o, ok := other.(ByteContent)
+	return ok && bytes.Equal(b, o), nil
+}
+
+// SHA256ByteContent is a leaf holding raw bytes, hashed with sha256.
+type SHA256ByteContent []byte
+
+// CalculateHash hashes the bytes with sha256
+func (b SHA256ByteContent) CalculateHash() ([]byte, error) {
+	sum := sha256.Sum256(b)
+	return sum[:], nil
+}
+
+// Equals tests the bytes for equality
+func (b SHA256ByteContent) Equals(other Content) (bool, error) {
+	o, ok := other.(SHA256ByteContent)
+	return ok && bytes.Equal(b, o), nil
+}
+
+// HashContent is a leaf given directly by its hash, computed elsewhere.
+type HashContent []byte
+
+// CalculateHash returns the hash itself
+func (h HashContent) CalculateHash() ([]byte, error) {
+	return h, nil
+}
+
+// Equals tests the hashes for equality
+func (h HashContent) Equals(other Content) (bool, error) {
+	o, ok := other.(HashContent)
+	return ok && bytes.Equal(h, o), nil
+}
+
+// blobContent is a leaf of a tree built by NewTreeFromBytes: raw bytes hashed with
+// the hash function of the tree.
+type blobContent struct {
+	data         []byte
+	hashStrategy func() hash.Hash
+}
+
+func (b blobContent) CalculateHash() ([]byte, error) {
+	h := b.hashStrategy()
+	if _, err := h.Write(b.data); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+func (b blobContent) Equals(other Content) (bool, error) {
+	o, ok := other.(blobContent)
+	return ok && bytes.Equal(b.data, o.data), nil
+}
+
+// NewTreeFromBytes builds a tree over byte blobs, each hashed with the hash
+// function of the tree, keccak256 unless opts set another. Paths and proofs are
+// then looked up by blob with GetMerklePathForBytes and GetProofForBytes.
+func NewTreeFromBytes(leaves [][]byte, opts ...Option) (*MerkleTree, error) {
+	hashStrategy := newConfiguredTree(opts).hashStrategy
+	cs := make([]Content, len(leaves))
+	for i, leaf := range leaves {
+		cs[i] = blobContent{data: leaf, hashStrategy: hashStrategy}
+	}
+	return NewTreeWithOptions(cs, opts...)
+}
+
+// GetMerklePathForBytes returns the merkle path of the blob data in a tree built
+// by NewTreeFromBytes.
+func (m *MerkleTree) GetMerklePathForBytes(data []byte) ([][]byte, []int64, error) {
+	return m.GetMerklePath(blobContent{data: data, hashStrategy: m.hashStrategy})
+}
+
+// GetProofForBytes returns the inclusion proof of the blob data in a tree built by
+// NewTreeFromBytes.
+func (m *MerkleTree) GetProofForBytes(data []byte) (*MerkleProof, error) {
+	return m.GetProof(blobContent{data: data, hashStrategy: m.hashStrategy})
+}
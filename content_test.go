@@ -0,0 +1,97 @@
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+func Test_BuiltinContents(t *testing.T) {
+	data := [][]byte{[]byte("alice"), []byte("bob"), []byte("carol")}
+
+	var keccakLeaves, sha256Leaves, hashLeaves []Content
+	for _, d := range data {
+		keccakLeaves = append(keccakLeaves, ByteContent(d))
+		sha256Leaves = append(sha256Leaves, SHA256ByteContent(d))
+		sum := sha256.Sum256(d)
+		hashLeaves = append(hashLeaves, HashContent(sum[:]))
+	}
+
+	h, _ := ByteContent(data[0]).CalculateHash()
+	if !bytes.Equal(h, gethcrypto.Keccak256(data[0])) {
+		t.Fatal("ByteContent is not hashed with keccak256")
+	}
+
+	sha256Tree, err := NewTreeWithHashStrategy(sha256Leaves, sha256.New)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hashTree, err := NewTreeWithHashStrategy(hashLeaves, sha256.New)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(sha256Tree.MerkleRoot(), hashTree.MerkleRoot()) {
+		t.Fatal("pre-hashed leaves give a different root")
+	}
+
+	keccakTree, err := NewTree(keccakLeaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range data {
+		if ok, err := keccakTree.VerifyContent(keccakLeaves[i]); err != nil || !ok {
+			t.Fatal("byte content does not verify")
+		}
+		if ok, _ := sha256Tree.VerifyContent(hashLeaves[i]); ok {
+			t.Fatal("a hash content matched a byte content")
+		}
+	}
+}
+
+func Test_NewTreeFromBytes(t *testing.T) {
+	blobs := [][]byte{[]byte("alice"), []byte("bob"), []byte("carol"), []byte("dave")}
+	var keccakContents, sha256Contents []Content
+	for _, b := range blobs {
+		keccakContents = append(keccakContents, ByteContent(b))
+		sha256Contents = append(sha256Contents, SHA256ByteContent(b))
+	}
+	for _, tc := range []struct {
+		opts     []Option
+		contents []Content
+	}{
+		{nil, keccakContents},
+		{[]Option{WithHashStrategy(sha256.New)}, sha256Contents},
+	} {
+		opts, contents := tc.opts, tc.contents
+		tree, err := NewTreeFromBytes(blobs, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		contentTree, _ := NewTreeWithOptions(contents, opts...)
+		if !bytes.Equal(tree.MerkleRoot(), contentTree.MerkleRoot()) {
+			t.Fatal("blob tree and content tree roots differ")
+		}
+
+		for _, b := range blobs {
+			path, _, err := tree.GetMerklePathForBytes(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			proof, err := tree.GetProofForBytes(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(path) != len(proof.Siblings) {
+				t.Fatal("path and proof differ")
+			}
+			if ok, err := VerifyProofWithOptions(tree.MerkleRoot(), proof, opts...); err != nil || !ok {
+				t.Fatal("proof does not verify")
+			}
+		}
+		if _, _, err := tree.GetMerklePathForBytes([]byte("mallory")); err != ErrContentNotFound {
+			t.Fatalf("got %v, want ErrContentNotFound", err)
+		}
+	}
+}
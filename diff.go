@@ -0,0 +1,157 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+)
+
+// TreeDiff lists how the leaves of one tree differ from those of another, see
+// Diff.
+type TreeDiff struct {
+	// Added holds the leaves of the other tree that the tree does not have,
+	// indexed by their position in the other tree
+	Added []LeafDiff
+	// Removed holds the leaves of the tree that the other tree does not have,
+	// indexed by their position in the tree
+	Removed []LeafDiff
+	// Changed holds the positions where both trees have a leaf with different
+	// hashes. Only trees kept in insertion order have changed leaves; in sorted
+	// trees a leaf with a new hash is a removal and an addition.
+	Changed []LeafChange
+}
+
+// LeafDiff is a leaf at a position of one of two trees compared by Diff.
+type LeafDiff struct {
+	Index int
+	Leaf  *Node
+}
+
+// LeafChange is a position where two trees compared by Diff hold different
+// leaves.
+type LeafChange struct {
+	Index int
+	Old   *Node
+	New   *Node
+}
+
+// Empty reports whether both trees have the same leaves.
+func (d *TreeDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Diff compares the tree with other, which must have been built with the same
+// options. Both trees are walked from the root down, side by side, and subtrees
+// with the same hash at the same position are skipped, so the cost depends on
+// the number of differing leaves rather than on the size of the trees.
+func (m *MerkleTree) Diff(other *MerkleTree) (*TreeDiff, error) {
+	if err := checkMergeable(m, other); err != nil {
+		return nil, err
+	}
+	if m.unsortedLeaves != other.unsortedLeaves {
+		return nil, errors.New("error: cannot compare trees with different layouts")
+	}
+
+	// lift the lower root so that both walks start at the same level
+	a, b := m.Root, other.Root
+	ha, hb := treeHeight(a), treeHeight(b)
+	for ; a != nil && ha < hb; ha++ {
+		a = &Node{Left: a, Right: a}
+	}
+	for ; b != nil && hb < ha; hb++ {
+		b = &Node{Left: b, Right: b}
+	}
+	if ha < hb {
+		ha = hb
+	}
+
+	var old, cur []LeafDiff
+	var walk func(a, b *Node, height, index int)
+	walk = func(a, b *Node, height, index int) {
+		if a == nil && b == nil {
+			return
+		}
+		if a != nil && b != nil && a.Hash != nil && bytes.Equal(a.Hash, b.Hash) {
+			return
+		}
+		if height == 0 {
+			if a != nil {
+				old = append(old, LeafDiff{Index: index, Leaf: a})
+			}
+			if b != nil {
+				cur = append(cur, LeafDiff{Index: index, Leaf: b})
+			}
+			return
+		}
+		aLeft, aRight := children(a)
+		bLeft, bRight := children(b)
+		walk(aLeft, bLeft, height-1, 2*index)
+		walk(aRight, bRight, height-1, 2*index+1)
+	}
+	walk(a, b, ha, 0)
+
+	d := &TreeDiff{}
+	if m.unsortedLeaves {
+		// both lists are in position order
+		i, j := 0, 0
+		for i < len(old) && j < len(cur) {
+			switch {
+			case old[i].Index == cur[j].Index:
+				d.Changed = append(d.Changed, LeafChange{Index: old[i].Index, Old: old[i].Leaf, New: cur[j].Leaf})
+				i, j = i+1, j+1
+			case old[i].Index < cur[j].Index:
+				d.Removed = append(d.Removed, old[i])
+				i++
+			default:
+				d.Added = append(d.Added, cur[j])
+				j++
+			}
+		}
+		d.Removed = append(d.Removed, old[i:]...)
+		d.Added = append(d.Added, cur[j:]...)
+		return d, nil
+	}
+
+	// Sorted leaves move when others come or go, so the differing leaves of both
+	// trees are matched by hash. Both lists are sorted by hash.
+	i, j := 0, 0
+	for i < len(old) && j < len(cur) {
+		switch cmp := bytes.Compare(old[i].Leaf.Hash, cur[j].Leaf.Hash); {
+		case cmp == 0:
+			i, j = i+1, j+1
+		case cmp < 0:
+			d.Removed = append(d.Removed, old[i])
+			i++
+		default:
+			d.Added = append(d.Added, cur[j])
+			j++
+		}
+	}
+	d.Removed = append(d.Removed, old[i:]...)
+	d.Added = append(d.Added, cur[j:]...)
+	return d, nil
+}
+
+// treeHeight returns the number of levels above the leaves of the tree under n,
+// or -1 for a tree without nodes.
+func treeHeight(n *Node) int {
+	height := -1
+	for ; n != nil; n = n.Left {
+		height++
+		if n.leaf {
+			break
+		}
+	}
+	return height
+}
+
+// children returns the children of n at the positions below it. A node with a
+// single child, promoted or paired with itself, has no right child.
+func children(n *Node) (*Node, *Node) {
+	if n == nil {
+		return nil, nil
+	}
+	if n.Left == n.Right {
+		return n.Left, nil
+	}
+	return n.Left, n.Right
+}
@@ -0,0 +1,99 @@
+package merkletree
+
+import (
+	"bytes"
+	"sort"
+	"testing"
+)
+
+func Test_Diff(t *testing.T) {
+	leaves := testLeaves(30)
+	for _, opts := range incrementalOptions[:4] {
+		opts = append(opts, WithEmptyRoot(ZeroRoot(32)))
+		base, _ := NewTreeWithOptions(leaves[:20], opts...)
+		same, _ := NewTreeWithOptions(leaves[:20], opts...)
+		if d, err := base.Diff(same); err != nil || !d.Empty() {
+			t.Fatal("expected no difference between equal trees")
+		}
+
+		changed := append([]Content(nil), leaves[:20]...)
+		changed[3], changed[11] = leaves[25], leaves[26]
+		changed = append(changed[:15], changed[16:]...)
+		changed = append(changed, leaves[20:23]...)
+		other, _ := NewTreeWithOptions(changed, opts...)
+
+		d, err := base.Diff(other)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range d.Removed {
+			if r.Leaf != base.Leafs[r.Index] {
+				t.Fatal("removed leaf not at its position")
+			}
+		}
+		for _, a := range d.Added {
+			if a.Leaf != other.Leafs[a.Index] {
+				t.Fatal("added leaf not at its position")
+			}
+		}
+
+		// applying the difference to the leaf hashes of base gives those of other
+		got := make(map[string]int)
+		for _, leaf := range base.Leafs {
+			got[string(leaf.Hash)]++
+		}
+		for _, r := range d.Removed {
+			got[string(r.Leaf.Hash)]--
+		}
+		for _, c := range d.Changed {
+			got[string(c.Old.Hash)]--
+			got[string(c.New.Hash)]++
+		}
+		for _, a := range d.Added {
+			got[string(a.Leaf.Hash)]++
+		}
+		for _, leaf := range other.Leafs {
+			got[string(leaf.Hash)]--
+		}
+		for _, n := range got {
+			if n != 0 {
+				t.Fatal("difference does not turn one tree into the other")
+			}
+		}
+
+		if base.unsortedLeaves {
+			// positions 3 and 11 changed, from 15 on every leaf moved one place left
+			var indexes []int
+			for _, c := range d.Changed {
+				indexes = append(indexes, c.Index)
+			}
+			if len(indexes) != 2+5 || indexes[0] != 3 || indexes[1] != 11 || len(d.Removed) != 0 || len(d.Added) != 2 {
+				t.Fatalf("unexpected positional difference %v", indexes)
+			}
+		} else {
+			var added [][]byte
+			for _, a := range d.Added {
+				added = append(added, a.Leaf.Hash)
+			}
+			if len(d.Changed) != 0 || len(d.Removed) != 3 || len(added) != 5 || !sort.SliceIsSorted(added, func(i, j int) bool {
+				return bytes.Compare(added[i], added[j]) < 0
+			}) {
+				t.Fatal("unexpected difference between sorted trees")
+			}
+		}
+
+		empty, _ := NewTreeWithOptions(nil, opts...)
+		if d, err := empty.Diff(base); err != nil || len(d.Added) != 20 || len(d.Removed) != 0 {
+			t.Fatal("expected every leaf added to an empty tree")
+		}
+		if d, err := base.Diff(empty); err != nil || len(d.Removed) != 20 || len(d.Added) != 0 {
+			t.Fatal("expected every leaf removed from an empty tree")
+		}
+	}
+
+	a, _ := NewTree(leaves)
+	b, _ := NewTreeWithOptions(leaves, WithInsertionOrder())
+	if _, err := a.Diff(b); err == nil {
+		t.Fatal("expected error comparing different layouts")
+	}
+}
@@ -0,0 +1,302 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"reflect"
+	"sync"
+)
+
+// Encodable is a Content that can serialize itself. Trees whose leaves are all
+// Encodable can be marshalled together with their content by MarshalFull.
+type Encodable interface {
+	Content
+	MarshalContent() ([]byte, error)
+}
+
+// ContentDecoder restores a Content from the bytes produced by MarshalContent.
+type ContentDecoder func(data []byte) (Content, error)
+
+var contentRegistry = struct {
+	sync.RWMutex
+	decoders map[string]ContentDecoder
+	tags     map[reflect.Type]string
+}{
+	decoders: make(map[string]ContentDecoder),
+	tags:     make(map[reflect.Type]string),
+}
+
+// RegisterContentType associates tag with the concrete type of sample and with the
+// decoder that restores it. Like gob.Register it is meant to be called from init
+// functions, and it panics if the tag or the type is already registered.
+func RegisterContentType(tag string, sample Encodable, decode ContentDecoder) {
+	typ := reflect.TypeOf(sample)
+
+	contentRegistry.Lock()
+	defer contentRegistry.Unlock()
+	if _, ok := contentRegistry.decoders[tag]; ok {
+		panic(fmt.Sprintf("merkletree: content tag %q registered twice", tag))
+	}
+	if _, ok := contentRegistry.tags[typ]; ok {
+		panic(fmt.Sprintf("merkletree: content type %v registered twice", typ))
+	}
+	contentRegistry.decoders[tag] = decode
+	contentRegistry.tags[typ] = tag
+}
+
+// fullVersion is the version byte of the MarshalFull layout.
+const fullVersion byte = 1
+
+// MarshalFull serializes the tree including the content of its leaves. Every leaf
+// must hold an Encodable whose type was registered with RegisterContentType.
+//
+// The layout is a version byte, the name of the hash function,
+// empty if it is not registered, the options of the tree as written by
+// writeLayout, the root, the leaf count and, for each leaf in order, its type tag
+// and encoded content; all lengths are uvarints.
+func (m *MerkleTree) MarshalFull() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(fullVersion)
+	name, _ := HashName(m.hashStrategy)
+	writeBytes(&buf, []byte(name))
+	m.writeLayout(&buf)
+	writeBytes(&buf, m.merkleRoot)
+	writeUvarint(&buf, uint64(len(m.Leafs)))
+
+	contentRegistry.RLock()
+	defer contentRegistry.RUnlock()
+	for _, leaf := range m.Leafs {
+		enc, ok := leaf.C.(Encodable)
+		if !ok {
+			return nil, fmt.Errorf("error: content %T is not Encodable", leaf.C)
+		}
+		tag, ok := contentRegistry.tags[reflect.TypeOf(enc)]
+		if !ok {
+			return nil, fmt.Errorf("error: content type %T is not registered", leaf.C)
+		}
+		data, err := enc.MarshalContent()
+		if err != nil {
+			return nil, err
+		}
+
+		writeBytes(&buf, []byte(tag))
+		writeBytes(&buf, data)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalFull restores a tree serialized by MarshalFull, rebuilding it with the
+// options it was built with. hashStrategy may be nil when the data names a
+// registered hash function; otherwise it must match the one named. opts supply
+// what the data cannot hold, such as the key of WithHMACKey, and are applied
+// after the recorded options. It fails if the rebuilt root differs from the
+// serialized one.
+func UnmarshalFull(data []byte, hashStrategy func() hash.Hash, opts ...Option) (*MerkleTree, error) {
+	r := bytes.NewReader(data)
+	version, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	if version != fullVersion {
+		return nil, fmt.Errorf("error: unsupported encoding version %d", version)
+	}
+	if hashStrategy, err = readHashName(r, hashStrategy); err != nil {
+		return nil, err
+	}
+	layout, flags, err := readLayout(r)
+	if err != nil {
+		return nil, err
+	}
+	if hashStrategy == nil {
+		return nil, errors.New("error: encoding does not name its hash function")
+	}
+
+	root, err := readBytes(r)
+	if err != nil {
+		return nil, err
+	}
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if count > uint64(r.Len()) {
+		return nil, errors.New("error: leaf count exceeds input size")
+	}
+
+	contentRegistry.RLock()
+	defer contentRegistry.RUnlock()
+	cs := make([]Content, 0, count)
+	for i := uint64(0); i < count; i++ {
+		tag, err := readBytes(r)
+		if err != nil {
+			return nil, err
+		}
+		payload, err := readBytes(r)
+		if err != nil {
+			return nil, err
+		}
+
+		decode, ok := contentRegistry.decoders[string(tag)]
+		if !ok {
+			return nil, fmt.Errorf("error: no decoder registered for content tag %q", tag)
+		}
+		c, err := decode(payload)
+		if err != nil {
+			return nil, err
+		}
+		cs = append(cs, c)
+	}
+	if r.Len() != 0 {
+		return nil, errors.New("error: trailing data after tree")
+	}
+
+	opts = append(append(layout, opts...), WithHashStrategy(hashStrategy))
+	if err := checkLayout(newConfiguredTree(opts), flags); err != nil {
+		return nil, err
+	}
+	t, err := NewTreeWithOptions(cs, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(t.merkleRoot, root) {
+		return nil, errors.New("error: restored tree root does not match")
+	}
+	return t, nil
+}
+
+// layout flags record the options a serialized tree was built with
+const (
+	layoutUnsortedLeaves byte = 1 << iota
+	layoutUnsortedPairs
+	layoutDuplicateOdd
+	layoutHashLeaves
+	layoutHMACKey    // the key itself is never written
+	layoutLevelTags  // nor are the tags
+	layoutPairHasher // nor the pair hasher
+	layoutEmptyRoot
+)
+
+// writeLayout writes the options the tree has to be rebuilt with: a flags byte,
+// then the leaf and node prefixes and the empty root, each prefixed with its
+// uvarint length. An HMAC
+// key, level tags and a pair hasher cannot be written and are only flagged, so
+// that restoring without them fails instead of giving another tree.
+func (m *MerkleTree) writeLayout(buf *bytes.Buffer) {
+	var flags byte
+	for _, f := range []struct {
+		set  bool
+		flag byte
+	}{
+		{m.unsortedLeaves, layoutUnsortedLeaves},
+		{m.unsortedPairs, layoutUnsortedPairs},
+		{m.duplicateOdd, layoutDuplicateOdd},
+		{m.hashLeaves, layoutHashLeaves},
+		{m.hmacKey != nil, layoutHMACKey},
+		{m.levelTag != nil, layoutLevelTags},
+		{m.pairHasher != nil, layoutPairHasher},
+		{m.emptyRoot != nil, layoutEmptyRoot},
+	} {
+		if f.set {
+			flags |= f.flag
+		}
+	}
+	buf.WriteByte(flags)
+	writeBytes(buf, m.leafPrefix)
+	writeBytes(buf, m.nodePrefix)
+	writeBytes(buf, m.emptyRoot)
+}
+
+// readLayout reads what writeLayout wrote and returns the options it records,
+// along with its flags for checkLayout.
+func readLayout(br io.ByteReader) ([]Option, byte, error) {
+	flags, err := br.ReadByte()
+	if err != nil {
+		return nil, 0, err
+	}
+	var fields [3][]byte
+	for i := range fields {
+		l, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, 0, err
+		}
+		if l > 255 {
+			return nil, 0, fmt.Errorf("error: implausible layout field length %d", l)
+		}
+		if l == 0 {
+			continue
+		}
+		fields[i] = make([]byte, l)
+		for j := range fields[i] {
+			if fields[i][j], err = br.ReadByte(); err != nil {
+				return nil, 0, err
+			}
+		}
+	}
+
+	leafPrefix, nodePrefix, emptyRoot := fields[0], fields[1], fields[2]
+	opts := []Option{
+		WithSortLeaves(flags&layoutUnsortedLeaves == 0),
+		WithSortPairs(flags&layoutUnsortedPairs == 0),
+		WithDuplicateOdd(flags&layoutDuplicateOdd != 0),
+		WithHashLeaves(flags&layoutHashLeaves != 0),
+		func(m *MerkleTree) {
+			m.leafPrefix, m.nodePrefix = leafPrefix, nodePrefix
+		},
+	}
+	if flags&layoutEmptyRoot != 0 {
+		opts = append(opts, WithEmptyRoot(emptyRoot))
+	}
+	return opts, flags, nil
+}
+
+// checkLayout reports an error if t, configured with the options read by
+// readLayout and those of the caller, has an HMAC key, level tags or a pair
+// hasher where flags say the serialized tree had none, or the other way round.
+func checkLayout(t *MerkleTree, flags byte) error {
+	for _, f := range []struct {
+		set  bool
+		flag byte
+		name string
+	}{
+		{t.hmacKey != nil, layoutHMACKey, "WithHMACKey"},
+		{t.levelTag != nil, layoutLevelTags, "WithLevelTags"},
+		{t.pairHasher != nil, layoutPairHasher, "WithPairHasher"},
+	} {
+		switch {
+		case flags&f.flag != 0 && !f.set:
+			return fmt.Errorf("error: tree was built %s, restore it with the same option", f.name)
+		case flags&f.flag == 0 && f.set:
+			return fmt.Errorf("error: tree was not built %s", f.name)
+		}
+	}
+	return nil
+}
+
+func writeUvarint(buf *bytes.Buffer, x uint64) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutUvarint(b[:], x)])
+}
+
+func writeBytes(buf *bytes.Buffer, bz []byte) {
+	writeUvarint(buf, uint64(len(bz)))
+	buf.Write(bz)
+}
+
+func readBytes(r *bytes.Reader) ([]byte, error) {
+	l, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if l > uint64(r.Len()) {
+		return nil, errors.New("error: length exceeds input size")
+	}
+	bz := make([]byte, l)
+	if _, err := io.ReadFull(r, bz); err != nil {
+		return nil, err
+	}
+	return bz, nil
+}
@@ -0,0 +1,131 @@
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+type encodableLeaf struct {
+	TestLeaf
+}
+
+func (l encodableLeaf) MarshalContent() ([]byte, error) {
+	return l.Bz, nil
+}
+
+func (l encodableLeaf) Equals(other Content) (bool, error) {
+	return bytes.Equal(l.Bz, other.(encodableLeaf).Bz), nil
+}
+
+func init() {
+	RegisterContentType("test/encodable", encodableLeaf{}, func(data []byte) (Content, error) {
+		return encodableLeaf{TestLeaf{Bz: data}}, nil
+	})
+}
+
+func Test_MarshalFull(t *testing.T) {
+	var leaves []Content
+	for _, c := range testLeaves(5) {
+		leaves = append(leaves, encodableLeaf{c.(TestLeaf)})
+	}
+	tree, err := NewTree(leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := tree.MarshalFull()
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored, err := UnmarshalFull(data, sha3.NewLegacyKeccak256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(restored.MerkleRoot(), tree.MerkleRoot()) {
+		t.Fatal("restored root mismatch")
+	}
+	for _, leaf := range leaves {
+		if ok, err := restored.VerifyContent(leaf); err != nil || !ok {
+			t.Fatal("restored tree lost content")
+		}
+	}
+
+	if _, err := UnmarshalFull(data[:len(data)-1], sha3.NewLegacyKeccak256); err == nil {
+		t.Fatal("expected error for truncated input")
+	}
+
+	plain, _ := NewTree(testLeaves(2))
+	if _, err := plain.MarshalFull(); err == nil {
+		t.Fatal("expected error for content that is not Encodable")
+	}
+}
+
+func Test_MarshalFullOptions(t *testing.T) {
+	var leaves []Content
+	for _, c := range testLeaves(7) {
+		leaves = append(leaves, encodableLeaf{c.(TestLeaf)})
+	}
+	key := []byte("key")
+	modes := []struct {
+		name string
+		opts []Option
+		// restore holds the options the encoding cannot carry
+		restore []Option
+	}{
+		{"insertion order", []Option{WithInsertionOrder()}, nil},
+		{"duplicate last", []Option{WithOddNodePolicy(DuplicateLast)}, nil},
+		{"rfc6962", []Option{WithRFC6962()}, nil},
+		{"domain separation", []Option{WithDomainSeparation(0x00, 0x01)}, nil},
+		{"hash leaves", []Option{WithHashLeaves(true), WithSortPairs(false)}, nil},
+		{"hmac key", []Option{WithHMACKey(key)}, []Option{WithHMACKey(key)}},
+	}
+	for _, mode := range modes {
+		tree, err := NewTreeWithOptions(leaves, mode.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := tree.MarshalFull()
+		if err != nil {
+			t.Fatal(err)
+		}
+		restored, err := UnmarshalFull(data, nil, mode.restore...)
+		if err != nil {
+			t.Fatalf("%s: %v", mode.name, err)
+		}
+		if !bytes.Equal(restored.MerkleRoot(), tree.MerkleRoot()) {
+			t.Fatalf("%s: restored root mismatch", mode.name)
+		}
+		if mode.restore != nil {
+			if _, err := UnmarshalFull(data, nil); err == nil {
+				t.Fatalf("%s: expected error without the restore options", mode.name)
+			}
+		}
+	}
+
+	tree, _ := NewTree(leaves)
+	data, _ := tree.MarshalFull()
+	if _, err := UnmarshalFull(data, nil, WithHMACKey(key)); err == nil {
+		t.Fatal("expected error for an HMAC key the tree was built without")
+	}
+
+	// the empty root survives, with and without leaves
+	for _, cs := range [][]Content{leaves, nil} {
+		tree, err := NewTreeWithOptions(cs, WithEmptyRoot(ZeroRoot(32)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := tree.MarshalFull()
+		if err != nil {
+			t.Fatal(err)
+		}
+		restored, err := UnmarshalFull(data, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(restored.emptyRoot, ZeroRoot(32)) || !bytes.Equal(restored.MerkleRoot(), tree.MerkleRoot()) {
+			t.Fatal("restored tree lost its empty root")
+		}
+	}
+}
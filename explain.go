@@ -0,0 +1,45 @@
+package merkletree
+
+import (
+	"bytes"
+	"fmt"
+	"hash"
+	"strings"
+)
+
+// ExplainProof folds proof step by step like the verifiers do and describes every
+// level: the running hash, the sibling, which of them was hashed first and the
+// result, followed by the verdict against root. The output is deterministic and
+// meant for debugging proofs that fail to verify, e.g. between implementations.
+func ExplainProof(leafHash []byte, proof *MerkleProof, root []byte, h func() hash.Hash) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "leaf: %#x\n", leafHash)
+	if proof == nil {
+		sb.WriteString("proof: nil\n")
+		return sb.String()
+	}
+
+	current := leafHash
+	for i, sibling := range proof.Siblings {
+		order := "current || sibling"
+		if bytes.Compare(current, sibling) >= 0 {
+			order = "sibling || current"
+		}
+
+		hasher := h()
+		if _, err := hasher.Write(combineTwoHash(current, sibling)); err != nil {
+			fmt.Fprintf(&sb, "level %d: hash error: %v\n", i, err)
+			return sb.String()
+		}
+		next := hasher.Sum(nil)
+		fmt.Fprintf(&sb, "level %d: current %#x, sibling %#x, hash(%s) = %#x\n", i, current, sibling, order, next)
+		current = next
+	}
+
+	if bytes.Equal(current, root) {
+		fmt.Fprintf(&sb, "root: %#x matches\n", root)
+	} else {
+		fmt.Fprintf(&sb, "root: computed %#x, expected %#x, MISMATCH\n", current, root)
+	}
+	return sb.String()
+}
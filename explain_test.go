@@ -0,0 +1,49 @@
+package merkletree
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+func Test_ExplainProof(t *testing.T) {
+	leaves := testLeaves(8)
+	tree, err := NewTree(leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	proof, err := tree.GetProof(leaves[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	leafHash, _ := leaves[2].CalculateHash()
+
+	explanation := ExplainProof(leafHash, proof, tree.MerkleRoot(), sha3.NewLegacyKeccak256)
+	if !strings.Contains(explanation, "matches") || strings.Count(explanation, "level ") != 3 {
+		t.Fatalf("unexpected explanation:\n%s", explanation)
+	}
+	if explanation != ExplainProof(leafHash, proof, tree.MerkleRoot(), sha3.NewLegacyKeccak256) {
+		t.Fatal("explanation is not deterministic")
+	}
+
+	corrupted := make([]byte, len(proof.Siblings[1]))
+	copy(corrupted, proof.Siblings[1])
+	corrupted[0] ^= 0xff
+	proof.Siblings[1] = corrupted
+
+	explanation = ExplainProof(leafHash, proof, tree.MerkleRoot(), sha3.NewLegacyKeccak256)
+	if !strings.Contains(explanation, fmt.Sprintf("level 1: current 0x%x, sibling %#x", nthFold(leafHash, proof, 1), corrupted)) {
+		t.Fatalf("explanation does not show the corrupted level:\n%s", explanation)
+	}
+	if !strings.Contains(explanation, "MISMATCH") {
+		t.Fatalf("explanation does not report the mismatch:\n%s", explanation)
+	}
+}
+
+// nthFold returns the running hash before level n of the proof.
+func nthFold(leafHash []byte, proof *MerkleProof, n int) []byte {
+	current, _ := foldProof(leafHash, proof.Siblings[:n], sha3.NewLegacyKeccak256)
+	return current
+}
@@ -0,0 +1,96 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// ExtendedProof is a self-contained inclusion proof. Besides the siblings and
+// directions it names the hash function and the pair ordering of the tree, so it
+// can be verified long after the fact without any out-of-band metadata.
+//
+// Leaf optionally carries the leaf preimage. When set, LeafHash must be the hash
+// of Leaf, which holds for contents whose CalculateHash hashes their bytes once
+// with the tree's hash function. Salt is set for SaltedContent leaves, whose
+// LeafHash is then H(Salt || H(Leaf)).
+type ExtendedProof struct {
+	Leaf       hexutil.Bytes   `json:"leaf,omitempty"`
+	Salt       hexutil.Bytes   `json:"salt,omitempty"`
+	LeafHash   hexutil.Bytes   `json:"leafHash"`
+	Siblings   []hexutil.Bytes `json:"siblings"`
+	Path       []int64         `json:"path"`
+	Root       hexutil.Bytes   `json:"root"`
+	Hash       string          `json:"hash"`
+	Positional bool            `json:"positional,omitempty"`
+}
+
+// GetExtendedProof returns the self-contained proof of content. The tree must use
+// one of the known hash functions so that it can be named in the proof, and hash
+// its nodes with it alone: the proof has no room for prefixes, an HMAC key, level
+// tags or a pair hasher, so such trees are refused.
+func (m *MerkleTree) GetExtendedProof(content Content) (*ExtendedProof, error) {
+	k, ok := identifyHash(m.hashStrategy)
+	if !ok {
+		return nil, errors.New("error: extended proofs require a known hash function")
+	}
+	if m.leafPrefix != nil || m.nodePrefix != nil || m.hmacKey != nil || m.levelTag != nil || m.pairHasher != nil {
+		return nil, errors.New("error: extended proofs do not support prefixes, HMAC keys, level tags or pair hashers")
+	}
+
+	leaf, err := m.findLeaf(content)
+	if err != nil {
+		return nil, err
+	}
+	proof := m.proofOf(leaf)
+
+	ep := &ExtendedProof{
+		LeafHash:   proof.LeafHash,
+		Siblings:   make([]hexutil.Bytes, len(proof.Siblings)),
+		Path:       proof.Path,
+		Root:       proof.Root,
+		Hash:       k.name,
+		Positional: m.unsortedPairs,
+	}
+	for i, sibling := range proof.Siblings {
+		ep.Siblings[i] = sibling
+	}
+	if s, ok := leaf.C.(SaltedContent); ok {
+		ep.Salt = s.Salt
+	}
+	return ep, nil
+}
+
+// Verify checks the proof using only the data it carries.
+func (p *ExtendedProof) Verify() (bool, error) {
+	hashStrategy, err := HashStrategyByName(p.Hash)
+	if err != nil {
+		return false, err
+	}
+
+	if len(p.Leaf) != 0 {
+		h := hashStrategy()
+		if _, err := h.Write(p.Leaf); err != nil {
+			return false, err
+		}
+		leafHash := h.Sum(nil)
+		if p.Salt != nil {
+			if leafHash, err = SaltedLeafHash(p.Salt, leafHash, hashStrategy); err != nil {
+				return false, err
+			}
+		}
+		if !bytes.Equal(leafHash, p.LeafHash) {
+			return false, nil
+		}
+	}
+
+	siblings := make([][]byte, len(p.Siblings))
+	for i, sibling := range p.Siblings {
+		siblings[i] = sibling
+	}
+	if p.Positional {
+		return VerifyPositionalProof(p.Root, p.LeafHash, siblings, p.Path, hashStrategy)
+	}
+	return VerifyProof(p.Root, p.LeafHash, siblings, hashStrategy)
+}
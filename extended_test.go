@@ -0,0 +1,79 @@
+package merkletree
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func Test_ExtendedProof(t *testing.T) {
+	leaves := testLeaves(7)
+	modes := []struct {
+		name      string
+		opts      []Option
+		supported bool
+	}{
+		{"sorted pairs", nil, true},
+		{"unsorted pairs", []Option{WithSortPairs(false)}, true},
+		{"insertion order", []Option{WithInsertionOrder()}, true},
+		{"duplicate last", []Option{WithOddNodePolicy(DuplicateLast)}, true},
+		{"domain separation", []Option{WithDomainSeparation(0x00, 0x01)}, false},
+		{"rfc6962", []Option{WithRFC6962()}, false},
+		{"hmac key", []Option{WithHMACKey([]byte("key"))}, false},
+		{"level numbers", []Option{WithLevelNumbers(), WithOddNodePolicy(DuplicateLast)}, false},
+	}
+	for _, mode := range modes {
+		tree, err := NewTreeWithOptions(leaves, mode.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !mode.supported {
+			if _, err := tree.GetExtendedProof(leaves[0]); err == nil {
+				t.Fatalf("%s: expected error", mode.name)
+			}
+			continue
+		}
+
+		for _, leaf := range leaves {
+			ep, err := tree.GetExtendedProof(leaf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ep.Leaf = leaf.(TestLeaf).Bz
+
+			archived, err := json.Marshal(ep)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var restored ExtendedProof
+			if err := json.Unmarshal(archived, &restored); err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := restored.Verify(); err != nil || !ok {
+				t.Fatalf("%s: restored proof does not verify", mode.name)
+			}
+
+			restored.Leaf = []byte("other")
+			if ok, _ := restored.Verify(); ok {
+				t.Fatal("proof with a wrong preimage verifies")
+			}
+		}
+	}
+
+	// pair hashers such as MiMC work on field elements the proof cannot name
+	elements := []Content{BN254Content{X: big.NewInt(1)}, BN254Content{X: big.NewInt(2)}}
+	mimc, err := NewTreeWithOptions(elements, WithMiMC7())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := mimc.GetExtendedProof(elements[0]); err == nil {
+		t.Fatal("mimc: expected error")
+	}
+
+	tree, _ := NewTree(leaves)
+	ep, _ := tree.GetExtendedProof(leaves[0])
+	ep.Hash = "md5"
+	if _, err := ep.Verify(); err == nil {
+		t.Fatal("expected error for an unknown hash function")
+	}
+}
@@ -0,0 +1,163 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+)
+
+// maxFixedDepth bounds the depth of a FixedDepthTree so that leaf indexes fit in
+// the 64-bit path of a positional proof.
+const maxFixedDepth = 64
+
+// FixedDepthTree is a tree of a fixed depth whose 2^depth leaf slots are filled
+// from the left with the given leaves and padded with a zero leaf, the layout of
+// on-chain incremental trees such as the deposit contract. Leaves keep their
+// order and every node is H(left || right). Only the filled part of each level is
+// stored; the padding is covered by precomputed hashes of empty subtrees.
+type FixedDepthTree struct {
+	depth        int
+	hashStrategy func() hash.Hash
+	zeros        [][]byte
+	// layers[0] holds the leaf hashes, layers[i] the filled part of level i
+	layers [][][]byte
+}
+
+// ZeroHashes returns the roots of empty subtrees of every height up to depth:
+// zeros[0] is zeroLeaf and zeros[i+1] is H(zeros[i] || zeros[i]).
+func ZeroHashes(depth int, zeroLeaf []byte, hashStrategy func() hash.Hash) ([][]byte, error) {
+	zeros := make([][]byte, depth+1)
+	zeros[0] = zeroLeaf
+	for i := 0; i < depth; i++ {
+		h := hashStrategy()
+		if _, err := h.Write(append(append([]byte(nil), zeros[i]...), zeros[i]...)); err != nil {
+			return nil, err
+		}
+		zeros[i+1] = h.Sum(nil)
+	}
+	return zeros, nil
+}
+
+// NewFixedDepthTree builds a tree of the given depth holding cs in order in its
+// first leaf slots, padded with zeroLeaf. cs may be empty.
+func NewFixedDepthTree(cs []Content, depth int, zeroLeaf []byte, hashStrategy func() hash.Hash) (*FixedDepthTree, error) {
+	if depth < 1 || depth > maxFixedDepth {
+		return nil, fmt.Errorf("error: depth %d out of range [1, %d]", depth, maxFixedDepth)
+	}
+	if depth < 63 && uint64(len(cs)) > 1<<uint(depth) {
+		return nil, fmt.Errorf("error: %d leaves do not fit in a tree of depth %d", len(cs), depth)
+	}
+
+	zeros, err := ZeroHashes(depth, zeroLeaf, hashStrategy)
+	if err != nil {
+		return nil, err
+	}
+	t := &FixedDepthTree{
+		depth:        depth,
+		hashStrategy: hashStrategy,
+		zeros:        zeros,
+		layers:       make([][][]byte, depth+1),
+	}
+
+	for _, c := range cs {
+		if c == nil {
+			return nil, errors.New("error: cannot construct tree with nil content")
+		}
+		hashBz, err := c.CalculateHash()
+		if err != nil {
+			return nil, err
+		}
+		t.layers[0] = append(t.layers[0], hashBz)
+	}
+
+	for level := 0; level < depth; level++ {
+		layer := t.layers[level]
+		for i := 0; i < len(layer); i += 2 {
+			right := zeros[level]
+			if i+1 < len(layer) {
+				right = layer[i+1]
+			}
+			h := hashStrategy()
+			if _, err := h.Write(append(append([]byte(nil), layer[i]...), right...)); err != nil {
+				return nil, err
+			}
+			t.layers[level+1] = append(t.layers[level+1], h.Sum(nil))
+		}
+	}
+	return t, nil
+}
+
+// MerkleRoot returns the root of the tree, the empty-subtree hash of its depth if
+// it holds no leaves.
+func (t *FixedDepthTree) MerkleRoot() []byte {
+	return t.node(t.depth, 0)
+}
+
+// Depth returns the depth of the tree.
+func (t *FixedDepthTree) Depth() int {
+	return t.depth
+}
+
+// LeafCount returns the number of filled leaf slots.
+func (t *FixedDepthTree) LeafCount() int {
+	return len(t.layers[0])
+}
+
+// ZeroHashes returns the precomputed empty-subtree hashes, see ZeroHashes.
+func (t *FixedDepthTree) ZeroHashes() [][]byte {
+	return t.zeros
+}
+
+// GetProof returns the proof of the leaf slot at index, which may be a padding
+// slot. It always has Depth siblings.
+func (t *FixedDepthTree) GetProof(index uint64) (*MerkleProof, error) {
+	if t.depth < 64 && index >= 1<<uint(t.depth) {
+		return nil, fmt.Errorf("error: leaf index %d out of range for depth %d", index, t.depth)
+	}
+
+	proof := &MerkleProof{
+		LeafHash: t.node(0, index),
+		Root:     t.MerkleRoot(),
+		Siblings: make([][]byte, t.depth),
+		Path:     make([]int64, t.depth),
+	}
+	for level := 0; level < t.depth; level++ {
+		proof.Siblings[level] = t.node(level, index^1)
+		if index&1 == 0 {
+			proof.Path[level] = 1 // right leaf
+		}
+		index >>= 1
+	}
+	return proof, nil
+}
+
+// node returns the hash at position i of level, falling back to the empty-subtree
+// hash beyond the filled part.
+func (t *FixedDepthTree) node(level int, i uint64) []byte {
+	if i < uint64(len(t.layers[level])) {
+		return t.layers[level][i]
+	}
+	return t.zeros[level]
+}
+
+// VerifyFixedDepthProof checks that proof shows its leaf hash in slot index of a
+// fixed-depth tree with the given root. The tree depth is the proof length.
+func VerifyFixedDepthProof(root []byte, index uint64, proof *MerkleProof, hashStrategy func() hash.Hash) (bool, error) {
+	if proof == nil {
+		return false, errors.New("error: nil proof")
+	}
+	depth := len(proof.Siblings)
+	if depth == 0 || depth > maxFixedDepth || (depth < 64 && index >= 1<<uint(depth)) {
+		return false, nil
+	}
+
+	bits, err := PackPath(proof.Path)
+	if err != nil {
+		return false, err
+	}
+	// a sibling is on the right exactly where the index has a zero bit
+	if len(proof.Path) != depth || bits != ^index&(1<<uint(depth)-1) {
+		return false, nil
+	}
+	return VerifyProofPacked(proof.LeafHash, proof.Siblings, bits, root, hashStrategy)
+}
@@ -0,0 +1,77 @@
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func Test_ZeroHashes(t *testing.T) {
+	// zero hashes of the beacon chain deposit contract
+	zeros, err := ZeroHashes(32, make([]byte, 32), sha256.New)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hexutil.Encode(zeros[1]) != "0xf5a5fd42d16a20302798ef6ed309979b43003d2320d9f0e8ea9831a92759fb4b" {
+		t.Fatalf("unexpected zeros[1] %x", zeros[1])
+	}
+	if hexutil.Encode(zeros[2]) != "0xdb56114e00fdd4c1f85c892bf35ac9a89289aaecb1ebd0a96cde606a748b5d71" {
+		t.Fatalf("unexpected zeros[2] %x", zeros[2])
+	}
+
+	empty, err := NewFixedDepthTree(nil, 32, make([]byte, 32), sha256.New)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(empty.MerkleRoot(), zeros[32]) {
+		t.Fatal("empty tree root should be the zero hash of its depth")
+	}
+}
+
+func Test_FixedDepthTree(t *testing.T) {
+	leaves := testLeaves(5)
+	zeroLeaf := make([]byte, 32)
+	tree, err := NewFixedDepthTree(leaves, 3, zeroLeaf, sha256.New)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the same tree materialized with its padding
+	padded := append([]Content(nil), leaves...)
+	for len(padded) < 8 {
+		padded = append(padded, hashLeaf(zeroLeaf))
+	}
+	full, _ := NewTreeWithOptions(padded, WithInsertionOrder(), WithHashStrategy(sha256.New))
+	if !bytes.Equal(tree.MerkleRoot(), full.MerkleRoot()) {
+		t.Fatal("root differs from the padded tree")
+	}
+
+	deep, _ := NewFixedDepthTree(leaves, 32, zeroLeaf, sha256.New)
+	for _, tr := range []*FixedDepthTree{tree, deep} {
+		for _, index := range []uint64{0, 3, 4, 5, 7} {
+			proof, err := tr.GetProof(index)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(proof.Siblings) != tr.Depth() {
+				t.Fatalf("proof has %d siblings for depth %d", len(proof.Siblings), tr.Depth())
+			}
+			ok, err := VerifyFixedDepthProof(tr.MerkleRoot(), index, proof, sha256.New)
+			if err != nil || !ok {
+				t.Fatalf("depth %d: proof of slot %d does not verify", tr.Depth(), index)
+			}
+			if ok, _ := VerifyFixedDepthProof(tr.MerkleRoot(), index^1, proof, sha256.New); ok {
+				t.Fatalf("depth %d: proof of slot %d verifies for another slot", tr.Depth(), index)
+			}
+		}
+	}
+
+	if _, err := NewFixedDepthTree(testLeaves(9), 3, zeroLeaf, sha256.New); err == nil {
+		t.Fatal("expected error for too many leaves")
+	}
+	if _, err := tree.GetProof(8); err == nil {
+		t.Fatal("expected error for a slot out of range")
+	}
+}
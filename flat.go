@@ -0,0 +1,296 @@
+package merkletree
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// flatMagic starts every flat file.
+var flatMagic = []byte("MKTF")
+
+const flatVersion byte = 1
+
+// flags of the flat format
+const (
+	flatDuplicateOdd byte = 1 << iota // odd nodes were paired with themselves
+	flatUnsorted                      // leaves are in insertion order
+)
+
+// flatHeaderSize is the size of the header without the hash name.
+const flatHeaderSize = 4 + 1 + 1 + 2 + 8 + 1
+
+// WriteFlat writes the tree to w in the flat format, which OpenFlatFile maps
+// into memory and serves proofs from without loading the tree. The header holds
+// the magic "MKTF", a version byte, a flags byte, the hash size as a big endian
+// uint16, the leaf count as a big endian uint64 and the name of the hash
+// function prefixed with its one-byte length, empty if it is not registered.
+// The levels follow, leaves first and root last, each an array of fixed-size
+// hashes. Node j of a level has the children 2j and 2j+1 on the level below; a
+// promoted node is written with the hash of its only child. Leaf content is not
+// written. All hashes of the tree must have the same size.
+func (m *MerkleTree) WriteFlat(w io.Writer) (int64, error) {
+	if m.Root == nil {
+		return 0, ErrEmptyTree
+	}
+	levels, err := m.flatLevels()
+	if err != nil {
+		return 0, err
+	}
+
+	hashSize := len(m.Root.Hash)
+	for _, level := range levels {
+		for _, n := range level {
+			if len(n.Hash) != hashSize {
+				return 0, errors.New("error: flat files need hashes of one size")
+			}
+		}
+	}
+	if hashSize == 0 || hashSize > 0xffff {
+		return 0, fmt.Errorf("error: hash size %d does not fit a flat file", hashSize)
+	}
+	name, _ := HashName(m.hashStrategy)
+	if len(name) > 255 {
+		return 0, fmt.Errorf("error: hash name %q does not fit a flat file", name)
+	}
+
+	var flags byte
+	if m.duplicateOdd {
+		flags |= flatDuplicateOdd
+	}
+	if m.unsortedLeaves {
+		flags |= flatUnsorted
+	}
+	header := make([]byte, 0, flatHeaderSize+len(name))
+	header = append(header, flatMagic...)
+	header = append(header, flatVersion, flags)
+	header = binary.BigEndian.AppendUint16(header, uint16(hashSize))
+	header = binary.BigEndian.AppendUint64(header, uint64(len(m.Leafs)))
+	header = append(header, byte(len(name)))
+	header = append(header, name...)
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	bw.Write(header)
+	for _, level := range levels {
+		for _, n := range level {
+			bw.Write(n.Hash)
+		}
+	}
+	err = bw.Flush()
+	return cw.n, err
+}
+
+// flatLevels returns the nodes of the tree level by level, leaves first. The
+// parent of node 2j of a level is node j of the level above.
+func (m *MerkleTree) flatLevels() ([][]*Node, error) {
+	level := m.Leafs
+	levels := [][]*Node{level}
+	for _, count := range flatCounts(len(m.Leafs))[1:] {
+		parents := make([]*Node, count)
+		for j := range parents {
+			if 2*j >= len(level) || level[2*j].Parent == nil {
+				return nil, errors.New("error: tree does not have the level layout of flat files")
+			}
+			parents[j] = level[2*j].Parent
+		}
+		level = parents
+		levels = append(levels, level)
+	}
+	// the root of a single leaf is a node promoting it
+	if level[0] != m.Root && !(len(m.Leafs) == 1 && bytes.Equal(m.Root.Hash, level[0].Hash)) {
+		return nil, errors.New("error: tree does not have the level layout of flat files")
+	}
+	return levels, nil
+}
+
+// NodeHash returns the hash of node index of level, in the layout of flat files:
+// level 0 holds the leaves and node j of a level has the children 2j and 2j+1 on
+// the level below. A promoted node has the hash of its only child.
+func (m *MerkleTree) NodeHash(level, index int) ([]byte, error) {
+	counts := flatCounts(len(m.Leafs))
+	if len(m.Leafs) == 0 || level < 0 || level >= len(counts) || index < 0 || index >= counts[level] {
+		return nil, fmt.Errorf("error: no node %d on level %d of a tree with %d leaves", index, level, len(m.Leafs))
+	}
+	n := m.Leafs[index<<uint(level)]
+	for ; level > 0; level-- {
+		n = n.Parent
+	}
+	return n.Hash, nil
+}
+
+// flatCounts returns the number of nodes on each level of a tree with leafCount
+// leaves, leaves first.
+func flatCounts(leafCount int) []int {
+	var counts []int
+	for count := leafCount; ; count = (count + 1) / 2 {
+		counts = append(counts, count)
+		if count == 1 {
+			return counts
+		}
+	}
+}
+
+// FlatTree serves a tree written by WriteFlat straight from its bytes, usually a
+// file mapped into memory by OpenFlatFile, so opening it takes no time whatever
+// the size of the tree. It is safe for concurrent use.
+type FlatTree struct {
+	data      []byte
+	hashSize  int
+	leafCount int
+	// offsets holds the offset of each level in data, leaves first
+	offsets []int
+	counts  []int
+	// t holds the options of the tree, for its node hashing
+	t *MerkleTree
+	// unmap releases data, if it was mapped
+	unmap func() error
+}
+
+// NewFlatTree returns the tree written by WriteFlat into data, which it uses
+// without copying. opts must be those the tree was built with; its layout and
+// hash function are checked against the header.
+func NewFlatTree(data []byte, opts ...Option) (*FlatTree, error) {
+	if len(data) < flatHeaderSize || !bytes.Equal(data[:4], flatMagic) {
+		return nil, errors.New("error: not a flat tree file")
+	}
+	if data[4] != flatVersion {
+		return nil, fmt.Errorf("error: unsupported flat file version %d", data[4])
+	}
+	flags := data[5]
+	hashSize := int(binary.BigEndian.Uint16(data[6:]))
+	leafCount := binary.BigEndian.Uint64(data[8:])
+	nameLen := int(data[16])
+	if hashSize == 0 || leafCount == 0 || len(data) < flatHeaderSize+nameLen {
+		return nil, errors.New("error: malformed flat file header")
+	}
+	name := string(data[flatHeaderSize : flatHeaderSize+nameLen])
+	// a larger leaf count could not fit into data anyway
+	if leafCount > uint64(len(data)/hashSize) {
+		return nil, errors.New("error: truncated flat file")
+	}
+
+	ft := &FlatTree{
+		data:      data,
+		hashSize:  hashSize,
+		leafCount: int(leafCount),
+		counts:    flatCounts(int(leafCount)),
+		t:         newConfiguredTree(opts),
+	}
+	if ft.t.duplicateOdd != (flags&flatDuplicateOdd != 0) {
+		return nil, errors.New("error: flat file was written with another odd node policy")
+	}
+	if ft.t.unsortedLeaves != (flags&flatUnsorted != 0) {
+		return nil, errors.New("error: flat file was written with another leaf order")
+	}
+	if got, ok := HashName(ft.t.hashStrategy); name != "" && ok && got != name {
+		return nil, fmt.Errorf("error: flat file was written with %s, not %s", name, got)
+	}
+
+	offset := flatHeaderSize + nameLen
+	for _, count := range ft.counts {
+		ft.offsets = append(ft.offsets, offset)
+		offset += count * hashSize
+	}
+	if offset != len(data) {
+		return nil, fmt.Errorf("error: flat file holds %d bytes, expected %d", len(data), offset)
+	}
+	return ft, nil
+}
+
+// Close releases the file mapped by OpenFlatFile. The tree and the hashes it
+// returned must not be used afterwards.
+func (ft *FlatTree) Close() error {
+	if ft.unmap == nil {
+		return nil
+	}
+	unmap := ft.unmap
+	ft.unmap, ft.data = nil, nil
+	return unmap()
+}
+
+// MerkleRoot returns the root of the tree.
+func (ft *FlatTree) MerkleRoot() []byte {
+	return ft.node(len(ft.counts)-1, 0)
+}
+
+// LeafCount returns the number of leaves of the tree.
+func (ft *FlatTree) LeafCount() int {
+	return ft.leafCount
+}
+
+// LeafHash returns the hash of the leaf at position i.
+func (ft *FlatTree) LeafHash(i int) ([]byte, error) {
+	if i < 0 || i >= ft.leafCount {
+		return nil, fmt.Errorf("error: leaf index %d out of range [0, %d)", i, ft.leafCount)
+	}
+	return ft.node(0, i), nil
+}
+
+// node returns the hash of node j of level. It points into the data of the tree.
+func (ft *FlatTree) node(level, j int) []byte {
+	start := ft.offsets[level] + j*ft.hashSize
+	return ft.data[start : start+ft.hashSize : start+ft.hashSize]
+}
+
+// NodeHash returns the hash of node index of level, see MerkleTree.NodeHash.
+func (ft *FlatTree) NodeHash(level, index int) ([]byte, error) {
+	if level < 0 || level >= len(ft.counts) || index < 0 || index >= ft.counts[level] {
+		return nil, fmt.Errorf("error: no node %d on level %d of a tree with %d leaves", index, level, ft.leafCount)
+	}
+	return ft.node(level, index), nil
+}
+
+// GetProofByIndex returns the proof of the leaf at position i, as the tree would
+// return it.
+func (ft *FlatTree) GetProofByIndex(i int) (*MerkleProof, error) {
+	leaf, err := ft.LeafHash(i)
+	if err != nil {
+		return nil, err
+	}
+	proof := &MerkleProof{LeafHash: leaf, Root: ft.MerkleRoot(), Siblings: [][]byte{}, Path: []int64{}}
+	for level := 0; level < len(ft.counts)-1; level++ {
+		j := i >> uint(level)
+		l, r := j&^1, j|1
+		if r >= ft.counts[level] {
+			if !ft.t.duplicateOdd {
+				// promoted
+				continue
+			}
+			r = l
+		}
+		left, right, child := ft.node(level, l), ft.node(level, r), ft.node(level, j)
+		// same direction rule as merklePath
+		if bytes.Equal(left, child) {
+			proof.Siblings, proof.Path = append(proof.Siblings, right), append(proof.Path, 1)
+		} else {
+			proof.Siblings, proof.Path = append(proof.Siblings, left), append(proof.Path, 0)
+		}
+	}
+	return proof, nil
+}
+
+// GetProofByLeafHash returns the proof of the leaf whose hash is leafHash. The
+// leaf is found by binary search, which needs leaves sorted by hash.
+func (ft *FlatTree) GetProofByLeafHash(leafHash []byte) (*MerkleProof, error) {
+	if ft.t.unsortedLeaves {
+		return nil, errors.New("error: lookups by hash need leaves sorted by hash")
+	}
+	i := sort.Search(ft.leafCount, func(i int) bool {
+		return bytes.Compare(ft.node(0, i), leafHash) >= 0
+	})
+	if i == ft.leafCount || !bytes.Equal(ft.node(0, i), leafHash) {
+		return nil, ErrContentNotFound
+	}
+	return ft.GetProofByIndex(i)
+}
+
+// VerifyProof checks proof against the root of the tree with the node hashing
+// of the tree.
+func (ft *FlatTree) VerifyProof(proof *MerkleProof) (bool, error) {
+	return ft.t.verifyProof(ft.MerkleRoot(), proof)
+}
@@ -0,0 +1,15 @@
+//go:build !unix
+
+package merkletree
+
+import "os"
+
+// OpenFlatFile reads the file at path, written by WriteFlat, and returns the tree
+// it holds, see NewFlatTree. Platforms without mmap read the whole file.
+func OpenFlatFile(path string, opts ...Option) (*FlatTree, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewFlatTree(data, opts...)
+}
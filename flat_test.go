@@ -0,0 +1,123 @@
+package merkletree
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func Test_FlatTree(t *testing.T) {
+	layouts := [][]Option{nil, {WithInsertionOrder()}, {WithOddNodePolicy(DuplicateLast)}, {WithRFC6962()}}
+	for _, opts := range layouts {
+		for _, n := range []int{1, 2, 5, 8, 13} {
+			tree, err := NewTreeWithOptions(testLeaves(n), opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if _, err := tree.WriteFlat(&buf); err != nil {
+				t.Fatal(err)
+			}
+
+			ft, err := NewFlatTree(buf.Bytes(), opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ft.LeafCount() != n || !bytes.Equal(ft.MerkleRoot(), tree.MerkleRoot()) {
+				t.Fatalf("%d leaves: unexpected flat tree", n)
+			}
+			for level, count := range flatCounts(n) {
+				for j := 0; j <= count; j++ {
+					want, wantErr := tree.NodeHash(level, j)
+					got, err := ft.NodeHash(level, j)
+					if (err == nil) != (j < count) || (wantErr == nil) != (j < count) || !bytes.Equal(got, want) {
+						t.Fatalf("%d leaves: unexpected node %d of level %d", n, j, level)
+					}
+				}
+			}
+			if root, _ := tree.NodeHash(len(flatCounts(n))-1, 0); !bytes.Equal(root, tree.MerkleRoot()) {
+				t.Fatalf("%d leaves: top node is not the root", n)
+			}
+			for i := 0; i < n; i++ {
+				proof, err := ft.GetProofByIndex(i)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want, _ := tree.GetProofByIndex(i)
+				if !bytes.Equal(proof.LeafHash, tree.Leafs[i].Hash) || len(proof.Siblings) != len(want.Siblings) {
+					t.Fatalf("%d leaves: proof of leaf %d differs from the tree", n, i)
+				}
+				for k := range want.Siblings {
+					if !bytes.Equal(proof.Siblings[k], want.Siblings[k]) || proof.Path[k] != want.Path[k] {
+						t.Fatalf("%d leaves: proof of leaf %d differs from the tree", n, i)
+					}
+				}
+				if ok, err := ft.VerifyProof(proof); err != nil || !ok {
+					t.Fatalf("%d leaves: proof of leaf %d does not verify", n, i)
+				}
+			}
+			if _, err := ft.GetProofByIndex(n); err == nil {
+				t.Fatal("expected error for an index out of range")
+			}
+		}
+	}
+
+	tree, _ := NewTree(testLeaves(11))
+	var buf bytes.Buffer
+	tree.WriteFlat(&buf)
+	ft, _ := NewFlatTree(buf.Bytes())
+	if written, err := tree.WriteFlat(&failingWriter{limit: 10}); err == nil || written != 10 {
+		t.Fatalf("expected 10 bytes written and an error, got %d, %v", written, err)
+	}
+	for _, leaf := range tree.Leafs {
+		proof, err := ft.GetProofByLeafHash(leaf.Hash)
+		if err != nil || !bytes.Equal(proof.LeafHash, leaf.Hash) {
+			t.Fatal("expected the proof of the leaf found by hash")
+		}
+	}
+	if _, err := ft.GetProofByLeafHash([]byte("missing")); err != ErrContentNotFound {
+		t.Fatalf("expected ErrContentNotFound, got %v", err)
+	}
+
+	if _, err := NewFlatTree(buf.Bytes(), WithInsertionOrder()); err == nil {
+		t.Fatal("expected error for another leaf order")
+	}
+	if _, err := NewFlatTree(buf.Bytes()[:buf.Len()-1]); err == nil {
+		t.Fatal("expected error for a truncated file")
+	}
+	if _, err := NewFlatTree([]byte("not a tree")); err == nil {
+		t.Fatal("expected error for a foreign file")
+	}
+}
+
+func Test_OpenFlatFile(t *testing.T) {
+	tree, _ := NewTreeWithOptions(testLeaves(100), WithInsertionOrder())
+	path := filepath.Join(t.TempDir(), "tree.flat")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.WriteFlat(f); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	ft, err := OpenFlatFile(path, WithInsertionOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ft.MerkleRoot(), tree.MerkleRoot()) {
+		t.Fatal("unexpected root")
+	}
+	proof, err := ft.GetProofByIndex(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyProofWithOptions(tree.MerkleRoot(), proof, WithInsertionOrder()); err != nil || !ok {
+		t.Fatal("expected the proof to verify against the tree")
+	}
+	if err := ft.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
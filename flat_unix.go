@@ -0,0 +1,44 @@
+//go:build unix
+
+package merkletree
+
+import (
+	"errors"
+	"os"
+	"syscall"
+)
+
+// OpenFlatFile maps the file at path, written by WriteFlat, into memory and
+// returns the tree it holds, see NewFlatTree. Close unmaps the file.
+func OpenFlatFile(path string, opts ...Option) (*FlatTree, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	size := fi.Size()
+	if size == 0 {
+		return nil, errors.New("error: not a flat tree file")
+	}
+	if int64(int(size)) != size {
+		return nil, errors.New("error: flat file too large to map")
+	}
+
+	data, err := syscall.Mmap(int(f.Fd()), 0, int(size), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, err
+	}
+	ft, err := NewFlatTree(data, opts...)
+	if err != nil {
+		syscall.Munmap(data)
+		return nil, err
+	}
+	ft.unmap = func() error {
+		return syscall.Munmap(data)
+	}
+	return ft, nil
+}
@@ -0,0 +1,150 @@
+package merkletree
+
+import "io"
+
+// FrozenTree is a tree that can no longer change. It only offers the methods of
+// MerkleTree that read, prove and verify, none of which modify the tree, so a
+// FrozenTree may be shared by any number of goroutines without locking.
+type FrozenTree struct {
+	t *MerkleTree
+}
+
+// Freeze returns a frozen copy of the tree. The copy is taken as by Clone, so the
+// tree itself may go on changing without affecting it.
+func (m *MerkleTree) Freeze() *FrozenTree {
+	t := m.Clone()
+	// nothing will ever be committed to the copy
+	t.versions = nil
+	return &FrozenTree{t: t}
+}
+
+// Thaw returns a copy of the frozen tree that may be changed again.
+func (f *FrozenTree) Thaw() *MerkleTree {
+	return f.t.Clone()
+}
+
+// MerkleRoot returns the root of the tree.
+func (f *FrozenTree) MerkleRoot() []byte {
+	return f.t.MerkleRoot()
+}
+
+// LeafCount returns the number of leaves of the tree.
+func (f *FrozenTree) LeafCount() int {
+	return len(f.t.Leafs)
+}
+
+// LeafHash returns the hash of the leaf at position i.
+func (f *FrozenTree) LeafHash(i int) []byte {
+	return f.t.Leafs[i].Hash
+}
+
+// NodeHash returns the hash of node index of level, see MerkleTree.NodeHash.
+func (f *FrozenTree) NodeHash(level, index int) ([]byte, error) {
+	return f.t.NodeHash(level, index)
+}
+
+// Content returns the content of the leaf at position i, which is nil for a tree
+// restored from leaf hashes.
+func (f *FrozenTree) Content(i int) Content {
+	return f.t.Leafs[i].C
+}
+
+// GetIndexOf returns the position of content, see MerkleTree.GetIndexOf.
+func (f *FrozenTree) GetIndexOf(content Content) (int, error) {
+	return f.t.GetIndexOf(content)
+}
+
+// GetMerklePath returns the merkle path of content, see MerkleTree.GetMerklePath.
+func (f *FrozenTree) GetMerklePath(content Content) ([][]byte, []int64, error) {
+	return f.t.GetMerklePath(content)
+}
+
+// GetMerklePathByIndex returns the merkle path of the leaf at position i.
+func (f *FrozenTree) GetMerklePathByIndex(i int) ([][]byte, []int64, error) {
+	return f.t.GetMerklePathByIndex(i)
+}
+
+// GetMerklePathByHash returns the merkle path of the leaf whose hash is leafHash.
+func (f *FrozenTree) GetMerklePathByHash(leafHash []byte) ([][]byte, []int64, error) {
+	return f.t.GetMerklePathByHash(leafHash)
+}
+
+// GetProof returns the inclusion proof of content.
+func (f *FrozenTree) GetProof(content Content) (*MerkleProof, error) {
+	return f.t.GetProof(content)
+}
+
+// GetProofByIndex returns the inclusion proof of the leaf at position i.
+func (f *FrozenTree) GetProofByIndex(i int) (*MerkleProof, error) {
+	return f.t.GetProofByIndex(i)
+}
+
+// GetProofByLeafHash returns the inclusion proof of the leaf whose hash is
+// leafHash.
+func (f *FrozenTree) GetProofByLeafHash(leafHash []byte) (*MerkleProof, error) {
+	return f.t.GetProofByLeafHash(leafHash)
+}
+
+// GetMultiProof returns one proof for several contents, see
+// MerkleTree.GetMultiProof.
+func (f *FrozenTree) GetMultiProof(contents []Content) (*MultiProof, error) {
+	return f.t.GetMultiProof(contents)
+}
+
+// GetMultiProofByLeafHashes returns one proof for several leaves given by their
+// hashes, see MerkleTree.GetMultiProofByLeafHashes.
+func (f *FrozenTree) GetMultiProofByLeafHashes(leafHashes [][]byte) (*MultiProof, error) {
+	return f.t.GetMultiProofByLeafHashes(leafHashes)
+}
+
+// GetRangeProof returns the proof of a range of leaves, see
+// MerkleTree.GetRangeProof.
+func (f *FrozenTree) GetRangeProof(startHash, endHash []byte) (*RangeProof, error) {
+	return f.t.GetRangeProof(startHash, endHash)
+}
+
+// GetNonMembershipProof returns the proof that no leaf has hashBz, see
+// MerkleTree.GetNonMembershipProof.
+func (f *FrozenTree) GetNonMembershipProof(hashBz []byte) (*NonMembershipProof, error) {
+	return f.t.GetNonMembershipProof(hashBz)
+}
+
+// ConsistencyProof returns the proof that the tree over the first oldSize leaves
+// is a prefix of the tree over the first newSize leaves.
+func (f *FrozenTree) ConsistencyProof(oldSize, newSize int) ([][]byte, error) {
+	return f.t.ConsistencyProof(oldSize, newSize)
+}
+
+// VerifyContent checks the hashes on the path of content, see
+// MerkleTree.VerifyContent.
+func (f *FrozenTree) VerifyContent(content Content) (bool, error) {
+	return f.t.VerifyContent(content)
+}
+
+// VerifyContents checks the paths of many contents at once.
+func (f *FrozenTree) VerifyContents(cs []Content) ([]bool, error) {
+	return f.t.VerifyContents(cs)
+}
+
+// VerifyTree recomputes every hash of the tree and compares the result with its
+// root.
+func (f *FrozenTree) VerifyTree() (bool, error) {
+	return f.t.VerifyTree()
+}
+
+// VerifyProof checks proof against the root of the tree with the node hashing of
+// the tree.
+func (f *FrozenTree) VerifyProof(proof *MerkleProof) (bool, error) {
+	return f.t.verifyProof(f.t.merkleRoot, proof)
+}
+
+// VerifyAndGet returns the verified content of the leaf whose hash is leafHash,
+// see MerkleTree.VerifyAndGet.
+func (f *FrozenTree) VerifyAndGet(leafHash []byte) (Content, error) {
+	return f.t.VerifyAndGet(leafHash)
+}
+
+// WriteTo writes a backup of the tree, see MerkleTree.WriteTo.
+func (f *FrozenTree) WriteTo(w io.Writer) (int64, error) {
+	return f.t.WriteTo(w)
+}
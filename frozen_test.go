@@ -0,0 +1,61 @@
+package merkletree
+
+import (
+	"bytes"
+	"sync"
+	"testing"
+)
+
+func Test_Freeze(t *testing.T) {
+	leaves := testLeaves(12)
+	tree, err := NewTreeWithOptions(leaves, WithProofCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := tree.MerkleRoot()
+	frozen := tree.Freeze()
+
+	// the tree moves on without the frozen copy
+	if err := tree.UpdateLeaf(3, TestLeaf{Bz: []byte("updated")}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(frozen.MerkleRoot(), root) || frozen.LeafCount() != len(leaves) {
+		t.Fatal("expected the frozen tree to keep its root")
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, len(leaves))
+	for _, c := range leaves {
+		wg.Add(1)
+		go func(c Content) {
+			defer wg.Done()
+			proof, err := frozen.GetProof(c)
+			if err == nil {
+				var ok bool
+				if ok, err = frozen.VerifyProof(proof); err == nil && !ok {
+					t.Error("expected the proof to verify")
+				}
+			}
+			if err == nil {
+				_, err = frozen.VerifyContent(c)
+			}
+			errs <- err
+		}(c)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	thawed := frozen.Thaw()
+	if err := thawed.AddLeaf(TestLeaf{Bz: []byte("added")}); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(frozen.MerkleRoot(), root) {
+		t.Fatal("expected changes to a thawed copy to leave the frozen tree alone")
+	}
+	checkSameTree(t, thawed, append(append([]Content(nil), leaves...), TestLeaf{Bz: []byte("added")}), nil)
+}
@@ -0,0 +1,155 @@
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"hash"
+	"sync"
+
+	"github.com/zeebo/blake3"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/sha3"
+)
+
+// knownHash associates a one-byte identifier and a name with a hash strategy,
+// so that commitments can tell a verifier which hash function built the tree.
+type knownHash struct {
+	id       byte
+	name     string
+	strategy func() hash.Hash
+}
+
+// hashIDUnknown is written for trees whose hash strategy has no identifier, either
+// because it is not registered or because it was registered with RegisterHash.
+const hashIDUnknown byte = 0
+
+var (
+	knownHashesMu sync.RWMutex
+	// knownHashes holds the built-in hash functions, whose identifiers are fixed,
+	// followed by those added with RegisterHash
+	knownHashes = []knownHash{
+		{id: 1, name: "keccak256", strategy: sha3.NewLegacyKeccak256},
+		{id: 2, name: "sha256", strategy: sha256.New},
+		{id: 3, name: "sha3-256", strategy: sha3.New256},
+		{id: 4, name: "blake2b-256", strategy: NewBlake2b256},
+		{id: 5, name: "doublesha256", strategy: NewDoubleSHA256},
+		{id: 6, name: "blake3", strategy: NewBlake3},
+		{id: 7, name: "sha512-256", strategy: sha512.New512_256},
+	}
+)
+
+// NewBlake2b256 returns an unkeyed BLAKE2b hash with a 32-byte digest.
+func NewBlake2b256() hash.Hash {
+	h, _ := blake2b.New256(nil) // only fails for keys longer than 64 bytes
+	return h
+}
+
+// NewBlake3 returns a BLAKE3 hash with its default 32-byte digest.
+func NewBlake3() hash.Hash {
+	return blake3.New()
+}
+
+// RegisterHash makes strategy known under name, so that trees built with it can
+// name it in extended proofs and serialized trees, and NewTreeWithHashName can
+// find it. Names are shared by every service reading those, so they should be
+// registered the same way everywhere.
+func RegisterHash(name string, strategy func() hash.Hash) error {
+	if name == "" || strategy == nil {
+		return errors.New("error: hash registration needs a name and a strategy")
+	}
+	knownHashesMu.Lock()
+	defer knownHashesMu.Unlock()
+	for _, k := range knownHashes {
+		if k.name == name {
+			return fmt.Errorf("error: hash function %q already registered", name)
+		}
+	}
+	knownHashes = append(knownHashes, knownHash{id: hashIDUnknown, name: name, strategy: strategy})
+	return nil
+}
+
+// HashStrategyByName returns the strategy of the hash function registered as name.
+func HashStrategyByName(name string) (func() hash.Hash, error) {
+	knownHashesMu.RLock()
+	defer knownHashesMu.RUnlock()
+	for _, k := range knownHashes {
+		if k.name == name {
+			return k.strategy, nil
+		}
+	}
+	return nil, fmt.Errorf("error: unknown hash function %q", name)
+}
+
+// HashName returns the name hashStrategy is registered under, if any.
+func HashName(hashStrategy func() hash.Hash) (string, bool) {
+	k, ok := identifyHash(hashStrategy)
+	return k.name, ok
+}
+
+// NewTreeWithHashName builds a tree from cs with the hash function registered as
+// name and the other options in opts.
+func NewTreeWithHashName(cs []Content, name string, opts ...Option) (*MerkleTree, error) {
+	hashStrategy, err := HashStrategyByName(name)
+	if err != nil {
+		return nil, err
+	}
+	return NewTreeWithOptions(cs, append([]Option{WithHashStrategy(hashStrategy)}, opts...)...)
+}
+
+// hashProbe is the input used to fingerprint a hash strategy. Function values
+// cannot be compared in Go, so strategies are told apart by their output.
+var hashProbe = []byte("smartbch/merkletree")
+
+func hashFingerprint(hashStrategy func() hash.Hash) []byte {
+	h := hashStrategy()
+	h.Write(hashProbe)
+	return h.Sum(nil)
+}
+
+// identifyHash returns the knownHashes entry matching hashStrategy, if any.
+func identifyHash(hashStrategy func() hash.Hash) (knownHash, bool) {
+	fp := hashFingerprint(hashStrategy)
+	knownHashesMu.RLock()
+	defer knownHashesMu.RUnlock()
+	for _, k := range knownHashes {
+		if bytes.Equal(fp, hashFingerprint(k.strategy)) {
+			return k, true
+		}
+	}
+	return knownHash{}, false
+}
+
+// hashByID returns the built-in hash function with identifier id.
+func hashByID(id byte) (knownHash, bool) {
+	if id == hashIDUnknown {
+		return knownHash{}, false
+	}
+	knownHashesMu.RLock()
+	defer knownHashesMu.RUnlock()
+	for _, k := range knownHashes {
+		if k.id == id {
+			return k, true
+		}
+	}
+	return knownHash{}, false
+}
+
+// doubleSHA256 is SHA-256 applied twice, as Bitcoin hashes transactions and
+// merkle nodes.
+type doubleSHA256 struct {
+	hash.Hash
+}
+
+// NewDoubleSHA256 returns a hash.Hash computing SHA-256(SHA-256(data)).
+func NewDoubleSHA256() hash.Hash {
+	return doubleSHA256{sha256.New()}
+}
+
+func (d doubleSHA256) Sum(b []byte) []byte {
+	first := d.Hash.Sum(nil)
+	second := sha256.Sum256(first)
+	return append(b, second[:]...)
+}
@@ -0,0 +1,86 @@
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"hash"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func Test_HashRegistry(t *testing.T) {
+	digests := map[string]string{
+		"keccak256":    "0x4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45",
+		"sha256":       "0xba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad",
+		"sha3-256":     "0x3a985da74fe225b2045c172d6bd390bd855f086e3e9d525b46bfe24511431532",
+		"blake2b-256":  "0xbddd813c634239723171ef3fee98579b94964e3bb1cb3e427262c8c068d52319",
+		"doublesha256": "0x4f8b42c22dd3729b519ba6f68d2da7cc5b2d606d05daed5ad5128cc03e6c6358",
+		"blake3":       "0x6437b3ac38465133ffb63b75273a8db548c558465d79db03fd359c6cd5bd9d85",
+		"sha512-256":   "0x53048e2681941ef99b2e29b76b4c7dabe4c2d0c634fc6d46e0e2f13107e7af23",
+	}
+	for name, want := range digests {
+		strategy, err := HashStrategyByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		h := strategy()
+		h.Write([]byte("abc"))
+		if got := hexutil.Encode(h.Sum(nil)); got != want {
+			t.Fatalf("%s: digest %s, want %s", name, got, want)
+		}
+		if got, ok := HashName(strategy); !ok || got != name {
+			t.Fatalf("%s identified as %q", name, got)
+		}
+
+		tree, err := NewTreeWithHashName(testLeaves(5), name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := NewTreeWithHashStrategy(testLeaves(5), strategy)
+		if !bytes.Equal(tree.MerkleRoot(), want.MerkleRoot()) {
+			t.Fatalf("%s: tree built by name differs", name)
+		}
+	}
+
+	if _, err := NewTreeWithHashName(testLeaves(2), "md5"); err == nil {
+		t.Fatal("expected error for an unknown name")
+	}
+	if err := RegisterHash("sha256", sha512.New); err == nil {
+		t.Fatal("expected error registering a taken name")
+	}
+	if _, err := HashStrategyByName("test-sha512"); err != nil {
+		if err := RegisterHash("test-sha512", sha512.New); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if name, ok := HashName(sha512.New); !ok || name != "test-sha512" {
+		t.Fatal("registered hash is not identified")
+	}
+}
+
+func Test_HashPresetRoots(t *testing.T) {
+	leaves := []Content{ByteContent("a"), ByteContent("b"), ByteContent("c")}
+	for _, strategy := range []func() hash.Hash{NewBlake2b256, NewBlake3, sha512.New512_256} {
+		tree, err := NewTreeWithHashStrategy(leaves, strategy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, _ := NewTreeWithHashStrategy(leaves, strategy)
+		if !bytes.Equal(tree.MerkleRoot(), again.MerkleRoot()) {
+			t.Fatal("root is not deterministic")
+		}
+
+		// the sorted leaves pair up as (0, 1) and leaf 2 is promoted
+		hashPair := func(a, b []byte) []byte {
+			h := strategy()
+			h.Write(combineTwoHash(a, b))
+			return h.Sum(nil)
+		}
+		l := tree.Leafs
+		want := hashPair(hashPair(l[0].Hash, l[1].Hash), l[2].Hash)
+		if !bytes.Equal(tree.MerkleRoot(), want) || len(want) != 32 {
+			t.Fatal("root does not follow the preset hash")
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package merkletree
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// commitmentHeaderSuffixLen is the size of the fields following the root in a
+// commitment header: a big-endian uint64 leaf count and a one-byte hash id.
+const commitmentHeaderSuffixLen = 8 + 1
+
+// CommitmentHeader returns a compact, self-describing commitment to the tree laid
+// out as root || uint64(leafCount) || hashID. The leaf count is big-endian. The
+// hash id is 0 when the tree's hash strategy is not one of the known presets.
+func (m *MerkleTree) CommitmentHeader() []byte {
+	id := hashIDUnknown
+	if k, ok := identifyHash(m.hashStrategy); ok {
+		id = k.id
+	}
+
+	b := make([]byte, len(m.merkleRoot)+commitmentHeaderSuffixLen)
+	copy(b, m.merkleRoot)
+	binary.BigEndian.PutUint64(b[len(m.merkleRoot):], uint64(len(m.Leafs)))
+	b[len(b)-1] = id
+	return b
+}
+
+// ParseCommitmentHeader decodes a header produced by CommitmentHeader. The
+// returned hashName is empty when the header was written for an unknown hash.
+func ParseCommitmentHeader(b []byte) (root []byte, count uint64, hashName string, err error) {
+	if len(b) <= commitmentHeaderSuffixLen {
+		return nil, 0, "", errors.New("error: commitment header too short")
+	}
+
+	rootLen := len(b) - commitmentHeaderSuffixLen
+	root = append([]byte(nil), b[:rootLen]...)
+	count = binary.BigEndian.Uint64(b[rootLen:])
+
+	id := b[len(b)-1]
+	if id == hashIDUnknown {
+		return root, count, "", nil
+	}
+	if k, ok := hashByID(id); ok {
+		return root, count, k.name, nil
+	}
+	return nil, 0, "", fmt.Errorf("error: unknown hash id %d in commitment header", id)
+}
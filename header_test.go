@@ -0,0 +1,39 @@
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func Test_CommitmentHeader(t *testing.T) {
+	tree, err := NewTree(testLeaves(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	header := tree.CommitmentHeader()
+	if len(header) != 32+8+1 {
+		t.Fatalf("unexpected header length %d", len(header))
+	}
+
+	root, count, hashName, err := ParseCommitmentHeader(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(root, tree.MerkleRoot()) || count != 7 || hashName != "keccak256" {
+		t.Fatalf("round trip mismatch: %x %d %s", root, count, hashName)
+	}
+
+	sha, err := NewTreeWithHashStrategy(testLeaves(3), sha256.New)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, hashName, _ := ParseCommitmentHeader(sha.CommitmentHeader()); hashName != "sha256" {
+		t.Fatalf("expected sha256, got %q", hashName)
+	}
+
+	if _, _, _, err := ParseCommitmentHeader(header[:9]); err == nil {
+		t.Fatal("expected error for truncated header")
+	}
+}
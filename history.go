@@ -0,0 +1,84 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// RootRecord is the root of a tree at one of its versions and the time the
+// version was made.
+type RootRecord struct {
+	Version uint64
+	Root    []byte
+	Time    time.Time
+}
+
+// rootHistory is a ring buffer of the last roots of a tree.
+type rootHistory struct {
+	records []RootRecord
+	// next is the slot of the next record, and of the oldest once full
+	next int
+	full bool
+}
+
+// WithRootHistory makes the tree remember the root of each of its last n
+// versions, so that proofs against a root published earlier can still be checked
+// after the tree has changed, see VerifyProofAtVersion. Only the roots are kept,
+// use WithRetainedVersions to also serve proofs of older versions.
+func WithRootHistory(n int) Option {
+	return func(m *MerkleTree) {
+		if n > 0 {
+			m.rootHistory = &rootHistory{records: make([]RootRecord, n)}
+		} else {
+			m.rootHistory = nil
+		}
+	}
+}
+
+func (h *rootHistory) add(r RootRecord) {
+	h.records[h.next] = r
+	h.next = (h.next + 1) % len(h.records)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// RootHistory returns the remembered roots, oldest first.
+func (m *MerkleTree) RootHistory() []RootRecord {
+	h := m.rootHistory
+	if h == nil {
+		return nil
+	}
+	if !h.full {
+		return append([]RootRecord(nil), h.records[:h.next]...)
+	}
+	return append(append([]RootRecord(nil), h.records[h.next:]...), h.records[:h.next]...)
+}
+
+// RootAtVersion returns the root of the tree at version, if it is still in the
+// history of the tree.
+func (m *MerkleTree) RootAtVersion(version uint64) ([]byte, error) {
+	if m.rootHistory == nil {
+		return nil, errors.New("error: tree does not keep a root history")
+	}
+	for _, r := range m.rootHistory.records {
+		if r.Root != nil && r.Version == version {
+			return r.Root, nil
+		}
+	}
+	return nil, fmt.Errorf("error: version %d is not in the root history", version)
+}
+
+// VerifyProofAtVersion checks proof against the root of the tree at version, the
+// way VerifyProofWithOptions checks it with the options of the tree.
+func (m *MerkleTree) VerifyProofAtVersion(version uint64, proof *MerkleProof) (bool, error) {
+	if proof == nil {
+		return false, errors.New("error: nil proof")
+	}
+	root, err := m.RootAtVersion(version)
+	if err != nil {
+		return false, err
+	}
+	return m.verifyProof(root, proof)
+}
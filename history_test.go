@@ -0,0 +1,61 @@
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_RootHistory(t *testing.T) {
+	leaves := testLeaves(8)
+	tree, err := NewTreeWithOptions(leaves[:2], WithInsertionOrder(), WithRootHistory(4))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// a proof handed out at version 1
+	proof, _ := tree.GetProof(leaves[0])
+	published := tree.MerkleRoot()
+
+	for _, c := range leaves[2:] {
+		if err := tree.AddLeaf(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if ok, err := tree.VerifyProofAtVersion(1, proof); err == nil || ok {
+		t.Fatal("expected version 1 to have left the history")
+	}
+
+	history := tree.RootHistory()
+	if len(history) != 4 {
+		t.Fatalf("unexpected history length %d", len(history))
+	}
+	for i, r := range history {
+		if r.Version != uint64(i+4) || r.Time.IsZero() {
+			t.Fatalf("unexpected record %d: version %d", i, r.Version)
+		}
+		if i > 0 && r.Time.Before(history[i-1].Time) {
+			t.Fatal("expected records in time order")
+		}
+	}
+	if root, err := tree.RootAtVersion(7); err != nil || !bytes.Equal(root, tree.MerkleRoot()) {
+		t.Fatal("expected the current root at the current version")
+	}
+
+	tree, _ = NewTreeWithOptions(leaves[:2], WithInsertionOrder(), WithRootHistory(4))
+	if err := tree.UpdateContent(leaves[0], leaves[5]); err != nil {
+		t.Fatal(err)
+	}
+	if root, _ := tree.RootAtVersion(1); !bytes.Equal(root, published) {
+		t.Fatal("expected the published root at version 1")
+	}
+	if ok, err := tree.VerifyProofAtVersion(1, proof); err != nil || !ok {
+		t.Fatal("proof against the published root does not verify")
+	}
+	if ok, _ := tree.VerifyProofAtVersion(2, proof); ok {
+		t.Fatal("proof of a replaced leaf verifies against the new root")
+	}
+
+	tree, _ = NewTreeWithOptions(leaves[:2])
+	if _, err := tree.RootAtVersion(1); err == nil {
+		t.Fatal("expected error without a root history")
+	}
+}
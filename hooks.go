@@ -0,0 +1,39 @@
+package merkletree
+
+import "bytes"
+
+// rootListener is a callback registered with OnRootChange.
+type rootListener struct {
+	fn func(oldRoot, newRoot []byte, version uint64)
+}
+
+// OnRootChange registers fn to be called whenever a build or a change of the
+// tree gives it a new root, with the root before and after and the new version.
+// Callbacks run in the order they were registered, once the change is complete,
+// on the goroutine that made it. The returned function unregisters fn. Clones
+// and merged trees do not inherit callbacks, and transaction previews do not
+// fire them.
+func (m *MerkleTree) OnRootChange(fn func(oldRoot, newRoot []byte, version uint64)) (cancel func()) {
+	l := &rootListener{fn: fn}
+	m.rootListeners = append(m.rootListeners, l)
+	return func() {
+		for i, other := range m.rootListeners {
+			if other == l {
+				m.rootListeners = append(m.rootListeners[:i:i], m.rootListeners[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// notifyRoot calls the root listeners if the root changed since the last call.
+func (m *MerkleTree) notifyRoot() {
+	old := m.notifiedRoot
+	m.notifiedRoot = m.merkleRoot
+	if bytes.Equal(old, m.merkleRoot) {
+		return
+	}
+	for _, l := range m.rootListeners {
+		l.fn(old, m.merkleRoot, m.version)
+	}
+}
@@ -0,0 +1,71 @@
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_OnRootChange(t *testing.T) {
+	leaves := testLeaves(8)
+	tree, err := NewTreeWithOptions(leaves[:4], WithInsertionOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type change struct {
+		old, new []byte
+		version  uint64
+	}
+	var changes []change
+	cancel := tree.OnRootChange(func(oldRoot, newRoot []byte, version uint64) {
+		changes = append(changes, change{oldRoot, newRoot, version})
+	})
+
+	root := tree.MerkleRoot()
+	if err := tree.AddLeaf(leaves[4]); err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.UpdateLeaf(0, leaves[5]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tree.RemoveLeafByIndex(1); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 3 {
+		t.Fatalf("expected 3 root changes, got %d", len(changes))
+	}
+	for _, c := range changes {
+		if !bytes.Equal(c.old, root) {
+			t.Fatal("expected the previous root as the old root")
+		}
+		root = c.new
+	}
+	if !bytes.Equal(root, tree.MerkleRoot()) || changes[2].version != tree.Version() {
+		t.Fatal("expected the last change to report the current root and version")
+	}
+
+	// previews and unchanged roots do not fire
+	tx := tree.Begin()
+	tx.Add(leaves[6])
+	if _, err := tx.PreviewRoot(); err != nil {
+		t.Fatal(err)
+	}
+	tx.Rollback()
+	if err := tree.UpdateLeaf(0, leaves[5]); err != nil {
+		t.Fatal(err)
+	}
+	if clone := tree.Clone(); clone.AddLeaf(leaves[6]) != nil {
+		t.Fatal("expected the clone to take a leaf")
+	}
+	if len(changes) != 3 {
+		t.Fatalf("expected no further root changes, got %d", len(changes)-3)
+	}
+
+	cancel()
+	if err := tree.AddLeaf(leaves[7]); err != nil {
+		t.Fatal(err)
+	}
+	if len(changes) != 3 {
+		t.Fatal("expected no calls after cancel")
+	}
+}
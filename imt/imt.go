@@ -0,0 +1,146 @@
+// Package imt implements the fixed-depth, append-only incremental merkle tree of
+// the Ethereum deposit contract and of Semaphore groups. The tree only keeps its
+// frontier, the last left child met on each level, and the hashes of empty
+// subtrees, so an append costs one hash per level whatever the number of leaves.
+package imt
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+
+	"github.com/smartbch/merkletree"
+)
+
+// MaxDepth bounds the depth of a tree so that its leaf count fits in a uint64.
+const MaxDepth = 63
+
+// DepositContractDepth is the depth of the tree of the Ethereum deposit contract.
+const DepositContractDepth = 32
+
+// Tree is an incremental merkle tree of a fixed depth. Its 2^depth leaf slots are
+// filled from the left and the empty ones hold the zero leaf, the same layout as
+// merkletree.FixedDepthTree. Every node is hasher.HashPair(left, right).
+type Tree struct {
+	depth  int
+	hasher merkletree.PairHasher
+	// zeros[i] is the root of an empty subtree of height i
+	zeros [][]byte
+	// frontier[i] is the last node of level i that is a left child
+	frontier [][]byte
+	count    uint64
+	root     []byte
+}
+
+// ConcatHasher hashes a pair as h(left || right), the node hash of the deposit
+// contract with sha256 and of most on-chain trees with keccak256.
+func ConcatHasher(h func() hash.Hash) merkletree.PairHasher {
+	return merkletree.PairHasherFunc(func(left, right []byte) ([]byte, error) {
+		hasher := h()
+		if _, err := hasher.Write(left); err != nil {
+			return nil, err
+		}
+		if _, err := hasher.Write(right); err != nil {
+			return nil, err
+		}
+		return hasher.Sum(nil), nil
+	})
+}
+
+// New returns an empty tree of the given depth whose empty slots hold zeroLeaf.
+// Semaphore groups take a SNARK-friendly hasher such as merkletree.PoseidonPairHasher
+// or merkletree.MiMC7PairHasher, and their zero value as zeroLeaf.
+func New(depth int, zeroLeaf []byte, hasher merkletree.PairHasher) (*Tree, error) {
+	if depth < 1 || depth > MaxDepth {
+		return nil, fmt.Errorf("error: depth %d out of range [1, %d]", depth, MaxDepth)
+	}
+
+	zeros := make([][]byte, depth+1)
+	zeros[0] = zeroLeaf
+	for i := 0; i < depth; i++ {
+		z, err := hasher.HashPair(zeros[i], zeros[i])
+		if err != nil {
+			return nil, err
+		}
+		zeros[i+1] = z
+	}
+	return &Tree{
+		depth:    depth,
+		hasher:   hasher,
+		zeros:    zeros,
+		frontier: make([][]byte, depth),
+		root:     zeros[depth],
+	}, nil
+}
+
+// NewDepositTree returns an empty tree laid out like the one of the Ethereum
+// deposit contract: depth 32, sha256 and a zero leaf of 32 zero bytes.
+func NewDepositTree() *Tree {
+	t, err := New(DepositContractDepth, make([]byte, 32), ConcatHasher(sha256.New))
+	if err != nil {
+		panic(err) // sha256 does not fail
+	}
+	return t
+}
+
+// Insert appends leaf to the tree and updates its root.
+func (t *Tree) Insert(leaf []byte) error {
+	if t.count == 1<<uint(t.depth) {
+		return errors.New("error: tree is full")
+	}
+
+	node := leaf
+	index := t.count
+	for level := 0; level < t.depth; level++ {
+		var err error
+		if index&1 == 0 {
+			t.frontier[level] = node
+			node, err = t.hasher.HashPair(node, t.zeros[level])
+		} else {
+			node, err = t.hasher.HashPair(t.frontier[level], node)
+		}
+		if err != nil {
+			return err
+		}
+		index >>= 1
+	}
+	t.root = node
+	t.count++
+	return nil
+}
+
+// Root returns the root of the tree, the root of an empty subtree of its depth
+// while it holds no leaves.
+func (t *Tree) Root() []byte {
+	return t.root
+}
+
+// Count returns the number of leaves inserted.
+func (t *Tree) Count() uint64 {
+	return t.count
+}
+
+// Depth returns the depth of the tree.
+func (t *Tree) Depth() int {
+	return t.depth
+}
+
+// ZeroHashes returns the roots of empty subtrees of every height up to the depth
+// of the tree.
+func (t *Tree) ZeroHashes() [][]byte {
+	return t.zeros
+}
+
+// DepositRoot returns the root reported by get_deposit_root of the deposit
+// contract: sha256(root || count || 24 zero bytes), with the leaf count as a
+// little-endian uint64. t should be a tree from NewDepositTree.
+func (t *Tree) DepositRoot() []byte {
+	var count [32]byte
+	binary.LittleEndian.PutUint64(count[:8], t.count)
+	h := sha256.New()
+	h.Write(t.root)
+	h.Write(count[:])
+	return h.Sum(nil)
+}
@@ -0,0 +1,92 @@
+package imt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/smartbch/merkletree"
+)
+
+func Test_DepositTree(t *testing.T) {
+	tree := NewDepositTree()
+	// get_deposit_root() of the deposit contract before the first deposit
+	want, _ := hex.DecodeString("d70a234731285c6804c2a4f56711ddb8c82c99740f207854891028af34e27e5e")
+	if !bytes.Equal(tree.DepositRoot(), want) {
+		t.Fatalf("unexpected empty deposit root %x", tree.DepositRoot())
+	}
+	if !bytes.Equal(tree.Root(), tree.ZeroHashes()[DepositContractDepth]) {
+		t.Fatal("expected the empty root to be the zero hash of the depth")
+	}
+}
+
+func Test_TreeMatchesFixedDepthTree(t *testing.T) {
+	for _, hasher := range []struct {
+		pair   merkletree.PairHasher
+		leaf   func(i int) []byte
+		concat bool
+	}{
+		{pair: ConcatHasher(sha256.New), concat: true, leaf: func(i int) []byte {
+			h := sha256.Sum256([]byte(fmt.Sprintf("leaf-%d", i)))
+			return h[:]
+		}},
+		{pair: merkletree.MiMC7PairHasher, leaf: func(i int) []byte {
+			return merkletree.HashToBN254([]byte(fmt.Sprintf("leaf-%d", i)))
+		}},
+	} {
+		const depth = 4
+		zero := make([]byte, 32)
+		tree, err := New(depth, zero, hasher.pair)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var cs []merkletree.Content
+		for i := 0; i < 1<<depth; i++ {
+			leaf := hasher.leaf(i)
+			if err := tree.Insert(leaf); err != nil {
+				t.Fatal(err)
+			}
+			cs = append(cs, merkletree.HashContent(leaf))
+
+			if !hasher.concat {
+				continue
+			}
+			want, err := merkletree.NewFixedDepthTree(cs, depth, zero, sha256.New)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(tree.Root(), want.MerkleRoot()) {
+				t.Fatalf("root after %d inserts differs from the fixed-depth tree", i+1)
+			}
+		}
+		if tree.Count() != 1<<depth {
+			t.Fatalf("unexpected count %d", tree.Count())
+		}
+		if err := tree.Insert(zero); err == nil {
+			t.Fatal("expected error inserting into a full tree")
+		}
+
+		// the root of a full tree is that of the complete binary tree over the leaves
+		level := make([][]byte, len(cs))
+		for i, c := range cs {
+			level[i] = c.(merkletree.HashContent)
+		}
+		for len(level) > 1 {
+			var next [][]byte
+			for i := 0; i < len(level); i += 2 {
+				node, _ := hasher.pair.HashPair(level[i], level[i+1])
+				next = append(next, node)
+			}
+			level = next
+		}
+		if !bytes.Equal(tree.Root(), level[0]) {
+			t.Fatal("root of the full tree differs from the complete tree")
+		}
+	}
+
+	if _, err := New(0, nil, ConcatHasher(sha256.New)); err == nil {
+		t.Fatal("expected error for depth 0")
+	}
+}
@@ -0,0 +1,341 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// AddLeaf inserts c into the tree and rehashes only the nodes whose subtree
+// changed, instead of rebuilding the whole tree. A tree built WithInsertionOrder
+// gets c as its last leaf, which touches one or two nodes per level. With sorted
+// leaves c goes to its sorted position and every node right of it on each level
+// is rebuilt. The duplicate policy and the leaf size check of the tree apply;
+// outside of AllowDuplicates an unsorted tree is scanned for the new leaf hash.
+func (m *MerkleTree) AddLeaf(c Content) error {
+	leaf, err := m.newLeaf(c, nil)
+	if err != nil || leaf == nil {
+		return err
+	}
+
+	pos := len(m.Leafs)
+	if !m.unsortedLeaves {
+		pos = m.searchLeafs(leaf.Hash)
+	}
+	m.insertLeaf(pos, leaf)
+	return m.rebuildFrom(pos)
+}
+
+// UpdateLeaf replaces the leaf at index with one holding c and rehashes only the
+// ancestors of the leaf. With sorted leaves a new hash that sorts elsewhere moves
+// the leaf, and the nodes right of its old or new position, whichever comes
+// first, are rebuilt as in AddLeaf. Under DedupeDuplicates a new hash already in
+// the tree removes the leaf.
+func (m *MerkleTree) UpdateLeaf(index int, c Content) error {
+	if index < 0 || index >= len(m.Leafs) {
+		return fmt.Errorf("error: leaf index %d out of range [0, %d)", index, len(m.Leafs))
+	}
+	leaf, err := m.newLeaf(c, m.Leafs[index])
+	if err != nil {
+		return err
+	}
+	if leaf == nil {
+		m.removeLeaf(index)
+		return m.rebuildFrom(index)
+	}
+
+	if m.unsortedLeaves || m.sortsAt(index, leaf.Hash) {
+		return m.replaceLeaf(index, leaf)
+	}
+	m.removeLeaf(index)
+	pos := m.searchLeafs(leaf.Hash)
+	m.insertLeaf(pos, leaf)
+	if pos < index {
+		return m.rebuildFrom(pos)
+	}
+	return m.rebuildFrom(index)
+}
+
+// UpdateContent replaces the leaf holding old with one holding c, see UpdateLeaf.
+func (m *MerkleTree) UpdateContent(old, c Content) error {
+	index, err := m.GetIndexOf(old)
+	if err != nil {
+		return err
+	}
+	return m.UpdateLeaf(index, c)
+}
+
+// RemoveLeafByIndex removes the leaf at index and returns the new root. Leaves
+// after it move one position left, so the nodes right of the leaf on each level
+// are rebuilt as in AddLeaf and the rest are kept. Only a tree with an empty root,
+// see WithEmptyRoot, may lose its last leaf.
+func (m *MerkleTree) RemoveLeafByIndex(index int) ([]byte, error) {
+	if index < 0 || index >= len(m.Leafs) {
+		return nil, fmt.Errorf("error: leaf index %d out of range [0, %d)", index, len(m.Leafs))
+	}
+	if len(m.Leafs) == 1 && m.emptyRoot == nil {
+		return nil, errors.New("error: cannot remove the last leaf of a tree without an empty root")
+	}
+	m.removeLeaf(index)
+	if err := m.rebuildFrom(index); err != nil {
+		return nil, err
+	}
+	return m.merkleRoot, nil
+}
+
+// RemoveLeaf removes the leaf holding content and returns the new root, see
+// RemoveLeafByIndex.
+func (m *MerkleTree) RemoveLeaf(content Content) ([]byte, error) {
+	index, err := m.GetIndexOf(content)
+	if err != nil {
+		return nil, err
+	}
+	return m.RemoveLeafByIndex(index)
+}
+
+// newLeaf returns the leaf node of c for a tree that already holds its leaves,
+// except for replaced. It returns nil if the tree drops the leaf as a duplicate.
+// Outside of AllowDuplicates a tree kept in insertion order is scanned for the
+// leaf hash.
+func (m *MerkleTree) newLeaf(c Content, replaced *Node) (*Node, error) {
+	acc := newLeafAccumulator(m, 1)
+	if err := acc.add(c); err != nil {
+		return nil, err
+	}
+	leaf := acc.leafs[0]
+
+	if m.duplicatePolicy != AllowDuplicates {
+		if dup := m.findLeafByHash(leaf.Hash); dup != nil && dup != replaced {
+			if m.duplicatePolicy == ErrorOnDuplicate {
+				return nil, ErrDuplicateLeaf
+			}
+			return nil, nil
+		}
+	}
+	return leaf, nil
+}
+
+// sortsAt reports whether a leaf with hashBz may stay at index of the sorted
+// leaves.
+func (m *MerkleTree) sortsAt(index int, hashBz []byte) bool {
+	return (index == 0 || bytes.Compare(m.Leafs[index-1].Hash, hashBz) <= 0) &&
+		(index == len(m.Leafs)-1 || bytes.Compare(hashBz, m.Leafs[index+1].Hash) <= 0)
+}
+
+func (m *MerkleTree) insertLeaf(pos int, leaf *Node) {
+	m.Leafs = append(m.Leafs, nil)
+	copy(m.Leafs[pos+1:], m.Leafs[pos:])
+	m.Leafs[pos] = leaf
+}
+
+func (m *MerkleTree) removeLeaf(index int) {
+	copy(m.Leafs[index:], m.Leafs[index+1:])
+	m.Leafs[len(m.Leafs)-1] = nil
+	m.Leafs = m.Leafs[:len(m.Leafs)-1]
+}
+
+// replaceLeaf puts leaf in place of the leaf at index, which keeps the shape of
+// the tree, and rehashes its ancestors.
+func (m *MerkleTree) replaceLeaf(index int, leaf *Node) error {
+	m.proofCache.invalidate()
+	m.swapLeaf(index, leaf)
+	if err := m.rehashAncestors([]int{index}, math.MaxInt); err != nil {
+		return err
+	}
+	m.merkleRoot = m.Root.Hash
+	m.commit()
+	return nil
+}
+
+// swapLeaf puts leaf in place of the leaf at index without rehashing anything.
+func (m *MerkleTree) swapLeaf(index int, leaf *Node) {
+	old := m.Leafs[index]
+	m.Leafs[index] = leaf
+	parent := m.own(old.Parent)
+	leaf.Parent, leaf.single = parent, old.single
+	if parent.Left == old {
+		parent.Left = leaf
+	}
+	if parent.Right == old {
+		parent.Right = leaf
+	}
+}
+
+// own returns n if it may be changed in place, or else a copy of n that takes
+// its place in the tree. The ancestors of n are copied as needed, and n is left
+// as it was for the snapshots that share it. Only parent pointers of the nodes
+// of a snapshot change, which snapshots do not use.
+func (m *MerkleTree) own(n *Node) *Node {
+	if n.epoch == m.epoch {
+		return n
+	}
+	c := *n
+	c.epoch = m.epoch
+	if !c.leaf {
+		m.keepLink(c.Left)
+		m.keepLink(c.Right)
+		c.Left.Parent, c.Right.Parent = &c, &c
+	}
+	if n.Parent == nil {
+		m.Root = &c
+		return &c
+	}
+	parent := m.own(n.Parent)
+	if parent.Left == n {
+		parent.Left = &c
+	}
+	if parent.Right == n {
+		parent.Right = &c
+	}
+	c.Parent = parent
+	return &c
+}
+
+// rehashAncestors rehashes every ancestor of the leaves at the ascending indexes
+// once, leaving out the nodes whose subtree reaches past the first end leaves.
+func (m *MerkleTree) rehashAncestors(indexes []int, end int) error {
+	type dirtyNode struct {
+		n *Node
+		i int
+	}
+	level := make([]dirtyNode, len(indexes))
+	for k, i := range indexes {
+		level[k] = dirtyNode{m.Leafs[i], i}
+	}
+
+	for height := 1; len(level) > 0; height++ {
+		var parents []dirtyNode
+		for _, d := range level {
+			p, i := d.n.Parent, d.i/2
+			if p == nil || (len(parents) > 0 && parents[len(parents)-1].n == p) {
+				continue
+			}
+			if end>>uint(height) <= i {
+				// rebuilt afterwards, see rebuildFrom
+				continue
+			}
+			p = m.own(p)
+			if p.Left.single {
+				p.Hash = p.Left.Hash
+			} else {
+				hashBz, err := m.hashPair(height, p.Left.Hash, p.Right.Hash)
+				if err != nil {
+					return err
+				}
+				p.Hash = hashBz
+			}
+			parents = append(parents, dirtyNode{p, i})
+		}
+		level = parents
+	}
+	return nil
+}
+
+// rebuildFrom brings the internal nodes up to date after the leaves from index
+// start on were replaced, inserted or removed. A node is kept if its subtree lies
+// entirely before start; on every level the nodes from the first changed one to
+// the end of the level are rebuilt, so appending to a tree rebuilds one or two
+// nodes per level.
+func (m *MerkleTree) rebuildFrom(start int) error {
+	m.proofCache.invalidate()
+	if len(m.Leafs) == 0 {
+		m.Root = nil
+		m.merkleRoot = m.emptyRoot
+		m.commit()
+		return nil
+	}
+
+	// The kept nodes of a level that get a new parent are found through the parent
+	// pointers of the leaves, so they are collected before any of those change.
+	// kept[k] holds the nodes of level k from firsts[k] up to the first one
+	// rebuilt.
+	var (
+		firsts []int
+		counts []int
+		kept   [][]*Node
+	)
+	count, offset := len(m.Leafs), start
+	for level := 0; ; level++ {
+		first := offset
+		if first > count-1 {
+			first = count - 1
+		}
+		first &^= 1
+
+		var nodes []*Node
+		if level > 0 {
+			for i := first; i < offset; i++ {
+				nodes = append(nodes, m.keptNode(level, i))
+			}
+		}
+		firsts = append(firsts, first)
+		counts = append(counts, count)
+		kept = append(kept, nodes)
+		if count <= 2 {
+			break
+		}
+		offset, count = first/2, (count+1)/2
+	}
+
+	nl := m.Leafs[firsts[0]:]
+	for level := range firsts {
+		parents, err := m.pairNodes(nl, counts[level], level+1)
+		if err != nil {
+			return err
+		}
+		if level+1 == len(firsts) {
+			m.Root = parents[0]
+			m.merkleRoot = m.Root.Hash
+			m.commit()
+			return nil
+		}
+		nl = append(kept[level+1], parents...)
+	}
+	return nil
+}
+
+// keptNode returns the node at index i of level in the tree as it was before the
+// current change, which must not have touched its subtree.
+func (m *MerkleTree) keptNode(level, i int) *Node {
+	n := m.Leafs[i<<uint(level)]
+	for ; level > 0; level-- {
+		n = n.Parent
+	}
+	return n
+}
+
+// pairNodes returns the parents of nl, the tail of a level of count nodes, the
+// way buildLevel pairs them. nl must start at an even index of its level.
+func (m *MerkleTree) pairNodes(nl []*Node, count, level int) ([]*Node, error) {
+	parents := make([]*Node, 0, (len(nl)+1)/2)
+	for i := 0; i < len(nl); i += 2 {
+		left, right := nl[i], nl[i]
+		if i+1 < len(nl) {
+			right = nl[i+1]
+		}
+		m.keepLink(left)
+		m.keepLink(right)
+		left.single, right.single = false, false
+
+		n := &Node{
+			Left:  left,
+			Right: right,
+			Tree:  m,
+			epoch: m.epoch,
+		}
+		if left != right || (m.duplicateOdd && count > 1) {
+			hashBz, err := m.hashPair(level, left.Hash, right.Hash)
+			if err != nil {
+				return nil, err
+			}
+			n.Hash = hashBz
+		} else {
+			n.Hash = left.Hash
+			left.single = true
+		}
+		left.Parent, right.Parent = n, n
+		parents = append(parents, n)
+	}
+	return parents, nil
+}
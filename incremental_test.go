@@ -0,0 +1,169 @@
+package merkletree
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// incrementalOptions are the layouts the incremental operations are checked on.
+var incrementalOptions = [][]Option{
+	nil,
+	{WithInsertionOrder()},
+	{WithOddNodePolicy(DuplicateLast)},
+	{WithInsertionOrder(), WithOddNodePolicy(DuplicateLast)},
+	{WithInsertionOrder(), WithLevelNumbers()},
+	{WithRFC6962()},
+}
+
+// checkSameTree fails unless tree has the root of a tree built from scratch over cs.
+func checkSameTree(t *testing.T, tree *MerkleTree, cs []Content, opts []Option) {
+	t.Helper()
+	want, err := NewTreeWithOptions(cs, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(tree.MerkleRoot(), want.MerkleRoot()) {
+		t.Fatalf("root over %d leaves differs from a full build", len(cs))
+	}
+	if ok, err := tree.VerifyTree(); err != nil || !ok {
+		t.Fatalf("tree over %d leaves does not verify", len(cs))
+	}
+	for _, c := range cs {
+		if ok, err := tree.VerifyContent(c); err != nil || !ok {
+			t.Fatalf("content of a tree over %d leaves does not verify", len(cs))
+		}
+	}
+}
+
+func Test_AddLeaf(t *testing.T) {
+	leaves := testLeaves(20)
+	for _, opts := range incrementalOptions {
+		tree, err := NewTreeWithOptions(leaves[:1], opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for n := 2; n <= len(leaves); n++ {
+			if err := tree.AddLeaf(leaves[n-1]); err != nil {
+				t.Fatal(err)
+			}
+			checkSameTree(t, tree, leaves[:n], opts)
+		}
+	}
+
+	tree, err := NewTreeWithOptions(nil, WithEmptyRoot(ZeroRoot(32)), WithInsertionOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := tree.AddLeaf(leaves[0]); err != nil {
+		t.Fatal(err)
+	}
+	checkSameTree(t, tree, leaves[:1], []Option{WithInsertionOrder()})
+
+	tree, _ = NewTreeWithOptions(leaves[:3], WithDuplicatePolicy(ErrorOnDuplicate))
+	if err := tree.AddLeaf(leaves[1]); err != ErrDuplicateLeaf {
+		t.Fatalf("expected ErrDuplicateLeaf, got %v", err)
+	}
+	tree, _ = NewTreeWithOptions(leaves[:3], WithDuplicatePolicy(DedupeDuplicates))
+	if err := tree.AddLeaf(leaves[1]); err != nil || len(tree.Leafs) != 3 {
+		t.Fatal("expected the duplicate to be dropped")
+	}
+}
+
+func Test_UpdateLeaf(t *testing.T) {
+	leaves := testLeaves(13)
+	for _, opts := range incrementalOptions {
+		tree, err := NewTreeWithOptions(leaves, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cs := append([]Content(nil), leaves...)
+		for i := range cs {
+			index, err := tree.GetIndexOf(cs[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+			cs[i] = TestLeaf{Bz: []byte(fmt.Sprintf("updated-%d", i))}
+			if err := tree.UpdateLeaf(index, cs[i]); err != nil {
+				t.Fatal(err)
+			}
+			checkSameTree(t, tree, cs, opts)
+		}
+	}
+
+	tree, _ := NewTreeWithOptions(leaves[:5], WithInsertionOrder())
+	updated := TestLeaf{Bz: []byte("updated")}
+	if err := tree.UpdateContent(leaves[2], updated); err != nil {
+		t.Fatal(err)
+	}
+	if index, err := tree.GetIndexOf(updated); err != nil || index != 2 {
+		t.Fatal("expected the updated content in place of the old one")
+	}
+	if err := tree.UpdateContent(leaves[2], updated); err != ErrContentNotFound {
+		t.Fatalf("expected ErrContentNotFound, got %v", err)
+	}
+	if err := tree.UpdateLeaf(5, updated); err == nil {
+		t.Fatal("expected error for an index out of range")
+	}
+
+	tree, _ = NewTreeWithOptions(leaves[:5], WithDuplicatePolicy(ErrorOnDuplicate))
+	if err := tree.UpdateLeaf(0, leaves[1]); err != ErrDuplicateLeaf {
+		t.Fatalf("expected ErrDuplicateLeaf, got %v", err)
+	}
+	tree, _ = NewTreeWithOptions(leaves[:5], WithDuplicatePolicy(DedupeDuplicates))
+	index, _ := tree.GetIndexOf(leaves[0])
+	if err := tree.UpdateLeaf(index, leaves[1]); err != nil {
+		t.Fatal(err)
+	}
+	checkSameTree(t, tree, leaves[1:5], nil)
+}
+
+func Test_RemoveLeaf(t *testing.T) {
+	leaves := testLeaves(13)
+	for _, opts := range incrementalOptions {
+		tree, err := NewTreeWithOptions(leaves, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// remove from the front, the back and the middle in turn
+		cs := append([]Content(nil), leaves...)
+		for k := 0; len(cs) > 1; k++ {
+			i := []int{0, len(cs) - 1, len(cs) / 2}[k%3]
+			root, err := tree.RemoveLeaf(cs[i])
+			if err != nil {
+				t.Fatal(err)
+			}
+			cs = append(cs[:i], cs[i+1:]...)
+			checkSameTree(t, tree, cs, opts)
+			if !bytes.Equal(root, tree.MerkleRoot()) {
+				t.Fatal("expected the new root to be returned")
+			}
+		}
+		if _, err := tree.RemoveLeafByIndex(0); err == nil {
+			t.Fatal("expected error removing the last leaf")
+		}
+	}
+
+	tree, _ := NewTreeWithOptions(leaves[:2], WithEmptyRoot(ZeroRoot(32)))
+	if _, err := tree.RemoveLeaf(leaves[0]); err != nil {
+		t.Fatal(err)
+	}
+	root, err := tree.RemoveLeafByIndex(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(root, ZeroRoot(32)) || tree.Root != nil || len(tree.Leafs) != 0 {
+		t.Fatal("expected an empty tree")
+	}
+	if err := tree.AddLeaf(leaves[1]); err != nil {
+		t.Fatal(err)
+	}
+	checkSameTree(t, tree, leaves[1:2], nil)
+
+	if _, err := tree.RemoveLeafByIndex(1); err == nil {
+		t.Fatal("expected error for an index out of range")
+	}
+	if _, err := tree.RemoveLeaf(leaves[0]); err != ErrContentNotFound {
+		t.Fatalf("expected ErrContentNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,84 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// MerkleTreeJSOptions mirrors the constructor options of the merkletreejs library.
+// The zero value matches its defaults, which differ from this package's: leaves
+// keep their order, pairs are not sorted and odd nodes are promoted.
+type MerkleTreeJSOptions struct {
+	SortLeaves   bool
+	SortPairs    bool
+	DuplicateOdd bool
+	HashLeaves   bool
+}
+
+// WithMerkleTreeJSOptions builds the tree the way merkletreejs does with the same
+// options and hash function. The content hashes play the role of the leaf buffers
+// handed to merkletreejs, so with HashLeaves they are hashed once more.
+func WithMerkleTreeJSOptions(o MerkleTreeJSOptions) Option {
+	return func(m *MerkleTree) {
+		WithSortLeaves(o.SortLeaves)(m)
+		WithSortPairs(o.SortPairs)(m)
+		WithDuplicateOdd(o.DuplicateOdd)(m)
+		WithHashLeaves(o.HashLeaves)(m)
+	}
+}
+
+// JSProofElement is one step of a proof in the shape of merkletreejs getProof:
+// Position tells on which side Data, the sibling hash, is combined.
+type JSProofElement struct {
+	Position string        `json:"position"`
+	Data     hexutil.Bytes `json:"data"`
+}
+
+// GetJSProof returns the proof of content in the shape of merkletreejs getProof.
+// For the last node of an odd level built with DuplicateOdd the proof holds the
+// node itself as its right sibling, which merkletreejs verify accepts.
+func (m *MerkleTree) GetJSProof(content Content) ([]JSProofElement, error) {
+	leaf, err := m.findLeaf(content)
+	if err != nil {
+		return nil, err
+	}
+
+	siblings, path := leaf.merklePath()
+	proof := make([]JSProofElement, len(siblings))
+	for i, sibling := range siblings {
+		position := "left"
+		if path[i] == 1 {
+			position = "right"
+		}
+		proof[i] = JSProofElement{Position: position, Data: sibling}
+	}
+	return proof, nil
+}
+
+// VerifyJSProof checks a proof in the shape of merkletreejs getProof for leaf, the
+// hash stored in the tree, against root, like merkletreejs verify.
+func VerifyJSProof(proof []JSProofElement, leaf, root []byte, o MerkleTreeJSOptions, h func() hash.Hash) (bool, error) {
+	siblings := make([][]byte, len(proof))
+	path := make([]int64, len(proof))
+	for i, element := range proof {
+		switch element.Position {
+		case "left":
+		case "right":
+			path[i] = 1
+		default:
+			return false, fmt.Errorf("error: invalid proof position %q", element.Position)
+		}
+		siblings[i] = element.Data
+	}
+	if len(leaf) == 0 {
+		return false, errors.New("error: empty leaf")
+	}
+
+	if o.SortPairs {
+		return VerifyProof(root, leaf, siblings, h)
+	}
+	return VerifyPositionalProof(root, leaf, siblings, path, h)
+}
@@ -0,0 +1,112 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/sha3"
+)
+
+// jsRoot is a port of the layer construction of merkletreejs with keccak256
+func jsRoot(leaves [][]byte, o MerkleTreeJSOptions) []byte {
+	layer := make([][]byte, len(leaves))
+	copy(layer, leaves)
+	if o.HashLeaves {
+		for i := range layer {
+			layer[i] = gethcrypto.Keccak256(layer[i])
+		}
+	}
+	if o.SortLeaves {
+		sort.Slice(layer, func(i, j int) bool { return bytes.Compare(layer[i], layer[j]) < 0 })
+	}
+
+	for len(layer) > 1 {
+		var next [][]byte
+		for i := 0; i < len(layer); i += 2 {
+			if i+1 == len(layer) && !o.DuplicateOdd {
+				next = append(next, layer[i])
+				continue
+			}
+			left, right := layer[i], layer[i]
+			if i+1 < len(layer) {
+				right = layer[i+1]
+			}
+			if o.SortPairs && bytes.Compare(left, right) > 0 {
+				left, right = right, left
+			}
+			next = append(next, gethcrypto.Keccak256(left, right))
+		}
+		layer = next
+	}
+	return layer[0]
+}
+
+func Test_MerkleTreeJSOptions(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 5, 6, 9} {
+		var raw [][]byte
+		var leaves []Content
+		for i := 0; i < n; i++ {
+			data := []byte(fmt.Sprintf("js-leaf-%d", i))
+			raw = append(raw, data)
+			leaves = append(leaves, hashLeaf(data))
+		}
+
+		for mask := 0; mask < 16; mask++ {
+			o := MerkleTreeJSOptions{
+				SortLeaves:   mask&1 != 0,
+				SortPairs:    mask&2 != 0,
+				DuplicateOdd: mask&4 != 0,
+				HashLeaves:   mask&8 != 0,
+			}
+			tree, err := NewTreeWithOptions(leaves, WithMerkleTreeJSOptions(o))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(tree.MerkleRoot(), jsRoot(raw, o)) {
+				t.Fatalf("%d leaves, %+v: root differs from merkletreejs", n, o)
+			}
+			if ok, err := tree.VerifyTree(); err != nil || !ok {
+				t.Fatalf("%d leaves, %+v: tree does not verify", n, o)
+			}
+
+			all, err := tree.AllProofs()
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, leaf := range tree.Leafs {
+				if !reflect.DeepEqual(all[i], tree.proofOf(leaf)) {
+					t.Fatalf("%d leaves, %+v: AllProofs differs from GetProof", n, o)
+				}
+			}
+
+			for _, leaf := range leaves {
+				proof, err := tree.GetJSProof(leaf)
+				if err != nil {
+					t.Fatal(err)
+				}
+				js, _ := json.Marshal(proof)
+				var decoded []JSProofElement
+				if err := json.Unmarshal(js, &decoded); err != nil {
+					t.Fatal(err)
+				}
+
+				leafHash, _ := tree.leafHash(leaf)
+				ok, err := VerifyJSProof(decoded, leafHash, tree.MerkleRoot(), o, sha3.NewLegacyKeccak256)
+				if err != nil || !ok {
+					t.Fatalf("%d leaves, %+v: proof does not verify", n, o)
+				}
+				if ok, err := tree.VerifyContent(leaf); err != nil || !ok {
+					t.Fatalf("%d leaves, %+v: content does not verify", n, o)
+				}
+				if _, err := tree.GetIndexOf(leaf); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"hash"
+)
+
+// LazyContent is leaf data that is expensive to materialize, such as a large blob
+// fetched on demand. Hash must be cheap and return the leaf hash of the content
+// that Load produces; Load is only called once the content itself is needed.
+type LazyContent interface {
+	Hash() []byte
+	Load() (Content, error)
+}
+
+// lazyLeaf adapts a LazyContent to Content without ever loading it.
+type lazyLeaf struct {
+	LazyContent
+}
+
+func (l lazyLeaf) CalculateHash() ([]byte, error) {
+	return l.Hash(), nil
+}
+
+func (l lazyLeaf) Equals(other Content) (bool, error) {
+	otherHash, err := other.CalculateHash()
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(l.Hash(), otherHash), nil
+}
+
+// NewLazyTree builds a tree from the precomputed hashes of cs. Building the tree
+// and generating proofs never call Load.
+func NewLazyTree(cs []LazyContent, hashStrategy func() hash.Hash) (*MerkleTree, error) {
+	leaves := make([]Content, len(cs))
+	for i, c := range cs {
+		leaves[i] = lazyLeaf{c}
+	}
+	return NewTreeWithHashStrategy(leaves, hashStrategy)
+}
+
+// VerifyAndGet verifies the path of the leaf with leafHash and returns its content,
+// loading it if the leaf holds a LazyContent. Loaded content must hash to leafHash.
+func (m *MerkleTree) VerifyAndGet(leafHash []byte) (Content, error) {
+	leaf := m.findLeafByHash(leafHash)
+	if leaf == nil {
+		return nil, ErrContentNotFound
+	}
+
+	ok, err := m.verifyLeaf(leaf, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("error: leaf does not verify against the root")
+	}
+
+	lazy, isLazy := leaf.C.(lazyLeaf)
+	if !isLazy {
+		return leaf.C, nil
+	}
+	c, err := lazy.Load()
+	if err != nil {
+		return nil, err
+	}
+	loadedHash, err := m.leafHash(c)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(loadedHash, leafHash) {
+		return nil, errors.New("error: loaded content does not match leaf hash")
+	}
+	return c, nil
+}
+
+// findLeafByHash returns the leaf with leafHash using binary search over the sorted
+// leaves, or a scan if they are kept in insertion order. It returns nil if there
+// is none.
+func (m *MerkleTree) findLeafByHash(leafHash []byte) *Node {
+	for i := m.searchLeafs(leafHash); i < len(m.Leafs); i++ {
+		if bytes.Equal(m.Leafs[i].Hash, leafHash) {
+			return m.Leafs[i]
+		}
+		if !m.unsortedLeaves {
+			break
+		}
+	}
+	return nil
+}
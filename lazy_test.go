@@ -0,0 +1,64 @@
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+type countingLazy struct {
+	leaf  TestLeaf
+	hash  []byte
+	loads *int
+}
+
+func (c countingLazy) Hash() []byte {
+	return c.hash
+}
+
+func (c countingLazy) Load() (Content, error) {
+	*c.loads++
+	return c.leaf, nil
+}
+
+func Test_LazyTree(t *testing.T) {
+	loads := 0
+	var lazies []LazyContent
+	var leaves []Content
+	for _, c := range testLeaves(7) {
+		h, _ := c.CalculateHash()
+		lazies = append(lazies, countingLazy{leaf: c.(TestLeaf), hash: h, loads: &loads})
+		leaves = append(leaves, c)
+	}
+
+	tree, err := NewLazyTree(lazies, sha3.NewLegacyKeccak256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	eager, _ := NewTree(leaves)
+	if !bytes.Equal(tree.MerkleRoot(), eager.MerkleRoot()) {
+		t.Fatal("lazy tree root differs from eager tree root")
+	}
+
+	for _, lazy := range lazies {
+		if _, err := tree.GetProof(lazyLeaf{lazy}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if loads != 0 {
+		t.Fatalf("Load called %d times during construction and proof generation", loads)
+	}
+
+	c, err := tree.VerifyAndGet(lazies[3].Hash())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, _ := c.Equals(leaves[3]); !ok || loads != 1 {
+		t.Fatalf("expected content to be loaded exactly once, got %d loads", loads)
+	}
+
+	if _, err := tree.VerifyAndGet([]byte("unknown")); err == nil {
+		t.Fatal("expected error for unknown leaf hash")
+	}
+}
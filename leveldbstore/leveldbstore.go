@@ -0,0 +1,52 @@
+// Package leveldbstore is a merkletree.NodeStore backed by LevelDB.
+package leveldbstore
+
+import (
+	"github.com/smartbch/merkletree"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+// Store keeps tree nodes in a LevelDB database.
+type Store struct {
+	db *leveldb.DB
+}
+
+var _ merkletree.NodeStore = (*Store)(nil)
+
+// Open opens or creates the database at path.
+func Open(path string) (*Store, error) {
+	db, err := leveldb.OpenFile(path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// New returns a store in db, which remains owned by the caller.
+func New(db *leveldb.DB) *Store {
+	return &Store{db: db}
+}
+
+// Get implements merkletree.NodeStore.
+func (s *Store) Get(hash []byte) ([]byte, error) {
+	value, err := s.db.Get(hash, nil)
+	if err == leveldb.ErrNotFound {
+		return nil, merkletree.ErrNodeNotFound
+	}
+	return value, err
+}
+
+// Put implements merkletree.NodeStore.
+func (s *Store) Put(hash, value []byte) error {
+	return s.db.Put(hash, value, nil)
+}
+
+// Delete implements merkletree.NodeStore.
+func (s *Store) Delete(hash []byte) error {
+	return s.db.Delete(hash, nil)
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
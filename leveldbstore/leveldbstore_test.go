@@ -0,0 +1,56 @@
+package leveldbstore
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/smartbch/merkletree"
+)
+
+func Test_Store(t *testing.T) {
+	s, err := Open(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	if _, err := s.Get([]byte("missing")); err != merkletree.ErrNodeNotFound {
+		t.Fatalf("expected ErrNodeNotFound, got %v", err)
+	}
+
+	var leaves []merkletree.Content
+	for i := 0; i < 10; i++ {
+		leaves = append(leaves, merkletree.ByteContent(fmt.Sprintf("leaf-%d", i)))
+	}
+	tree, _ := merkletree.NewTree(leaves)
+	if err := tree.PersistTo(s); err != nil {
+		t.Fatal(err)
+	}
+	st, err := merkletree.OpenStoredTree(s, tree.MerkleRoot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < st.LeafCount(); i++ {
+		proof, err := st.GetProofByIndex(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := st.VerifyProof(proof); err != nil || !ok {
+			t.Fatalf("proof of leaf %d does not verify", i)
+		}
+	}
+
+	if err := merkletree.DeleteFromStore(s, tree.MerkleRoot()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(tree.MerkleRoot()); err != merkletree.ErrNodeNotFound {
+		t.Fatal("expected the tree to be deleted")
+	}
+	if err := s.Put([]byte("k"), []byte("v")); err != nil {
+		t.Fatal(err)
+	}
+	if value, _ := s.Get([]byte("k")); !bytes.Equal(value, []byte("v")) {
+		t.Fatal("unexpected value")
+	}
+}
@@ -0,0 +1,170 @@
+package merkletree
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"errors"
+)
+
+// MergeRoots returns the root of the tree holding the leaves of both a and b.
+// Both leaf lists are already sorted, so they are merged linearly instead of being
+// sorted again. The trees must use the same hash strategy and must not share any
+// leaf hash, or ErrDuplicateLeaf is returned.
+func MergeRoots(a, b *MerkleTree) ([]byte, error) {
+	if err := checkMergeable(a, b); err != nil {
+		return nil, err
+	}
+	if a.unsortedLeaves || b.unsortedLeaves {
+		return nil, errors.New("error: cannot merge trees that keep insertion order")
+	}
+
+	t := &MerkleTree{
+		hashStrategy:  a.hashStrategy,
+		unsortedPairs: a.unsortedPairs,
+		duplicateOdd:  a.duplicateOdd,
+		hashLeaves:    a.hashLeaves,
+		leafPrefix:    a.leafPrefix,
+		nodePrefix:    a.nodePrefix,
+		hmacKey:       a.hmacKey,
+	}
+	leafs, err := mergeLeafs(a.Leafs, b.Leafs, t, ErrorOnDuplicate)
+	if err != nil {
+		return nil, err
+	}
+	if len(leafs) == 0 {
+		return nil, ErrEmptyTree
+	}
+
+	root, err := buildIntermediate(context.Background(), leafs, t)
+	if err != nil {
+		return nil, err
+	}
+	return root.Hash, nil
+}
+
+// Merge returns a new tree with the options of a holding the leaves of both a
+// and b, which must have been built with the same options. The leaf hashes of
+// both trees are reused: sorted leaves are merged linearly and leaves kept in
+// insertion order are those of a followed by those of b. A leaf hash found in
+// both trees is handled by their duplicate policy.
+func Merge(a, b *MerkleTree) (*MerkleTree, error) {
+	if err := checkMergeable(a, b); err != nil {
+		return nil, err
+	}
+	if a.unsortedLeaves != b.unsortedLeaves || a.duplicatePolicy != b.duplicatePolicy || !bytes.Equal(a.emptyRoot, b.emptyRoot) {
+		return nil, errors.New("error: cannot merge trees with different layouts")
+	}
+
+	t := a.emptyCopy()
+	var leafs []*Node
+	if a.unsortedLeaves {
+		acc := newLeafAccumulator(t, len(a.Leafs)+len(b.Leafs))
+		for _, n := range append(append([]*Node(nil), a.Leafs...), b.Leafs...) {
+			if acc.seen != nil {
+				if acc.seen[string(n.Hash)] {
+					if t.duplicatePolicy == ErrorOnDuplicate {
+						return nil, ErrDuplicateLeaf
+					}
+					continue
+				}
+				acc.seen[string(n.Hash)] = true
+			}
+			leafs = append(leafs, copyLeaf(n, t))
+		}
+	} else {
+		var err error
+		if leafs, err = mergeLeafs(a.Leafs, b.Leafs, t, t.duplicatePolicy); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(leafs) == 0 && t.emptyRoot == nil {
+		return nil, ErrEmptyTree
+	}
+	t.Leafs = leafs
+	if err := t.rebuildFrom(0); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// checkMergeable returns an error unless a and b hash their leaves and nodes the
+// same way.
+func checkMergeable(a, b *MerkleTree) error {
+	if !bytes.Equal(hashFingerprint(a.hashStrategy), hashFingerprint(b.hashStrategy)) {
+		return errors.New("error: cannot merge trees with different hash strategies")
+	}
+	if a.pairHasher != nil || b.pairHasher != nil || a.levelTag != nil || b.levelTag != nil {
+		// functions cannot be compared to check both trees hash nodes alike
+		return errors.New("error: cannot merge trees with pair hashers or level tags")
+	}
+	if a.unsortedPairs != b.unsortedPairs || a.duplicateOdd != b.duplicateOdd || a.hashLeaves != b.hashLeaves || !bytes.Equal(a.leafPrefix, b.leafPrefix) || !bytes.Equal(a.nodePrefix, b.nodePrefix) || !hmac.Equal(a.hmacKey, b.hmacKey) {
+		return errors.New("error: cannot merge trees with different layouts")
+	}
+	return nil
+}
+
+// emptyCopy returns a tree without leaves with the options of m.
+func (m *MerkleTree) emptyCopy() *MerkleTree {
+	c := *m
+	t := &c
+	t.Root, t.Leafs, t.merkleRoot = nil, nil, nil
+	t.building = nil
+	t.version, t.epoch, t.versions = 0, 0, nil
+	t.rootListeners, t.notifiedRoot = nil, nil
+	if m.proofCache != nil {
+		t.proofCache = &proofCache{}
+	}
+	if m.rootHistory != nil {
+		t.rootHistory = &rootHistory{records: make([]RootRecord, len(m.rootHistory.records))}
+	}
+	return t
+}
+
+func copyLeaf(n *Node, t *MerkleTree) *Node {
+	return &Node{
+		Hash: n.Hash,
+		C:    n.C,
+		leaf: true,
+		Tree: t,
+	}
+}
+
+// mergeLeafs merges two sorted leaf lists into fresh leaf nodes of t, handling
+// hashes present in both according to policy.
+func mergeLeafs(a, b []*Node, t *MerkleTree, policy DuplicatePolicy) ([]*Node, error) {
+	merged := make([]*Node, 0, len(a)+len(b))
+	appendLeaf := func(n *Node) {
+		merged = append(merged, copyLeaf(n, t))
+	}
+
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		switch cmp := bytes.Compare(a[i].Hash, b[j].Hash); {
+		case cmp < 0:
+			appendLeaf(a[i])
+			i++
+		case cmp > 0:
+			appendLeaf(b[j])
+			j++
+		default:
+			switch policy {
+			case ErrorOnDuplicate:
+				return nil, ErrDuplicateLeaf
+			case DedupeDuplicates:
+				j++
+			default:
+				appendLeaf(b[j])
+				j++
+			}
+		}
+	}
+	for ; i < len(a); i++ {
+		appendLeaf(a[i])
+	}
+	for ; j < len(b); j++ {
+		appendLeaf(b[j])
+	}
+	return merged, nil
+}
@@ -0,0 +1,84 @@
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"testing"
+)
+
+func Test_MergeRoots(t *testing.T) {
+	leaves := testLeaves(11)
+	for split := 1; split < len(leaves); split++ {
+		a, _ := NewTree(leaves[:split])
+		b, _ := NewTree(leaves[split:])
+		union, _ := NewTree(leaves)
+
+		root, err := MergeRoots(a, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(root, union.MerkleRoot()) {
+			t.Fatalf("split %d: merged root differs from union", split)
+		}
+	}
+
+	a, _ := NewTree(leaves[:5])
+	b, _ := NewTree(leaves[4:])
+	if _, err := MergeRoots(a, b); err == nil {
+		t.Fatal("expected error for duplicate leaves")
+	}
+
+	c, _ := NewTreeWithHashStrategy(leaves[5:], sha256.New)
+	if _, err := MergeRoots(a, c); err == nil {
+		t.Fatal("expected error for hash strategy mismatch")
+	}
+}
+
+func Test_Merge(t *testing.T) {
+	leaves := testLeaves(11)
+	for _, opts := range incrementalOptions[:4] {
+		for split := 0; split <= len(leaves); split++ {
+			a, _ := NewTreeWithOptions(leaves[:split], append(opts, WithEmptyRoot(ZeroRoot(32)))...)
+			b, _ := NewTreeWithOptions(leaves[split:], append(opts, WithEmptyRoot(ZeroRoot(32)))...)
+			merged, err := Merge(a, b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkSameTree(t, merged, leaves, opts)
+			for _, leaf := range merged.Leafs {
+				if leaf.Tree != merged {
+					t.Fatal("expected the merged tree to own its leaves")
+				}
+			}
+			// the merged tree grows like any other
+			if err := merged.AddLeaf(TestLeaf{Bz: []byte("more")}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	a, _ := NewTree(leaves[:5])
+	b, _ := NewTree(leaves[4:])
+	if _, err := Merge(a, b); err != nil {
+		t.Fatal(err)
+	}
+	a, _ = NewTreeWithOptions(leaves[:5], WithDuplicatePolicy(ErrorOnDuplicate))
+	b, _ = NewTreeWithOptions(leaves[4:], WithDuplicatePolicy(ErrorOnDuplicate))
+	if _, err := Merge(a, b); err != ErrDuplicateLeaf {
+		t.Fatalf("expected ErrDuplicateLeaf, got %v", err)
+	}
+	for _, opts := range [][]Option{{WithDuplicatePolicy(DedupeDuplicates)}, {WithDuplicatePolicy(DedupeDuplicates), WithInsertionOrder()}} {
+		a, _ = NewTreeWithOptions(leaves[:5], opts...)
+		b, _ = NewTreeWithOptions(leaves[4:], opts...)
+		merged, err := Merge(a, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkSameTree(t, merged, leaves, opts)
+	}
+
+	b, _ = NewTreeWithOptions(leaves[5:], WithInsertionOrder())
+	if _, err := Merge(a, b); err == nil {
+		t.Fatal("expected error for different layouts")
+	}
+}
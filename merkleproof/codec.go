@@ -0,0 +1,199 @@
+package merkleproof
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+const proofBinaryVersion byte = 1
+
+// MarshalBinary encodes the proof as a version byte, the leaf hash, the root, the
+// siblings and the directions, each prefixed with its uvarint length or count.
+func (p *Proof) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte(proofBinaryVersion)
+	writeBytes(&buf, p.LeafHash)
+	writeBytes(&buf, p.Root)
+	writeUvarint(&buf, uint64(len(p.Siblings)))
+	for _, sibling := range p.Siblings {
+		writeBytes(&buf, sibling)
+	}
+	writeUvarint(&buf, uint64(len(p.Path)))
+	for _, direction := range p.Path {
+		if direction != 0 && direction != 1 {
+			return nil, fmt.Errorf("error: invalid direction %d", direction)
+		}
+		buf.WriteByte(byte(direction))
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a proof encoded by MarshalBinary.
+func (p *Proof) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	version, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	if version != proofBinaryVersion {
+		return fmt.Errorf("error: unsupported proof version %d", version)
+	}
+
+	var decoded Proof
+	if decoded.LeafHash, err = readBytes(r); err != nil {
+		return err
+	}
+	if decoded.Root, err = readBytes(r); err != nil {
+		return err
+	}
+
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return err
+	}
+	if count > uint64(r.Len()) {
+		return errors.New("error: sibling count exceeds input size")
+	}
+	for i := uint64(0); i < count; i++ {
+		sibling, err := readBytes(r)
+		if err != nil {
+			return err
+		}
+		decoded.Siblings = append(decoded.Siblings, sibling)
+	}
+
+	count, err = binary.ReadUvarint(r)
+	if err != nil {
+		return err
+	}
+	if count > uint64(r.Len()) {
+		return errors.New("error: direction count exceeds input size")
+	}
+	for i := uint64(0); i < count; i++ {
+		direction, err := r.ReadByte()
+		if err != nil {
+			return err
+		}
+		if direction > 1 {
+			return fmt.Errorf("error: invalid direction %d", direction)
+		}
+		decoded.Path = append(decoded.Path, int64(direction))
+	}
+	if r.Len() != 0 {
+		return errors.New("error: trailing data after proof")
+	}
+
+	*p = decoded
+	return nil
+}
+
+// HexProof is the canonical JSON form of a proof for web3 clients. All hashes are
+// 0x-prefixed hex strings, so Siblings can be handed to Solidity's
+// MerkleProof.verify as its bytes32[] proof unchanged. Positions are the
+// directions of Proof.Path.
+type HexProof struct {
+	Root      string   `json:"root"`
+	Leaf      string   `json:"leaf"`
+	Siblings  []string `json:"siblings"`
+	Positions []int64  `json:"positions,omitempty"`
+}
+
+// ToHex converts the proof to its hex form.
+func (p *Proof) ToHex() *HexProof {
+	hp := &HexProof{
+		Root:      hexutil.Encode(p.Root),
+		Leaf:      hexutil.Encode(p.LeafHash),
+		Siblings:  make([]string, len(p.Siblings)),
+		Positions: p.Path,
+	}
+	for i, sibling := range p.Siblings {
+		hp.Siblings[i] = hexutil.Encode(sibling)
+	}
+	return hp
+}
+
+// ToProof parses the hex form back into a proof. Every hash must be 0x-prefixed
+// and every position must be 0 or 1.
+func (hp *HexProof) ToProof() (*Proof, error) {
+	root, err := hexutil.Decode(hp.Root)
+	if err != nil {
+		return nil, fmt.Errorf("error: invalid root: %w", err)
+	}
+	leaf, err := hexutil.Decode(hp.Leaf)
+	if err != nil {
+		return nil, fmt.Errorf("error: invalid leaf: %w", err)
+	}
+
+	p := &Proof{
+		LeafHash: leaf,
+		Root:     root,
+		Siblings: make([][]byte, len(hp.Siblings)),
+		Path:     hp.Positions,
+	}
+	for i, sibling := range hp.Siblings {
+		if p.Siblings[i], err = hexutil.Decode(sibling); err != nil {
+			return nil, fmt.Errorf("error: invalid sibling %d: %w", i, err)
+		}
+	}
+	for _, direction := range hp.Positions {
+		if direction != 0 && direction != 1 {
+			return nil, fmt.Errorf("error: invalid direction %d", direction)
+		}
+	}
+	return p, nil
+}
+
+// ParseProofJSON decodes a proof from its canonical JSON form.
+func ParseProofJSON(data []byte) (*Proof, error) {
+	var hp HexProof
+	if err := json.Unmarshal(data, &hp); err != nil {
+		return nil, err
+	}
+	return hp.ToProof()
+}
+
+// MarshalJSON encodes the proof in its canonical JSON form, see HexProof.
+func (p *Proof) MarshalJSON() ([]byte, error) {
+	return json.Marshal(p.ToHex())
+}
+
+// UnmarshalJSON decodes a proof from its canonical JSON form.
+func (p *Proof) UnmarshalJSON(data []byte) error {
+	decoded, err := ParseProofJSON(data)
+	if err != nil {
+		return err
+	}
+	*p = *decoded
+	return nil
+}
+
+func writeUvarint(buf *bytes.Buffer, x uint64) {
+	var b [binary.MaxVarintLen64]byte
+	buf.Write(b[:binary.PutUvarint(b[:], x)])
+}
+
+func writeBytes(buf *bytes.Buffer, bz []byte) {
+	writeUvarint(buf, uint64(len(bz)))
+	buf.Write(bz)
+}
+
+func readBytes(r *bytes.Reader) ([]byte, error) {
+	l, err := binary.ReadUvarint(r)
+	if err != nil {
+		return nil, err
+	}
+	if l > uint64(r.Len()) {
+		return nil, errors.New("error: length exceeds input size")
+	}
+	bz := make([]byte, l)
+	if _, err := io.ReadFull(r, bz); err != nil {
+		return nil, err
+	}
+	return bz, nil
+}
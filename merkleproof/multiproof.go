@@ -0,0 +1,83 @@
+package merkleproof
+
+import (
+	"bytes"
+	"errors"
+	"hash"
+)
+
+// MultiProof proves several leaves at once, in the layout taken by OpenZeppelin's
+// MerkleProof.multiProofVerify(proof, proofFlags, root, leaves). Leaves holds the
+// leaf hashes in the order the verifier consumes them, which is not necessarily
+// the order they were requested in.
+type MultiProof struct {
+	Leaves     [][]byte
+	Proof      [][]byte
+	ProofFlags []bool
+}
+
+// VerifyMultiProof reports whether mp proves all of its leaves under root. It
+// follows OpenZeppelin's processMultiProof step by step.
+func VerifyMultiProof(root []byte, mp *MultiProof, hashStrategy func() hash.Hash) (bool, error) {
+	if mp == nil {
+		return false, errors.New("error: nil multiproof")
+	}
+
+	leavesLen, proofLen, totalHashes := len(mp.Leaves), len(mp.Proof), len(mp.ProofFlags)
+	if leavesLen+proofLen-1 != totalHashes {
+		return false, errors.New("error: invalid multiproof")
+	}
+
+	hashes := make([][]byte, totalHashes)
+	leafPos, hashPos, proofPos := 0, 0, 0
+	next := func(i int) ([]byte, error) {
+		if leafPos < leavesLen {
+			leafPos++
+			return mp.Leaves[leafPos-1], nil
+		}
+		if hashPos >= i {
+			return nil, errors.New("error: invalid multiproof")
+		}
+		hashPos++
+		return hashes[hashPos-1], nil
+	}
+
+	for i := 0; i < totalHashes; i++ {
+		a, err := next(i)
+		if err != nil {
+			return false, err
+		}
+		var b []byte
+		if mp.ProofFlags[i] {
+			if b, err = next(i); err != nil {
+				return false, err
+			}
+		} else {
+			if proofPos >= proofLen {
+				return false, errors.New("error: invalid multiproof")
+			}
+			b = mp.Proof[proofPos]
+			proofPos++
+		}
+
+		h := hashStrategy()
+		if _, err := h.Write(combineTwoHash(a, b)); err != nil {
+			return false, err
+		}
+		hashes[i] = h.Sum(nil)
+	}
+
+	var computed []byte
+	switch {
+	case totalHashes > 0:
+		if proofPos != proofLen {
+			return false, errors.New("error: invalid multiproof")
+		}
+		computed = hashes[totalHashes-1]
+	case leavesLen > 0:
+		computed = mp.Leaves[0]
+	default:
+		computed = mp.Proof[0]
+	}
+	return bytes.Equal(computed, root), nil
+}
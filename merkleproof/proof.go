@@ -0,0 +1,207 @@
+// Package merkleproof verifies proofs produced by github.com/smartbch/merkletree.
+// It holds only the proof types and the verification logic, so light clients can
+// check proofs against a trusted root without depending on tree construction.
+package merkleproof
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Proof is an inclusion proof for a single leaf. Siblings are ordered from the leaf
+// up to the root and Path holds the matching directions (1 when the sibling is the
+// right child, 0 when it is the left one). Pairs are combined in sorted order
+// unless the tree was built positionally, so Path is usually informational and
+// not needed to verify. LeafHash and Root may be left empty by callers who pass
+// them to the verification functions separately.
+type Proof struct {
+	LeafHash []byte
+	Root     []byte
+	Siblings [][]byte
+	Path     []int64
+}
+
+// Verify checks the proof against its own Root using keccak256, the default hash of
+// the tree.
+func (p *Proof) Verify() (bool, error) {
+	return p.VerifyWithHashStrategy(sha3.NewLegacyKeccak256)
+}
+
+// VerifyWithHashStrategy checks the proof against its own Root using hashStrategy.
+func (p *Proof) VerifyWithHashStrategy(hashStrategy func() hash.Hash) (bool, error) {
+	if len(p.LeafHash) == 0 || len(p.Root) == 0 {
+		return false, errors.New("error: proof has no leaf hash or root")
+	}
+	return VerifyProof(p.Root, p.LeafHash, p.Siblings, hashStrategy)
+}
+
+// VerifyProof checks that leafHash is included under root given the sibling hashes
+// of proof, ordered from the leaf up, as returned by the tree. It needs
+// neither the tree nor any other leaf, so light clients can verify with a proof
+// and a trusted root alone.
+func VerifyProof(root []byte, leafHash []byte, proof [][]byte, hashStrategy func() hash.Hash) (bool, error) {
+	computed, err := ComputeRoot(leafHash, proof, hashStrategy)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(computed, root), nil
+}
+
+// VerifyAnonymousProof verifies proof using sorted-pair combination only. Any
+// positions carried by proof are ignored.
+func VerifyAnonymousProof(leafHash []byte, proof *Proof, root []byte, h func() hash.Hash) (bool, error) {
+	if proof == nil {
+		return false, errors.New("error: nil proof")
+	}
+
+	return VerifyProof(root, leafHash, proof.Siblings, h)
+}
+
+// VerifyProofWithDepth verifies proof like VerifyAnonymousProof and also reports
+// the number of folds performed, i.e. how deep below the root the leaf sits.
+func VerifyProofWithDepth(leafHash []byte, proof *Proof, root []byte, h func() hash.Hash) (ok bool, depth int, err error) {
+	ok, err = VerifyAnonymousProof(leafHash, proof, root, h)
+	if err != nil {
+		return false, 0, err
+	}
+	return ok, len(proof.Siblings), nil
+}
+
+// VerifyProofMultiHash checks proof against root with each of strategies in turn and
+// reports the index of the first one that reproduces the root. It returns -1 and
+// false when none of them does.
+func VerifyProofMultiHash(leafHash []byte, proof *Proof, root []byte, strategies []func() hash.Hash) (matchedIdx int, ok bool, err error) {
+	if proof == nil {
+		return -1, false, errors.New("error: nil proof")
+	}
+
+	for i, hashStrategy := range strategies {
+		computed, err := ComputeRoot(leafHash, proof.Siblings, hashStrategy)
+		if err != nil {
+			return -1, false, err
+		}
+		if bytes.Equal(computed, root) {
+			return i, true, nil
+		}
+	}
+	return -1, false, nil
+}
+
+// VerifyProofWithLeafTransform derives the leaf hash by applying transform to
+// leafPreimage and verifies proof for it against root. transform must reproduce
+// whatever leaf hashing the tree used, e.g. salting, prefixing or double hashing.
+func VerifyProofWithLeafTransform(leafPreimage []byte, transform func([]byte) ([]byte, error), proof *Proof, root []byte, h func() hash.Hash) (bool, error) {
+	if proof == nil {
+		return false, errors.New("error: nil proof")
+	}
+
+	leafHash, err := transform(leafPreimage)
+	if err != nil {
+		return false, err
+	}
+	return VerifyProof(root, leafHash, proof.Siblings, h)
+}
+
+// VerifyProofPacked verifies a positional proof whose directions are packed into
+// pathBits: bit i set means siblings[i] is the right child and is hashed after the
+// running hash, bit i clear means it is the left child and is hashed before it.
+// Pairs are not sorted, matching verifiers that take a single direction mask.
+func VerifyProofPacked(leafHash []byte, siblings [][]byte, pathBits uint64, root []byte, h func() hash.Hash) (bool, error) {
+	if len(siblings) > 64 {
+		return false, fmt.Errorf("error: %d siblings do not fit in a 64-bit path", len(siblings))
+	}
+	if len(siblings) < 64 && pathBits>>uint(len(siblings)) != 0 {
+		return false, errors.New("error: path bits set beyond the proof length")
+	}
+
+	current := leafHash
+	for i, sibling := range siblings {
+		left, right := sibling, current
+		if pathBits&(1<<uint(i)) != 0 {
+			left, right = current, sibling
+		}
+
+		hasher := h()
+		if _, err := hasher.Write(append(append([]byte(nil), left...), right...)); err != nil {
+			return false, err
+		}
+		current = hasher.Sum(nil)
+	}
+	return bytes.Equal(current, root), nil
+}
+
+// VerifyPositionalProof checks a proof of a tree built with unsorted pairs. Each
+// sibling is hashed on the side given by the matching entry of path: 1 when the
+// sibling is the right child and 0 when it is the left one.
+func VerifyPositionalProof(root []byte, leafHash []byte, siblings [][]byte, path []int64, hashStrategy func() hash.Hash) (bool, error) {
+	if len(path) != len(siblings) {
+		return false, fmt.Errorf("error: %d siblings but %d directions", len(siblings), len(path))
+	}
+	bits, err := PackPath(path)
+	if err != nil {
+		return false, err
+	}
+	return VerifyProofPacked(leafHash, siblings, bits, root, hashStrategy)
+}
+
+// VerifyPositional checks the proof against its own Root using its Path, for trees
+// built with unsorted pairs.
+func (p *Proof) VerifyPositional(hashStrategy func() hash.Hash) (bool, error) {
+	if len(p.LeafHash) == 0 || len(p.Root) == 0 {
+		return false, errors.New("error: proof has no leaf hash or root")
+	}
+	return VerifyPositionalProof(p.Root, p.LeafHash, p.Siblings, p.Path, hashStrategy)
+}
+
+// PackPath packs a positional path as found in Proof.Path into the bit mask
+// taken by VerifyProofPacked.
+func PackPath(path []int64) (uint64, error) {
+	if len(path) > 64 {
+		return 0, fmt.Errorf("error: path of length %d does not fit in 64 bits", len(path))
+	}
+
+	var bits uint64
+	for i, p := range path {
+		switch p {
+		case 0:
+		case 1:
+			bits |= 1 << uint(i)
+		default:
+			return 0, fmt.Errorf("error: invalid path position %d", p)
+		}
+	}
+	return bits, nil
+}
+
+// ComputeRoot hashes leafHash together with each sibling in turn, combining every
+// pair in sorted order, and returns the resulting root.
+func ComputeRoot(leafHash []byte, siblings [][]byte, hashStrategy func() hash.Hash) ([]byte, error) {
+	current := leafHash
+	for _, sibling := range siblings {
+		h := hashStrategy()
+		if _, err := h.Write(combineTwoHash(current, sibling)); err != nil {
+			return nil, err
+		}
+		current = h.Sum(nil)
+	}
+	return current, nil
+}
+
+// combineTwoHash concatenates a and b in sorted order, the way the tree combines
+// the two children of a node.
+func combineTwoHash(a, b []byte) []byte {
+	bf := bytes.NewBuffer(nil)
+	if bytes.Compare(a, b) < 0 {
+		bf.Write(a)
+		bf.Write(b)
+		return bf.Bytes()
+	}
+
+	bf.Write(b)
+	bf.Write(a)
+	return bf.Bytes()
+}
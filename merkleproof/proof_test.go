@@ -0,0 +1,56 @@
+package merkleproof
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+func keccak(parts ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
+
+func Test_Proof(t *testing.T) {
+	// a sorted-pair tree over a, b, c, d
+	a, b, c, d := keccak([]byte("a")), keccak([]byte("b")), keccak([]byte("c")), keccak([]byte("d"))
+	ab, cd := keccak(combineTwoHash(a, b)), keccak(combineTwoHash(c, d))
+	root := keccak(combineTwoHash(ab, cd))
+
+	proof := &Proof{LeafHash: c, Root: root, Siblings: [][]byte{d, ab}}
+	if ok, err := proof.Verify(); err != nil || !ok {
+		t.Fatal("proof does not verify")
+	}
+	if ok, _ := VerifyProof(root, a, proof.Siblings, sha3.NewLegacyKeccak256); ok {
+		t.Fatal("proof verifies for another leaf")
+	}
+
+	bin, err := proof.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromBinary Proof
+	if err := fromBinary.UnmarshalBinary(bin); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&fromBinary, proof) {
+		t.Fatal("binary round trip mismatch")
+	}
+
+	js, err := json.Marshal(proof)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromJSON, err := ParseProofJSON(js)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := fromJSON.Verify(); err != nil || !ok {
+		t.Fatal("decoded proof does not verify")
+	}
+}
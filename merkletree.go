@@ -2,12 +2,42 @@ package merkletree
 
 import (
 	"bytes"
+	"context"
+	"crypto/hmac"
 	"errors"
+	"fmt"
 	"golang.org/x/crypto/sha3"
 	"hash"
 	"sort"
 )
 
+var (
+	// ErrEmptyTree is returned when a tree would be built from, or holds, no leaves.
+	ErrEmptyTree = errors.New("error: cannot construct tree with no content")
+	// ErrContentNotFound is returned when the requested content is not a leaf of the tree.
+	ErrContentNotFound = errors.New("error: content not found in tree")
+	// ErrDuplicateLeaf is returned when a tree built with ErrorOnDuplicate would hold
+	// two leaves with the same hash.
+	ErrDuplicateLeaf = errors.New("error: duplicate leaf hash")
+)
+
+// LeafHashSizeError is returned by trees built with WithLeafHashSizeCheck for a
+// leaf whose hash does not have the size of the tree's hash function.
+type LeafHashSizeError struct {
+	// Index is the position of the offending content in the input
+	Index int
+	Size  int
+	Want  int
+}
+
+func (e *LeafHashSizeError) Error() string {
+	return fmt.Sprintf("error: leaf hash at index %d is %d bytes, want %d", e.Index, e.Size, e.Want)
+}
+
+// ctxCheckInterval is the number of nodes hashed between two checks of the
+// context of a build.
+const ctxCheckInterval = 1024
+
 // Content represents the data that is stored and verified by the tree. A type that
 // implements this interface can be used as an item in the tree.
 type Content interface {
@@ -22,6 +52,63 @@ type MerkleTree struct {
 	merkleRoot   []byte
 	Leafs        []*Node
 	hashStrategy func() hash.Hash
+	// unsortedPairs combines each pair as left || right instead of in sorted order
+	unsortedPairs bool
+	// unsortedLeaves keeps the leaves in the order they were given
+	unsortedLeaves bool
+	// duplicateOdd pairs the last node of an odd level with itself instead of
+	// promoting it
+	duplicateOdd bool
+	// hashLeaves hashes the content hash once more to get the leaf hash
+	hashLeaves bool
+	// leafPrefix is written before the content hash when hashing it into the leaf
+	// hash, which implies hashLeaves
+	leafPrefix []byte
+	// nodePrefix is written before the two child hashes of every internal node
+	nodePrefix []byte
+	// proofCache memoizes merkle paths, see WithProofCache
+	proofCache *proofCache
+	// emptyRoot is the root of the tree without leaves, see WithEmptyRoot
+	emptyRoot []byte
+	// duplicatePolicy decides what happens to leaves with the same hash
+	duplicatePolicy DuplicatePolicy
+	// progress receives the progress of builds, see WithProgress
+	progress func(done, total int)
+	// building counts the progress of the build under way, if reported
+	building *buildProgress
+	// pairHasher hashes internal nodes in place of hashStrategy, see WithPairHasher
+	pairHasher PairHasher
+	// hmacKey keys every leaf and node hash, see WithHMACKey
+	hmacKey []byte
+	// levelTag returns the tag mixed into the nodes of a level, see WithLevelTags
+	levelTag func(level int) []byte
+	// checkLeafSize rejects leaf hashes whose size is not that of hashStrategy
+	checkLeafSize bool
+	// slim leaves keep only their hash, see WithoutContent
+	slim bool
+	// version counts the changes made to the tree, see Version
+	version uint64
+	// epoch is the epoch of the nodes that may be changed in place; nodes of
+	// older epochs may be shared with snapshots, see Snapshot
+	epoch uint64
+	// retainVersions is the number of snapshots kept in versions
+	retainVersions int
+	versions       []*Snapshot
+	// rootHistory remembers the roots of the last versions, see WithRootHistory
+	rootHistory *rootHistory
+	// previewing is set while changes are applied only to be undone, with the
+	// parent links they overwrite kept in undo, see Tx.PreviewRoot
+	previewing bool
+	undo       []parentLink
+	// rootListeners are called on root changes, see OnRootChange
+	rootListeners []*rootListener
+	// notifiedRoot is the root the listeners last heard of
+	notifiedRoot []byte
+	// frontier caches the roots of the perfect subtrees along the right edge of
+	// the tree with root frontierRoot at frontierVersion, see AppendFast
+	frontier        []*Node
+	frontierRoot    *Node
+	frontierVersion uint64
 }
 
 type Node struct {
@@ -33,11 +120,13 @@ type Node struct {
 	single bool
 	Hash   []byte
 	C      Content
+	// epoch is the epoch of the tree the node was created in
+	epoch uint64
 }
 
 func (n *Node) verifyNode() ([]byte, error) {
 	if n.leaf {
-		return n.C.CalculateHash()
+		return n.contentHash()
 	}
 
 	rightBytes, err := n.Right.verifyNode()
@@ -55,17 +144,12 @@ func (n *Node) verifyNode() ([]byte, error) {
 		return n.Hash, nil
 	}
 
-	h := n.Tree.hashStrategy()
-	if _, err := h.Write(combineTwoHash(leftBytes, rightBytes)); err != nil {
-		return nil, err
-	}
-
-	return h.Sum(nil), nil
+	return n.Tree.hashPair(n.Tree.levelOf(n), leftBytes, rightBytes)
 }
 
 func (n *Node) calculateNodeHash() ([]byte, error) {
 	if n.leaf {
-		return n.C.CalculateHash()
+		return n.contentHash()
 	}
 
 	// if n is single or n's child is single
@@ -73,12 +157,16 @@ func (n *Node) calculateNodeHash() ([]byte, error) {
 		return n.Hash, nil
 	}
 
-	h := n.Tree.hashStrategy()
-	if _, err := h.Write(combineTwoHash(n.Left.Hash, n.Right.Hash)); err != nil {
-		return nil, err
-	}
+	return n.Tree.hashPair(n.Tree.levelOf(n), n.Left.Hash, n.Right.Hash)
+}
 
-	return h.Sum(nil), nil
+// contentHash recomputes the hash of the leaf n from its content. Leaves restored
+// without content can only vouch for their stored hash.
+func (n *Node) contentHash() ([]byte, error) {
+	if n.C == nil {
+		return n.Hash, nil
+	}
+	return n.Tree.leafHash(n.C)
 }
 
 func NewTree(cs []Content) (*MerkleTree, error) {
@@ -87,76 +175,254 @@ func NewTree(cs []Content) (*MerkleTree, error) {
 }
 
 func NewTreeWithHashStrategy(cs []Content, hashStrategy func() hash.Hash) (*MerkleTree, error) {
-	t := &MerkleTree{
-		hashStrategy: hashStrategy,
+	return NewTreeWithOptions(cs, WithHashStrategy(hashStrategy))
+}
+
+// NewTreeFromLeafHashes builds a tree over leaf hashes computed elsewhere, without
+// wrapping them in Content. Proofs are then obtained with GetProofByLeafHash or
+// GetProofByIndex. opts apply as in NewTreeWithOptions, so WithHashLeaves or a leaf
+// prefix still hash the given values once more.
+func NewTreeFromLeafHashes(hashes [][]byte, opts ...Option) (*MerkleTree, error) {
+	cs := make([]Content, len(hashes))
+	for i, h := range hashes {
+		if len(h) == 0 {
+			return nil, fmt.Errorf("error: empty leaf hash at index %d", i)
+		}
+		cs[i] = HashContent(h)
 	}
-	root, leafs, err := buildWithContent(cs, t)
+	return NewTreeWithOptions(cs, opts...)
+}
+
+// GetMerklePath returns the sibling hashes on the path from content up to the root
+// and their positions. It returns ErrContentNotFound if content is not in the tree.
+// GetProof returns the same path as a Proof and is preferred in new code.
+func (m *MerkleTree) GetMerklePath(content Content) ([][]byte, []int64, error) {
+	_, merklePath, index, err := m.leafPath(content)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	t.Root = root
-	t.Leafs = leafs
-	t.merkleRoot = root.Hash
-	return t, nil
+	return merklePath, index, nil
 }
 
-func (m *MerkleTree) GetMerklePath(content Content) ([][]byte, []int64, error) {
+// GetMerklePathByIndex returns the merkle path of the leaf at position i of Leafs,
+// i.e. after the leaves have been sorted by hash unless the tree keeps insertion
+// order.
+func (m *MerkleTree) GetMerklePathByIndex(i int) ([][]byte, []int64, error) {
+	if i < 0 || i >= len(m.Leafs) {
+		return nil, nil, fmt.Errorf("error: leaf index %d out of range [0, %d)", i, len(m.Leafs))
+	}
+
+	merklePath, index := m.Leafs[i].merklePath()
+	return merklePath, index, nil
+}
+
+// GetMerklePathByHash returns the merkle path of the leaf whose hash is leafHash,
+// for callers that kept only leaf hashes and not the original content. It returns
+// ErrContentNotFound if no leaf has that hash.
+func (m *MerkleTree) GetMerklePathByHash(leafHash []byte) ([][]byte, []int64, error) {
+	if len(m.Leafs) == 0 {
+		return nil, nil, ErrEmptyTree
+	}
+	leaf := m.findLeafByHash(leafHash)
+	if leaf == nil {
+		return nil, nil, ErrContentNotFound
+	}
+
+	merklePath, index := leaf.merklePath()
+	return merklePath, index, nil
+}
+
+// GetIndexOf returns the position of content in Leafs. It locates the leaf by its
+// hash with a binary search over the sorted leaves instead of scanning them.
+func (m *MerkleTree) GetIndexOf(content Content) (int, error) {
+	if len(m.Leafs) == 0 {
+		return -1, ErrEmptyTree
+	}
+	hashBz, err := m.leafHash(content)
+	if err != nil {
+		return -1, err
+	}
+
+	for i := m.searchLeafs(hashBz); i < len(m.Leafs); i++ {
+		if !bytes.Equal(m.Leafs[i].Hash, hashBz) {
+			if m.unsortedLeaves {
+				continue
+			}
+			break
+		}
+		if m.Leafs[i].C == nil {
+			continue
+		}
+		ok, err := m.Leafs[i].C.Equals(content)
+		if err != nil {
+			return -1, err
+		}
+		if ok {
+			return i, nil
+		}
+	}
+	return -1, ErrContentNotFound
+}
+
+// searchLeafs returns the position of the first leaf whose hash is not less than
+// hashBz, which is len(m.Leafs) if there is none. Leaves kept in insertion order
+// cannot be searched, so it returns 0 for them and callers scan every leaf.
+func (m *MerkleTree) searchLeafs(hashBz []byte) int {
+	if m.unsortedLeaves {
+		return 0
+	}
+	return sort.Search(len(m.Leafs), func(i int) bool {
+		return bytes.Compare(m.Leafs[i].Hash, hashBz) >= 0
+	})
+}
+
+// findLeaf returns the first leaf whose content equals content.
+func (m *MerkleTree) findLeaf(content Content) (*Node, error) {
+	if len(m.Leafs) == 0 {
+		return nil, ErrEmptyTree
+	}
 	for _, current := range m.Leafs {
+		if current.C == nil {
+			// restored from hashes only, nothing to compare against
+			continue
+		}
+
 		ok, err := current.C.Equals(content)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 
 		if ok {
-			currentParent := current.Parent
-			var merklePath [][]byte
-			var index []int64
-			for currentParent != nil {
-				if !current.single {
-					if bytes.Equal(currentParent.Left.Hash, current.Hash) {
-						merklePath = append(merklePath, currentParent.Right.Hash)
-						index = append(index, 1) // right leaf
-					} else {
-						merklePath = append(merklePath, currentParent.Left.Hash)
-						index = append(index, 0) // left leaf
-					}
-				}
-
-				current = currentParent
-				currentParent = currentParent.Parent
+			return current, nil
+		}
+	}
+	return nil, ErrContentNotFound
+}
+
+// merklePath collects the sibling hashes from the leaf n up to the root.
+func (n *Node) merklePath() ([][]byte, []int64) {
+	current := n
+	currentParent := current.Parent
+	var merklePath [][]byte
+	var index []int64
+	for currentParent != nil {
+		if !current.single {
+			if bytes.Equal(currentParent.Left.Hash, current.Hash) {
+				merklePath = append(merklePath, currentParent.Right.Hash)
+				index = append(index, 1) // right leaf
+			} else {
+				merklePath = append(merklePath, currentParent.Left.Hash)
+				index = append(index, 0) // left leaf
 			}
-			return merklePath, index, nil
 		}
+
+		current = currentParent
+		currentParent = currentParent.Parent
+	}
+	return merklePath, index
+}
+
+func buildWithContent(ctx context.Context, cs []Content, t *MerkleTree) (*Node, []*Node, error) {
+	leafs, err := newLeafs(ctx, cs, t)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	root, err := buildIntermediate(ctx, leafs, t)
+	if err != nil {
+		return nil, nil, err
 	}
-	return nil, nil, nil
+
+	return root, leafs, nil
 }
 
-func buildWithContent(cs []Content, t *MerkleTree) (*Node, []*Node, error) {
+// newLeafs hashes cs into leaf nodes of t, sorted by hash unless t keeps insertion
+// order. It stops with the error of ctx once ctx is done.
+func newLeafs(ctx context.Context, cs []Content, t *MerkleTree) ([]*Node, error) {
 	if len(cs) == 0 {
-		return nil, nil, errors.New("error: cannot construct tree with no content")
+		return nil, ErrEmptyTree
 	}
-	var leafs []*Node
-	for _, c := range cs {
-		hashBz, err := c.CalculateHash()
-		if err != nil {
-			return nil, nil, err
+	acc := newLeafAccumulator(t, len(cs))
+	for i, c := range cs {
+		if i%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		if err := acc.add(c); err != nil {
+			return nil, err
 		}
+	}
+	return acc.finish(), nil
+}
+
+// leafAccumulator hashes contents into leaf nodes of a tree one at a time,
+// applying its duplicate policy.
+type leafAccumulator struct {
+	t     *MerkleTree
+	leafs []*Node
+	seen  map[string]bool
+	// count is the number of contents added, including dropped duplicates
+	count int
+	// hashSize is the size leaf hashes must have if the tree checks it
+	hashSize int
+}
 
-		leafs = append(leafs, &Node{
-			Hash: hashBz,
-			C:    c,
-			leaf: true,
-			Tree: t,
-		})
+func newLeafAccumulator(t *MerkleTree, sizeHint int) *leafAccumulator {
+	acc := &leafAccumulator{t: t, leafs: make([]*Node, 0, sizeHint)}
+	if t.checkLeafSize {
+		acc.hashSize = t.hashStrategy().Size()
+	}
+	if t.duplicatePolicy != AllowDuplicates {
+		acc.seen = make(map[string]bool, sizeHint)
 	}
+	return acc
+}
 
-	leafs = sortLeafs(leafs)
-	root, err := buildIntermediate(leafs, t)
+func (acc *leafAccumulator) add(c Content) error {
+	if c == nil {
+		return errors.New("error: cannot construct tree with nil content")
+	}
+	t := acc.t
+	hashBz, err := t.leafHash(c)
 	if err != nil {
-		return nil, nil, err
+		return err
 	}
+	if t.checkLeafSize && len(hashBz) != acc.hashSize {
+		return &LeafHashSizeError{Index: acc.count, Size: len(hashBz), Want: acc.hashSize}
+	}
+	acc.count++
+	t.building.step()
 
-	return root, leafs, nil
+	if acc.seen != nil {
+		if acc.seen[string(hashBz)] {
+			if t.duplicatePolicy == ErrorOnDuplicate {
+				return ErrDuplicateLeaf
+			}
+			return nil
+		}
+		acc.seen[string(hashBz)] = true
+	}
+
+	leaf := &Node{
+		Hash: hashBz,
+		leaf: true,
+		Tree: t,
+	}
+	if !t.slim {
+		leaf.C = c
+	}
+	acc.leafs = append(acc.leafs, leaf)
+	return nil
+}
+
+// finish returns the leaves, sorted by hash unless the tree keeps insertion order.
+func (acc *leafAccumulator) finish() []*Node {
+	acc.t.building.leavesKept(acc.count, len(acc.leafs))
+	if acc.t.unsortedLeaves {
+		return acc.leafs
+	}
+	return sortLeafs(acc.leafs)
 }
 
 func sortLeafs(leafs []*Node) []*Node {
@@ -166,23 +432,33 @@ func sortLeafs(leafs []*Node) []*Node {
 	return leafs
 }
 
-func buildIntermediate(nl []*Node, t *MerkleTree) (*Node, error) {
+// buildIntermediate builds the levels above the leaves nl up to the root. It stops
+// with the error of ctx once ctx is done.
+func buildIntermediate(ctx context.Context, nl []*Node, t *MerkleTree) (*Node, error) {
+	return buildLevel(ctx, nl, t, 1)
+}
+
+// buildLevel builds level, the parents of nl, and the levels above it.
+func buildLevel(ctx context.Context, nl []*Node, t *MerkleTree, level int) (*Node, error) {
 	var nodes []*Node
 	for i := 0; i < len(nl); i += 2 {
-		h := t.hashStrategy()
+		if i%ctxCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
 		var left, right = i, i + 1
 		if i+1 == len(nl) {
 			right = i
 		}
 
 		var nextHash []byte
-		if left != right {
-			// appear in pairs
-			// compare their child hashes when doing combine
-			if _, err := h.Write(combineTwoHash(nl[left].Hash, nl[right].Hash)); err != nil {
+		if left != right || (t.duplicateOdd && len(nl) > 1) {
+			// appear in pairs, or the odd node is paired with itself
+			var err error
+			if nextHash, err = t.hashPair(level, nl[left].Hash, nl[right].Hash); err != nil {
 				return nil, err
 			}
-			nextHash = h.Sum(nil)
 		} else {
 			// single node
 			// don't compute new hash
@@ -195,8 +471,10 @@ func buildIntermediate(nl []*Node, t *MerkleTree) (*Node, error) {
 			Right: nl[right],
 			Hash:  nextHash,
 			Tree:  t,
+			epoch: t.epoch,
 		}
 		nodes = append(nodes, n)
+		t.building.step()
 		nl[left].Parent = n
 		nl[right].Parent = n
 		if len(nl) == 2 || len(nl) == 1 {
@@ -204,80 +482,182 @@ func buildIntermediate(nl []*Node, t *MerkleTree) (*Node, error) {
 			return n, nil
 		}
 	}
-	return buildIntermediate(nodes, t)
+	return buildLevel(ctx, nodes, t, level+1)
 }
 
 func (m *MerkleTree) MerkleRoot() []byte {
 	return m.merkleRoot
 }
 
+// IsFirstLeaf reports whether leafHash is the hash of the first leaf. Leaves are
+// kept sorted by hash, so this is the smallest leaf hash unless the tree keeps
+// insertion order.
+func (m *MerkleTree) IsFirstLeaf(leafHash []byte) bool {
+	return len(m.Leafs) > 0 && bytes.Equal(m.Leafs[0].Hash, leafHash)
+}
+
+// IsLastLeaf reports whether leafHash is the hash of the last leaf, the largest
+// one unless the tree keeps insertion order.
+func (m *MerkleTree) IsLastLeaf(leafHash []byte) bool {
+	return len(m.Leafs) > 0 && bytes.Equal(m.Leafs[len(m.Leafs)-1].Hash, leafHash)
+}
+
 func (m *MerkleTree) RebuildTree() error {
+	return m.RebuildTreeCtx(context.Background())
+}
+
+// RebuildTreeCtx is RebuildTree stopping with the error of ctx once ctx is done,
+// in which case the tree is left unchanged.
+func (m *MerkleTree) RebuildTreeCtx(ctx context.Context) error {
 	var cs []Content
 	for _, c := range m.Leafs {
+		if c.C == nil {
+			return errors.New("error: cannot rebuild a tree whose leaves have no content")
+		}
 		cs = append(cs, c.C)
 	}
-	root, leafs, err := buildWithContent(cs, m)
-	if err != nil {
-		return err
-	}
-	m.Root = root
-	m.Leafs = leafs
-	m.merkleRoot = root.Hash
-	return nil
+	return m.build(ctx, cs)
 }
 
 func (m *MerkleTree) RebuildTreeWith(cs []Content) error {
-	root, leafs, err := buildWithContent(cs, m)
+	return m.build(context.Background(), cs)
+}
+
+// RebuildTreeWithCtx is RebuildTreeWith stopping with the error of ctx once ctx is
+// done, in which case the tree is left unchanged.
+func (m *MerkleTree) RebuildTreeWithCtx(ctx context.Context, cs []Content) error {
+	return m.build(ctx, cs)
+}
+
+// build replaces the nodes of m with a tree over cs. Without contents the tree is
+// left without nodes if it has an empty root, see WithEmptyRoot.
+func (m *MerkleTree) build(ctx context.Context, cs []Content) error {
+	if len(cs) == 0 && m.emptyRoot != nil {
+		m.Root = nil
+		m.Leafs = nil
+		m.merkleRoot = m.emptyRoot
+		m.commit()
+		return nil
+	}
+
+	m.building = newBuildProgress(m.progress, len(cs))
+	defer func() { m.building = nil }()
+	root, leafs, err := buildWithContent(ctx, cs, m)
 	if err != nil {
 		return err
 	}
 	m.Root = root
 	m.Leafs = leafs
 	m.merkleRoot = root.Hash
+	m.commit()
 	return nil
 }
 
+// VerifyContent recomputes the hashes on the path of content and compares them with
+// the stored ones. It returns ErrContentNotFound if content is not in the tree.
 func (m *MerkleTree) VerifyContent(content Content) (bool, error) {
-	for _, current := range m.Leafs {
-		ok, err := current.C.Equals(content)
+	current, err := m.findLeaf(content)
+	if err != nil {
+		return false, err
+	}
+	return m.verifyLeaf(current, nil)
+}
+
+// verifyLeaf recomputes the hashes on the path from the leaf current to the root
+// and compares them with the stored ones. When verified is not nil it caches the
+// outcome for every node on the path, so later calls stop at the first node whose
+// path to the root has already been checked.
+func (m *MerkleTree) verifyLeaf(current *Node, verified map[*Node]bool) (bool, error) {
+	var visited []*Node
+	result := func(ok bool) (bool, error) {
+		if verified != nil {
+			for _, n := range visited {
+				verified[n] = ok
+			}
+		}
+		return ok, nil
+	}
+
+	currentParent := current.Parent
+	for currentParent != nil {
+		if ok, known := verified[currentParent]; known {
+			return result(ok)
+		}
+		visited = append(visited, currentParent)
+
+		if !current.single {
+			rightHash, err := currentParent.Right.calculateNodeHash()
+			if err != nil {
+				return false, err
+			}
+
+			leftHash, err := currentParent.Left.calculateNodeHash()
+			if err != nil {
+				return false, err
+			}
+
+			calHash, err := m.hashPair(m.levelOf(currentParent), leftHash, rightHash)
+			if err != nil {
+				return false, err
+			}
+			if bytes.Compare(calHash, currentParent.Hash) != 0 {
+				return result(false)
+			}
+		}
+
+		current = currentParent
+		currentParent = currentParent.Parent
+	}
+	return result(true)
+}
+
+// VerifyContents verifies many contents at once like VerifyContent. Internal nodes
+// shared by several paths are only checked once. Contents that are not in the tree
+// are reported as false rather than failing the whole batch.
+func (m *MerkleTree) VerifyContents(cs []Content) ([]bool, error) {
+	if len(m.Leafs) == 0 {
+		return nil, ErrEmptyTree
+	}
+
+	byHash := make(map[string][]*Node, len(m.Leafs))
+	for _, leaf := range m.Leafs {
+		if leaf.C != nil {
+			byHash[string(leaf.Hash)] = append(byHash[string(leaf.Hash)], leaf)
+		}
+	}
+
+	verified := make(map[*Node]bool)
+	results := make([]bool, len(cs))
+	for i, c := range cs {
+		hashBz, err := m.leafHash(c)
 		if err != nil {
-			return false, err
+			return nil, err
 		}
 
-		if ok {
-			currentParent := current.Parent
-			for currentParent != nil {
-				if !current.single {
-					h := m.hashStrategy()
-					rightHash, err := currentParent.Right.calculateNodeHash()
-					if err != nil {
-						return false, err
-					}
-
-					leftHash, err := currentParent.Left.calculateNodeHash()
-					if err != nil {
-						return false, err
-					}
-
-					if _, err := h.Write(combineTwoHash(leftHash, rightHash)); err != nil {
-						return false, err
-					}
-					calHash := h.Sum(nil)
-					if bytes.Compare(calHash, currentParent.Hash) != 0 {
-						return false, nil
-					}
-				}
-
-				current = currentParent
-				currentParent = currentParent.Parent
+		for _, leaf := range byHash[string(hashBz)] {
+			ok, err := leaf.C.Equals(c)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			if results[i], err = m.verifyLeaf(leaf, verified); err != nil {
+				return nil, err
 			}
-			return true, nil
+			break
 		}
 	}
-	return false, nil
+	return results, nil
 }
 
 func (m *MerkleTree) VerifyTree() (bool, error) {
+	if m.Root == nil {
+		if m.emptyRoot == nil {
+			return false, ErrEmptyTree
+		}
+		return bytes.Equal(m.merkleRoot, m.emptyRoot), nil
+	}
 	calculatedMerkleRoot, err := m.Root.verifyNode()
 	if err != nil {
 		return false, err
@@ -291,6 +671,81 @@ func (m *MerkleTree) VerifyTree() (bool, error) {
 
 // ----------------------------------------------------------------------------
 
+// sortedPositional reports whether the tree keeps its leaves sorted by hash while
+// combining pairs positionally. Proofs that rely on the sort order of adjacent
+// leaves need both: the directions bind each leaf to its position.
+func (m *MerkleTree) sortedPositional() bool {
+	return !m.unsortedLeaves && m.unsortedPairs
+}
+
+// leafHash returns the hash of the leaf holding c. With hashLeaves set the content
+// hash is hashed once more, the way merkletreejs hashes its input leaves, after
+// the leaf prefix if there is one. A tree with an HMAC key always does so, under
+// the key.
+func (m *MerkleTree) leafHash(c Content) ([]byte, error) {
+	hashBz, err := c.CalculateHash()
+	if err != nil || (!m.hashLeaves && m.leafPrefix == nil && m.hmacKey == nil) {
+		return hashBz, err
+	}
+
+	h := m.newHash()
+	if _, err := h.Write(append(append([]byte(nil), m.leafPrefix...), hashBz...)); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// levelOf returns the level of the internal node n, which only matters to trees
+// with level tags. Every node sits one level above its left child.
+func (m *MerkleTree) levelOf(n *Node) int {
+	if m.levelTag == nil {
+		return 0
+	}
+	level := 0
+	for ; n != nil && !n.leaf; n = n.Left {
+		level++
+	}
+	return level
+}
+
+// newHash returns a hash of the tree's hash function, keyed if the tree has an
+// HMAC key.
+func (m *MerkleTree) newHash() hash.Hash {
+	if m.hmacKey != nil {
+		return hmac.New(m.hashStrategy, m.hmacKey)
+	}
+	return m.hashStrategy()
+}
+
+// hashPair returns the hash of the parent of two sibling nodes. Pairs are combined
+// in sorted order unless the tree was built with WithSortPairs(false), after the
+// node prefix and the tag of level if there are, or handed to the pair hasher of
+// the tree. Level 1 holds the parents of the leaves.
+func (m *MerkleTree) hashPair(level int, left, right []byte) ([]byte, error) {
+	if m.pairHasher != nil {
+		if !m.unsortedPairs && bytes.Compare(left, right) > 0 {
+			left, right = right, left
+		}
+		return m.pairHasher.HashPair(left, right)
+	}
+
+	data := append(make([]byte, 0, len(m.nodePrefix)+len(left)+len(right)), m.nodePrefix...)
+	if m.levelTag != nil {
+		data = append(data, m.levelTag(level)...)
+	}
+	if m.unsortedPairs {
+		data = append(append(data, left...), right...)
+	} else {
+		data = append(data, combineTwoHash(left, right)...)
+	}
+
+	h := m.newHash()
+	if _, err := h.Write(data); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
 func combineTwoHash(a, b []byte) []byte {
 	bf := bytes.NewBuffer(nil)
 	if bytes.Compare(a, b) < 0 {
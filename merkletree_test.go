@@ -2,6 +2,7 @@ package merkletree
 
 import (
 	"bytes"
+	"errors"
 	"fmt"
 	"testing"
 
@@ -28,6 +29,15 @@ func (l TestLeaf) Equals(other Content) (bool, error) {
 	return bytes.Equal(l.Bz, other.(TestLeaf).Bz), nil
 }
 
+// testLeaves returns n distinct leaves for building test trees
+func testLeaves(n int) []Content {
+	var leaves []Content
+	for i := 0; i < n; i++ {
+		leaves = append(leaves, TestLeaf{Bz: []byte(fmt.Sprintf("leaf-%d", i))})
+	}
+	return leaves
+}
+
 // TODO: add more unit tests for merkle tree
 func Test_Example(t *testing.T) {
 	var leaves []Content
@@ -58,3 +68,200 @@ func Test_Example(t *testing.T) {
 	verifyTree, _ := tree.VerifyTree()
 	fmt.Printf("verifyTree: %v\n", verifyTree)
 }
+
+func Test_IsFirstAndLastLeaf(t *testing.T) {
+	leaves := testLeaves(6)
+	tree, err := NewTree(leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var min, max []byte
+	for _, leaf := range leaves {
+		h, _ := leaf.CalculateHash()
+		if min == nil || bytes.Compare(h, min) < 0 {
+			min = h
+		}
+		if max == nil || bytes.Compare(h, max) > 0 {
+			max = h
+		}
+	}
+
+	if !tree.IsFirstLeaf(min) || tree.IsLastLeaf(min) {
+		t.Fatal("smallest hash must be the first leaf only")
+	}
+	if !tree.IsLastLeaf(max) || tree.IsFirstLeaf(max) {
+		t.Fatal("largest hash must be the last leaf only")
+	}
+	for _, leaf := range tree.Leafs[1 : len(tree.Leafs)-1] {
+		if tree.IsFirstLeaf(leaf.Hash) || tree.IsLastLeaf(leaf.Hash) {
+			t.Fatal("interior leaf reported as a boundary")
+		}
+	}
+}
+
+func Test_GetMerklePathByIndex(t *testing.T) {
+	leaves := testLeaves(11)
+	tree, err := NewTree(leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, leaf := range leaves {
+		i, err := tree.GetIndexOf(leaf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, _ := tree.Leafs[i].C.Equals(leaf); !ok {
+			t.Fatalf("GetIndexOf returned %d for the wrong leaf", i)
+		}
+
+		byIndex, dirsByIndex, err := tree.GetMerklePathByIndex(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		byContent, dirsByContent, _ := tree.GetMerklePath(leaf)
+		if fmt.Sprint(byIndex, dirsByIndex) != fmt.Sprint(byContent, dirsByContent) {
+			t.Fatalf("path of leaf %d differs between index and content lookups", i)
+		}
+	}
+
+	if _, _, err := tree.GetMerklePathByIndex(len(leaves)); err == nil {
+		t.Fatal("expected error for out of range index")
+	}
+	if i, err := tree.GetIndexOf(TestLeaf{Bz: []byte("missing")}); err == nil || i != -1 {
+		t.Fatal("expected error for missing content")
+	}
+}
+
+func Test_SentinelErrors(t *testing.T) {
+	if _, err := NewTree(nil); !errors.Is(err, ErrEmptyTree) {
+		t.Fatalf("expected ErrEmptyTree, got %v", err)
+	}
+
+	tree, _ := NewTree(testLeaves(3))
+	missing := TestLeaf{Bz: []byte("missing")}
+	if _, _, err := tree.GetMerklePath(missing); !errors.Is(err, ErrContentNotFound) {
+		t.Fatalf("expected ErrContentNotFound from GetMerklePath, got %v", err)
+	}
+	if ok, err := tree.VerifyContent(missing); ok || !errors.Is(err, ErrContentNotFound) {
+		t.Fatalf("expected ErrContentNotFound from VerifyContent, got %v", err)
+	}
+	if _, err := tree.GetProof(missing); !errors.Is(err, ErrContentNotFound) {
+		t.Fatalf("expected ErrContentNotFound from GetProof, got %v", err)
+	}
+
+	var empty MerkleTree
+	if _, _, err := empty.GetMerklePath(missing); !errors.Is(err, ErrEmptyTree) {
+		t.Fatalf("expected ErrEmptyTree from an empty tree, got %v", err)
+	}
+}
+
+func Test_VerifyContents(t *testing.T) {
+	leaves := testLeaves(9)
+	tree, err := NewTree(leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	query := append(testLeaves(9), TestLeaf{Bz: []byte("absent")})
+	results, err := tree.VerifyContents(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, ok := range results {
+		if ok != (i < len(leaves)) {
+			t.Fatalf("unexpected result %v for content %d", ok, i)
+		}
+	}
+
+	// corrupt one internal node and compare with verifying one by one
+	tree.Leafs[0].Parent.Hash = []byte("corrupted")
+	results, err = tree.VerifyContents(leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := 0
+	for i, leaf := range leaves {
+		ok, err := tree.VerifyContent(leaf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok != results[i] {
+			t.Fatalf("content %d: batch result %v differs from %v", i, results[i], ok)
+		}
+		if !ok {
+			failed++
+		}
+	}
+	if failed == 0 {
+		t.Fatal("corruption went unnoticed")
+	}
+}
+
+func Test_GetMerklePathByHash(t *testing.T) {
+	leaves := testLeaves(7)
+	tree, _ := NewTree(leaves)
+
+	for _, leaf := range leaves {
+		leafHash, _ := leaf.CalculateHash()
+		path, index, err := tree.GetMerklePathByHash(leafHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		wantPath, wantIndex, _ := tree.GetMerklePath(leaf)
+		if fmt.Sprint(path, index) != fmt.Sprint(wantPath, wantIndex) {
+			t.Fatal("path by hash differs from path by content")
+		}
+
+		proof, err := tree.GetProofByLeafHash(leafHash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := proof.Verify(); err != nil || !ok {
+			t.Fatal("proof by leaf hash does not verify")
+		}
+	}
+
+	if _, _, err := tree.GetMerklePathByHash([]byte("missing")); !errors.Is(err, ErrContentNotFound) {
+		t.Fatal("expected ErrContentNotFound")
+	}
+}
+
+func Test_NewTreeFromLeafHashes(t *testing.T) {
+	leaves := testLeaves(7)
+	hashes := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		hashes[i], _ = leaf.CalculateHash()
+	}
+
+	tree, err := NewTreeFromLeafHashes(hashes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	contentTree, _ := NewTree(leaves)
+	if !bytes.Equal(tree.MerkleRoot(), contentTree.MerkleRoot()) {
+		t.Fatal("roots of hashes and contents differ")
+	}
+	for _, h := range hashes {
+		proof, err := tree.GetProofByLeafHash(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := proof.Verify(); err != nil || !ok {
+			t.Fatal("proof does not verify")
+		}
+	}
+
+	ordered, err := NewTreeFromLeafHashes(hashes, WithInsertionOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(ordered.Leafs[3].Hash, hashes[3]) {
+		t.Fatal("insertion order was not kept")
+	}
+
+	if _, err := NewTreeFromLeafHashes([][]byte{hashes[0], nil}); err == nil {
+		t.Fatal("expected error for an empty hash")
+	}
+}
@@ -0,0 +1,118 @@
+package merkletree
+
+import (
+	"math/big"
+
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// mimc7Rounds is the number of rounds of MiMC-7 in circomlib and go-iden3-crypto.
+const mimc7Rounds = 91
+
+// mimc7Constants are the round constants of circomlib: 0, then the keccak256 chain
+// seeded with "mimc", each link reduced modulo the BN254 scalar field.
+var mimc7Constants = mimc7RoundConstants()
+
+func mimc7RoundConstants() []*big.Int {
+	cts := make([]*big.Int, mimc7Rounds)
+	cts[0] = new(big.Int)
+	c := gethcrypto.Keccak256([]byte("mimc"))
+	for i := 1; i < mimc7Rounds; i++ {
+		c = gethcrypto.Keccak256(c)
+		cts[i] = new(big.Int).Mod(new(big.Int).SetBytes(c), bn254ScalarField)
+	}
+	return cts
+}
+
+// MiMC7 is the MiMC-7 block cipher over the BN254 scalar field as implemented by
+// circomlib's MiMC7 template: 91 rounds of x -> (x + k + c)^7 followed by adding k.
+func MiMC7(x, k *big.Int) *big.Int {
+	p := bn254ScalarField
+	r := new(big.Int)
+	t := new(big.Int)
+	seven := big.NewInt(7)
+	for i, c := range mimc7Constants {
+		if i == 0 {
+			t.Add(x, k)
+		} else {
+			t.Add(r, k)
+			t.Add(t, c)
+		}
+		t.Mod(t, p)
+		r.Exp(t, seven, p)
+	}
+	return r.Add(r, k).Mod(r, p)
+}
+
+// MiMC7MultiHash hashes elements in the Miyaguchi-Preneel mode of circomlib's
+// MultiMiMC7 and go-iden3-crypto's mimc7.Hash: starting from key, each element x
+// updates the state r to r + x + MiMC7(x, r).
+func MiMC7MultiHash(elements []*big.Int, key *big.Int) *big.Int {
+	r := new(big.Int)
+	if key != nil {
+		r.Mod(key, bn254ScalarField)
+	}
+	for _, x := range elements {
+		e := MiMC7(x, r)
+		r.Add(r, x).Add(r, e).Mod(r, bn254ScalarField)
+	}
+	return r
+}
+
+// MiMC7PairHasher hashes a node as MiMC7MultiHash([left, right], 0), the merkle
+// node of circuits built on MultiMiMC7(2, 91). Circuits that take path bits
+// rather than sorting their inputs need trees built with WithSortPairs(false).
+var MiMC7PairHasher PairHasher = PairHasherFunc(func(left, right []byte) ([]byte, error) {
+	l, err := ParseBN254Element(left)
+	if err != nil {
+		return nil, err
+	}
+	r, err := ParseBN254Element(right)
+	if err != nil {
+		return nil, err
+	}
+	return BN254Element(MiMC7MultiHash([]*big.Int{l, r}, nil)), nil
+})
+
+// WithMiMC7 hashes internal nodes with MiMC7PairHasher.
+func WithMiMC7() Option {
+	return WithPairHasher(MiMC7PairHasher)
+}
+
+// BytesToBN254Elements splits data into 31-byte chunks, each read as a
+// little-endian integer and so always below the modulus, the mapping of
+// go-iden3-crypto's HashBytes.
+func BytesToBN254Elements(data []byte) []*big.Int {
+	const chunkSize = 31
+	elements := make([]*big.Int, 0, (len(data)+chunkSize-1)/chunkSize)
+	for len(data) > 0 {
+		n := chunkSize
+		if len(data) < n {
+			n = len(data)
+		}
+		elements = append(elements, new(big.Int).SetBytes(reverseBytes(data[:n])))
+		data = data[n:]
+	}
+	return elements
+}
+
+// MiMC7HashBytes returns MiMC7MultiHash of the chunks of data given by
+// BytesToBN254Elements, encoded as by BN254Element.
+func MiMC7HashBytes(data []byte) []byte {
+	return BN254Element(MiMC7MultiHash(BytesToBN254Elements(data), nil))
+}
+
+// MiMC7Content is a leaf holding bytes, hashed into a field element with
+// MiMC7HashBytes.
+type MiMC7Content []byte
+
+// CalculateHash hashes the bytes with MiMC7HashBytes
+func (c MiMC7Content) CalculateHash() ([]byte, error) {
+	return MiMC7HashBytes(c), nil
+}
+
+// Equals tests the bytes for equality
+func (c MiMC7Content) Equals(other Content) (bool, error) {
+	o, ok := other.(MiMC7Content)
+	return ok && string(c) == string(o), nil
+}
@@ -0,0 +1,63 @@
+package merkletree
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func Test_MiMC7(t *testing.T) {
+	// vectors of go-iden3-crypto
+	if got := MiMC7(big.NewInt(12), big.NewInt(45)).String(); got != "19746142529723647765530752502670948774458299263315590587358840390982005703908" {
+		t.Fatalf("unexpected MiMC7 %s", got)
+	}
+	h1 := MiMC7MultiHash([]*big.Int{big.NewInt(12)}, nil)
+	if got := hexutil.Encode(BN254Element(h1)); got != "0x237c92644dbddb86d8a259e0e923aaab65a93f1ec5758b8799988894ac0958fd" {
+		t.Fatalf("unexpected hash of one element %s", got)
+	}
+	h2 := MiMC7MultiHash([]*big.Int{big.NewInt(78), big.NewInt(41)}, nil)
+	if got := hexutil.Encode(BN254Element(h2)); got != "0x067f3202335ea256ae6e6aadcd2d5f7f4b06a00b2d1e0de903980d5ab552dc70" {
+		t.Fatalf("unexpected hash of two elements %s", got)
+	}
+
+	// MiMC7PairHasher is the two-element hash
+	parent, err := MiMC7PairHasher.HashPair(BN254Element(big.NewInt(78)), BN254Element(big.NewInt(41)))
+	if err != nil || hexutil.Encode(parent) != hexutil.Encode(BN254Element(h2)) {
+		t.Fatal("pair hasher differs from the two-element hash")
+	}
+}
+
+func Test_WithMiMC7(t *testing.T) {
+	var leaves []Content
+	for _, s := range []string{"alice", "bob", "carol", "a leaf longer than thirty-one bytes"} {
+		leaves = append(leaves, MiMC7Content(s))
+	}
+	tree, err := NewTreeWithOptions(leaves, WithMiMC7(), WithInsertionOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l0, _ := leaves[0].CalculateHash()
+	l1, _ := leaves[1].CalculateHash()
+	l2, _ := leaves[2].CalculateHash()
+	l3, _ := leaves[3].CalculateHash()
+	elem := func(b []byte) *big.Int { return new(big.Int).SetBytes(b) }
+	left := MiMC7MultiHash([]*big.Int{elem(l0), elem(l1)}, nil)
+	right := MiMC7MultiHash([]*big.Int{elem(l2), elem(l3)}, nil)
+	if hexutil.Encode(tree.MerkleRoot()) != hexutil.Encode(BN254Element(MiMC7MultiHash([]*big.Int{left, right}, nil))) {
+		t.Fatal("root does not follow MiMC7")
+	}
+
+	for i := range leaves {
+		proof, _ := tree.GetProofByIndex(i)
+		ok, err := VerifyIndexedProof(tree.MerkleRoot(), i, len(leaves), proof, WithMiMC7(), WithInsertionOrder())
+		if err != nil || !ok {
+			t.Fatalf("proof of %d does not verify", i)
+		}
+	}
+
+	if n := len(BytesToBN254Elements(make([]byte, 62))); n != 2 {
+		t.Fatalf("62 bytes split into %d elements", n)
+	}
+}
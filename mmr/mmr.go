@@ -0,0 +1,282 @@
+// Package mmr implements a Merkle Mountain Range, an append-only accumulator made
+// of perfect binary trees, the mountains, whose roots are the peaks. Appending a
+// leaf only merges the mountains it completes, and the root is obtained by
+// bagging the peaks. Nodes are numbered in the order they are appended, which
+// together with the hashing below matches the ckb merkle-mountain-range crate
+// used by Nervos and Substrate: a parent is H(left || right) and the peaks are
+// bagged from the right, each time as H(right || left).
+package mmr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash"
+	"math/bits"
+)
+
+// ErrEmpty is returned for the root of a range without leaves.
+var ErrEmpty = errors.New("error: empty merkle mountain range")
+
+// MMR is a Merkle Mountain Range held in memory.
+type MMR struct {
+	hashStrategy func() hash.Hash
+	// nodes holds every node by position
+	nodes  [][]byte
+	leaves uint64
+}
+
+// Proof shows that the leaf at Position is part of a range of Size nodes. Items
+// are the siblings from the leaf up to its peak, then the bagged peaks right of
+// it if there are, then the peaks left of it from right to left.
+type Proof struct {
+	Size     uint64
+	Position uint64
+	Items    [][]byte
+}
+
+// New returns an empty range hashing with hashStrategy.
+func New(hashStrategy func() hash.Hash) *MMR {
+	return &MMR{hashStrategy: hashStrategy}
+}
+
+// Append adds leaf, already hashed, to the range and returns its position.
+func (m *MMR) Append(leaf []byte) (uint64, error) {
+	pos := uint64(len(m.nodes))
+	m.nodes = append(m.nodes, leaf)
+
+	// merge while the new node completes a mountain
+	next, height := pos, 0
+	for posHeight(next+1) > height {
+		next++
+		left := next - parentOffset(height)
+		parent, err := merge(m.hashStrategy, m.nodes[left], m.nodes[left+siblingOffset(height)])
+		if err != nil {
+			m.nodes = m.nodes[:pos]
+			return 0, err
+		}
+		m.nodes = append(m.nodes, parent)
+		height++
+	}
+	m.leaves++
+	return pos, nil
+}
+
+// Size returns the number of nodes in the range.
+func (m *MMR) Size() uint64 {
+	return uint64(len(m.nodes))
+}
+
+// LeafCount returns the number of leaves appended.
+func (m *MMR) LeafCount() uint64 {
+	return m.leaves
+}
+
+// Peaks returns the peaks from left to right.
+func (m *MMR) Peaks() [][]byte {
+	positions, _ := peakPositions(m.Size())
+	peaks := make([][]byte, len(positions))
+	for i, pos := range positions {
+		peaks[i] = m.nodes[pos]
+	}
+	return peaks
+}
+
+// Root returns the bagged peaks.
+func (m *MMR) Root() ([]byte, error) {
+	if m.leaves == 0 {
+		return nil, ErrEmpty
+	}
+	return bagPeaks(m.hashStrategy, m.Peaks())
+}
+
+// GetProof returns the inclusion proof of the leaf with the given index, counting
+// leaves from zero, against the current root.
+func (m *MMR) GetProof(leafIndex uint64) (*Proof, error) {
+	if leafIndex >= m.leaves {
+		return nil, fmt.Errorf("error: leaf index %d out of range [0, %d)", leafIndex, m.leaves)
+	}
+	size := m.Size()
+	p := &Proof{Size: size, Position: LeafIndexToPos(leafIndex)}
+
+	pos, height := p.Position, 0
+	for {
+		sibling, next := siblingOf(pos, height)
+		if sibling >= size {
+			break
+		}
+		p.Items = append(p.Items, m.nodes[sibling])
+		pos, height = next, height+1
+	}
+
+	peaks, _ := peakPositions(size)
+	k := 0
+	for peaks[k] != pos {
+		k++
+	}
+	if k+1 < len(peaks) {
+		rhs := make([][]byte, 0, len(peaks)-k-1)
+		for _, peak := range peaks[k+1:] {
+			rhs = append(rhs, m.nodes[peak])
+		}
+		bagged, err := bagPeaks(m.hashStrategy, rhs)
+		if err != nil {
+			return nil, err
+		}
+		p.Items = append(p.Items, bagged)
+	}
+	for i := k - 1; i >= 0; i-- {
+		p.Items = append(p.Items, m.nodes[peaks[i]])
+	}
+	return p, nil
+}
+
+// VerifyProof reports whether p shows leaf under root.
+func VerifyProof(root, leaf []byte, p *Proof, hashStrategy func() hash.Hash) (bool, error) {
+	if p == nil {
+		return false, errors.New("error: nil proof")
+	}
+	peaks, ok := peakPositions(p.Size)
+	if !ok || p.Position >= p.Size || posHeight(p.Position) != 0 {
+		return false, nil
+	}
+	items := p.Items
+
+	// climb to the peak of the leaf
+	node, pos, height := leaf, p.Position, 0
+	for {
+		sibling, next := siblingOf(pos, height)
+		if sibling >= p.Size {
+			break
+		}
+		if len(items) == 0 {
+			return false, nil
+		}
+		var err error
+		if sibling < pos {
+			node, err = merge(hashStrategy, items[0], node)
+		} else {
+			node, err = merge(hashStrategy, node, items[0])
+		}
+		if err != nil {
+			return false, err
+		}
+		items = items[1:]
+		pos, height = next, height+1
+	}
+
+	k := 0
+	for k < len(peaks) && peaks[k] != pos {
+		k++
+	}
+	if k == len(peaks) {
+		return false, nil
+	}
+	want := k
+	if k+1 < len(peaks) {
+		want++
+	}
+	if len(items) != want {
+		return false, nil
+	}
+
+	// the peaks from left to right, with the ones right of the leaf bagged
+	hashes := make([][]byte, 0, want+1)
+	for i := len(items) - 1; i >= 0 && len(hashes) < k; i-- {
+		hashes = append(hashes, items[i])
+	}
+	hashes = append(hashes, node)
+	if k+1 < len(peaks) {
+		hashes = append(hashes, items[0])
+	}
+	bagged, err := bagPeaks(hashStrategy, hashes)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(bagged, root), nil
+}
+
+// LeafIndexToPos returns the position of the leaf with the given index.
+func LeafIndexToPos(index uint64) uint64 {
+	// the size of the range once the leaf is appended, less the parents it adds
+	return leafIndexToSize(index) - uint64(bits.TrailingZeros64(index+1)) - 1
+}
+
+// leafIndexToSize returns the size of the range ending with the leaf of index.
+func leafIndexToSize(index uint64) uint64 {
+	leaves := index + 1
+	return 2*leaves - uint64(bits.OnesCount64(leaves))
+}
+
+// siblingOf returns the position of the sibling of the node at pos and height and
+// the position of their parent.
+func siblingOf(pos uint64, height int) (sibling, parent uint64) {
+	if posHeight(pos+1) > height {
+		// pos is a right child, its parent follows it
+		return pos - siblingOffset(height), pos + 1
+	}
+	return pos + siblingOffset(height), pos + parentOffset(height)
+}
+
+// posHeight returns the height of the node at pos, leaves having height zero.
+func posHeight(pos uint64) int {
+	pos++
+	// the rightmost node of a perfect tree numbered from one is all ones, and
+	// every other node is found at the same height in a smaller tree to the left
+	for pos&(pos+1) != 0 {
+		pos -= 1<<(uint(bits.Len64(pos))-1) - 1
+	}
+	return bits.Len64(pos) - 1
+}
+
+func parentOffset(height int) uint64 {
+	return 2 << uint(height)
+}
+
+func siblingOffset(height int) uint64 {
+	return 2<<uint(height) - 1
+}
+
+// peakPositions returns the positions of the peaks of a range of size nodes from
+// left to right, and false if no range has that size. Each mountain is larger
+// than all smaller ones together, so the mountains are found greedily.
+func peakPositions(size uint64) ([]uint64, bool) {
+	var peaks []uint64
+	offset, rest := uint64(0), size
+	for height := 63; height >= 0; height-- {
+		mountain := uint64(1)<<uint(height+1) - 1
+		if mountain <= rest {
+			peaks = append(peaks, offset+mountain-1)
+			offset += mountain
+			rest -= mountain
+		}
+	}
+	return peaks, rest == 0
+}
+
+// bagPeaks folds the peaks from the right: the last two are replaced by
+// H(right || left) until one is left.
+func bagPeaks(hashStrategy func() hash.Hash, peaks [][]byte) ([]byte, error) {
+	if len(peaks) == 0 {
+		return nil, ErrEmpty
+	}
+	bagged := peaks[len(peaks)-1]
+	for i := len(peaks) - 2; i >= 0; i-- {
+		var err error
+		if bagged, err = merge(hashStrategy, bagged, peaks[i]); err != nil {
+			return nil, err
+		}
+	}
+	return bagged, nil
+}
+
+func merge(hashStrategy func() hash.Hash, left, right []byte) ([]byte, error) {
+	h := hashStrategy()
+	if _, err := h.Write(left); err != nil {
+		return nil, err
+	}
+	if _, err := h.Write(right); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
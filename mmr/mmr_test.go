@@ -0,0 +1,100 @@
+package mmr
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+func keccak(parts ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
+
+func Test_Layout(t *testing.T) {
+	// positions of the first leaves and the heights of the first nodes
+	for index, pos := range []uint64{0, 1, 3, 4, 7, 8, 10, 11, 15} {
+		if got := LeafIndexToPos(uint64(index)); got != pos {
+			t.Fatalf("leaf %d at position %d, want %d", index, got, pos)
+		}
+	}
+	for pos, height := range []int{0, 0, 1, 0, 0, 1, 2, 0, 0, 1, 0, 0, 1, 2, 3} {
+		if got := posHeight(uint64(pos)); got != height {
+			t.Fatalf("node %d at height %d, want %d", pos, got, height)
+		}
+	}
+	if peaks, ok := peakPositions(11); !ok || fmt.Sprint(peaks) != "[6 9 10]" {
+		t.Fatalf("unexpected peaks %v", peaks)
+	}
+	if _, ok := peakPositions(5); ok {
+		t.Fatal("expected no range of 5 nodes")
+	}
+}
+
+func Test_MMR(t *testing.T) {
+	m := New(sha3.NewLegacyKeccak256)
+	if _, err := m.Root(); err != ErrEmpty {
+		t.Fatalf("expected ErrEmpty, got %v", err)
+	}
+
+	var leaves [][]byte
+	for n := 1; n <= 40; n++ {
+		leaf := keccak([]byte(fmt.Sprintf("leaf-%d", n-1)))
+		pos, err := m.Append(leaf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pos != LeafIndexToPos(uint64(n-1)) {
+			t.Fatalf("leaf appended at position %d", pos)
+		}
+		leaves = append(leaves, leaf)
+		if m.Size() != leafIndexToSize(uint64(n-1)) || m.LeafCount() != uint64(n) {
+			t.Fatalf("unexpected size %d after %d leaves", m.Size(), n)
+		}
+
+		root, err := m.Root()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, leaf := range leaves {
+			p, err := m.GetProof(uint64(i))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := VerifyProof(root, leaf, p, sha3.NewLegacyKeccak256); err != nil || !ok {
+				t.Fatalf("proof of leaf %d of %d does not verify", i, n)
+			}
+			if ok, _ := VerifyProof(root, leaves[(i+1)%n], p, sha3.NewLegacyKeccak256); ok && n > 1 {
+				t.Fatalf("proof of leaf %d verifies for another leaf", i)
+			}
+		}
+	}
+
+	// seven leaves form mountains of four, two and one leaves
+	l := leaves[:7]
+	m4 := keccak(keccak(l[0], l[1]), keccak(l[2], l[3]))
+	m2 := keccak(l[4], l[5])
+	want := keccak(keccak(l[6], m2), m4)
+	small := New(sha3.NewLegacyKeccak256)
+	for _, leaf := range l {
+		if _, err := small.Append(leaf); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if root, _ := small.Root(); !bytes.Equal(root, want) {
+		t.Fatal("unexpected root of seven leaves")
+	}
+	if _, err := small.GetProof(7); err == nil {
+		t.Fatal("expected error for a leaf out of range")
+	}
+	p, _ := small.GetProof(4)
+	p.Size = 12
+	if ok, _ := VerifyProof(want, l[4], p, sha3.NewLegacyKeccak256); ok {
+		t.Fatal("proof verifies for another size")
+	}
+}
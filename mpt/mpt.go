@@ -0,0 +1,187 @@
+// Package mpt implements the hexary Merkle Patricia Trie of Ethereum, the trie
+// behind the state, storage, transaction and receipt roots. Nodes are RLP encoded
+// and referenced by their keccak256 hash, or embedded in their parent when their
+// encoding is shorter than 32 bytes, so roots and proofs match those of the trie
+// package of go-ethereum.
+//
+// Keys are used as given. The state and storage tries of Ethereum, the secure
+// tries of go-ethereum, key every entry by the keccak256 hash of its address or
+// slot, which callers compute themselves.
+package mpt
+
+import (
+	"bytes"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// EmptyRoot is the root of a trie without entries, keccak256(rlp("")).
+var EmptyRoot = crypto.Keccak256(rlp.EmptyString)
+
+// Trie is a Merkle Patricia Trie held in memory. Changes copy the nodes on the
+// path they touch, so the hashes cached by the other nodes stay valid.
+type Trie struct {
+	root node
+}
+
+// New returns an empty trie.
+func New() *Trie {
+	return &Trie{}
+}
+
+// Get returns the value of key, or nil if the trie holds no value for it.
+func (t *Trie) Get(key []byte) []byte {
+	n, k := t.root, keybytesToHex(key)
+	for {
+		switch nn := n.(type) {
+		case nil:
+			return nil
+		case valueNode:
+			return append([]byte(nil), nn...)
+		case *shortNode:
+			if len(k) < len(nn.key) || !bytes.Equal(nn.key, k[:len(nn.key)]) {
+				return nil
+			}
+			n, k = nn.val, k[len(nn.key):]
+		case *fullNode:
+			n, k = nn.children[k[0]], k[1:]
+		}
+	}
+}
+
+// Update sets the value of key. As in go-ethereum an empty value deletes the key.
+func (t *Trie) Update(key, value []byte) {
+	if len(value) == 0 {
+		t.Delete(key)
+		return
+	}
+	t.root = insert(t.root, keybytesToHex(key), valueNode(append([]byte(nil), value...)))
+}
+
+// Delete removes key from the trie, if it holds it.
+func (t *Trie) Delete(key []byte) {
+	t.root, _ = remove(t.root, keybytesToHex(key))
+}
+
+// Hash returns the root hash of the trie.
+func (t *Trie) Hash() []byte {
+	if t.root == nil {
+		return append([]byte(nil), EmptyRoot...)
+	}
+	return crypto.Keccak256(encodeNode(t.root))
+}
+
+// DeriveRoot returns the root of the trie that maps the RLP encoding of every
+// index to the item at that index, the transaction and receipt roots of a block
+// header when given the consensus encodings of its transactions or receipts.
+func DeriveRoot(items [][]byte) []byte {
+	t := New()
+	for i, item := range items {
+		key, _ := rlp.EncodeToBytes(uint64(i))
+		t.Update(key, item)
+	}
+	return t.Hash()
+}
+
+// insert returns n with value stored under the rest of the hex key.
+func insert(n node, key []byte, value valueNode) node {
+	switch n := n.(type) {
+	case nil:
+		if len(key) == 0 {
+			return value
+		}
+		return &shortNode{key: key, val: value}
+	case valueNode:
+		// keys end with the terminator, so only an equal key gets here
+		return value
+	case *shortNode:
+		match := prefixLen(key, n.key)
+		if match == len(n.key) {
+			return &shortNode{key: n.key, val: insert(n.val, key[match:], value)}
+		}
+		// the keys part ways after match nibbles
+		branch := &fullNode{}
+		branch.children[n.key[match]] = shorten(n.key[match+1:], n.val)
+		branch.children[key[match]] = insert(nil, key[match+1:], value)
+		if match == 0 {
+			return branch
+		}
+		return &shortNode{key: key[:match], val: branch}
+	case *fullNode:
+		c := n.copy()
+		c.children[key[0]] = insert(n.children[key[0]], key[1:], value)
+		return c
+	}
+	panic("mpt: unknown node type")
+}
+
+// remove returns n without the rest of the hex key, and whether it held it.
+func remove(n node, key []byte) (node, bool) {
+	switch n := n.(type) {
+	case nil:
+		return nil, false
+	case valueNode:
+		return nil, true
+	case *shortNode:
+		match := prefixLen(key, n.key)
+		if match < len(n.key) {
+			return n, false
+		}
+		child, found := remove(n.val, key[match:])
+		if !found {
+			return n, false
+		}
+		if child == nil {
+			return nil, true
+		}
+		return shorten(n.key, child), true
+	case *fullNode:
+		child, found := remove(n.children[key[0]], key[1:])
+		if !found {
+			return n, false
+		}
+		c := n.copy()
+		c.children[key[0]] = child
+		if child != nil {
+			return c, true
+		}
+
+		// a branch left with a single child turns into a short node
+		pos := -1
+		for i, cn := range c.children {
+			if cn != nil {
+				if pos >= 0 {
+					return c, true
+				}
+				pos = i
+			}
+		}
+		return shorten([]byte{byte(pos)}, c.children[pos]), true
+	}
+	panic("mpt: unknown node type")
+}
+
+// shorten returns a node standing for n under the hex key, merging the keys of
+// nested short nodes.
+func shorten(key []byte, n node) node {
+	if len(key) == 0 {
+		return n
+	}
+	if sn, ok := n.(*shortNode); ok {
+		return &shortNode{key: concat(key, sn.key), val: sn.val}
+	}
+	return &shortNode{key: key, val: n}
+}
+
+func prefixLen(a, b []byte) int {
+	i := 0
+	for i < len(a) && i < len(b) && a[i] == b[i] {
+		i++
+	}
+	return i
+}
+
+func concat(a, b []byte) []byte {
+	return append(append(make([]byte, 0, len(a)+len(b)), a...), b...)
+}
@@ -0,0 +1,165 @@
+package mpt
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+// The roots below are those of the trie tests of go-ethereum.
+func Test_GethRoots(t *testing.T) {
+	tr := New()
+	if !bytes.Equal(tr.Hash(), mustHex("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")) {
+		t.Fatal("unexpected empty root")
+	}
+	tr.Update([]byte("doe"), []byte("reindeer"))
+	tr.Update([]byte("dog"), []byte("puppy"))
+	tr.Update([]byte("dogglesworth"), []byte("cat"))
+	if !bytes.Equal(tr.Hash(), mustHex("8aad789dff2f538bca5d8ea56e8abe10f4c7ba3a5dea95fea4cd6e7c3a1168d3")) {
+		t.Fatalf("unexpected root %x", tr.Hash())
+	}
+	if got := tr.Get([]byte("dog")); string(got) != "puppy" {
+		t.Fatalf("expected puppy, got %q", got)
+	}
+	if got := tr.Get([]byte("do")); got != nil {
+		t.Fatal("expected no value for a prefix of a key")
+	}
+
+	tr = New()
+	tr.Update([]byte("A"), []byte("aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"))
+	if !bytes.Equal(tr.Hash(), mustHex("d23786fb4a010da3ce639d66d5e904a11dbc02746d1ce25029e53290cabf28ab")) {
+		t.Fatalf("unexpected root %x", tr.Hash())
+	}
+
+	tr = New()
+	for _, kv := range []struct{ k, v string }{
+		{"do", "verb"},
+		{"ether", "wookiedoo"},
+		{"horse", "stallion"},
+		{"shaman", "horse"},
+		{"doge", "coin"},
+		{"ether", ""},
+		{"dog", "puppy"},
+		{"shaman", ""},
+	} {
+		tr.Update([]byte(kv.k), []byte(kv.v))
+	}
+	if !bytes.Equal(tr.Hash(), mustHex("5991bb8c6514148a29db676a14ac506cd2cd5775ace63c30a4fe457715e9ac84")) {
+		t.Fatalf("unexpected root %x", tr.Hash())
+	}
+
+	for _, group := range [][][3]string{
+		{
+			{"00", "v_______________________0___0", "5cb26357b95bb9af08475be00243ceb68ade0b66b5cd816b0c18a18c612d2d21"},
+			{"70", "v_______________________0___1", "8ff64309574f7a437a7ad1628e690eb7663cfde10676f8a904a8c8291dbc1603"},
+			{"f0", "v_______________________0___2", "9e3a01bd8d43efb8e9d4b5506648150b8e3ed1caea596f84ee28e01a72635470"},
+		},
+		{
+			{"00cccc", "v_______________________3___0", "e57dc2785b99ce9205080cb41b32ebea7ac3e158952b44c87d186e6d190a6530"},
+			{"245600", "v_______________________3___1", "0335354adbd360a45c1871a842452287721b64b4234dfe08760b243523c998db"},
+			{"245622", "v_______________________3___2", "9e6832db0dca2b5cf81c0e0727bfde6afc39d5de33e5720bccacc183c162104e"},
+		},
+		{
+			{"f0fccc", "v_______________________7___0", "b0966b5aa469a3e292bc5fcfa6c396ae7a657255eef552ea7e12f996de795b90"},
+			{"ffff0f", "v_______________________7___1", "3b1ca154ec2a3d96d8d77bddef0abfe40a53a64eb03cecf78da9ec43799fa3d0"},
+			{"ffffff", "v_______________________7___2", "e75463041f1be8252781be0ace579a44ea4387bf5b2739f4607af676f7719678"},
+		},
+		{
+			{"1234da", "x___________________________0", "1c4b4462e9f56a80ca0f5d77c0d632c41b0102290930343cf1791e971a045a79"},
+			{"1234ea", "x___________________________1", "2f502917f3ba7d328c21c8b45ee0f160652e68450332c166d4ad02d1afe31862"},
+			{"1235aa", "x___________________________2", "21840121d11a91ac8bbad9a5d06af902a5c8d56a47b85600ba813814b7bfcb9b"},
+		},
+	} {
+		tr := New()
+		for _, kvh := range group {
+			tr.Update(mustHex(kvh[0]), []byte(kvh[1]))
+			if !bytes.Equal(tr.Hash(), mustHex(kvh[2])) {
+				t.Fatalf("unexpected root %x after inserting %s", tr.Hash(), kvh[0])
+			}
+		}
+	}
+}
+
+func Test_Delete(t *testing.T) {
+	tr := New()
+	var roots [][]byte
+	for i := 0; i < 100; i++ {
+		roots = append(roots, tr.Hash())
+		tr.Update(crypto.Keccak256([]byte{byte(i)})[:5], []byte(fmt.Sprintf("value-%d", i)))
+	}
+	// deleting in reverse goes back through every root
+	for i := 99; i >= 0; i-- {
+		tr.Delete(crypto.Keccak256([]byte{byte(i)})[:5])
+		if !bytes.Equal(tr.Hash(), roots[i]) {
+			t.Fatalf("unexpected root after deleting key %d", i)
+		}
+	}
+	tr.Delete([]byte("absent"))
+	if !bytes.Equal(tr.Hash(), EmptyRoot) {
+		t.Fatal("expected the empty root")
+	}
+}
+
+func Test_Proofs(t *testing.T) {
+	tr := New()
+	keys := [][]byte{[]byte("doe"), []byte("dog"), []byte("dogglesworth"), []byte("horse"), []byte("h")}
+	for i := 0; i < 50; i++ {
+		keys = append(keys, crypto.Keccak256([]byte{byte(i)}))
+	}
+	for i, k := range keys {
+		tr.Update(k, bytes.Repeat([]byte{byte(i + 1)}, i%40+1))
+	}
+	root := tr.Hash()
+
+	for i, k := range keys {
+		value, err := VerifyProof(root, k, tr.Prove(k))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(value, bytes.Repeat([]byte{byte(i + 1)}, i%40+1)) {
+			t.Fatalf("unexpected value for key %x", k)
+		}
+	}
+	for _, k := range [][]byte{[]byte("do"), []byte("dogs"), []byte("zebra"), {}} {
+		value, err := VerifyProof(root, k, tr.Prove(k))
+		if err != nil || value != nil {
+			t.Fatalf("expected a proof of absence for %q", k)
+		}
+	}
+
+	proof := tr.Prove(keys[0])
+	if _, err := VerifyProof(root, keys[0], proof[:len(proof)-1]); err == nil {
+		t.Fatal("expected error for a proof missing a node")
+	}
+	if _, err := VerifyProof(crypto.Keccak256([]byte("other")), keys[0], proof); err == nil {
+		t.Fatal("expected error for another root")
+	}
+}
+
+func Test_DeriveRoot(t *testing.T) {
+	if !bytes.Equal(DeriveRoot(nil), EmptyRoot) {
+		t.Fatal("expected the empty root without items")
+	}
+	items := make([][]byte, 200)
+	tr := New()
+	for i := len(items) - 1; i >= 0; i-- {
+		items[i] = []byte(fmt.Sprintf("receipt-%d", i))
+		key, _ := rlp.EncodeToBytes(uint64(i))
+		tr.Update(key, items[i])
+	}
+	if !bytes.Equal(DeriveRoot(items), tr.Hash()) {
+		t.Fatal("expected the root of the indexed trie")
+	}
+}
@@ -0,0 +1,197 @@
+package mpt
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+type node interface{}
+
+type (
+	// fullNode is a branch with a child for every nibble and the value of the key
+	// ending at the branch in children[16].
+	fullNode struct {
+		children [17]node
+		enc      []byte
+	}
+	// shortNode is an extension, or a leaf if its key ends with the terminator.
+	shortNode struct {
+		key []byte
+		val node
+		enc []byte
+	}
+	valueNode []byte
+	// hashNode references a node of a proof by its hash.
+	hashNode []byte
+)
+
+// terminator ends the hex key of every value.
+const terminator = 16
+
+func (n *fullNode) copy() *fullNode {
+	return &fullNode{children: n.children}
+}
+
+// encodeNode returns the RLP encoding of n, which is cached since nodes never
+// change once built.
+func encodeNode(n node) []byte {
+	switch n := n.(type) {
+	case *fullNode:
+		if n.enc == nil {
+			items := make([]interface{}, len(n.children))
+			for i, c := range n.children {
+				items[i] = reference(c)
+			}
+			n.enc, _ = rlp.EncodeToBytes(items)
+		}
+		return n.enc
+	case *shortNode:
+		if n.enc == nil {
+			n.enc, _ = rlp.EncodeToBytes([]interface{}{hexToCompact(n.key), reference(n.val)})
+		}
+		return n.enc
+	}
+	panic(fmt.Sprintf("mpt: cannot encode %T", n))
+}
+
+// reference returns how a parent refers to n: by its hash if its encoding takes
+// 32 bytes or more and by the encoding itself otherwise.
+func reference(n node) interface{} {
+	switch n := n.(type) {
+	case nil:
+		return []byte(nil)
+	case valueNode:
+		return []byte(n)
+	}
+	enc := encodeNode(n)
+	if len(enc) < 32 {
+		return rlp.RawValue(enc)
+	}
+	return crypto.Keccak256(enc)
+}
+
+// decodeNode decodes the encoding of a node of a proof. Children referenced by
+// hash are left as hashNodes.
+func decodeNode(enc []byte) (node, error) {
+	elems, _, err := rlp.SplitList(enc)
+	if err != nil {
+		return nil, err
+	}
+	count, err := rlp.CountValues(elems)
+	if err != nil {
+		return nil, err
+	}
+	switch count {
+	case 2:
+		kbuf, rest, err := rlp.SplitString(elems)
+		if err != nil {
+			return nil, err
+		}
+		if len(kbuf) == 0 {
+			return nil, errors.New("error: short node without key")
+		}
+		key := compactToHex(kbuf)
+		if hasTerminator(key) {
+			val, _, err := rlp.SplitString(rest)
+			if err != nil {
+				return nil, err
+			}
+			return &shortNode{key: key, val: valueNode(val)}, nil
+		}
+		val, _, err := decodeRef(rest)
+		if err != nil {
+			return nil, err
+		}
+		return &shortNode{key: key, val: val}, nil
+	case 17:
+		n := &fullNode{}
+		for i := 0; i < 16; i++ {
+			var err error
+			if n.children[i], elems, err = decodeRef(elems); err != nil {
+				return nil, err
+			}
+		}
+		val, _, err := rlp.SplitString(elems)
+		if err != nil {
+			return nil, err
+		}
+		if len(val) > 0 {
+			n.children[16] = valueNode(val)
+		}
+		return n, nil
+	}
+	return nil, fmt.Errorf("error: node with %d items", count)
+}
+
+// decodeRef decodes the reference to a child at the start of buf.
+func decodeRef(buf []byte) (node, []byte, error) {
+	kind, val, rest, err := rlp.Split(buf)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch {
+	case kind == rlp.List:
+		size := len(buf) - len(rest)
+		if size >= 32 {
+			return nil, nil, errors.New("error: embedded node of 32 bytes or more")
+		}
+		n, err := decodeNode(buf[:size])
+		return n, rest, err
+	case kind == rlp.String && len(val) == 0:
+		return nil, rest, nil
+	case kind == rlp.String && len(val) == 32:
+		return hashNode(val), rest, nil
+	}
+	return nil, nil, fmt.Errorf("error: invalid child reference of %d bytes", len(val))
+}
+
+// keybytesToHex splits key into nibbles and appends the terminator.
+func keybytesToHex(key []byte) []byte {
+	nibbles := make([]byte, len(key)*2+1)
+	for i, b := range key {
+		nibbles[i*2] = b / 16
+		nibbles[i*2+1] = b % 16
+	}
+	nibbles[len(nibbles)-1] = terminator
+	return nibbles
+}
+
+// hexToCompact encodes a hex key with the hex prefix encoding of the yellow
+// paper: a first nibble flagging a terminator and an odd length, then the
+// nibbles packed into bytes.
+func hexToCompact(hex []byte) []byte {
+	var flags byte
+	if hasTerminator(hex) {
+		flags = 2
+		hex = hex[:len(hex)-1]
+	}
+	buf := make([]byte, len(hex)/2+1)
+	if len(hex)&1 == 1 {
+		flags |= 1
+		buf[0] = hex[0]
+		hex = hex[1:]
+	}
+	buf[0] |= flags << 4
+	for i := 0; i < len(hex); i += 2 {
+		buf[i/2+1] = hex[i]<<4 | hex[i+1]
+	}
+	return buf
+}
+
+// compactToHex decodes a key encoded by hexToCompact.
+func compactToHex(compact []byte) []byte {
+	base := keybytesToHex(compact)
+	// drop the terminator unless flagged
+	if base[0] < 2 {
+		base = base[:len(base)-1]
+	}
+	// drop the flag nibble, and the padding nibble of an even key
+	return base[2-base[0]&1:]
+}
+
+func hasTerminator(hex []byte) bool {
+	return len(hex) > 0 && hex[len(hex)-1] == terminator
+}
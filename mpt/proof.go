@@ -0,0 +1,81 @@
+package mpt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// Prove returns the proof of key: the encodings of the nodes on the path of key
+// from the root down that are referenced by hash, as eth_getProof returns them.
+// Nodes embedded in their parent are part of its encoding. For a key the trie
+// does not hold the proof ends with the node showing that the path stops.
+func (t *Trie) Prove(key []byte) [][]byte {
+	var proof [][]byte
+	n, k := t.root, keybytesToHex(key)
+	for i := 0; n != nil; i++ {
+		var next node
+		switch nn := n.(type) {
+		case *shortNode:
+			if len(k) >= len(nn.key) && bytes.Equal(nn.key, k[:len(nn.key)]) {
+				next, k = nn.val, k[len(nn.key):]
+			}
+		case *fullNode:
+			next, k = nn.children[k[0]], k[1:]
+		case valueNode:
+			return proof
+		}
+		if enc := encodeNode(n); i == 0 || len(enc) >= 32 {
+			proof = append(proof, enc)
+		}
+		n = next
+	}
+	return proof
+}
+
+// VerifyProof checks proof, as returned by Prove, against root and returns the
+// value it shows for key, which is nil if it shows that the trie does not hold
+// key.
+func VerifyProof(root, key []byte, proof [][]byte) ([]byte, error) {
+	nodes := make(map[string][]byte, len(proof))
+	for _, enc := range proof {
+		nodes[string(crypto.Keccak256(enc))] = enc
+	}
+
+	k, want := keybytesToHex(key), root
+	for {
+		enc, ok := nodes[string(want)]
+		if !ok {
+			return nil, fmt.Errorf("error: proof node %x missing", want)
+		}
+		n, err := decodeNode(enc)
+		if err != nil {
+			return nil, err
+		}
+
+	walk:
+		for {
+			switch nn := n.(type) {
+			case nil:
+				return nil, nil
+			case valueNode:
+				return append([]byte(nil), nn...), nil
+			case hashNode:
+				want = nn
+				break walk
+			case *shortNode:
+				if len(k) < len(nn.key) || !bytes.Equal(nn.key, k[:len(nn.key)]) {
+					return nil, nil
+				}
+				n, k = nn.val, k[len(nn.key):]
+			case *fullNode:
+				if len(k) == 0 {
+					return nil, errors.New("error: proof path runs past the key")
+				}
+				n, k = nn.children[k[0]], k[1:]
+			}
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package merkletree
+
+import (
+	"errors"
+	"hash"
+	"sort"
+
+	"github.com/smartbch/merkletree/merkleproof"
+)
+
+// MultiProof proves several leaves at once, in the layout taken by OpenZeppelin's
+// MerkleProof.multiProofVerify(proof, proofFlags, root, leaves).
+type MultiProof = merkleproof.MultiProof
+
+// GetMultiProof returns a single proof covering all of contents.
+//
+// The on-chain verifier consumes every leaf before any hash it computes and pairs
+// each node with the next one in its queue, so two siblings that both lie above
+// selected leaves must come up back to back. Trees whose leaf count is a power of
+// two always satisfy this, and so do selections that do not reach a promoted
+// node. A node promoted by single-node promotion is ready before its sibling
+// has been computed, though: the last of 5 leaves, for instance, can only be
+// proven alone. Such selections return an error and have to be proven in parts
+// or leaf by leaf.
+func (m *MerkleTree) GetMultiProof(contents []Content) (*MultiProof, error) {
+	if len(contents) == 0 {
+		return nil, errors.New("error: no contents to prove")
+	}
+	if m.unsortedPairs || m.duplicateOdd {
+		return nil, errors.New("error: multiproofs require sorted pairs and promoted odd nodes")
+	}
+
+	leafs := make([]*Node, len(contents))
+	for i, c := range contents {
+		leaf, err := m.findLeaf(c)
+		if err != nil {
+			return nil, err
+		}
+		leafs[i] = leaf
+	}
+	return m.multiProofOf(leafs)
+}
+
+// GetMultiProofByLeafHashes returns a single proof covering the leaves whose
+// hashes are leafHashes, see GetMultiProof.
+func (m *MerkleTree) GetMultiProofByLeafHashes(leafHashes [][]byte) (*MultiProof, error) {
+	if len(leafHashes) == 0 {
+		return nil, errors.New("error: no leaves to prove")
+	}
+	if m.unsortedPairs || m.duplicateOdd {
+		return nil, errors.New("error: multiproofs require sorted pairs and promoted odd nodes")
+	}
+
+	leafs := make([]*Node, len(leafHashes))
+	for i, leafHash := range leafHashes {
+		leaf := m.findLeafByHash(leafHash)
+		if leaf == nil {
+			return nil, ErrContentNotFound
+		}
+		leafs[i] = leaf
+	}
+	return m.multiProofOf(leafs)
+}
+
+// multiProofOf returns the multiproof of leafs, which must belong to m.
+func (m *MerkleTree) multiProofOf(leafs []*Node) (*MultiProof, error) {
+	index := make(map[*Node]int, len(m.Leafs))
+	for i, leaf := range m.Leafs {
+		index[leaf] = i
+	}
+
+	// selected leaves and every node above them
+	covered := make(map[*Node]bool)
+	var selected []*Node
+	for _, leaf := range leafs {
+		if covered[leaf] {
+			continue
+		}
+		selected = append(selected, leaf)
+		for n := leaf; n != nil && !covered[n]; n = n.Parent {
+			covered[n] = true
+		}
+	}
+
+	// consume lower levels first, left to right within a level
+	sort.Slice(selected, func(i, j int) bool {
+		li, lj := liftLevels(selected[i]), liftLevels(selected[j])
+		if li != lj {
+			return li < lj
+		}
+		return index[selected[i]] < index[selected[j]]
+	})
+
+	mp := &MultiProof{}
+	queue := make([]*Node, 0, len(selected))
+	for _, leaf := range selected {
+		mp.Leaves = append(mp.Leaves, leaf.Hash)
+		queue = append(queue, lift(leaf))
+	}
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current == m.Root {
+			break
+		}
+
+		parent := current.Parent
+		sibling := parent.Left
+		if sibling == current {
+			sibling = parent.Right
+		}
+
+		switch {
+		case len(queue) > 0 && queue[0] == sibling:
+			mp.ProofFlags = append(mp.ProofFlags, true)
+			queue = queue[1:]
+		case covered[sibling]:
+			return nil, errors.New("error: leaves cannot be proven together in the multiproof layout")
+		default:
+			mp.ProofFlags = append(mp.ProofFlags, false)
+			mp.Proof = append(mp.Proof, sibling.Hash)
+		}
+		queue = append(queue, lift(parent))
+	}
+	return mp, nil
+}
+
+// VerifyMultiProof reports whether mp proves all of its leaves under root. It
+// follows OpenZeppelin's processMultiProof step by step.
+func VerifyMultiProof(root []byte, mp *MultiProof, hashStrategy func() hash.Hash) (bool, error) {
+	return merkleproof.VerifyMultiProof(root, mp, hashStrategy)
+}
+
+// lift returns the highest node that n was promoted to without hashing; that node
+// has the same hash as n and is either paired on its level or the root.
+func lift(n *Node) *Node {
+	for n.single {
+		n = n.Parent
+	}
+	return n
+}
+
+// liftLevels counts how many levels n is promoted without hashing.
+func liftLevels(n *Node) int {
+	levels := 0
+	for ; n.single; n = n.Parent {
+		levels++
+	}
+	return levels
+}
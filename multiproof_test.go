@@ -0,0 +1,152 @@
+package merkletree
+
+import (
+	"sort"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// multiProvable tells whether the leaves at indexes of a tree of n leaves can be
+// proven together, by running the queue of the on-chain verifier over the node
+// positions: it fails where a node is popped while its sibling, also above the
+// selected leaves, is not next in line.
+func multiProvable(n int, indexes []int) bool {
+	type pos struct{ level, index int }
+	counts := flatCounts(n)
+	top := len(counts) - 1
+	lift := func(p pos) pos {
+		for p.level < top && p.index == counts[p.level]-1 && counts[p.level]%2 == 1 {
+			p = pos{p.level + 1, p.index / 2}
+		}
+		return p
+	}
+
+	covered := make(map[pos]bool)
+	var queue []pos
+	for _, i := range indexes {
+		queue = append(queue, lift(pos{0, i}))
+		for p := lift(pos{0, i}); ; p = lift(pos{p.level + 1, p.index / 2}) {
+			covered[p] = true
+			if p.level == top {
+				break
+			}
+		}
+	}
+	sort.Slice(queue, func(a, b int) bool {
+		if queue[a].level != queue[b].level {
+			return queue[a].level < queue[b].level
+		}
+		return queue[a].index < queue[b].index
+	})
+
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+		if current.level == top {
+			break
+		}
+		sibling := pos{current.level, current.index ^ 1}
+		switch {
+		case len(queue) > 0 && queue[0] == sibling:
+			queue = queue[1:]
+		case covered[sibling]:
+			return false
+		}
+		queue = append(queue, lift(pos{current.level + 1, current.index / 2}))
+	}
+	return true
+}
+
+func Test_MultiProof(t *testing.T) {
+	for n := 1; n <= 10; n++ {
+		tree, err := NewTree(testLeaves(n))
+		if err != nil {
+			t.Fatal(err)
+		}
+		powerOfTwo := n&(n-1) == 0
+
+		// every non-empty subset of the leaves
+		for mask := 1; mask < 1<<uint(n); mask++ {
+			var subset []Content
+			var indexes []int
+			for i := 0; i < n; i++ {
+				if mask&(1<<uint(i)) != 0 {
+					subset = append(subset, tree.Leafs[i].C)
+					indexes = append(indexes, i)
+				}
+			}
+			provable := multiProvable(n, indexes)
+			if powerOfTwo && !provable {
+				t.Fatalf("%d leaves, subset %b: expected every selection to be provable", n, mask)
+			}
+
+			mp, err := tree.GetMultiProof(subset)
+			if !provable {
+				if err == nil {
+					t.Fatalf("%d leaves, subset %b: expected error", n, mask)
+				}
+				continue
+			}
+			if err != nil {
+				t.Fatalf("%d leaves, subset %b: %v", n, mask, err)
+			}
+			if len(mp.Leaves) != len(subset) {
+				t.Fatalf("%d leaves, subset %b: proof covers %d leaves", n, mask, len(mp.Leaves))
+			}
+			ok, err := VerifyMultiProof(tree.MerkleRoot(), mp, sha3.NewLegacyKeccak256)
+			if err != nil || !ok {
+				t.Fatalf("%d leaves, subset %b: multiproof does not verify", n, mask)
+			}
+		}
+	}
+
+	// the last of 5 leaves is promoted twice and ready before any pair above the
+	// others, so it can only be proven alone
+	five, _ := NewTree(testLeaves(5))
+	if _, err := five.GetMultiProof([]Content{five.Leafs[0].C, five.Leafs[4].C}); err == nil {
+		t.Fatal("expected error for a promoted leaf with another one")
+	}
+	if _, err := five.GetMultiProof([]Content{five.Leafs[4].C}); err != nil {
+		t.Fatal(err)
+	}
+
+	leaves := testLeaves(8)
+	tree, _ := NewTree(leaves)
+	mp, _ := tree.GetMultiProof(leaves[:3])
+	mp.Leaves[0] = mp.Leaves[1]
+	if ok, _ := VerifyMultiProof(tree.MerkleRoot(), mp, sha3.NewLegacyKeccak256); ok {
+		t.Fatal("tampered multiproof must not verify")
+	}
+	mp.ProofFlags = mp.ProofFlags[1:]
+	if _, err := VerifyMultiProof(tree.MerkleRoot(), mp, sha3.NewLegacyKeccak256); err == nil {
+		t.Fatal("expected error for inconsistent multiproof lengths")
+	}
+}
+
+func Test_MultiProofByLeafHashes(t *testing.T) {
+	leaves := testLeaves(8)
+	tree, _ := NewTree(leaves)
+	want, err := tree.GetMultiProof(leaves[2:5])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hashes [][]byte
+	for _, c := range leaves[2:5] {
+		hashBz, _ := c.CalculateHash()
+		hashes = append(hashes, hashBz)
+	}
+	mp, err := tree.GetMultiProofByLeafHashes(hashes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyMultiProof(tree.MerkleRoot(), mp, sha3.NewLegacyKeccak256); err != nil || !ok {
+		t.Fatal("multiproof does not verify")
+	}
+	if len(mp.Proof) != len(want.Proof) || len(mp.ProofFlags) != len(want.ProofFlags) {
+		t.Fatal("expected the proof of the same leaves by content")
+	}
+	if _, err := tree.GetMultiProofByLeafHashes([][]byte{hashes[0], []byte("missing")}); err != ErrContentNotFound {
+		t.Fatalf("expected ErrContentNotFound, got %v", err)
+	}
+}
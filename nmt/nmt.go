@@ -0,0 +1,234 @@
+// Package nmt implements the Namespaced Merkle Tree of Celestia. Leaves are
+// namespaced data, pushed in the order of their namespaces, and every node is
+// prefixed with the smallest and largest namespace below it. A proof for a
+// namespace then shows every leaf of the namespace, or that it has none, and a
+// verifier can tell from the namespaces of the other nodes of the proof that no
+// leaf of the namespace was left out.
+//
+// Hashing, tree shape and proofs follow github.com/celestiaorg/nmt with sha256: a
+// leaf is ns || ns || sha256(0x00 || ns || data), a node
+// min || max || sha256(0x01 || left || right), and the tree splits its leaves at
+// the largest power of two below their number, as RFC 6962 does.
+package nmt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/smartbch/merkletree"
+)
+
+const (
+	leafPrefix = 0
+	nodePrefix = 1
+)
+
+// Option configures a Tree.
+type Option func(*Tree)
+
+// IgnoreMaxNamespace leaves the largest namespace, all 0xff bytes, out of the
+// largest namespace of the nodes above it unless their leaves all have it.
+// Celestia gives it to parity data so that namespace proofs do not have to step
+// over it. It is on by default, as in the nmt package of Celestia.
+func IgnoreMaxNamespace(ignore bool) Option {
+	return func(t *Tree) {
+		t.hasher.ignoreMax = ignore
+	}
+}
+
+// Tree is a Namespaced Merkle Tree held in memory.
+type Tree struct {
+	hasher     hasher
+	leaves     [][]byte
+	leafHashes [][]byte
+	root       []byte
+}
+
+// New returns an empty tree with namespaces of nsSize bytes.
+func New(nsSize int, opts ...Option) *Tree {
+	t := &Tree{hasher: hasher{nsSize: nsSize, ignoreMax: true}}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Push appends data, which starts with its namespace. Namespaces must be pushed
+// in ascending order.
+func (t *Tree) Push(data []byte) error {
+	size := t.hasher.nsSize
+	if len(data) < size {
+		return fmt.Errorf("error: leaf of %d bytes shorter than its namespace", len(data))
+	}
+	if n := len(t.leaves); n > 0 && bytes.Compare(data[:size], t.leaves[n-1][:size]) < 0 {
+		return errors.New("error: leaves must be pushed in namespace order")
+	}
+	t.leaves = append(t.leaves, append([]byte(nil), data...))
+	t.leafHashes = append(t.leafHashes, t.hasher.hashLeaf(data))
+	t.root = nil
+	return nil
+}
+
+// Root returns the root of the tree, min || max || hash.
+func (t *Tree) Root() []byte {
+	if t.root == nil {
+		if len(t.leafHashes) == 0 {
+			t.root = t.hasher.emptyRoot()
+		} else {
+			t.root = t.computeRoot(0, len(t.leafHashes))
+		}
+	}
+	return t.root
+}
+
+func (t *Tree) computeRoot(start, end int) []byte {
+	if end-start == 1 {
+		return t.leafHashes[start]
+	}
+	k := merkletree.SplitPoint(end - start)
+	return t.hasher.hashNode(t.computeRoot(start, start+k), t.computeRoot(start+k, end))
+}
+
+// LeafCount returns the number of leaves of the tree.
+func (t *Tree) LeafCount() int {
+	return len(t.leaves)
+}
+
+// ProveRange returns the proof of the leaves from start up to end.
+func (t *Tree) ProveRange(start, end int) (*Proof, error) {
+	if start < 0 || start >= end || end > len(t.leaves) {
+		return nil, fmt.Errorf("error: invalid range [%d, %d) for %d leaves", start, end, len(t.leaves))
+	}
+	return &Proof{
+		Start:              start,
+		End:                end,
+		Nodes:              t.rangeNodes(start, end),
+		IgnoreMaxNamespace: t.hasher.ignoreMax,
+	}, nil
+}
+
+// ProveNamespace returns the proof of the leaves of ns. If there are none the
+// proof shows the leaf with the next namespace instead, or nothing if ns lies
+// outside of the namespaces of the tree, which the root shows by itself.
+func (t *Tree) ProveNamespace(ns []byte) (*Proof, error) {
+	size := t.hasher.nsSize
+	if len(ns) != size {
+		return nil, fmt.Errorf("error: namespace of %d bytes, want %d", len(ns), size)
+	}
+	start := sort.Search(len(t.leaves), func(i int) bool {
+		return bytes.Compare(t.leaves[i][:size], ns) >= 0
+	})
+	end := sort.Search(len(t.leaves), func(i int) bool {
+		return bytes.Compare(t.leaves[i][:size], ns) > 0
+	})
+	if start < end {
+		return t.ProveRange(start, end)
+	}
+	if start == 0 || start == len(t.leaves) {
+		return &Proof{IgnoreMaxNamespace: t.hasher.ignoreMax}, nil
+	}
+	return &Proof{
+		Start:              start,
+		End:                start + 1,
+		Nodes:              t.rangeNodes(start, start+1),
+		LeafHash:           t.leafHashes[start],
+		IgnoreMaxNamespace: t.hasher.ignoreMax,
+	}, nil
+}
+
+// rangeNodes returns the roots of the subtrees next to the leaves from start up
+// to end, in the order they appear from left to right in the tree.
+func (t *Tree) rangeNodes(proofStart, proofEnd int) [][]byte {
+	var nodes [][]byte
+	var walk func(start, end int, include bool) []byte
+	walk = func(start, end int, include bool) []byte {
+		if start >= len(t.leafHashes) {
+			return nil
+		}
+		// the subtree is collected whole if it lies outside of the range
+		includeChildren := include && end > proofStart && start < proofEnd
+		if end-start == 1 {
+			if include && !includeChildren {
+				nodes = append(nodes, t.leafHashes[start])
+			}
+			return t.leafHashes[start]
+		}
+		k := merkletree.SplitPoint(end - start)
+		left := walk(start, start+k, includeChildren)
+		right := walk(start+k, end, includeChildren)
+		h := left
+		if right != nil {
+			h = t.hasher.hashNode(left, right)
+		}
+		if include && !includeChildren {
+			nodes = append(nodes, h)
+		}
+		return h
+	}
+	walk(0, fullSize(len(t.leafHashes)), true)
+	return nodes
+}
+
+// fullSize returns the number of leaves of the smallest perfect tree holding n
+// leaves.
+func fullSize(n int) int {
+	if n <= 1 {
+		return 1
+	}
+	return merkletree.SplitPoint(n) * 2
+}
+
+// hasher hashes the leaves and nodes of a tree.
+type hasher struct {
+	nsSize    int
+	ignoreMax bool
+}
+
+func (h hasher) hashLeaf(data []byte) []byte {
+	ns := data[:h.nsSize]
+	sum := sha256.Sum256(append([]byte{leafPrefix}, data...))
+	return append(append(append(make([]byte, 0, 2*h.nsSize+sha256.Size), ns...), ns...), sum[:]...)
+}
+
+func (h hasher) hashNode(left, right []byte) []byte {
+	size := h.nsSize
+	minNs, maxNs := left[:size], right[size:2*size]
+	if bytes.Compare(right[:size], minNs) < 0 {
+		minNs = right[:size]
+	}
+	if h.ignoreMax && isMaxNamespace(right[:size]) {
+		maxNs = left[size : 2*size]
+	} else if bytes.Compare(left[size:2*size], maxNs) > 0 {
+		maxNs = left[size : 2*size]
+	}
+
+	data := make([]byte, 0, 1+len(left)+len(right))
+	data = append(append(append(data, nodePrefix), left...), right...)
+	sum := sha256.Sum256(data)
+	return append(append(append(make([]byte, 0, 2*size+sha256.Size), minNs...), maxNs...), sum[:]...)
+}
+
+func (h hasher) emptyRoot() []byte {
+	sum := sha256.Sum256(nil)
+	return append(make([]byte, 2*h.nsSize), sum[:]...)
+}
+
+func (h hasher) minNs(node []byte) []byte {
+	return node[:h.nsSize]
+}
+
+func (h hasher) maxNs(node []byte) []byte {
+	return node[h.nsSize : 2*h.nsSize]
+}
+
+func isMaxNamespace(ns []byte) bool {
+	for _, b := range ns {
+		if b != 0xff {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,109 @@
+package nmt
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+)
+
+// testTree pushes leaves under the namespaces 2, 2, 3, 5, 5, 6, 8, 8, ... with a
+// parity leaf under the largest namespace at the end.
+func testTree(t *testing.T, n int, opts ...Option) (*Tree, [][]byte) {
+	tree := New(1, opts...)
+	var leaves [][]byte
+	for i := 0; i < n; i++ {
+		ns := byte(2 + i/3*3 + i%3/2)
+		if i == n-1 {
+			ns = 0xff
+		}
+		leaf := append([]byte{ns}, fmt.Sprintf("data-%d", i)...)
+		if err := tree.Push(leaf); err != nil {
+			t.Fatal(err)
+		}
+		leaves = append(leaves, leaf)
+	}
+	return tree, leaves
+}
+
+func Test_ProveRange(t *testing.T) {
+	for n := 1; n <= 12; n++ {
+		tree, leaves := testTree(t, n)
+		root := tree.Root()
+		for start := 0; start < n; start++ {
+			for end := start + 1; end <= n; end++ {
+				p, err := tree.ProveRange(start, end)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ns := leaves[start][:1]
+				var data [][]byte
+				same := true
+				for _, leaf := range leaves[start:end] {
+					same = same && leaf[0] == ns[0]
+					data = append(data, leaf[1:])
+				}
+				if ok := p.VerifyInclusion(ns, data, root); ok != same {
+					t.Fatalf("range [%d, %d) of %d leaves: expected %v", start, end, n, same)
+				}
+			}
+		}
+		if _, err := tree.ProveRange(0, n+1); err == nil {
+			t.Fatal("expected error for a range past the leaves")
+		}
+	}
+}
+
+func Test_ProveNamespace(t *testing.T) {
+	for _, ignore := range []bool{true, false} {
+		for n := 1; n <= 12; n++ {
+			tree, leaves := testTree(t, n, IgnoreMaxNamespace(ignore))
+			root := tree.Root()
+			for ns := 0; ns <= 0xff; ns++ {
+				var want [][]byte
+				for _, leaf := range leaves {
+					if int(leaf[0]) == ns {
+						want = append(want, leaf)
+					}
+				}
+				p, err := tree.ProveNamespace([]byte{byte(ns)})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !p.VerifyNamespace([]byte{byte(ns)}, want, root) {
+					t.Fatalf("namespace %d of %d leaves does not verify", ns, n)
+				}
+				if len(want) > 0 {
+					if p.VerifyNamespace([]byte{byte(ns)}, want[1:], root) {
+						t.Fatal("expected a missing leaf to fail")
+					}
+				} else if p.VerifyNamespace([]byte{byte(ns)}, [][]byte{{byte(ns)}}, root) {
+					t.Fatal("expected a made up leaf to fail")
+				}
+			}
+		}
+	}
+}
+
+func Test_Completeness(t *testing.T) {
+	tree, leaves := testTree(t, 9)
+	root := tree.Root()
+	// leaves 3 and 4 have namespace 5; a proof of leaf 4 alone is not complete
+	p, err := tree.ProveRange(4, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.VerifyInclusion([]byte{5}, [][]byte{leaves[4][1:]}, root) {
+		t.Fatal("expected the inclusion proof to verify")
+	}
+	if p.VerifyNamespace([]byte{5}, leaves[4:5], root) {
+		t.Fatal("expected an incomplete namespace proof to fail")
+	}
+
+	if err := tree.Push([]byte{1}); err == nil {
+		t.Fatal("expected error pushing a namespace out of order")
+	}
+	empty := New(1)
+	if !bytes.Equal(empty.Root(), empty.hasher.emptyRoot()) {
+		t.Fatal("expected the empty root")
+	}
+}
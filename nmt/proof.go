@@ -0,0 +1,136 @@
+package nmt
+
+import (
+	"bytes"
+	"crypto/sha256"
+
+	"github.com/smartbch/merkletree"
+)
+
+// Proof shows the leaves from Start up to End. Nodes holds the roots of the
+// subtrees around them from left to right. A proof of absence shows the single
+// leaf with the namespace following the one asked for, whose hash is LeafHash,
+// and an empty proof, without range nor nodes, claims a namespace outside of the
+// namespaces of the root.
+type Proof struct {
+	Start              int
+	End                int
+	Nodes              [][]byte
+	LeafHash           []byte
+	IgnoreMaxNamespace bool
+}
+
+// IsOfAbsence reports whether the proof shows that a namespace has no leaves.
+func (p *Proof) IsOfAbsence() bool {
+	return p.LeafHash != nil
+}
+
+// IsEmpty reports whether the proof holds neither range nor nodes.
+func (p *Proof) IsEmpty() bool {
+	return p.Start == p.End && len(p.Nodes) == 0
+}
+
+// VerifyNamespace checks that leaves, with their namespace prefix, are all the
+// leaves of ns in the tree with the given root. leaves is empty for a proof that
+// the tree has no leaf of ns.
+func (p *Proof) VerifyNamespace(ns []byte, leaves [][]byte, root []byte) bool {
+	h := hasher{nsSize: len(ns), ignoreMax: p.IgnoreMaxNamespace}
+	if len(root) != 2*h.nsSize+sha256.Size {
+		return false
+	}
+	if p.IsEmpty() {
+		return len(leaves) == 0 &&
+			(bytes.Compare(ns, h.minNs(root)) < 0 || bytes.Compare(ns, h.maxNs(root)) > 0)
+	}
+
+	var hashes [][]byte
+	if p.IsOfAbsence() {
+		if len(leaves) != 0 || p.End-p.Start != 1 || len(p.LeafHash) != len(root) ||
+			bytes.Compare(h.minNs(p.LeafHash), ns) <= 0 {
+			return false
+		}
+		hashes = [][]byte{p.LeafHash}
+	} else {
+		for _, leaf := range leaves {
+			if len(leaf) < h.nsSize || !bytes.Equal(leaf[:h.nsSize], ns) {
+				return false
+			}
+			hashes = append(hashes, h.hashLeaf(leaf))
+		}
+	}
+	return p.verifyLeafHashes(h, ns, hashes, root)
+}
+
+// VerifyInclusion checks that data, without namespace prefix, are the leaves of
+// ns from Start up to End in the tree with the given root. Unlike VerifyNamespace
+// it does not check that the range holds every leaf of ns.
+func (p *Proof) VerifyInclusion(ns []byte, data [][]byte, root []byte) bool {
+	h := hasher{nsSize: len(ns), ignoreMax: p.IgnoreMaxNamespace}
+	if p.IsOfAbsence() || len(root) != 2*h.nsSize+sha256.Size {
+		return false
+	}
+	hashes := make([][]byte, len(data))
+	for i, d := range data {
+		hashes[i] = h.hashLeaf(append(append([]byte(nil), ns...), d...))
+	}
+	return p.verifyLeafHashes(h, nil, hashes, root)
+}
+
+// verifyLeafHashes recomputes the root from the hashes of the leaves of the range
+// and the nodes of the proof. With ns set it also checks that the nodes left of
+// the range end before ns and the nodes right of it start after ns.
+func (p *Proof) verifyLeafHashes(h hasher, ns []byte, hashes [][]byte, root []byte) bool {
+	if p.Start < 0 || p.Start >= p.End || len(hashes) != p.End-p.Start {
+		return false
+	}
+	size := 2*h.nsSize + sha256.Size
+	nodes, ok := p.Nodes, true
+	pop := func(right bool) []byte {
+		if len(nodes) == 0 {
+			return nil
+		}
+		n := nodes[0]
+		nodes = nodes[1:]
+		switch {
+		case len(n) != size:
+			ok = false
+		case ns != nil && !right && bytes.Compare(h.maxNs(n), ns) >= 0:
+			ok = false
+		case ns != nil && right && bytes.Compare(h.minNs(n), ns) <= 0:
+			ok = false
+		}
+		return n
+	}
+	hashNode := func(left, right []byte) []byte {
+		if left == nil || bytes.Compare(h.maxNs(left), h.minNs(right)) > 0 {
+			ok = false
+			return right
+		}
+		return h.hashNode(left, right)
+	}
+
+	var compute func(start, end int) []byte
+	compute = func(start, end int) []byte {
+		switch {
+		case end <= p.Start:
+			return pop(false)
+		case start >= p.End:
+			return pop(true)
+		case end-start == 1:
+			leaf := hashes[0]
+			hashes = hashes[1:]
+			return leaf
+		}
+		k := merkletree.SplitPoint(end - start)
+		left, right := compute(start, start+k), compute(start+k, end)
+		if right == nil {
+			return left
+		}
+		return hashNode(left, right)
+	}
+	computed := compute(0, fullSize(p.End))
+	for len(nodes) > 0 {
+		computed = hashNode(computed, pop(true))
+	}
+	return ok && bytes.Equal(computed, root)
+}
@@ -0,0 +1,373 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// ErrNodeNotFound is returned by a NodeStore for a hash it does not hold.
+var ErrNodeNotFound = errors.New("error: node not found in store")
+
+// NodeStore holds the nodes of trees keyed by their hash, in the layout written
+// by Persist. Nodes are content-addressed, so trees sharing subtrees share their
+// nodes in the store. Implementations must be safe for concurrent use; see the
+// leveldbstore and badgerstore packages for persistent ones.
+type NodeStore interface {
+	// Get returns the value stored for hash, or ErrNodeNotFound.
+	Get(hash []byte) ([]byte, error)
+	// Put stores value for hash. It must not keep value.
+	Put(hash, value []byte) error
+	// Delete removes hash. Deleting a missing hash is not an error.
+	Delete(hash []byte) error
+}
+
+// MemNodeStore is a NodeStore in memory.
+type MemNodeStore struct {
+	mu    sync.RWMutex
+	nodes map[string][]byte
+}
+
+// NewMemNodeStore returns an empty store in memory.
+func NewMemNodeStore() *MemNodeStore {
+	return &MemNodeStore{nodes: make(map[string][]byte)}
+}
+
+// Get implements NodeStore.
+func (s *MemNodeStore) Get(hash []byte) ([]byte, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	value, ok := s.nodes[string(hash)]
+	if !ok {
+		return nil, ErrNodeNotFound
+	}
+	return value, nil
+}
+
+// Put implements NodeStore.
+func (s *MemNodeStore) Put(hash, value []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nodes[string(hash)] = append([]byte(nil), value...)
+	return nil
+}
+
+// Delete implements NodeStore.
+func (s *MemNodeStore) Delete(hash []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.nodes, string(hash))
+	return nil
+}
+
+// Len returns the number of records in the store.
+func (s *MemNodeStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.nodes)
+}
+
+// leafCountKey is the key of the record holding the leaf count of the tree with
+// root, which StoredTree needs to know the shape of the tree.
+func leafCountKey(root []byte) []byte {
+	return append([]byte("merkletree/leafcount/"), root...)
+}
+
+// PersistTo writes every node of the tree to s, see Persist, along with its leaf
+// count, so that OpenStoredTree can serve the tree from s.
+func (m *MerkleTree) PersistTo(s NodeStore) error {
+	if err := m.Persist(s.Put); err != nil {
+		return err
+	}
+	return s.Put(leafCountKey(m.merkleRoot), binary.AppendUvarint(nil, uint64(len(m.Leafs))))
+}
+
+// DeleteFromStore removes the tree with root from s. Its nodes shared with the
+// trees whose roots are in keep stay in s; finding them reads every node of the
+// kept trees.
+func DeleteFromStore(s NodeStore, root []byte, keep ...[]byte) error {
+	kept := make(map[string]bool)
+	for _, k := range keep {
+		if err := walkStore(s, k, func(hash []byte) bool {
+			kept[string(hash)] = true
+			return true
+		}); err != nil {
+			return err
+		}
+	}
+
+	var doomed [][]byte
+	if err := walkStore(s, root, func(hash []byte) bool {
+		if kept[string(hash)] {
+			// shared subtrees are kept entirely
+			return false
+		}
+		doomed = append(doomed, hash)
+		return true
+	}); err != nil {
+		return err
+	}
+	for _, hash := range doomed {
+		if err := s.Delete(hash); err != nil {
+			return err
+		}
+	}
+	return s.Delete(leafCountKey(root))
+}
+
+// walkStore calls visit with the hash of every stored node under root, parents
+// first, and descends into the children of the nodes it returns true for.
+func walkStore(s NodeStore, root []byte, visit func(hash []byte) bool) error {
+	value, err := s.Get(root)
+	if err != nil {
+		return fmt.Errorf("error: node %x: %w", root, err)
+	}
+	if !visit(root) || bytes.Equal(value, storeLeafMarker) {
+		return nil
+	}
+	if len(value) == 0 || len(value)%2 != 0 {
+		return fmt.Errorf("error: malformed store value for node %x", root)
+	}
+	half := len(value) / 2
+	left, right := value[:half], value[half:]
+	if err := walkStore(s, left, visit); err != nil {
+		return err
+	}
+	if bytes.Equal(left, right) {
+		return nil
+	}
+	return walkStore(s, right, visit)
+}
+
+// StoredTree serves a tree persisted by PersistTo straight from its NodeStore,
+// reading only the nodes on the path of each proof, so trees too large for
+// memory can be proven without loading them. It is safe for concurrent use if
+// its store is.
+type StoredTree struct {
+	get       func(hash []byte) ([]byte, error)
+	root      []byte
+	leafCount int
+	// t holds the options of the tree, for its node hashing
+	t *MerkleTree
+	// counts holds the number of nodes on each level, leaves first
+	counts []int
+}
+
+// OpenStoredTree returns the tree with root in s. opts must be those the tree
+// was built with.
+func OpenStoredTree(s NodeStore, root []byte, opts ...Option) (*StoredTree, error) {
+	value, err := s.Get(leafCountKey(root))
+	if err != nil {
+		return nil, fmt.Errorf("error: leaf count of root %x: %w", root, err)
+	}
+	leafCount, n := binary.Uvarint(value)
+	if n <= 0 || leafCount == 0 {
+		return nil, fmt.Errorf("error: malformed leaf count of root %x", root)
+	}
+
+	st := newStoredTree(s.Get, root, int(leafCount), newConfiguredTree(opts))
+	if err := st.checkRoot(); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// newStoredTree returns the view of the tree with root and leafCount leaves whose
+// nodes get reads, configured like t.
+func newStoredTree(get func(hash []byte) ([]byte, error), root []byte, leafCount int, t *MerkleTree) *StoredTree {
+	st := &StoredTree{
+		get:       get,
+		root:      append([]byte(nil), root...),
+		leafCount: leafCount,
+		t:         t,
+	}
+	for count := st.leafCount; ; count = (count + 1) / 2 {
+		st.counts = append(st.counts, count)
+		if count == 1 {
+			break
+		}
+	}
+	return st
+}
+
+// checkRoot reads the root node and checks that it hashes to the root, so that a
+// wrong root fails when the tree is opened rather than on its first read.
+func (st *StoredTree) checkRoot() error {
+	value, err := st.get(st.root)
+	if err != nil {
+		return fmt.Errorf("error: root node %x: %w", st.root, err)
+	}
+	if st.leafCount == 1 {
+		if !bytes.Equal(value, storeLeafMarker) {
+			return fmt.Errorf("error: root %x of a single leaf is not a leaf", st.root)
+		}
+		return nil
+	}
+	if len(value) == 0 || len(value)%2 != 0 {
+		return fmt.Errorf("error: malformed store value for node %x", st.root)
+	}
+	half := len(value) / 2
+	computed, err := st.t.hashPair(len(st.counts)-1, value[:half], value[half:])
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(computed, st.root) {
+		return fmt.Errorf("error: root node %x does not hash to its children", st.root)
+	}
+	return nil
+}
+
+// leafHashes returns the hashes of all the leaves in order, reading every node
+// once, for trees that do not duplicate odd nodes. Nodes below the height of the tree are not descended into, so a store
+// with cycles cannot loop.
+func (st *StoredTree) leafHashes() ([][]byte, error) {
+	hashes := make([][]byte, 0, st.leafCount)
+	var walk func(hashBz []byte, depth int) error
+	walk = func(hashBz []byte, depth int) error {
+		value, err := st.get(hashBz)
+		if err != nil {
+			return fmt.Errorf("error: node %x: %w", hashBz, err)
+		}
+		if bytes.Equal(value, storeLeafMarker) {
+			hashes = append(hashes, hashBz)
+			return nil
+		}
+		if len(value) == 0 || len(value)%2 != 0 {
+			return fmt.Errorf("error: malformed store value for node %x", hashBz)
+		}
+		if depth == len(st.counts)-1 {
+			return fmt.Errorf("error: node %x is below the leaves", hashBz)
+		}
+		half := len(value) / 2
+		left, right := value[:half], value[half:]
+		if err := walk(left, depth+1); err != nil {
+			return err
+		}
+		return walk(right, depth+1)
+	}
+	if err := walk(st.root, 0); err != nil {
+		return nil, err
+	}
+	if len(hashes) != st.leafCount {
+		return nil, fmt.Errorf("error: store holds %d leaves, not %d", len(hashes), st.leafCount)
+	}
+	return hashes, nil
+}
+
+// MerkleRoot returns the root of the tree. The root is the key the tree was
+// opened with and its node is checked once on opening, so nothing is computed or
+// read here and concurrent calls need no guard.
+func (st *StoredTree) MerkleRoot() []byte {
+	return st.root
+}
+
+// LeafCount returns the number of leaves of the tree.
+func (st *StoredTree) LeafCount() int {
+	return st.leafCount
+}
+
+// LeafHash returns the hash of the leaf at position i.
+func (st *StoredTree) LeafHash(i int) ([]byte, error) {
+	leaf, _, _, err := st.descend(i, false)
+	return leaf, err
+}
+
+// GetProofByIndex returns the proof of the leaf at position i, as the tree would
+// return it.
+func (st *StoredTree) GetProofByIndex(i int) (*MerkleProof, error) {
+	leaf, siblings, path, err := st.descend(i, true)
+	if err != nil {
+		return nil, err
+	}
+	proof := &MerkleProof{LeafHash: leaf, Root: st.root, Siblings: [][]byte{}, Path: []int64{}}
+	for j := len(siblings) - 1; j >= 0; j-- {
+		proof.Siblings = append(proof.Siblings, siblings[j])
+		proof.Path = append(proof.Path, path[j])
+	}
+	return proof, nil
+}
+
+// GetProofByLeafHash returns the proof of the leaf whose hash is leafHash. The
+// leaf is found by binary search, which needs leaves sorted by hash.
+func (st *StoredTree) GetProofByLeafHash(leafHash []byte) (*MerkleProof, error) {
+	if st.t.unsortedLeaves {
+		return nil, errors.New("error: lookups by hash need leaves sorted by hash")
+	}
+	var searchErr error
+	i := sort.Search(st.leafCount, func(i int) bool {
+		hashBz, err := st.LeafHash(i)
+		if err != nil {
+			searchErr = err
+			return true
+		}
+		return bytes.Compare(hashBz, leafHash) >= 0
+	})
+	if searchErr != nil {
+		return nil, searchErr
+	}
+	if i == st.leafCount {
+		return nil, ErrContentNotFound
+	}
+	proof, err := st.GetProofByIndex(i)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(proof.LeafHash, leafHash) {
+		return nil, ErrContentNotFound
+	}
+	return proof, nil
+}
+
+// VerifyProof checks proof against the root of the tree with the node hashing
+// of the tree.
+func (st *StoredTree) VerifyProof(proof *MerkleProof) (bool, error) {
+	return st.t.verifyProof(st.root, proof)
+}
+
+// descend walks from the root to the leaf at position i, reading one node per
+// level, and collects the siblings and directions of its merkle path from the
+// root down if withPath is set. Node j of a level has the children 2j and 2j+1
+// on the level below; a node without a right child was promoted, and is stored
+// as that child, unless the tree duplicates odd nodes.
+func (st *StoredTree) descend(i int, withPath bool) ([]byte, [][]byte, []int64, error) {
+	if i < 0 || i >= st.leafCount {
+		return nil, nil, nil, fmt.Errorf("error: leaf index %d out of range [0, %d)", i, st.leafCount)
+	}
+
+	var siblings [][]byte
+	var path []int64
+	hashBz := st.root
+	for level := len(st.counts) - 1; level > 0; level-- {
+		j := i >> uint(level)
+		if 2*j+1 >= st.counts[level-1] && !st.t.duplicateOdd {
+			continue
+		}
+
+		value, err := st.get(hashBz)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("error: node %x: %w", hashBz, err)
+		}
+		if len(value) == 0 || len(value)%2 != 0 {
+			return nil, nil, nil, fmt.Errorf("error: malformed store value for node %x", hashBz)
+		}
+		half := len(value) / 2
+		left, right := value[:half], value[half:]
+		child := left
+		if (i>>uint(level-1))&1 == 1 {
+			child = right
+		}
+		if withPath {
+			// same direction rule as merklePath
+			if bytes.Equal(left, child) {
+				siblings, path = append(siblings, right), append(path, 1)
+			} else {
+				siblings, path = append(siblings, left), append(path, 0)
+			}
+		}
+		hashBz = child
+	}
+	return hashBz, siblings, path, nil
+}
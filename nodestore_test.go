@@ -0,0 +1,108 @@
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_StoredTree(t *testing.T) {
+	layouts := [][]Option{nil, {WithInsertionOrder()}, {WithOddNodePolicy(DuplicateLast)}, {WithRFC6962()}}
+	for _, opts := range layouts {
+		for _, n := range []int{1, 2, 5, 8, 13} {
+			tree, err := NewTreeWithOptions(testLeaves(n), opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			store := NewMemNodeStore()
+			if err := tree.PersistTo(store); err != nil {
+				t.Fatal(err)
+			}
+
+			st, err := OpenStoredTree(store, tree.MerkleRoot(), opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if st.LeafCount() != n || !bytes.Equal(st.MerkleRoot(), tree.MerkleRoot()) {
+				t.Fatalf("%d leaves: unexpected stored tree", n)
+			}
+			for i := 0; i < n; i++ {
+				proof, err := st.GetProofByIndex(i)
+				if err != nil {
+					t.Fatal(err)
+				}
+				want, _ := tree.GetProofByIndex(i)
+				if !bytes.Equal(proof.LeafHash, tree.Leafs[i].Hash) || len(proof.Siblings) != len(want.Siblings) {
+					t.Fatalf("%d leaves: proof of leaf %d differs from the tree", n, i)
+				}
+				for k := range want.Siblings {
+					if !bytes.Equal(proof.Siblings[k], want.Siblings[k]) || proof.Path[k] != want.Path[k] {
+						t.Fatalf("%d leaves: proof of leaf %d differs from the tree", n, i)
+					}
+				}
+				if ok, err := st.VerifyProof(proof); err != nil || !ok {
+					t.Fatalf("%d leaves: proof of leaf %d does not verify", n, i)
+				}
+			}
+			if _, err := st.GetProofByIndex(n); err == nil {
+				t.Fatal("expected error for an index out of range")
+			}
+		}
+	}
+
+	tree, _ := NewTree(testLeaves(11))
+	store := NewMemNodeStore()
+	tree.PersistTo(store)
+	st, _ := OpenStoredTree(store, tree.MerkleRoot())
+	for _, leaf := range tree.Leafs {
+		proof, err := st.GetProofByLeafHash(leaf.Hash)
+		if err != nil || !bytes.Equal(proof.LeafHash, leaf.Hash) {
+			t.Fatal("expected the proof of the leaf found by hash")
+		}
+	}
+	if _, err := st.GetProofByLeafHash([]byte("missing")); err != ErrContentNotFound {
+		t.Fatalf("expected ErrContentNotFound, got %v", err)
+	}
+
+	if _, err := OpenStoredTree(store, []byte("missing")); err == nil {
+		t.Fatal("expected error for an unknown root")
+	}
+}
+
+func Test_DeleteFromStore(t *testing.T) {
+	leaves := testLeaves(9)
+	old, _ := NewTreeWithOptions(leaves[:8], WithInsertionOrder())
+	cur, _ := NewTreeWithOptions(leaves, WithInsertionOrder())
+	store := NewMemNodeStore()
+	old.PersistTo(store)
+	cur.PersistTo(store)
+	records := store.Len()
+
+	if err := DeleteFromStore(store, old.MerkleRoot(), cur.MerkleRoot()); err != nil {
+		t.Fatal(err)
+	}
+	// the first 8 leaves are a subtree of the current tree, so only the leaf
+	// count of the old root goes
+	if store.Len() != records-1 {
+		t.Fatalf("expected the shared nodes to stay, %d of %d left", store.Len(), records)
+	}
+	if _, err := OpenStoredTree(store, old.MerkleRoot()); err == nil {
+		t.Fatal("expected the old root to be gone")
+	}
+
+	st, err := OpenStoredTree(store, cur.MerkleRoot(), WithInsertionOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 9; i++ {
+		if _, err := st.GetProofByIndex(i); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := DeleteFromStore(store, cur.MerkleRoot()); err != nil {
+		t.Fatal(err)
+	}
+	if store.Len() != 0 {
+		t.Fatalf("expected an empty store, %d records left", store.Len())
+	}
+}
@@ -0,0 +1,140 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+)
+
+// NonMembershipProof shows that Target is not a leaf hash of a tree by presenting
+// the two leaves adjacent in sorted order that enclose it. The tree must keep its
+// leaves sorted and combine pairs positionally, see WithSortPairs, so that the
+// proofs of the neighbours commit to their positions. Left is nil when Target
+// sorts before every leaf and Right is nil when it sorts after every leaf.
+// LeftIndex and RightIndex are the positions of the two leaves in Leafs and
+// LeafCount is the number of leaves of the tree.
+type NonMembershipProof struct {
+	Target     []byte
+	Left       *Proof
+	Right      *Proof
+	LeftIndex  uint64
+	RightIndex uint64
+	LeafCount  uint64
+}
+
+// GetNonMembershipProof returns a proof that hashBz is not a leaf hash of the tree.
+// It fails if some leaf has exactly that hash.
+func (m *MerkleTree) GetNonMembershipProof(hashBz []byte) (*NonMembershipProof, error) {
+	if len(m.Leafs) == 0 {
+		return nil, ErrEmptyTree
+	}
+	if !m.sortedPositional() {
+		return nil, errors.New("error: non-membership proofs require sorted leaves and unsorted pairs")
+	}
+
+	i := m.searchLeafs(hashBz)
+	if i < len(m.Leafs) && bytes.Equal(m.Leafs[i].Hash, hashBz) {
+		return nil, errors.New("error: hash is a member of the tree")
+	}
+
+	p := &NonMembershipProof{
+		Target:     hashBz,
+		LeafCount:  uint64(len(m.Leafs)),
+		RightIndex: uint64(i),
+	}
+	if i > 0 {
+		p.Left = m.proofOf(m.Leafs[i-1])
+		p.LeftIndex = uint64(i - 1)
+	}
+	if i < len(m.Leafs) {
+		p.Right = m.proofOf(m.Leafs[i])
+	}
+	return p, nil
+}
+
+// VerifyNonMembershipProof checks that p proves its Target absent from the tree
+// with the given root and leaf count, built with opts. Both neighbours must enclose
+// Target strictly, sit at adjacent positions and verify against root as the
+// leaves at those positions, the way VerifyIndexedProof checks them. Trees that
+// sort their pairs are refused: their proofs do not commit to positions.
+func VerifyNonMembershipProof(p *NonMembershipProof, root []byte, leafCount uint64, opts ...Option) (bool, error) {
+	if p == nil || (p.Left == nil && p.Right == nil) {
+		return false, errors.New("error: non-membership proof has no neighbours")
+	}
+	if !newConfiguredTree(opts).sortedPositional() {
+		return false, errors.New("error: non-membership proofs require sorted leaves and unsorted pairs")
+	}
+	if p.LeafCount != leafCount {
+		return false, nil
+	}
+
+	check := func(proof *Proof, index uint64) (bool, error) {
+		if index >= leafCount {
+			return false, nil
+		}
+		return VerifyIndexedProof(root, int(index), int(leafCount), proof, opts...)
+	}
+
+	switch {
+	case p.Left == nil:
+		if p.RightIndex != 0 || bytes.Compare(p.Target, p.Right.LeafHash) >= 0 {
+			return false, nil
+		}
+		return check(p.Right, p.RightIndex)
+	case p.Right == nil:
+		if p.LeftIndex != leafCount-1 || bytes.Compare(p.Left.LeafHash, p.Target) >= 0 {
+			return false, nil
+		}
+		return check(p.Left, p.LeftIndex)
+	}
+
+	if p.RightIndex != p.LeftIndex+1 {
+		return false, nil
+	}
+	if bytes.Compare(p.Left.LeafHash, p.Target) >= 0 || bytes.Compare(p.Target, p.Right.LeafHash) >= 0 {
+		return false, nil
+	}
+	ok, err := check(p.Left, p.LeftIndex)
+	if err != nil || !ok {
+		return false, err
+	}
+	return check(p.Right, p.RightIndex)
+}
+
+// expectedPath returns the directions GetMerklePath reports for the leaf at index
+// in a tree of count leaves. A node that is last on an odd-sized level is promoted
+// and contributes no direction, unless duplicateOdd pairs it with itself.
+func expectedPath(index, count uint64, duplicateOdd bool) []int64 {
+	path, _ := expectedPathLevels(index, count, duplicateOdd)
+	return path
+}
+
+// expectedPathLevels returns expectedPath along with the level of the pair each
+// direction belongs to.
+func expectedPathLevels(index, count uint64, duplicateOdd bool) ([]int64, []int) {
+	var path []int64
+	var levels []int
+	for level := 1; count > 1; index, count, level = index/2, (count+1)/2, level+1 {
+		switch {
+		case index == count-1 && count%2 == 1 && !duplicateOdd:
+			continue
+		case index%2 == 0:
+			path = append(path, 1)
+		default:
+			path = append(path, 0)
+		}
+		levels = append(levels, level)
+	}
+	return path, levels
+}
+
+func equalPath(a, b []int64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,90 @@
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_NonMembershipProof(t *testing.T) {
+	layouts := [][]Option{
+		{WithSortPairs(false)},
+		{WithSortPairs(false), WithOddNodePolicy(DuplicateLast)},
+		{WithSortPairs(false), WithDomainSeparation(0x00, 0x01)},
+	}
+	for _, opts := range layouts {
+		for _, n := range []int{1, 2, 5, 8, 13} {
+			tree, err := NewTreeWithOptions(testLeaves(n), opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			root, count := tree.MerkleRoot(), uint64(len(tree.Leafs))
+
+			for i, leaf := range tree.Leafs {
+				if !equalPath(expectedPath(uint64(i), count, tree.duplicateOdd), tree.proofOf(leaf).Path) {
+					t.Fatalf("%d leaves: expected path of leaf %d differs", n, i)
+				}
+			}
+
+			// a target just above each leaf hash, plus one below the first leaf
+			targets := [][]byte{make([]byte, 32)}
+			for _, leaf := range tree.Leafs {
+				targets = append(targets, append(append([]byte(nil), leaf.Hash...), 0))
+			}
+			for _, target := range targets {
+				proof, err := tree.GetNonMembershipProof(target)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ok, err := VerifyNonMembershipProof(proof, root, count, opts...)
+				if err != nil || !ok {
+					t.Fatalf("%d leaves: non-membership of %x does not verify", n, target)
+				}
+				if ok, _ := VerifyNonMembershipProof(proof, root, count+1, opts...); ok {
+					t.Fatalf("%d leaves: proof verifies for the wrong leaf count", n)
+				}
+			}
+
+			if _, err := tree.GetNonMembershipProof(tree.Leafs[0].Hash); err == nil {
+				t.Fatalf("%d leaves: expected error for a member", n)
+			}
+		}
+	}
+
+	opts := []Option{WithSortPairs(false)}
+	tree, _ := NewTreeWithOptions(testLeaves(8), opts...)
+	target := append(append([]byte(nil), tree.Leafs[2].Hash...), 0)
+
+	// neighbours that are not adjacent must be rejected
+	proof, _ := tree.GetNonMembershipProof(target)
+	proof.Right, proof.RightIndex = tree.proofOf(tree.Leafs[4]), 4
+	if ok, _ := VerifyNonMembershipProof(proof, tree.MerkleRoot(), 8, opts...); ok {
+		t.Fatal("non-adjacent neighbours verify")
+	}
+
+	// nor may a leaf claim the position of another with made-up directions
+	proof, _ = tree.GetNonMembershipProof(target)
+	proof.Right = tree.proofOf(tree.Leafs[4])
+	proof.Right.Path = expectedPath(3, 8, false)
+	if ok, _ := VerifyNonMembershipProof(proof, tree.MerkleRoot(), 8, opts...); ok {
+		t.Fatal("leaf verifies at the position of another")
+	}
+
+	proof, _ = tree.GetNonMembershipProof(target)
+	proof.Target = bytes.Repeat([]byte{0xff}, 33)
+	if ok, _ := VerifyNonMembershipProof(proof, tree.MerkleRoot(), 8, opts...); ok {
+		t.Fatal("target outside the neighbours verifies")
+	}
+
+	// sorted pairs do not bind positions
+	sorted, _ := NewTree(testLeaves(8))
+	if _, err := sorted.GetNonMembershipProof(target); err == nil {
+		t.Fatal("expected error for a tree with sorted pairs")
+	}
+	proof, _ = tree.GetNonMembershipProof(target)
+	if _, err := VerifyNonMembershipProof(proof, tree.MerkleRoot(), 8); err == nil {
+		t.Fatal("expected error for sorted pairs")
+	}
+	if _, err := VerifyNonMembershipProof(proof, tree.MerkleRoot(), 8, WithInsertionOrder()); err == nil {
+		t.Fatal("expected error for unsorted leaves")
+	}
+}
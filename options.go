@@ -0,0 +1,241 @@
+package merkletree
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"hash"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// Option configures how NewTreeWithOptions builds a tree.
+type Option func(*MerkleTree)
+
+// WithHashStrategy sets the hash function of the tree. The default is keccak256.
+func WithHashStrategy(hashStrategy func() hash.Hash) Option {
+	return func(m *MerkleTree) {
+		m.hashStrategy = hashStrategy
+	}
+}
+
+// WithSortPairs controls whether the two children of a node are hashed in sorted
+// order, which is the default. With sorting disabled a node is H(left || right)
+// and proofs must be checked with their directions, see VerifyPositionalProof.
+// This is the positional format of Bitcoin and RFC 6962 verifiers.
+func WithSortPairs(sortPairs bool) Option {
+	return func(m *MerkleTree) {
+		m.unsortedPairs = !sortPairs
+	}
+}
+
+// WithSortLeaves controls whether the leaves are sorted by hash, which is the
+// default. With sorting disabled the leaves keep the order of the contents.
+func WithSortLeaves(sortLeaves bool) Option {
+	return func(m *MerkleTree) {
+		m.unsortedLeaves = !sortLeaves
+	}
+}
+
+// SortsLeaves reports whether the leaves of the tree are sorted by hash, see
+// WithSortLeaves.
+func (m *MerkleTree) SortsLeaves() bool {
+	return !m.unsortedLeaves
+}
+
+// OddNodePolicy decides what happens to the last node of a level with an odd
+// number of nodes.
+type OddNodePolicy int
+
+const (
+	// PromoteSingle moves the node up to the next level unchanged. This is the
+	// default, and the behaviour of RFC 6962 and merkletreejs.
+	PromoteSingle OddNodePolicy = iota
+	// DuplicateLast pairs the node with itself and hashes it, as Bitcoin does.
+	DuplicateLast
+)
+
+// WithOddNodePolicy sets how the last node of an odd level is handled.
+func WithOddNodePolicy(policy OddNodePolicy) Option {
+	return func(m *MerkleTree) {
+		m.duplicateOdd = policy == DuplicateLast
+	}
+}
+
+// WithDuplicateOdd selects DuplicateLast when duplicateOdd is set and
+// PromoteSingle otherwise, mirroring the merkletreejs option of the same name.
+func WithDuplicateOdd(duplicateOdd bool) Option {
+	return func(m *MerkleTree) {
+		m.duplicateOdd = duplicateOdd
+	}
+}
+
+// DuplicatePolicy decides what happens to leaves with the same leaf hash.
+type DuplicatePolicy int
+
+const (
+	// AllowDuplicates keeps every leaf. This is the default. Sorted leaves with the
+	// same hash end up next to each other, so a duplicate changes the shape of the
+	// tree.
+	AllowDuplicates DuplicatePolicy = iota
+	// ErrorOnDuplicate fails the build with ErrDuplicateLeaf.
+	ErrorOnDuplicate
+	// DedupeDuplicates keeps only the first of the leaves with the same hash.
+	DedupeDuplicates
+)
+
+// WithDuplicatePolicy sets how leaves with the same hash are handled.
+func WithDuplicatePolicy(policy DuplicatePolicy) Option {
+	return func(m *MerkleTree) {
+		m.duplicatePolicy = policy
+	}
+}
+
+// WithHashLeaves controls whether each content hash is hashed once more to get its
+// leaf hash, as merkletreejs does with its hashLeaves option.
+func WithHashLeaves(hashLeaves bool) Option {
+	return func(m *MerkleTree) {
+		m.hashLeaves = hashLeaves
+	}
+}
+
+// WithLeafPrefix makes every leaf hash H(prefix || content hash), so that leaves
+// are hashed in their own domain.
+func WithLeafPrefix(prefix byte) Option {
+	return func(m *MerkleTree) {
+		m.leafPrefix = []byte{prefix}
+	}
+}
+
+// WithNodePrefix makes every internal node hash H(prefix || left || right), so that
+// internal nodes are hashed in their own domain.
+func WithNodePrefix(prefix byte) Option {
+	return func(m *MerkleTree) {
+		m.nodePrefix = []byte{prefix}
+	}
+}
+
+// WithDomainSeparation hashes leaves and internal nodes in distinct domains by
+// prefixing them with leafPrefix and nodePrefix, which must differ. An internal
+// node then cannot be passed off as a leaf, which rules out the second-preimage
+// attack on naive Merkle trees. It changes every root, so it is opt-in; proofs are
+// verified with VerifyProofWithOptions given the same option.
+func WithDomainSeparation(leafPrefix, nodePrefix byte) Option {
+	return func(m *MerkleTree) {
+		WithLeafPrefix(leafPrefix)(m)
+		WithNodePrefix(nodePrefix)(m)
+	}
+}
+
+// WithEmptyRoot lets the tree be built without contents, instead of failing with
+// ErrEmptyTree, and gives it root as its root. Protocols that need a canonical
+// root before the first entry commonly use EmptyStringRoot or ZeroRoot.
+func WithEmptyRoot(root []byte) Option {
+	return func(m *MerkleTree) {
+		m.emptyRoot = append([]byte{}, root...)
+	}
+}
+
+// EmptyStringRoot returns the hash of the empty string, the empty root of RFC 6962
+// among others.
+func EmptyStringRoot(hashStrategy func() hash.Hash) []byte {
+	return hashStrategy().Sum(nil)
+}
+
+// ZeroRoot returns size zero bytes, the empty root used by most on-chain trees.
+func ZeroRoot(size int) []byte {
+	return make([]byte, size)
+}
+
+// WithHMACKey makes every leaf and node hash an HMAC under key with the hash
+// function of the tree: leaves are HMAC(key, content hash) and nodes HMAC(key,
+// left || right). Whoever sees the contents but not the key then cannot compute
+// or forge a root, which suits integrity trees kept by a single party. Proofs
+// verify with VerifyProofWithOptions given the same option, and so only by
+// holders of the key. It cannot be combined with WithPairHasher.
+func WithHMACKey(key []byte) Option {
+	return func(m *MerkleTree) {
+		m.hmacKey = append([]byte{}, key...)
+	}
+}
+
+// WithLevelTags mixes tag(level) into the hash of every internal node, after the
+// node prefix and before the children: H(prefix || tag(level) || left || right).
+// Level 1 holds the parents of the leaves and each level above adds one; a
+// promoted node keeps the hash it got on its own level. Binding the height into
+// nodes keeps a node of one level from standing in for a node of another.
+//
+// With promoted odd nodes the level of each step of a proof depends on the leaf
+// position, so proofs verify with VerifyIndexedProof; with DuplicateLast they also
+// verify with VerifyProofWithOptions. Consistency proofs are not supported.
+func WithLevelTags(tag func(level int) []byte) Option {
+	return func(m *MerkleTree) {
+		m.levelTag = tag
+	}
+}
+
+// WithLevelNumbers tags every internal node with its level as a single byte, see
+// WithLevelTags.
+func WithLevelNumbers() Option {
+	return WithLevelTags(func(level int) []byte {
+		return []byte{byte(level)}
+	})
+}
+
+// WithLeafHashSizeCheck makes the build fail with a *LeafHashSizeError for any leaf
+// hash whose size differs from that of the hash function of the tree. Shorter or
+// mixed-size leaves, such as a Content returning a 20-byte address, otherwise
+// build a tree whose proofs do not fit verifiers expecting bytes32.
+func WithLeafHashSizeCheck() Option {
+	return func(m *MerkleTree) {
+		m.checkLeafSize = true
+	}
+}
+
+// NewTreeWithOptions builds a tree from cs configured by opts.
+func NewTreeWithOptions(cs []Content, opts ...Option) (*MerkleTree, error) {
+	return NewTreeCtx(context.Background(), cs, opts...)
+}
+
+// NewTreeCtx is NewTreeWithOptions stopping with the error of ctx once ctx is
+// done, so that long builds can be cancelled or bounded by a deadline.
+func NewTreeCtx(ctx context.Context, cs []Content, opts ...Option) (*MerkleTree, error) {
+	t := newConfiguredTree(opts)
+	if err := t.checkOptions(); err != nil {
+		return nil, err
+	}
+	if err := t.build(ctx, cs); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// checkOptions rejects option combinations a tree cannot be built with.
+func (m *MerkleTree) checkOptions() error {
+	if m.leafPrefix != nil && bytes.Equal(m.leafPrefix, m.nodePrefix) {
+		return errors.New("error: leaf and node prefixes must differ")
+	}
+	if m.hmacKey != nil && len(m.hmacKey) == 0 {
+		return errors.New("error: empty HMAC key")
+	}
+	if m.pairHasher != nil && (m.nodePrefix != nil || m.levelTag != nil) {
+		return errors.New("error: a pair hasher cannot be combined with a node prefix or level tags")
+	}
+	if m.pairHasher != nil && (m.hmacKey != nil || m.leafPrefix != nil || m.hashLeaves) {
+		// the nodes would not be keyed, and the leaves not field elements
+		return errors.New("error: a pair hasher cannot be combined with an HMAC key, a leaf prefix or leaf hashing")
+	}
+	return nil
+}
+
+// newConfiguredTree returns an empty tree with opts applied, which also serves to
+// hash nodes the way a tree built with the same options would.
+func newConfiguredTree(opts []Option) *MerkleTree {
+	t := &MerkleTree{
+		hashStrategy: sha3.NewLegacyKeccak256,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
@@ -0,0 +1,411 @@
+package merkletree
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"testing"
+
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/sha3"
+)
+
+func Test_WithSortPairs(t *testing.T) {
+	leaves := testLeaves(4)
+	tree, err := NewTreeWithOptions(leaves, WithSortPairs(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	l := tree.Leafs
+	want := gethcrypto.Keccak256(
+		gethcrypto.Keccak256(l[0].Hash, l[1].Hash),
+		gethcrypto.Keccak256(l[2].Hash, l[3].Hash),
+	)
+	if !bytes.Equal(tree.MerkleRoot(), want) {
+		t.Fatal("unexpected positional root")
+	}
+
+	if ok, err := tree.VerifyTree(); err != nil || !ok {
+		t.Fatal("positional tree does not verify")
+	}
+	for _, n := range []int{1, 2, 5, 8, 11} {
+		leaves := testLeaves(n)
+		tree, err := NewTreeWithOptions(leaves, WithSortPairs(false))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, leaf := range leaves {
+			if ok, err := tree.VerifyContent(leaf); err != nil || !ok {
+				t.Fatalf("%d leaves: content does not verify", n)
+			}
+			proof, err := tree.GetProof(leaf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := proof.VerifyPositional(sha3.NewLegacyKeccak256); err != nil || !ok {
+				t.Fatalf("%d leaves: positional proof does not verify", n)
+			}
+		}
+	}
+
+	if _, _, _, err := tree.GetSolidityProof(leaves[0]); err == nil {
+		t.Fatal("expected error for a solidity proof of a positional tree")
+	}
+	sorted, _ := NewTree(leaves)
+	if _, err := MergeRoots(tree, sorted); err == nil {
+		t.Fatal("expected error when merging trees with different pair ordering")
+	}
+}
+
+func Test_NewTreeWithOptions(t *testing.T) {
+	leaves := testLeaves(7)
+
+	plain, _ := NewTree(leaves)
+	tree, err := NewTreeWithOptions(leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(tree.MerkleRoot(), plain.MerkleRoot()) {
+		t.Fatal("default options should match NewTree")
+	}
+
+	tree, _ = NewTreeWithOptions(leaves, WithSortLeaves(false))
+	for i, leaf := range tree.Leafs {
+		if ok, _ := leaf.C.Equals(leaves[i]); !ok {
+			t.Fatal("leaves should keep their order")
+		}
+	}
+
+	// the individual options combine into the same tree as the merkletreejs preset
+	tree, _ = NewTreeWithOptions(leaves, WithSortLeaves(false), WithSortPairs(false), WithDuplicateOdd(true), WithHashLeaves(true))
+	js, _ := NewTreeWithOptions(leaves, WithMerkleTreeJSOptions(MerkleTreeJSOptions{DuplicateOdd: true, HashLeaves: true}))
+	if !bytes.Equal(tree.MerkleRoot(), js.MerkleRoot()) {
+		t.Fatal("individual options differ from the merkletreejs preset")
+	}
+
+	tree, _ = NewTreeWithOptions(leaves, WithLeafPrefix(0x00))
+	for _, leaf := range tree.Leafs {
+		contentHash, _ := leaf.C.CalculateHash()
+		if !bytes.Equal(leaf.Hash, gethcrypto.Keccak256([]byte{0x00}, contentHash)) {
+			t.Fatal("leaf hash is not H(prefix || content hash)")
+		}
+	}
+	if ok, err := tree.VerifyTree(); err != nil || !ok {
+		t.Fatal("prefixed tree does not verify")
+	}
+	if ok, err := tree.VerifyContent(leaves[3]); err != nil || !ok {
+		t.Fatal("prefixed content does not verify")
+	}
+}
+
+func Test_WithOddNodePolicy(t *testing.T) {
+	leaves := testLeaves(3)
+	tree, err := NewTreeWithOptions(leaves, WithInsertionOrder(), WithOddNodePolicy(DuplicateLast))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h0, _ := leaves[0].CalculateHash()
+	h1, _ := leaves[1].CalculateHash()
+	h2, _ := leaves[2].CalculateHash()
+	want := gethcrypto.Keccak256(gethcrypto.Keccak256(h0, h1), gethcrypto.Keccak256(h2, h2))
+	if !bytes.Equal(tree.MerkleRoot(), want) {
+		t.Fatal("last node was not duplicated")
+	}
+
+	promoted, _ := NewTreeWithOptions(leaves, WithInsertionOrder(), WithOddNodePolicy(PromoteSingle))
+	if !bytes.Equal(promoted.MerkleRoot(), gethcrypto.Keccak256(gethcrypto.Keccak256(h0, h1), h2)) {
+		t.Fatal("last node was not promoted")
+	}
+
+	// sorted trees support both policies too
+	for _, policy := range []OddNodePolicy{PromoteSingle, DuplicateLast} {
+		tree, _ := NewTreeWithOptions(testLeaves(7), WithOddNodePolicy(policy))
+		for _, leaf := range testLeaves(7) {
+			proof, err := tree.GetProof(leaf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := proof.Verify(); err != nil || !ok {
+				t.Fatalf("policy %d: proof does not verify", policy)
+			}
+		}
+	}
+}
+
+func Test_WithDomainSeparation(t *testing.T) {
+	leaves := testLeaves(5)
+	plain, _ := NewTree(leaves)
+	tree, err := NewTreeWithOptions(leaves, WithDomainSeparation(0x00, 0x01))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(plain.MerkleRoot(), tree.MerkleRoot()) {
+		t.Fatal("domain separation did not change the root")
+	}
+
+	for _, leaf := range leaves {
+		proof, err := tree.GetProof(leaf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := VerifyProofWithOptions(tree.MerkleRoot(), proof, WithDomainSeparation(0x00, 0x01)); err != nil || !ok {
+			t.Fatal("domain-separated proof does not verify")
+		}
+		if ok, _ := VerifyProofWithOptions(tree.MerkleRoot(), proof); ok {
+			t.Fatal("proof verified without the prefixes")
+		}
+	}
+
+	// a leaf whose hash is that of an internal node, with the rest of its proof,
+	// folds to the root of the plain tree but not of the separated one
+	for _, tr := range []*MerkleTree{plain, tree} {
+		proof, _ := tr.GetProof(tr.Leafs[0].C)
+		forgedHash, _ := tr.leafHash(hashLeaf(tr.Leafs[0].Parent.Hash))
+		forged := &MerkleProof{LeafHash: forgedHash, Siblings: proof.Siblings[1:]}
+		ok, _ := VerifyProofWithOptions(tr.MerkleRoot(), forged, WithDomainSeparation(0x00, 0x01))
+		if tr == tree && ok {
+			t.Fatal("internal node verified as a leaf")
+		}
+		if ok, _ := VerifyProofWithOptions(tr.MerkleRoot(), forged); tr == plain && !ok {
+			t.Fatal("internal node of the plain tree does not fold to its root")
+		}
+	}
+
+	// the proof of a single leaf has neither siblings nor directions
+	for _, opts := range [][]Option{{WithInsertionOrder()}, {WithInsertionOrder(), WithDomainSeparation(0x00, 0x01)}} {
+		single, _ := NewTreeWithOptions(leaves[:1], opts...)
+		proof, err := single.GetProof(leaves[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := VerifyProofWithOptions(single.MerkleRoot(), proof, opts...); err != nil || !ok {
+			t.Fatal("proof of a single leaf does not verify")
+		}
+	}
+
+	if _, err := NewTreeWithOptions(leaves, WithDomainSeparation(0x01, 0x01)); err == nil {
+		t.Fatal("expected error for equal prefixes")
+	}
+}
+
+func Test_WithEmptyRoot(t *testing.T) {
+	if _, err := NewTreeWithOptions(nil); err != ErrEmptyTree {
+		t.Fatalf("got %v, want ErrEmptyTree", err)
+	}
+
+	tree, err := NewTreeWithOptions(nil, WithEmptyRoot(ZeroRoot(32)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(tree.MerkleRoot(), make([]byte, 32)) {
+		t.Fatal("unexpected empty root")
+	}
+	if ok, err := tree.VerifyTree(); err != nil || !ok {
+		t.Fatal("empty tree does not verify")
+	}
+	if _, err := tree.GetProof(testLeaves(1)[0]); err != ErrEmptyTree {
+		t.Fatalf("got %v, want ErrEmptyTree", err)
+	}
+
+	// the tree leaves the empty root once it has contents, and returns to it
+	if err := tree.RebuildTreeWith(testLeaves(3)); err != nil {
+		t.Fatal(err)
+	}
+	full, _ := NewTree(testLeaves(3))
+	if !bytes.Equal(tree.MerkleRoot(), full.MerkleRoot()) {
+		t.Fatal("rebuilt tree has the wrong root")
+	}
+	if err := tree.RebuildTreeWith(nil); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(tree.MerkleRoot(), ZeroRoot(32)) || len(tree.Leafs) != 0 {
+		t.Fatal("tree was not emptied")
+	}
+
+	rfc, err := NewTreeWithOptions(nil, WithRFC6962(), WithEmptyRoot(EmptyStringRoot(sha256.New)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(rfc.MerkleRoot(), RFC6962EmptyRoot(sha256.New)) {
+		t.Fatal("unexpected RFC 6962 empty root")
+	}
+}
+
+func Test_WithDuplicatePolicy(t *testing.T) {
+	leaves := append(testLeaves(4), testLeaves(2)...)
+
+	allowed, err := NewTreeWithOptions(leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(allowed.Leafs) != 6 {
+		t.Fatalf("%d leaves, want 6", len(allowed.Leafs))
+	}
+
+	if _, err := NewTreeWithOptions(leaves, WithDuplicatePolicy(ErrorOnDuplicate)); err != ErrDuplicateLeaf {
+		t.Fatalf("got %v, want ErrDuplicateLeaf", err)
+	}
+	if _, err := NewTreeWithOptions(testLeaves(4), WithDuplicatePolicy(ErrorOnDuplicate)); err != nil {
+		t.Fatal(err)
+	}
+
+	deduped, err := NewTreeWithOptions(leaves, WithDuplicatePolicy(DedupeDuplicates))
+	if err != nil {
+		t.Fatal(err)
+	}
+	unique, _ := NewTree(testLeaves(4))
+	if !bytes.Equal(deduped.MerkleRoot(), unique.MerkleRoot()) {
+		t.Fatal("deduplicated tree differs from the tree of unique leaves")
+	}
+}
+
+func Test_NewTreeCtx(t *testing.T) {
+	leaves := testLeaves(3000)
+	tree, err := NewTreeCtx(context.Background(), leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := tree.MerkleRoot()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := NewTreeCtx(ctx, leaves); err != context.Canceled {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if err := tree.RebuildTreeWithCtx(ctx, testLeaves(5)); err != context.Canceled {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if err := tree.RebuildTreeCtx(ctx); err != context.Canceled {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if !bytes.Equal(tree.MerkleRoot(), root) || len(tree.Leafs) != len(leaves) {
+		t.Fatal("cancelled rebuild changed the tree")
+	}
+}
+
+func Test_WithHMACKey(t *testing.T) {
+	leaves := testLeaves(5)
+	key := []byte("tree key")
+	tree, err := NewTreeWithOptions(leaves, WithHMACKey(key), WithInsertionOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mac := func(data ...[]byte) []byte {
+		h := hmac.New(sha3.NewLegacyKeccak256, key)
+		for _, d := range data {
+			h.Write(d)
+		}
+		return h.Sum(nil)
+	}
+	var l [5][]byte
+	for i, leaf := range leaves {
+		contentHash, _ := leaf.CalculateHash()
+		l[i] = mac(contentHash)
+	}
+	want := mac(mac(mac(l[0], l[1]), mac(l[2], l[3])), l[4])
+	if !bytes.Equal(tree.MerkleRoot(), want) {
+		t.Fatal("root is not built from HMACs")
+	}
+
+	for i := range leaves {
+		proof, _ := tree.GetProofByIndex(i)
+		if ok, err := VerifyIndexedProof(tree.MerkleRoot(), i, len(leaves), proof, WithHMACKey(key), WithInsertionOrder()); err != nil || !ok {
+			t.Fatal("proof does not verify under the key")
+		}
+		if ok, _ := VerifyIndexedProof(tree.MerkleRoot(), i, len(leaves), proof, WithHMACKey([]byte("other key")), WithInsertionOrder()); ok {
+			t.Fatal("proof verifies under another key")
+		}
+	}
+
+	other, _ := NewTreeWithOptions(leaves, WithHMACKey([]byte("other key")), WithInsertionOrder())
+	if bytes.Equal(tree.MerkleRoot(), other.MerkleRoot()) {
+		t.Fatal("keys do not change the root")
+	}
+	if _, err := NewTreeWithOptions(leaves, WithHMACKey(nil)); err == nil {
+		t.Fatal("expected error for an empty key")
+	}
+}
+
+func Test_WithLevelTags(t *testing.T) {
+	leaves := testLeaves(5)
+	tree, err := NewTreeWithOptions(leaves, WithInsertionOrder(), WithLevelNumbers())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var l [5][]byte
+	for i, leaf := range leaves {
+		l[i], _ = leaf.CalculateHash()
+	}
+	node := func(level byte, left, right []byte) []byte {
+		return gethcrypto.Keccak256([]byte{level}, left, right)
+	}
+	// leaf 4 is promoted to level 3, where it meets the level 2 node
+	want := node(3, node(2, node(1, l[0], l[1]), node(1, l[2], l[3])), l[4])
+	if !bytes.Equal(tree.MerkleRoot(), want) {
+		t.Fatal("root does not bind the levels")
+	}
+	if ok, err := tree.VerifyTree(); err != nil || !ok {
+		t.Fatal("level-tagged tree does not verify")
+	}
+	if ok, err := tree.VerifyContent(leaves[4]); err != nil || !ok {
+		t.Fatal("promoted leaf does not verify")
+	}
+
+	for i := range leaves {
+		proof, _ := tree.GetProofByIndex(i)
+		ok, err := VerifyIndexedProof(tree.MerkleRoot(), i, len(leaves), proof, WithInsertionOrder(), WithLevelNumbers())
+		if err != nil || !ok {
+			t.Fatalf("proof of %d does not verify", i)
+		}
+		if _, err := VerifyProofWithOptions(tree.MerkleRoot(), proof, WithInsertionOrder(), WithLevelNumbers()); err == nil {
+			t.Fatal("expected error verifying without the index")
+		}
+	}
+
+	// with every level paired, proofs verify without their index
+	dup, _ := NewTreeWithOptions(leaves, WithLevelNumbers(), WithOddNodePolicy(DuplicateLast))
+	for _, leaf := range leaves {
+		proof, _ := dup.GetProof(leaf)
+		if ok, err := VerifyProofWithOptions(dup.MerkleRoot(), proof, WithLevelNumbers(), WithOddNodePolicy(DuplicateLast)); err != nil || !ok {
+			t.Fatal("proof does not verify with duplicated odd nodes")
+		}
+		if ok, _ := VerifyProofWithOptions(dup.MerkleRoot(), proof, WithOddNodePolicy(DuplicateLast)); ok {
+			t.Fatal("proof verifies without the level tags")
+		}
+	}
+
+	if _, err := tree.ConsistencyProof(2, 5); err == nil {
+		t.Fatal("expected error for a consistency proof with level tags")
+	}
+}
+
+func Test_WithLeafHashSizeCheck(t *testing.T) {
+	leaves := append(testLeaves(3), HashContent(make([]byte, 20)), HashContent(make([]byte, 32)))
+
+	// the check is opt-in, as some trees commit to addresses directly
+	if _, err := NewTreeWithOptions(leaves); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := NewTreeWithOptions(leaves, WithLeafHashSizeCheck())
+	sizeErr, ok := err.(*LeafHashSizeError)
+	if !ok {
+		t.Fatalf("got %v, want a *LeafHashSizeError", err)
+	}
+	if sizeErr.Index != 3 || sizeErr.Size != 20 || sizeErr.Want != 32 {
+		t.Fatalf("unexpected error %+v", sizeErr)
+	}
+
+	if _, err := NewTreeWithOptions(testLeaves(4), WithLeafHashSizeCheck()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewTreeWithOptions(testLeaves(4), WithLeafHashSizeCheck(), WithHashStrategy(sha512.New)); err == nil {
+		t.Fatal("expected error for keccak leaves in a sha512 tree")
+	}
+}
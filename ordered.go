@@ -0,0 +1,72 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+)
+
+// WithInsertionOrder builds an ordered tree: the leaves keep the order of the
+// contents and every node is H(left || right). Positions are then part of what
+// the root commits to, as transaction trees and event logs require, and a proof
+// from GetProofByIndex shows where in the order its leaf sits.
+func WithInsertionOrder() Option {
+	return func(m *MerkleTree) {
+		WithSortLeaves(false)(m)
+		WithSortPairs(false)(m)
+	}
+}
+
+// GetProofByIndex returns the inclusion proof of the leaf at position i of Leafs.
+func (m *MerkleTree) GetProofByIndex(i int) (*MerkleProof, error) {
+	if i < 0 || i >= len(m.Leafs) {
+		return nil, fmt.Errorf("error: leaf index %d out of range [0, %d)", i, len(m.Leafs))
+	}
+	return m.proofOf(m.Leafs[i]), nil
+}
+
+// AuditProof returns the proof and the leaf hash at position index of an ordered
+// tree, answering what the leaf at index is. The proof verifies with
+// VerifyPositionalProof; VerifyIndexedProof also checks that its directions are
+// those of index, so that it cannot be passed off for another position.
+func (m *MerkleTree) AuditProof(index int) (*MerkleProof, []byte, error) {
+	if !m.unsortedLeaves || !m.unsortedPairs {
+		return nil, nil, errors.New("error: audit proofs need a tree built WithInsertionOrder")
+	}
+	proof, err := m.GetProofByIndex(index)
+	if err != nil {
+		return nil, nil, err
+	}
+	return proof, proof.LeafHash, nil
+}
+
+// VerifyIndexedProof checks that proof shows its leaf hash at position index of a
+// tree of leafCount leaves with the given root. The directions of the proof must
+// be those of that position and are used to combine each pair, so opts must
+// describe an ordered tree, e.g. WithInsertionOrder, plus any other options the
+// tree was built with.
+func VerifyIndexedProof(root []byte, index, leafCount int, proof *MerkleProof, opts ...Option) (bool, error) {
+	if proof == nil {
+		return false, errors.New("error: nil proof")
+	}
+	if index < 0 || index >= leafCount {
+		return false, fmt.Errorf("error: leaf index %d out of range [0, %d)", index, leafCount)
+	}
+	t := newConfiguredTree(opts)
+	if !t.unsortedPairs {
+		return false, errors.New("error: indexed proofs require unsorted pairs")
+	}
+
+	if len(proof.Siblings) != len(proof.Path) {
+		return false, nil
+	}
+	path, levels := expectedPathLevels(uint64(index), uint64(leafCount), t.duplicateOdd)
+	if !equalPath(proof.Path, path) {
+		return false, nil
+	}
+	computed, err := t.foldPath(proof.LeafHash, proof.Siblings, proof.Path, levels)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(computed, root), nil
+}
@@ -0,0 +1,95 @@
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/sha3"
+)
+
+func Test_InsertionOrder(t *testing.T) {
+	leaves := testLeaves(3)
+	tree, err := NewTreeWithOptions(leaves, WithInsertionOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h0, _ := leaves[0].CalculateHash()
+	h1, _ := leaves[1].CalculateHash()
+	h2, _ := leaves[2].CalculateHash()
+	if !bytes.Equal(tree.MerkleRoot(), gethcrypto.Keccak256(gethcrypto.Keccak256(h0, h1), h2)) {
+		t.Fatal("unexpected ordered root")
+	}
+
+	reversed, _ := NewTreeWithOptions([]Content{leaves[2], leaves[1], leaves[0]}, WithInsertionOrder())
+	if bytes.Equal(tree.MerkleRoot(), reversed.MerkleRoot()) {
+		t.Fatal("the order of the leaves must change the root")
+	}
+
+	for _, duplicateOdd := range []bool{false, true} {
+		opts := []Option{WithInsertionOrder(), WithDuplicateOdd(duplicateOdd)}
+		for _, n := range []int{1, 2, 5, 8, 11} {
+			tree, err := NewTreeWithOptions(testLeaves(n), opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := 0; i < n; i++ {
+				proof, err := tree.GetProofByIndex(i)
+				if err != nil {
+					t.Fatal(err)
+				}
+				ok, err := VerifyIndexedProof(tree.MerkleRoot(), i, n, proof, opts...)
+				if err != nil || !ok {
+					t.Fatalf("duplicateOdd=%v, %d leaves: proof of %d does not verify", duplicateOdd, n, i)
+				}
+				if n > 1 {
+					if ok, _ := VerifyIndexedProof(tree.MerkleRoot(), (i+1)%n, n, proof, opts...); ok {
+						t.Fatalf("duplicateOdd=%v, %d leaves: proof of %d verifies at another index", duplicateOdd, n, i)
+					}
+				}
+			}
+		}
+	}
+
+	if _, err := tree.GetProofByIndex(3); err == nil {
+		t.Fatal("expected error for an index out of range")
+	}
+}
+
+func Test_AuditProof(t *testing.T) {
+	leaves := testLeaves(11)
+	tree, err := NewTreeWithOptions(leaves, WithInsertionOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, i := range []int{0, 3, 7, 10} {
+		proof, leafHash, err := tree.AuditProof(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, _ := leaves[i].CalculateHash(); !bytes.Equal(leafHash, want) {
+			t.Fatalf("audit of position %d returned another leaf", i)
+		}
+		ok, err := VerifyPositionalProof(tree.MerkleRoot(), leafHash, proof.Siblings, proof.Path, sha3.NewLegacyKeccak256)
+		if err != nil || !ok {
+			t.Fatalf("audit of position %d does not verify", i)
+		}
+		if ok, err := VerifyIndexedProof(tree.MerkleRoot(), i, len(leaves), proof, WithInsertionOrder()); err != nil || !ok {
+			t.Fatalf("audit of position %d does not verify at its index", i)
+		}
+		if ok, _ := VerifyIndexedProof(tree.MerkleRoot(), (i+1)%len(leaves), len(leaves), proof, WithInsertionOrder()); ok {
+			t.Fatalf("audit of position %d verifies at another index", i)
+		}
+	}
+
+	for _, i := range []int{-1, len(leaves)} {
+		if _, _, err := tree.AuditProof(i); err == nil {
+			t.Fatalf("expected error auditing position %d", i)
+		}
+	}
+	sorted, _ := NewTree(leaves)
+	if _, _, err := sorted.AuditProof(0); err == nil {
+		t.Fatal("expected error auditing a tree with sorted leaves")
+	}
+}
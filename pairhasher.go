@@ -0,0 +1,99 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// PairHasher combines the hashes of two sibling nodes into the hash of their
+// parent. It stands in for the hash.Hash of a tree when nodes are not hashed by
+// a byte-stream hash, as with SNARK-friendly hashes such as Poseidon or MiMC that
+// work on field elements.
+type PairHasher interface {
+	HashPair(left, right []byte) ([]byte, error)
+}
+
+// PairHasherFunc adapts a function to PairHasher, the way PoseidonPairHasher and
+// MiMC7PairHasher wrap PoseidonHash and MiMC7MultiHash.
+type PairHasherFunc func(left, right []byte) ([]byte, error)
+
+// HashPair calls f(left, right).
+func (f PairHasherFunc) HashPair(left, right []byte) ([]byte, error) {
+	return f(left, right)
+}
+
+// WithPairHasher hashes every internal node with p instead of the hash function
+// of the tree. Pairs are still handed over in sorted order unless the tree was
+// built with WithSortPairs(false). Leaves are the content hashes as they are,
+// so contents of a tree over field elements should hash to canonical elements,
+// see BN254Content. It cannot be combined with a node prefix, level tags, an HMAC
+// key, a leaf prefix or WithHashLeaves, whose byte-stream hashes would leave the
+// nodes unkeyed or the leaves outside the field. Proofs verify with
+// VerifyProofWithOptions given the same option.
+func WithPairHasher(p PairHasher) Option {
+	return func(m *MerkleTree) {
+		m.pairHasher = p
+	}
+}
+
+// bn254ScalarField is the order of the scalar field of the BN254 curve, the field
+// of the circuits of Circom, gnark and Ethereum's precompiles.
+var bn254ScalarField, _ = new(big.Int).SetString("21888242871839275222246405745257275088548364400416034343698204186575808495617", 10)
+
+// BN254ScalarField returns the modulus of the BN254 scalar field.
+func BN254ScalarField() *big.Int {
+	return new(big.Int).Set(bn254ScalarField)
+}
+
+// BN254Element encodes x reduced modulo the BN254 scalar field as 32 big-endian
+// bytes, the encoding of field elements as leaves and node hashes.
+func BN254Element(x *big.Int) []byte {
+	b := make([]byte, 32)
+	return new(big.Int).Mod(x, bn254ScalarField).FillBytes(b)
+}
+
+// ParseBN254Element decodes a big-endian encoding of a canonical field element,
+// rejecting values that are not below the modulus.
+func ParseBN254Element(b []byte) (*big.Int, error) {
+	if len(b) > 32 {
+		return nil, fmt.Errorf("error: field element of %d bytes", len(b))
+	}
+	x := new(big.Int).SetBytes(b)
+	if x.Cmp(bn254ScalarField) >= 0 {
+		return nil, errors.New("error: value is not a canonical BN254 field element")
+	}
+	return x, nil
+}
+
+// HashToBN254 maps arbitrary bytes to a field element, keccak256(data) reduced
+// modulo the BN254 scalar field and encoded as by BN254Element. A circuit taking
+// the leaf as a private input gets the same element from the same reduction.
+func HashToBN254(data []byte) []byte {
+	return BN254Element(new(big.Int).SetBytes(gethcrypto.Keccak256(data)))
+}
+
+// BN254Content is a leaf holding a field element, whose hash is its encoding as
+// given by BN254Element.
+type BN254Content struct {
+	X *big.Int
+}
+
+// CalculateHash encodes the element
+func (c BN254Content) CalculateHash() ([]byte, error) {
+	if c.X == nil {
+		return nil, errors.New("error: nil field element")
+	}
+	return BN254Element(c.X), nil
+}
+
+// Equals tests the elements for equality modulo the field
+func (c BN254Content) Equals(other Content) (bool, error) {
+	o, ok := other.(BN254Content)
+	if !ok || c.X == nil || o.X == nil {
+		return false, nil
+	}
+	return new(big.Int).Mod(c.X, bn254ScalarField).Cmp(new(big.Int).Mod(o.X, bn254ScalarField)) == 0, nil
+}
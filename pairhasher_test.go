@@ -0,0 +1,90 @@
+package merkletree
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+func Test_WithPairHasher(t *testing.T) {
+	keccakPair := PairHasherFunc(func(left, right []byte) ([]byte, error) {
+		return gethcrypto.Keccak256(left, right), nil
+	})
+	leaves := testLeaves(7)
+	for _, sortPairs := range []bool{true, false} {
+		tree, err := NewTreeWithOptions(leaves, WithPairHasher(keccakPair), WithSortPairs(sortPairs))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := NewTreeWithOptions(leaves, WithSortPairs(sortPairs))
+		if !bytes.Equal(tree.MerkleRoot(), want.MerkleRoot()) {
+			t.Fatalf("sortPairs=%v: pair hasher gives a different root", sortPairs)
+		}
+	}
+
+	// a field-arithmetic hasher over BN254 elements
+	addPair := PairHasherFunc(func(left, right []byte) ([]byte, error) {
+		l, err := ParseBN254Element(left)
+		if err != nil {
+			return nil, err
+		}
+		r, err := ParseBN254Element(right)
+		if err != nil {
+			return nil, err
+		}
+		return BN254Element(new(big.Int).Add(new(big.Int).Mul(l, big.NewInt(3)), r)), nil
+	})
+	var elements []Content
+	for i := int64(1); i <= 5; i++ {
+		elements = append(elements, BN254Content{X: big.NewInt(i)})
+	}
+	tree, err := NewTreeWithOptions(elements, WithPairHasher(addPair))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// sorted leaves 1..5: (5, 13, 5) -> (28, 5) -> 3*5+28
+	if new(big.Int).SetBytes(tree.MerkleRoot()).Int64() != 43 {
+		t.Fatalf("unexpected root %x", tree.MerkleRoot())
+	}
+	for _, c := range elements {
+		proof, err := tree.GetProof(c)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := VerifyProofWithOptions(tree.MerkleRoot(), proof, WithPairHasher(addPair)); err != nil || !ok {
+			t.Fatal("proof does not verify with the pair hasher")
+		}
+	}
+
+	// nodes hashed by the pair hasher skip the prefix, tags and key, and leaves
+	// hashed by the hash function are not field elements
+	for _, opt := range []Option{WithNodePrefix(1), WithLevelNumbers(), WithHMACKey([]byte("key")), WithLeafPrefix(0), WithHashLeaves(true)} {
+		if _, err := NewTreeWithOptions(elements, WithPairHasher(addPair), opt); err == nil {
+			t.Fatal("expected error combining a pair hasher with node or leaf hashing options")
+		}
+	}
+}
+
+func Test_BN254Helpers(t *testing.T) {
+	p := BN254ScalarField()
+	if !bytes.Equal(BN254Element(p), make([]byte, 32)) {
+		t.Fatal("modulus does not reduce to zero")
+	}
+	if _, err := ParseBN254Element(p.Bytes()); err == nil {
+		t.Fatal("expected error for a non-canonical element")
+	}
+	x, err := ParseBN254Element(BN254Element(big.NewInt(42)))
+	if err != nil || x.Int64() != 42 {
+		t.Fatal("element does not round-trip")
+	}
+
+	h := HashToBN254([]byte("leaf"))
+	if len(h) != 32 || new(big.Int).SetBytes(h).Cmp(p) >= 0 {
+		t.Fatal("hashed leaf is not a canonical element")
+	}
+	if ok, _ := (BN254Content{X: big.NewInt(1)}).Equals(BN254Content{X: new(big.Int).Add(p, big.NewInt(1))}); !ok {
+		t.Fatal("elements equal modulo the field differ")
+	}
+}
@@ -0,0 +1,143 @@
+package merkletree
+
+import "math/big"
+
+// The Poseidon permutation of circomlib's Poseidon(2) template: width t = 3 over
+// the BN254 scalar field, x^5 S-boxes, 8 full and 57 partial rounds.
+const (
+	poseidonWidth         = 3
+	poseidonFullRounds    = 8
+	poseidonPartialRounds = 57
+)
+
+// poseidonConstants and poseidonMDS are the round constants and the MDS matrix of
+// circomlib, generated like the reference script generate_parameters_grain.sage
+// of the Poseidon authors.
+var poseidonConstants, poseidonMDS = poseidonParameters()
+
+// poseidonParameters draws the round constants and the MDS matrix from the Grain
+// LFSR seeded with the field, S-box, field size, width and round numbers.
+func poseidonParameters() ([]*big.Int, [poseidonWidth][poseidonWidth]*big.Int) {
+	p := bn254ScalarField
+	fieldSize := p.BitLen()
+
+	// 80 bits of state: field type 1 (prime field), S-box 0 (x^alpha), then the
+	// field size, width and round numbers, padded with ones
+	var state []byte
+	appendBits := func(x, n int) {
+		for i := n - 1; i >= 0; i-- {
+			state = append(state, byte(x>>uint(i))&1)
+		}
+	}
+	appendBits(1, 2)
+	appendBits(0, 4)
+	appendBits(fieldSize, 12)
+	appendBits(poseidonWidth, 12)
+	appendBits(poseidonFullRounds, 10)
+	appendBits(poseidonPartialRounds, 10)
+	appendBits(1<<30-1, 30)
+
+	update := func() byte {
+		b := state[62] ^ state[51] ^ state[38] ^ state[23] ^ state[13] ^ state[0]
+		state = append(state[1:], b)
+		return b
+	}
+	for i := 0; i < 160; i++ {
+		update()
+	}
+	// bits come in pairs, the first deciding whether the second is kept
+	randomBit := func() byte {
+		for update() == 0 {
+			update()
+		}
+		return update()
+	}
+	randomInt := func() *big.Int {
+		x := new(big.Int)
+		for i := 0; i < fieldSize; i++ {
+			x.Lsh(x, 1)
+			x.SetBit(x, 0, uint(randomBit()))
+		}
+		return x
+	}
+
+	// round constants at or above the modulus are drawn again
+	count := (poseidonFullRounds + poseidonPartialRounds) * poseidonWidth
+	constants := make([]*big.Int, 0, count)
+	for len(constants) < count {
+		if c := randomInt(); c.Cmp(p) < 0 {
+			constants = append(constants, c)
+		}
+	}
+
+	// the MDS matrix is the Cauchy matrix 1/(x_i + y_j) of the next elements
+	var xs, ys [poseidonWidth]*big.Int
+	for _, v := range [][]*big.Int{xs[:], ys[:]} {
+		for i := range v {
+			x := randomInt()
+			v[i] = x.Mod(x, p)
+		}
+	}
+	var mds [poseidonWidth][poseidonWidth]*big.Int
+	for i := range mds {
+		for j := range mds[i] {
+			sum := new(big.Int).Add(xs[i], ys[j])
+			mds[i][j] = sum.ModInverse(sum.Mod(sum, p), p)
+		}
+	}
+	return constants, mds
+}
+
+// PoseidonHash returns circomlib's Poseidon([a, b]), as computed by poseidon.circom,
+// circomlibjs and go-iden3-crypto: the first element of the permutation of the
+// state [0, a, b].
+func PoseidonHash(a, b *big.Int) *big.Int {
+	p := bn254ScalarField
+	state := [poseidonWidth]*big.Int{new(big.Int), new(big.Int).Mod(a, p), new(big.Int).Mod(b, p)}
+	five := big.NewInt(5)
+
+	rounds := poseidonFullRounds + poseidonPartialRounds
+	for r := 0; r < rounds; r++ {
+		for i := range state {
+			state[i].Add(state[i], poseidonConstants[r*poseidonWidth+i]).Mod(state[i], p)
+		}
+		if r < poseidonFullRounds/2 || r >= poseidonFullRounds/2+poseidonPartialRounds {
+			for i := range state {
+				state[i].Exp(state[i], five, p)
+			}
+		} else {
+			state[0].Exp(state[0], five, p)
+		}
+
+		var mixed [poseidonWidth]*big.Int
+		for i := range mixed {
+			mixed[i] = new(big.Int)
+			for j := range state {
+				mixed[i].Add(mixed[i], new(big.Int).Mul(poseidonMDS[i][j], state[j]))
+			}
+			mixed[i].Mod(mixed[i], p)
+		}
+		state = mixed
+	}
+	return state[0]
+}
+
+// PoseidonPairHasher hashes a node as PoseidonHash(left, right), the merkle node
+// of circuits built on circomlib's Poseidon(2). Circuits that take path bits
+// rather than sorting their inputs need trees built with WithSortPairs(false).
+var PoseidonPairHasher PairHasher = PairHasherFunc(func(left, right []byte) ([]byte, error) {
+	l, err := ParseBN254Element(left)
+	if err != nil {
+		return nil, err
+	}
+	r, err := ParseBN254Element(right)
+	if err != nil {
+		return nil, err
+	}
+	return BN254Element(PoseidonHash(l, r)), nil
+})
+
+// WithPoseidon hashes internal nodes with PoseidonPairHasher.
+func WithPoseidon() Option {
+	return WithPairHasher(PoseidonPairHasher)
+}
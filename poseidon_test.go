@@ -0,0 +1,58 @@
+package merkletree
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func Test_Poseidon(t *testing.T) {
+	// parameters and vectors of circomlib
+	if got := hexutil.Encode(BN254Element(poseidonConstants[0])); got != "0x0ee9a592ba9a9518d05986d656f40c2114c4993c11bb29938d21d47304cd8e6e" {
+		t.Fatalf("unexpected first round constant %s", got)
+	}
+	if got := hexutil.Encode(BN254Element(poseidonMDS[0][0])); got != "0x109b7f411ba0e4c9b2b70caf5c36a7b194be7c11ad24378bfedb68592ba8118b" {
+		t.Fatalf("unexpected MDS entry %s", got)
+	}
+	if got := PoseidonHash(big.NewInt(1), big.NewInt(2)).String(); got != "7853200120776062878684798364095072458815029376092732009249414926327459813530" {
+		t.Fatalf("unexpected Poseidon([1, 2]) %s", got)
+	}
+	if got := PoseidonHash(big.NewInt(3), big.NewInt(4)).String(); got != "14763215145315200506921711489642608356394854266165572616578112107564877678998" {
+		t.Fatalf("unexpected Poseidon([3, 4]) %s", got)
+	}
+
+	// PoseidonPairHasher is the two-element hash
+	parent, err := PoseidonPairHasher.HashPair(BN254Element(big.NewInt(1)), BN254Element(big.NewInt(2)))
+	if err != nil || new(big.Int).SetBytes(parent).Cmp(PoseidonHash(big.NewInt(1), big.NewInt(2))) != 0 {
+		t.Fatal("pair hasher differs from the two-element hash")
+	}
+	if _, err := PoseidonPairHasher.HashPair(BN254Element(big.NewInt(1)), BN254ScalarField().Bytes()); err == nil {
+		t.Fatal("expected error for a non-canonical element")
+	}
+}
+
+func Test_WithPoseidon(t *testing.T) {
+	var leaves []Content
+	for i := int64(1); i <= 4; i++ {
+		leaves = append(leaves, BN254Content{X: big.NewInt(i)})
+	}
+	tree, err := NewTreeWithOptions(leaves, WithPoseidon(), WithInsertionOrder())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	left := PoseidonHash(big.NewInt(1), big.NewInt(2))
+	right := PoseidonHash(big.NewInt(3), big.NewInt(4))
+	if hexutil.Encode(tree.MerkleRoot()) != hexutil.Encode(BN254Element(PoseidonHash(left, right))) {
+		t.Fatal("root does not follow Poseidon")
+	}
+
+	for i := range leaves {
+		proof, _ := tree.GetProofByIndex(i)
+		ok, err := VerifyIndexedProof(tree.MerkleRoot(), i, len(leaves), proof, WithPoseidon(), WithInsertionOrder())
+		if err != nil || !ok {
+			t.Fatalf("proof of %d does not verify", i)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package merkletree
+
+// WithProgress makes the builds of the tree report their progress to fn, with done
+// out of total nodes hashed so far, counting leaves first and then the nodes of
+// every level above them. fn is called every few thousand nodes and once the root
+// is built, from the goroutine doing the build. total only shrinks if leaves are
+// dropped by DedupeDuplicates.
+func WithProgress(fn func(done, total int)) Option {
+	return func(m *MerkleTree) {
+		m.progress = fn
+	}
+}
+
+// buildProgress counts the nodes hashed by a build of a tree with WithProgress.
+// The methods do nothing on a nil buildProgress, so builds without a progress
+// callback need no checks.
+type buildProgress struct {
+	report      func(done, total int)
+	done, total int
+}
+
+func newBuildProgress(report func(done, total int), leafCount int) *buildProgress {
+	if report == nil {
+		return nil
+	}
+	return &buildProgress{report: report, total: leafCount + internalNodeCount(leafCount)}
+}
+
+// step counts one more node.
+func (p *buildProgress) step() {
+	if p == nil {
+		return
+	}
+	p.done++
+	if p.done%ctxCheckInterval == 0 || p.done == p.total {
+		p.report(p.done, p.total)
+	}
+}
+
+// leavesKept records that contentCount contents were hashed into leafCount leaves,
+// fewer if the duplicate policy dropped some, and adjusts the total to match.
+func (p *buildProgress) leavesKept(contentCount, leafCount int) {
+	if p == nil {
+		return
+	}
+	p.done = contentCount
+	p.total = contentCount + internalNodeCount(leafCount)
+}
+
+// internalNodeCount returns the number of nodes buildIntermediate creates above
+// leafCount leaves.
+func internalNodeCount(leafCount int) int {
+	count := 0
+	for l := leafCount; l > 0; l = (l + 1) / 2 {
+		count += (l + 1) / 2
+		if l <= 2 {
+			break
+		}
+	}
+	return count
+}
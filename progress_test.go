@@ -0,0 +1,46 @@
+package merkletree
+
+import "testing"
+
+func Test_WithProgress(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 5, 1000, 5000} {
+		var calls, lastDone, lastTotal int
+		tree, err := NewTreeWithOptions(testLeaves(n), WithProgress(func(done, total int) {
+			if done <= lastDone || done > total {
+				t.Fatalf("%d leaves: progress %d/%d after %d", n, done, total, lastDone)
+			}
+			calls++
+			lastDone, lastTotal = done, total
+		}))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if calls == 0 || lastDone != lastTotal {
+			t.Fatalf("%d leaves: build ended at %d/%d", n, lastDone, lastTotal)
+		}
+		if n >= 5000 && calls < 2 {
+			t.Fatalf("%d leaves: only %d progress reports", n, calls)
+		}
+
+		// rebuilds report too
+		calls, lastDone = 0, 0
+		if err := tree.RebuildTree(); err != nil {
+			t.Fatal(err)
+		}
+		if calls == 0 || lastDone != lastTotal {
+			t.Fatalf("%d leaves: rebuild ended at %d/%d", n, lastDone, lastTotal)
+		}
+	}
+
+	var lastDone, lastTotal int
+	leaves := append(testLeaves(7), testLeaves(7)...)
+	_, err := NewTreeWithOptions(leaves, WithDuplicatePolicy(DedupeDuplicates), WithProgress(func(done, total int) {
+		lastDone, lastTotal = done, total
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lastDone != lastTotal || lastTotal != 14+internalNodeCount(7) {
+		t.Fatalf("deduplicated build ended at %d/%d", lastDone, lastTotal)
+	}
+}
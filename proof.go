@@ -0,0 +1,255 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"hash"
+
+	"github.com/smartbch/merkletree/merkleproof"
+)
+
+// MerkleProof is an inclusion proof for a single leaf. Siblings are ordered from the
+// leaf up to the root and Path holds the matching directions reported by
+// GetMerklePath. The type and its verification live in the merkleproof package,
+// which light clients can import without the tree.
+type MerkleProof = merkleproof.Proof
+
+// Proof is the same type as MerkleProof.
+type Proof = MerkleProof
+
+// GetProof returns the inclusion proof of content. It carries the same siblings and
+// directions as GetMerklePath, which keeps returning them as separate slices.
+func (m *MerkleTree) GetProof(content Content) (*MerkleProof, error) {
+	leaf, siblings, path, err := m.leafPath(content)
+	if err != nil {
+		return nil, err
+	}
+	return &MerkleProof{
+		LeafHash: leaf.Hash,
+		Root:     m.merkleRoot,
+		Siblings: siblings,
+		Path:     path,
+	}, nil
+}
+
+// GetProofByLeafHash returns the inclusion proof of the leaf whose hash is leafHash.
+func (m *MerkleTree) GetProofByLeafHash(leafHash []byte) (*MerkleProof, error) {
+	if len(m.Leafs) == 0 {
+		return nil, ErrEmptyTree
+	}
+	leaf := m.findLeafByHash(leafHash)
+	if leaf == nil {
+		return nil, ErrContentNotFound
+	}
+	return m.proofOf(leaf), nil
+}
+
+// proofOf returns the proof of leaf, which must belong to m.
+func (m *MerkleTree) proofOf(leaf *Node) *MerkleProof {
+	siblings, path := leaf.merklePath()
+	return &MerkleProof{
+		LeafHash: leaf.Hash,
+		Root:     m.merkleRoot,
+		Siblings: siblings,
+		Path:     path,
+	}
+}
+
+// AllProofs returns the proof of every leaf keyed by its index in Leafs. It walks the
+// tree once from the root, collecting siblings on the way down, so it costs
+// O(n log n) in total instead of a search and a climb for every leaf.
+func (m *MerkleTree) AllProofs() (map[int]*Proof, error) {
+	if len(m.Leafs) == 0 {
+		return nil, ErrEmptyTree
+	}
+
+	indexes := make(map[*Node]int, len(m.Leafs))
+	for i, leaf := range m.Leafs {
+		indexes[leaf] = i
+	}
+
+	proofs := make(map[int]*Proof, len(m.Leafs))
+	// siblings and path are kept from the root down and reversed for each leaf
+	var siblings [][]byte
+	var path []int64
+	var walk func(n *Node) error
+	walk = func(n *Node) error {
+		if n.leaf {
+			i, ok := indexes[n]
+			if !ok {
+				return errors.New("error: leaf is not part of the tree")
+			}
+			proof := &MerkleProof{LeafHash: n.Hash, Root: m.merkleRoot}
+			for j := len(siblings) - 1; j >= 0; j-- {
+				proof.Siblings = append(proof.Siblings, siblings[j])
+				proof.Path = append(proof.Path, path[j])
+			}
+			proofs[i] = proof
+			return nil
+		}
+
+		if n.Left.single {
+			// promoted single node, nothing to add to the proof
+			return walk(n.Left)
+		}
+		children := []*Node{n.Left, n.Right}
+		if n.Left == n.Right {
+			// odd node paired with itself
+			children = children[:1]
+		}
+		for _, child := range children {
+			// same direction rule as merklePath
+			if bytes.Equal(n.Left.Hash, child.Hash) {
+				siblings, path = append(siblings, n.Right.Hash), append(path, 1)
+			} else {
+				siblings, path = append(siblings, n.Left.Hash), append(path, 0)
+			}
+			if err := walk(child); err != nil {
+				return err
+			}
+			siblings, path = siblings[:len(siblings)-1], path[:len(path)-1]
+		}
+		return nil
+	}
+
+	if m.Root == nil {
+		return proofs, nil
+	}
+	if err := walk(m.Root); err != nil {
+		return nil, err
+	}
+	return proofs, nil
+}
+
+// VerifyProof checks that leafHash is included under root given the sibling hashes
+// of proof, ordered from the leaf up, as returned by GetMerklePath. It needs
+// neither the tree nor any other leaf, so light clients can verify with a proof
+// and a trusted root alone.
+func VerifyProof(root []byte, leafHash []byte, proof [][]byte, hashStrategy func() hash.Hash) (bool, error) {
+	return merkleproof.VerifyProof(root, leafHash, proof, hashStrategy)
+}
+
+// GetAnonymousProof returns the inclusion proof of content without any positions.
+// Pairs are combined in sorted order, so the siblings alone verify the leaf and
+// the proof says nothing about where in the tree the leaf sits. Trees with
+// unsorted pairs cannot drop the positions and are refused.
+func (m *MerkleTree) GetAnonymousProof(content Content) (*MerkleProof, error) {
+	if m.unsortedPairs {
+		return nil, errors.New("error: anonymous proofs require sorted pairs")
+	}
+	proof, err := m.GetProof(content)
+	if err != nil {
+		return nil, err
+	}
+	proof.Path = nil
+	return proof, nil
+}
+
+// VerifyAnonymousProof verifies proof using sorted-pair combination only. Any
+// positions carried by proof are ignored.
+func VerifyAnonymousProof(leafHash []byte, proof *MerkleProof, root []byte, h func() hash.Hash) (bool, error) {
+	return merkleproof.VerifyAnonymousProof(leafHash, proof, root, h)
+}
+
+// VerifyProofWithDepth verifies proof like VerifyAnonymousProof and also reports
+// the number of folds performed, i.e. how deep below the root the leaf sits.
+func VerifyProofWithDepth(leafHash []byte, proof *MerkleProof, root []byte, h func() hash.Hash) (ok bool, depth int, err error) {
+	return merkleproof.VerifyProofWithDepth(leafHash, proof, root, h)
+}
+
+// VerifyProofMultiHash checks proof against root with each of strategies in turn and
+// reports the index of the first one that reproduces the root. It returns -1 and
+// false when none of them does.
+func VerifyProofMultiHash(leafHash []byte, proof *MerkleProof, root []byte, strategies []func() hash.Hash) (matchedIdx int, ok bool, err error) {
+	return merkleproof.VerifyProofMultiHash(leafHash, proof, root, strategies)
+}
+
+// VerifyProofWithLeafTransform derives the leaf hash by applying transform to
+// leafPreimage and verifies proof for it against root. transform must reproduce
+// whatever leaf hashing the tree used, e.g. salting, prefixing or double hashing.
+func VerifyProofWithLeafTransform(leafPreimage []byte, transform func([]byte) ([]byte, error), proof *MerkleProof, root []byte, h func() hash.Hash) (bool, error) {
+	return merkleproof.VerifyProofWithLeafTransform(leafPreimage, transform, proof, root, h)
+}
+
+// VerifyProofPacked verifies a positional proof whose directions are packed into
+// pathBits: bit i set means siblings[i] is the right child and is hashed after the
+// running hash, bit i clear means it is the left child and is hashed before it.
+// Pairs are not sorted, matching verifiers that take a single direction mask.
+func VerifyProofPacked(leafHash []byte, siblings [][]byte, pathBits uint64, root []byte, h func() hash.Hash) (bool, error) {
+	return merkleproof.VerifyProofPacked(leafHash, siblings, pathBits, root, h)
+}
+
+// VerifyPositionalProof checks a proof of a tree built with WithSortPairs(false).
+// Each sibling is hashed on the side given by the matching entry of path: 1 when
+// the sibling is the right child and 0 when it is the left one, as reported by
+// GetMerklePath.
+func VerifyPositionalProof(root []byte, leafHash []byte, siblings [][]byte, path []int64, hashStrategy func() hash.Hash) (bool, error) {
+	return merkleproof.VerifyPositionalProof(root, leafHash, siblings, path, hashStrategy)
+}
+
+// PackPath packs a positional path as found in MerkleProof.Path into the bit mask
+// taken by VerifyProofPacked.
+func PackPath(path []int64) (uint64, error) {
+	return merkleproof.PackPath(path)
+}
+
+// foldProof hashes leafHash together with each sibling in turn and returns the
+// resulting root.
+func foldProof(leafHash []byte, siblings [][]byte, hashStrategy func() hash.Hash) ([]byte, error) {
+	return merkleproof.ComputeRoot(leafHash, siblings, hashStrategy)
+}
+
+// VerifyProofWithOptions checks proof against root for a tree built with opts,
+// applying the same pair ordering and node prefix. Proofs of trees with unsorted
+// pairs must carry their directions, unless they have no siblings.
+func VerifyProofWithOptions(root []byte, proof *MerkleProof, opts ...Option) (bool, error) {
+	if proof == nil {
+		return false, errors.New("error: nil proof")
+	}
+	return newConfiguredTree(opts).verifyProof(root, proof)
+}
+
+// verifyProof checks proof against root for a tree configured like m, see
+// VerifyProofWithOptions.
+func (m *MerkleTree) verifyProof(root []byte, proof *MerkleProof) (bool, error) {
+	if proof.Path != nil && len(proof.Path) != len(proof.Siblings) {
+		return false, errors.New("error: proof directions do not match its siblings")
+	}
+	if m.unsortedPairs && proof.Path == nil && len(proof.Siblings) > 0 {
+		return false, errors.New("error: proof of a tree with unsorted pairs needs directions")
+	}
+	if m.levelTag != nil && !m.duplicateOdd {
+		return false, errors.New("error: levels of promoted nodes depend on the leaf index, use VerifyIndexedProof")
+	}
+
+	computed, err := m.foldPath(proof.LeafHash, proof.Siblings, proof.Path, nil)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(computed, root), nil
+}
+
+// foldPath combines leafHash with each sibling in turn on the side given by path,
+// hashing every pair the way the tree does. path may be nil for trees with sorted
+// pairs, where the side does not matter. levels gives the level of each pair for
+// trees with level tags; it may be nil when every level has a sibling, as with
+// DuplicateLast.
+func (m *MerkleTree) foldPath(leafHash []byte, siblings [][]byte, path []int64, levels []int) ([]byte, error) {
+	current := leafHash
+	for i, sibling := range siblings {
+		level := i + 1
+		if levels != nil {
+			level = levels[i]
+		}
+		var err error
+		if path == nil || path[i] == 1 {
+			current, err = m.hashPair(level, current, sibling)
+		} else {
+			current, err = m.hashPair(level, sibling, current)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return current, nil
+}
@@ -0,0 +1,12 @@
+package merkletree
+
+import "github.com/smartbch/merkletree/merkleproof"
+
+// HexProof is the canonical JSON form of a proof for web3 clients, with every hash
+// as a 0x-prefixed hex string. MerkleProof marshals to and from it.
+type HexProof = merkleproof.HexProof
+
+// ParseProofJSON decodes a proof from its canonical JSON form.
+func ParseProofJSON(data []byte) (*MerkleProof, error) {
+	return merkleproof.ParseProofJSON(data)
+}
@@ -0,0 +1,96 @@
+package merkletree
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_ProofEncoding(t *testing.T) {
+	leaves := testLeaves(7)
+	tree, err := NewTree(leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, leaf := range leaves {
+		proof, err := tree.GetProof(leaf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := proof.Verify(); err != nil || !ok {
+			t.Fatal("proof does not verify")
+		}
+
+		bin, err := proof.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var fromBinary Proof
+		if err := fromBinary.UnmarshalBinary(bin); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(&fromBinary, proof) {
+			t.Fatal("binary round trip mismatch")
+		}
+
+		js, err := json.Marshal(proof)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(js), `"root":"0x`) {
+			t.Fatalf("unexpected JSON %s", js)
+		}
+		var fromJSON Proof
+		if err := json.Unmarshal(js, &fromJSON); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(&fromJSON, proof) {
+			t.Fatal("JSON round trip mismatch")
+		}
+		if ok, err := fromJSON.Verify(); err != nil || !ok {
+			t.Fatal("decoded proof does not verify")
+		}
+	}
+
+	proof, _ := tree.GetProof(leaves[0])
+	bin, _ := proof.MarshalBinary()
+	var p Proof
+	if err := p.UnmarshalBinary(bin[:len(bin)-1]); err == nil {
+		t.Fatal("expected error for truncated proof")
+	}
+}
+
+func Test_HexProof(t *testing.T) {
+	leaves := testLeaves(5)
+	tree, _ := NewTree(leaves)
+	proof, err := tree.GetProof(leaves[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hp := proof.ToHex()
+	if !strings.HasPrefix(hp.Root, "0x") || !strings.HasPrefix(hp.Leaf, "0x") || len(hp.Siblings) != len(proof.Siblings) {
+		t.Fatalf("unexpected hex proof %+v", hp)
+	}
+	for _, sibling := range hp.Siblings {
+		if !strings.HasPrefix(sibling, "0x") || len(sibling) != 66 {
+			t.Fatalf("sibling %s is not a 0x-prefixed bytes32", sibling)
+		}
+	}
+
+	js, _ := json.Marshal(hp)
+	parsed, err := ParseProofJSON(js)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(parsed, proof) {
+		t.Fatal("hex round trip mismatch")
+	}
+
+	hp.Siblings[0] = strings.TrimPrefix(hp.Siblings[0], "0x")
+	if _, err := hp.ToProof(); err == nil {
+		t.Fatal("expected error for a sibling without 0x prefix")
+	}
+}
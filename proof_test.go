@@ -0,0 +1,248 @@
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/sha3"
+)
+
+// hashLeaf is a leaf whose hash was computed elsewhere
+type hashLeaf []byte
+
+func (l hashLeaf) CalculateHash() ([]byte, error) {
+	return l, nil
+}
+
+func (l hashLeaf) Equals(other Content) (bool, error) {
+	return bytes.Equal(l, other.(hashLeaf)), nil
+}
+
+func Test_VerifyProofMultiHash(t *testing.T) {
+	leaves := testLeaves(5)
+	strategies := []func() hash.Hash{sha3.NewLegacyKeccak256, sha256.New}
+
+	for want, hashStrategy := range strategies {
+		tree, err := NewTreeWithHashStrategy(leaves, hashStrategy)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, leaf := range leaves {
+			proof, err := tree.GetProof(leaf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			leafHash, _ := leaf.CalculateHash()
+
+			idx, ok, err := VerifyProofMultiHash(leafHash, proof, tree.MerkleRoot(), strategies)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok || idx != want {
+				t.Fatalf("expected strategy %d to match, got %d (ok=%v)", want, idx, ok)
+			}
+		}
+	}
+
+	tree, _ := NewTree(leaves)
+	proof, _ := tree.GetProof(leaves[0])
+	leafHash, _ := leaves[1].CalculateHash()
+	if idx, ok, _ := VerifyProofMultiHash(leafHash, proof, tree.MerkleRoot(), strategies); ok || idx != -1 {
+		t.Fatal("proof for another leaf must not verify")
+	}
+}
+
+func Test_VerifyProofPacked(t *testing.T) {
+	// a positional tree over a, b, c, d: root = H(H(a||b) || H(c||d))
+	keccak := func(parts ...[]byte) []byte {
+		h := sha3.NewLegacyKeccak256()
+		for _, p := range parts {
+			h.Write(p)
+		}
+		return h.Sum(nil)
+	}
+	a, b, c, d := keccak([]byte("a")), keccak([]byte("b")), keccak([]byte("c")), keccak([]byte("d"))
+	ab, cd := keccak(a, b), keccak(c, d)
+	root := keccak(ab, cd)
+
+	// proof of c: d is its right sibling, then ab is the left sibling
+	proof := &MerkleProof{Siblings: [][]byte{d, ab}, Path: []int64{1, 0}}
+	bits, err := PackPath(proof.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bits != 1 {
+		t.Fatalf("unexpected packed path %b", bits)
+	}
+
+	ok, err := VerifyProofPacked(c, proof.Siblings, bits, root, sha3.NewLegacyKeccak256)
+	if err != nil || !ok {
+		t.Fatal("packed proof should verify")
+	}
+	if ok, _ := VerifyProofPacked(c, proof.Siblings, 2, root, sha3.NewLegacyKeccak256); ok {
+		t.Fatal("wrong directions must not verify")
+	}
+	if _, err := VerifyProofPacked(c, proof.Siblings, 4, root, sha3.NewLegacyKeccak256); err == nil {
+		t.Fatal("expected error for bits beyond the proof length")
+	}
+	if _, err := VerifyProofPacked(c, make([][]byte, 65), 0, root, sha3.NewLegacyKeccak256); err == nil {
+		t.Fatal("expected error for more than 64 siblings")
+	}
+}
+
+func Test_VerifyProofWithLeafTransform(t *testing.T) {
+	// OpenZeppelin StandardMerkleTree leaf for (address, uint256):
+	// keccak256(bytes.concat(keccak256(abi.encode(account, amount))))
+	abiEncode := func(account common.Address, amount int64) []byte {
+		return append(common.LeftPadBytes(account.Bytes(), 32), common.LeftPadBytes(big.NewInt(amount).Bytes(), 32)...)
+	}
+	doubleKeccak := func(preimage []byte) ([]byte, error) {
+		return gethcrypto.Keccak256(gethcrypto.Keccak256(preimage)), nil
+	}
+
+	var preimages [][]byte
+	var leaves []Content
+	for i := int64(1); i <= 5; i++ {
+		preimage := abiEncode(common.BigToAddress(big.NewInt(i*1000)), i*100)
+		leafHash, _ := doubleKeccak(preimage)
+		preimages = append(preimages, preimage)
+		leaves = append(leaves, hashLeaf(leafHash))
+	}
+	tree, err := NewTree(leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, preimage := range preimages {
+		proof, err := tree.GetProof(leaves[i])
+		if err != nil {
+			t.Fatal(err)
+		}
+		ok, err := VerifyProofWithLeafTransform(preimage, doubleKeccak, proof, tree.MerkleRoot(), sha3.NewLegacyKeccak256)
+		if err != nil || !ok {
+			t.Fatalf("leaf %d does not verify", i)
+		}
+
+		singleKeccak := func(b []byte) ([]byte, error) { return gethcrypto.Keccak256(b), nil }
+		if ok, _ := VerifyProofWithLeafTransform(preimage, singleKeccak, proof, tree.MerkleRoot(), sha3.NewLegacyKeccak256); ok {
+			t.Fatal("wrong transform must not verify")
+		}
+	}
+}
+
+func Test_AnonymousProof(t *testing.T) {
+	leaves := testLeaves(9)
+	tree, err := NewTree(leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, leaf := range leaves {
+		proof, err := tree.GetAnonymousProof(leaf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if proof.Path != nil {
+			t.Fatal("anonymous proof must not carry positions")
+		}
+
+		leafHash, _ := leaf.CalculateHash()
+		ok, err := VerifyAnonymousProof(leafHash, proof, tree.MerkleRoot(), sha3.NewLegacyKeccak256)
+		if err != nil || !ok {
+			t.Fatal("anonymous proof does not verify")
+		}
+	}
+
+	positional, _ := NewTreeWithOptions(leaves, WithSortPairs(false))
+	if _, err := positional.GetAnonymousProof(leaves[0]); err == nil {
+		t.Fatal("expected error for a tree with unsorted pairs")
+	}
+}
+
+func Test_VerifyProofWithDepth(t *testing.T) {
+	// with 5 leaves four of them sit 3 levels deep and the promoted one only 1
+	leaves := testLeaves(5)
+	tree, err := NewTree(leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	depths := make(map[int]int)
+	for _, leaf := range leaves {
+		proof, _ := tree.GetProof(leaf)
+		leafHash, _ := leaf.CalculateHash()
+
+		ok, depth, err := VerifyProofWithDepth(leafHash, proof, tree.MerkleRoot(), sha3.NewLegacyKeccak256)
+		if err != nil || !ok {
+			t.Fatal("proof does not verify")
+		}
+		if depth != len(proof.Siblings) {
+			t.Fatalf("depth %d does not match proof length %d", depth, len(proof.Siblings))
+		}
+		depths[depth]++
+	}
+	if depths[3] != 4 || depths[1] != 1 {
+		t.Fatalf("unexpected depth distribution %v", depths)
+	}
+}
+
+func Test_VerifyProof(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 6, 16, 19} {
+		leaves := testLeaves(n)
+		tree, err := NewTree(leaves)
+		if err != nil {
+			t.Fatal(err)
+		}
+		root := tree.MerkleRoot()
+
+		for _, leaf := range leaves {
+			path, _, err := tree.GetMerklePath(leaf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			leafHash, _ := leaf.CalculateHash()
+
+			ok, err := VerifyProof(root, leafHash, path, sha3.NewLegacyKeccak256)
+			if err != nil || !ok {
+				t.Fatalf("%d leaves: proof does not verify", n)
+			}
+			if ok, _ := VerifyProof(root, []byte("forged"), path, sha3.NewLegacyKeccak256); ok {
+				t.Fatalf("%d leaves: forged leaf verifies", n)
+			}
+		}
+	}
+}
+
+func Test_AllProofs(t *testing.T) {
+	for _, n := range []int{1, 2, 5, 8, 13} {
+		tree, err := NewTree(testLeaves(n))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		proofs, err := tree.AllProofs()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(proofs) != n {
+			t.Fatalf("%d leaves: got %d proofs", n, len(proofs))
+		}
+		for i, leaf := range tree.Leafs {
+			if !reflect.DeepEqual(proofs[i], tree.proofOf(leaf)) {
+				t.Fatalf("%d leaves: proof %d differs from GetProof", n, i)
+			}
+		}
+	}
+
+	if _, err := (&MerkleTree{}).AllProofs(); !errors.Is(err, ErrEmptyTree) {
+		t.Fatal("expected ErrEmptyTree")
+	}
+}
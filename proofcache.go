@@ -0,0 +1,84 @@
+package merkletree
+
+import (
+	"bytes"
+	"sync"
+)
+
+// proofCache memoizes the merkle paths of leaves looked up by content. Entries
+// are keyed by leaf hash and belong to the root they were computed under; the
+// cache empties itself as soon as it sees that the tree has a different root.
+type proofCache struct {
+	mu      sync.Mutex
+	root    []byte
+	entries map[string][]cachedPath
+}
+
+type cachedPath struct {
+	leaf  *Node
+	path  [][]byte
+	index []int64
+}
+
+// WithProofCache makes GetMerklePath and GetProof memoize the path of every
+// content they are asked about, which pays off when the same leaves are proven
+// over and over. Rebuilding the tree invalidates the cache.
+func WithProofCache() Option {
+	return func(m *MerkleTree) {
+		m.proofCache = &proofCache{}
+	}
+}
+
+// leafPath returns the leaf of content and its merkle path, going through the
+// proof cache when the tree has one. The returned slices are owned by the caller.
+func (m *MerkleTree) leafPath(content Content) (*Node, [][]byte, []int64, error) {
+	if m.proofCache == nil {
+		leaf, err := m.findLeaf(content)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		path, index := leaf.merklePath()
+		return leaf, path, index, nil
+	}
+
+	hashBz, err := content.CalculateHash()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	c := m.proofCache
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.entries == nil || !bytes.Equal(c.root, m.merkleRoot) {
+		c.root = m.merkleRoot
+		c.entries = make(map[string][]cachedPath)
+	}
+	for _, e := range c.entries[string(hashBz)] {
+		ok, err := e.leaf.C.Equals(content)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		if ok {
+			return e.leaf, append([][]byte(nil), e.path...), append([]int64(nil), e.index...), nil
+		}
+	}
+
+	leaf, err := m.findLeaf(content)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	path, index := leaf.merklePath()
+	c.entries[string(hashBz)] = append(c.entries[string(hashBz)], cachedPath{leaf: leaf, path: path, index: index})
+	return leaf, append([][]byte(nil), path...), append([]int64(nil), index...), nil
+}
+
+// invalidate drops every cached path. Changes that keep the root, such as
+// replacing a leaf with one of the same hash, would not empty the cache.
+func (c *proofCache) invalidate() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	c.entries = nil
+	c.mu.Unlock()
+}
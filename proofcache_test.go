@@ -0,0 +1,51 @@
+package merkletree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_ProofCache(t *testing.T) {
+	leaves := testLeaves(9)
+	tree, err := NewTreeWithOptions(leaves, WithProofCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, _ := NewTree(leaves)
+
+	for round := 0; round < 2; round++ {
+		for _, leaf := range leaves {
+			path, index, err := tree.GetMerklePath(leaf)
+			if err != nil {
+				t.Fatal(err)
+			}
+			wantPath, wantIndex, _ := plain.GetMerklePath(leaf)
+			if !reflect.DeepEqual(path, wantPath) || !reflect.DeepEqual(index, wantIndex) {
+				t.Fatalf("round %d: cached path differs", round)
+			}
+			// callers may modify what they get back
+			if len(path) > 0 {
+				path[0] = nil
+			}
+		}
+	}
+	if n := len(tree.proofCache.entries); n != len(leaves) {
+		t.Fatalf("expected %d cache entries, got %d", len(leaves), n)
+	}
+
+	grown := testLeaves(12)
+	if err := tree.RebuildTreeWith(grown); err != nil {
+		t.Fatal(err)
+	}
+	plain, _ = NewTree(grown)
+	for _, leaf := range grown {
+		proof, err := tree.GetProof(leaf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := plain.GetProof(leaf)
+		if !reflect.DeepEqual(proof, want) {
+			t.Fatal("cache was not invalidated by the rebuild")
+		}
+	}
+}
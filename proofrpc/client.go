@@ -0,0 +1,55 @@
+package proofrpc
+
+import (
+	"context"
+
+	"github.com/smartbch/merkletree"
+	"google.golang.org/grpc"
+)
+
+// Client calls a ProofService and converts its answers to the types of package
+// merkletree.
+type Client struct {
+	c ProofServiceClient
+}
+
+// NewClient returns a client of the ProofService reached through cc.
+func NewClient(cc grpc.ClientConnInterface) *Client {
+	return &Client{c: NewProofServiceClient(cc)}
+}
+
+// Root returns the root and leaf count of the served tree.
+func (c *Client) Root(ctx context.Context) ([]byte, uint64, error) {
+	resp, err := c.c.GetRoot(ctx, &GetRootRequest{})
+	if err != nil {
+		return nil, 0, err
+	}
+	return resp.GetRoot(), resp.GetLeafCount(), nil
+}
+
+// Proof returns the proof of the leaf whose hash is leafHash.
+func (c *Client) Proof(ctx context.Context, leafHash []byte) (*merkletree.MerkleProof, error) {
+	resp, err := c.c.GetProof(ctx, &GetProofRequest{LeafHash: leafHash})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetProof().MerkleProof(), nil
+}
+
+// MultiProof returns one proof of the leaves whose hashes are leafHashes.
+func (c *Client) MultiProof(ctx context.Context, leafHashes [][]byte) (*merkletree.MultiProof, error) {
+	resp, err := c.c.GetMultiProof(ctx, &GetMultiProofRequest{LeafHashes: leafHashes})
+	if err != nil {
+		return nil, err
+	}
+	return resp.GetProof().MultiProof(), nil
+}
+
+// VerifyProof has the server check proof against the root of its tree.
+func (c *Client) VerifyProof(ctx context.Context, proof *merkletree.MerkleProof) (bool, error) {
+	resp, err := c.c.VerifyProof(ctx, &VerifyProofRequest{Proof: NewProof(proof)})
+	if err != nil {
+		return false, err
+	}
+	return resp.GetValid(), nil
+}
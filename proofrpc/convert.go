@@ -0,0 +1,34 @@
+package proofrpc
+
+import "github.com/smartbch/merkletree"
+
+// NewProof returns the message of p.
+func NewProof(p *merkletree.MerkleProof) *Proof {
+	return &Proof{LeafHash: p.LeafHash, Root: p.Root, Siblings: p.Siblings, Path: p.Path}
+}
+
+// MerkleProof returns the proof held by the message. A nil message gives an
+// empty proof.
+func (x *Proof) MerkleProof() *merkletree.MerkleProof {
+	return &merkletree.MerkleProof{
+		LeafHash: x.GetLeafHash(),
+		Root:     x.GetRoot(),
+		Siblings: x.GetSiblings(),
+		Path:     x.GetPath(),
+	}
+}
+
+// NewMultiProof returns the message of mp.
+func NewMultiProof(mp *merkletree.MultiProof) *MultiProof {
+	return &MultiProof{Leaves: mp.Leaves, Proof: mp.Proof, ProofFlags: mp.ProofFlags}
+}
+
+// MultiProof returns the multiproof held by the message. A nil message gives an
+// empty multiproof.
+func (x *MultiProof) MultiProof() *merkletree.MultiProof {
+	return &merkletree.MultiProof{
+		Leaves:     x.GetLeaves(),
+		Proof:      x.GetProof(),
+		ProofFlags: x.GetProofFlags(),
+	}
+}
@@ -0,0 +1,88 @@
+// Package proofrpc serves the root and proofs of a tree over gRPC, see
+// proof.proto for the service definition. Server implements the service on a
+// frozen tree and Client wraps the generated stub with the types of package
+// merkletree.
+package proofrpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative proof.proto
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/smartbch/merkletree"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Server implements ProofService on a frozen tree, which may be swapped for a
+// newer one while the server runs.
+type Server struct {
+	UnimplementedProofServiceServer
+	tree atomic.Pointer[merkletree.FrozenTree]
+}
+
+// NewServer returns a server of tree.
+func NewServer(tree *merkletree.FrozenTree) *Server {
+	s := &Server{}
+	s.tree.Store(tree)
+	return s
+}
+
+// Register registers srv as the ProofService of s.
+func Register(s *grpc.Server, srv *Server) {
+	RegisterProofServiceServer(s, srv)
+}
+
+// SetTree makes the server answer with tree from now on. Calls in progress
+// finish with the tree they started with.
+func (s *Server) SetTree(tree *merkletree.FrozenTree) {
+	s.tree.Store(tree)
+}
+
+// GetRoot implements ProofServiceServer.
+func (s *Server) GetRoot(ctx context.Context, req *GetRootRequest) (*GetRootResponse, error) {
+	tree := s.tree.Load()
+	return &GetRootResponse{Root: tree.MerkleRoot(), LeafCount: uint64(tree.LeafCount())}, nil
+}
+
+// GetProof implements ProofServiceServer.
+func (s *Server) GetProof(ctx context.Context, req *GetProofRequest) (*GetProofResponse, error) {
+	proof, err := s.tree.Load().GetProofByLeafHash(req.GetLeafHash())
+	if err != nil {
+		return nil, statusOf(err)
+	}
+	return &GetProofResponse{Proof: NewProof(proof)}, nil
+}
+
+// GetMultiProof implements ProofServiceServer.
+func (s *Server) GetMultiProof(ctx context.Context, req *GetMultiProofRequest) (*GetMultiProofResponse, error) {
+	mp, err := s.tree.Load().GetMultiProofByLeafHashes(req.GetLeafHashes())
+	if err != nil {
+		return nil, statusOf(err)
+	}
+	return &GetMultiProofResponse{Proof: NewMultiProof(mp)}, nil
+}
+
+// VerifyProof implements ProofServiceServer.
+func (s *Server) VerifyProof(ctx context.Context, req *VerifyProofRequest) (*VerifyProofResponse, error) {
+	if req.GetProof() == nil {
+		return nil, status.Error(codes.InvalidArgument, "missing proof")
+	}
+	tree := s.tree.Load()
+	valid, err := tree.VerifyProof(req.GetProof().MerkleProof())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &VerifyProofResponse{Valid: valid, Root: tree.MerkleRoot()}, nil
+}
+
+// statusOf maps an error of the tree to a gRPC status.
+func statusOf(err error) error {
+	switch err {
+	case merkletree.ErrContentNotFound, merkletree.ErrEmptyTree:
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
@@ -0,0 +1,117 @@
+package proofrpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/smartbch/merkletree"
+	"golang.org/x/crypto/sha3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func testTree(t *testing.T, n int) (*merkletree.FrozenTree, [][]byte) {
+	t.Helper()
+	var leaves []merkletree.Content
+	var hashes [][]byte
+	for i := 0; i < n; i++ {
+		c := merkletree.ByteContent(fmt.Sprintf("leaf-%d", i))
+		hashBz, _ := c.CalculateHash()
+		leaves, hashes = append(leaves, c), append(hashes, hashBz)
+	}
+	tree, err := merkletree.NewTree(leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree.Freeze(), hashes
+}
+
+// startServer serves srv over an in-memory connection and returns a client of it.
+func startServer(t *testing.T, srv *Server) *Client {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	s := grpc.NewServer()
+	Register(s, srv)
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	cc, err := grpc.Dial("bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cc.Close() })
+	return NewClient(cc)
+}
+
+func Test_ProofService(t *testing.T) {
+	ctx := context.Background()
+	tree, hashes := testTree(t, 8)
+	srv := NewServer(tree)
+	c := startServer(t, srv)
+
+	root, count, err := c.Root(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(root, tree.MerkleRoot()) || count != 8 {
+		t.Fatal("unexpected root")
+	}
+
+	proof, err := c.Proof(ctx, hashes[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := tree.VerifyProof(proof); err != nil || !ok {
+		t.Fatal("served proof does not verify")
+	}
+	if ok, err := c.VerifyProof(ctx, proof); err != nil || !ok {
+		t.Fatal("expected the server to verify its proof")
+	}
+	proof.LeafHash = hashes[4]
+	if ok, err := c.VerifyProof(ctx, proof); err != nil || ok {
+		t.Fatal("expected a tampered proof not to verify")
+	}
+
+	mp, err := c.MultiProof(ctx, hashes[1:4])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := merkletree.VerifyMultiProof(root, mp, sha3.NewLegacyKeccak256); err != nil || !ok {
+		t.Fatal("served multiproof does not verify")
+	}
+
+	next, _ := testTree(t, 9)
+	srv.SetTree(next)
+	if _, count, _ := c.Root(ctx); count != 9 {
+		t.Fatal("expected the new tree to be served")
+	}
+}
+
+func Test_ProofServiceErrors(t *testing.T) {
+	ctx := context.Background()
+	tree, hashes := testTree(t, 4)
+	c := startServer(t, NewServer(tree))
+
+	if _, err := c.Proof(ctx, []byte("missing")); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+	if _, err := c.MultiProof(ctx, [][]byte{hashes[0], []byte("missing")}); status.Code(err) != codes.NotFound {
+		t.Fatalf("expected NotFound, got %v", err)
+	}
+	if _, err := c.MultiProof(ctx, nil); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+	if _, err := c.c.VerifyProof(ctx, &VerifyProofRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("expected InvalidArgument, got %v", err)
+	}
+}
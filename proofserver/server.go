@@ -0,0 +1,146 @@
+// Package proofserver serves the root and proofs of a tree over HTTP with JSON
+// responses, for claim frontends and other clients that fetch proofs on demand:
+//
+//	GET  /root             {"root": "0x…", "leafCount": n}
+//	GET  /proof/{leafHash} the proof of the leaf in canonical JSON form
+//	POST /verify           a proof in canonical JSON form, answered with
+//	                       {"valid": bool, "root": "0x…"}
+//
+// Errors are answered with {"error": "…"} and a 4xx status.
+package proofserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/smartbch/merkletree"
+	"github.com/smartbch/merkletree/merkleproof"
+)
+
+// maxBodySize bounds the proofs accepted by /verify.
+const maxBodySize = 1 << 20
+
+// Server is an http.Handler serving the proofs of a frozen tree, which may be
+// swapped for a newer one while the server runs.
+type Server struct {
+	tree atomic.Pointer[merkletree.FrozenTree]
+	mux  *http.ServeMux
+}
+
+// New returns a server of tree.
+func New(tree *merkletree.FrozenTree) *Server {
+	s := &Server{mux: http.NewServeMux()}
+	s.tree.Store(tree)
+	s.mux.HandleFunc("/root", s.handleRoot)
+	s.mux.HandleFunc("/proof/", s.handleProof)
+	s.mux.HandleFunc("/verify", s.handleVerify)
+	return s
+}
+
+// ListenAndServe serves tree on the TCP address addr until the server fails.
+func ListenAndServe(addr string, tree *merkletree.FrozenTree) error {
+	return http.ListenAndServe(addr, New(tree))
+}
+
+// SetTree makes the server answer with tree from now on. Requests in progress
+// finish with the tree they started with.
+func (s *Server) SetTree(tree *merkletree.FrozenTree) {
+	s.tree.Store(tree)
+}
+
+// ServeHTTP implements http.Handler.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// RootResponse is the answer of /root.
+type RootResponse struct {
+	Root      hexutil.Bytes `json:"root"`
+	LeafCount int           `json:"leafCount"`
+}
+
+// VerifyResponse is the answer of /verify.
+type VerifyResponse struct {
+	Valid bool          `json:"valid"`
+	Root  hexutil.Bytes `json:"root"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func (s *Server) handleRoot(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	tree := s.tree.Load()
+	writeJSON(w, http.StatusOK, RootResponse{Root: tree.MerkleRoot(), LeafCount: tree.LeafCount()})
+}
+
+func (s *Server) handleProof(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	leafHash, err := hexutil.Decode(strings.TrimPrefix(r.URL.Path, "/proof/"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid leaf hash: "+err.Error())
+		return
+	}
+	proof, err := s.tree.Load().GetProofByLeafHash(leafHash)
+	if err == merkletree.ErrContentNotFound || err == merkletree.ErrEmptyTree {
+		writeError(w, http.StatusNotFound, "leaf not found")
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, proof)
+}
+
+func (s *Server) handleVerify(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+	var hp merkleproof.HexProof
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err := dec.Decode(&hp); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid proof: "+err.Error())
+		return
+	}
+	proof, err := hp.ToProof()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	tree := s.tree.Load()
+	valid, err := tree.VerifyProof(proof)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, VerifyResponse{Valid: valid, Root: tree.MerkleRoot()})
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, errorResponse{Error: msg})
+}
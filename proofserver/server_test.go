@@ -0,0 +1,120 @@
+package proofserver
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/smartbch/merkletree"
+)
+
+func testTree(t *testing.T, n int) *merkletree.FrozenTree {
+	t.Helper()
+	var leaves []merkletree.Content
+	for i := 0; i < n; i++ {
+		leaves = append(leaves, merkletree.ByteContent(fmt.Sprintf("leaf-%d", i)))
+	}
+	tree, err := merkletree.NewTree(leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree.Freeze()
+}
+
+func get(t *testing.T, srv *httptest.Server, path string, v interface{}) int {
+	t.Helper()
+	resp, err := http.Get(srv.URL + path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode
+}
+
+func post(t *testing.T, srv *httptest.Server, path, body string, v interface{}) int {
+	t.Helper()
+	resp, err := http.Post(srv.URL+path, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		t.Fatal(err)
+	}
+	return resp.StatusCode
+}
+
+func Test_Server(t *testing.T) {
+	tree := testTree(t, 9)
+	s := New(tree)
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+
+	var root RootResponse
+	if status := get(t, srv, "/root", &root); status != http.StatusOK {
+		t.Fatalf("unexpected status %d", status)
+	}
+	if !bytes.Equal(root.Root, tree.MerkleRoot()) || root.LeafCount != 9 {
+		t.Fatal("unexpected root")
+	}
+
+	leafHash := hexutil.Encode(tree.LeafHash(4))
+	var proof merkletree.MerkleProof
+	if status := get(t, srv, "/proof/"+leafHash, &proof); status != http.StatusOK {
+		t.Fatalf("unexpected status %d", status)
+	}
+	if ok, err := tree.VerifyProof(&proof); err != nil || !ok {
+		t.Fatal("served proof does not verify")
+	}
+
+	body, _ := json.Marshal(&proof)
+	var verified VerifyResponse
+	if status := post(t, srv, "/verify", string(body), &verified); status != http.StatusOK || !verified.Valid {
+		t.Fatal("expected the proof to verify")
+	}
+	proof.LeafHash = tree.LeafHash(5)
+	body, _ = json.Marshal(&proof)
+	if post(t, srv, "/verify", string(body), &verified); verified.Valid {
+		t.Fatal("expected a tampered proof not to verify")
+	}
+
+	// a new tree answers from then on
+	s.SetTree(testTree(t, 10))
+	if get(t, srv, "/root", &root); root.LeafCount != 10 {
+		t.Fatal("expected the new tree to be served")
+	}
+}
+
+func Test_ServerErrors(t *testing.T) {
+	srv := httptest.NewServer(New(testTree(t, 3)))
+	defer srv.Close()
+
+	var e errorResponse
+	for path, want := range map[string]int{
+		"/proof/0x1234": http.StatusNotFound,
+		"/proof/xyz":    http.StatusBadRequest,
+		"/verify":       http.StatusMethodNotAllowed,
+	} {
+		e = errorResponse{}
+		if status := get(t, srv, path, &e); status != want || e.Error == "" {
+			t.Fatalf("%s: expected status %d with an error, got %d", path, want, status)
+		}
+	}
+	if status := post(t, srv, "/verify", "{", &e); status != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", status)
+	}
+	if status := post(t, srv, "/verify", `{"root":"0x","leaf":"0x","siblings":["zz"]}`, &e); status != http.StatusBadRequest {
+		t.Fatalf("expected status 400, got %d", status)
+	}
+	if status := post(t, srv, "/root", "", &e); status != http.StatusMethodNotAllowed {
+		t.Fatalf("expected status 405, got %d", status)
+	}
+}
@@ -0,0 +1,56 @@
+package merkletree
+
+import (
+	"bytes"
+	"hash"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// ProtoContent is a leaf committing to a protobuf message. The message is
+// marshalled deterministically, so messages with map fields always produce the
+// same bytes and therefore the same leaf hash within one protobuf version.
+type ProtoContent struct {
+	Msg          proto.Message
+	data         []byte
+	hashStrategy func() hash.Hash
+}
+
+// NewProtoContent marshals msg deterministically for use as a leaf hashed with
+// hashStrategy.
+func NewProtoContent(msg proto.Message, hashStrategy func() hash.Hash) (ProtoContent, error) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(msg)
+	if err != nil {
+		return ProtoContent{}, err
+	}
+	return ProtoContent{Msg: msg, data: data, hashStrategy: hashStrategy}, nil
+}
+
+// CalculateHash hashes the deterministic encoding of the message
+func (p ProtoContent) CalculateHash() ([]byte, error) {
+	h := p.hashStrategy()
+	if _, err := h.Write(p.data); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// Equals compares the deterministic encodings of two messages
+func (p ProtoContent) Equals(other Content) (bool, error) {
+	o, ok := other.(ProtoContent)
+	return ok && bytes.Equal(p.data, o.data), nil
+}
+
+// NewTreeFromProtoMessages builds a tree whose leaves are the hashes of the
+// deterministic encodings of msgs, using hashStrategy for both leaves and nodes.
+func NewTreeFromProtoMessages(msgs []proto.Message, hashStrategy func() hash.Hash) (*MerkleTree, error) {
+	cs := make([]Content, len(msgs))
+	for i, msg := range msgs {
+		c, err := NewProtoContent(msg, hashStrategy)
+		if err != nil {
+			return nil, err
+		}
+		cs[i] = c
+	}
+	return NewTreeWithHashStrategy(cs, hashStrategy)
+}
@@ -0,0 +1,53 @@
+package merkletree
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func protoRecords(t *testing.T) []proto.Message {
+	var msgs []proto.Message
+	for i := 0; i < 6; i++ {
+		// map fields make non-deterministic marshalling order visible
+		s, err := structpb.NewStruct(map[string]interface{}{
+			"id":      i,
+			"owner":   fmt.Sprintf("0x%040x", i),
+			"amount":  float64(i * 100),
+			"enabled": i%2 == 0,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		msgs = append(msgs, s)
+	}
+	return msgs
+}
+
+func Test_NewTreeFromProtoMessages(t *testing.T) {
+	var root []byte
+	for run := 0; run < 10; run++ {
+		tree, err := NewTreeFromProtoMessages(protoRecords(t), sha3.NewLegacyKeccak256)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if root != nil && !bytes.Equal(root, tree.MerkleRoot()) {
+			t.Fatal("root is not stable across runs")
+		}
+		root = tree.MerkleRoot()
+	}
+
+	msgs := protoRecords(t)
+	tree, _ := NewTreeFromProtoMessages(msgs, sha3.NewLegacyKeccak256)
+	c, err := NewProtoContent(msgs[2], sha3.NewLegacyKeccak256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := tree.VerifyContent(c); err != nil || !ok {
+		t.Fatal("proto content does not verify")
+	}
+}
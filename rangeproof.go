@@ -0,0 +1,126 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+)
+
+// RangeProof proves which leaves of a tree have hashes between Start and End
+// inclusive. Proofs covers consecutive leaves starting at index First: every leaf
+// in the range, preceded by the last leaf below Start and followed by the first
+// leaf above End when those exist. The neighbours show that no leaf of the range
+// was left out. As with NonMembershipProof, the tree must keep its leaves sorted
+// and combine pairs positionally.
+type RangeProof struct {
+	Start     []byte
+	End       []byte
+	First     uint64
+	LeafCount uint64
+	Proofs    *ProofBundle
+}
+
+// GetRangeProof returns the proof of all leaves whose hashes fall in
+// [startHash, endHash].
+func (m *MerkleTree) GetRangeProof(startHash, endHash []byte) (*RangeProof, error) {
+	if len(m.Leafs) == 0 {
+		return nil, ErrEmptyTree
+	}
+	if !m.sortedPositional() {
+		return nil, errors.New("error: range proofs require sorted leaves and unsorted pairs")
+	}
+	if bytes.Compare(startHash, endHash) > 0 {
+		return nil, errors.New("error: range start is after its end")
+	}
+
+	// [from, to) is the range itself, widened by one leaf on each side if possible
+	from := m.searchLeafs(startHash)
+	to := from
+	for to < len(m.Leafs) && bytes.Compare(m.Leafs[to].Hash, endHash) <= 0 {
+		to++
+	}
+	if from > 0 {
+		from--
+	}
+	if to < len(m.Leafs) {
+		to++
+	}
+
+	proofs := make([]*Proof, 0, to-from)
+	for _, leaf := range m.Leafs[from:to] {
+		proofs = append(proofs, m.proofOf(leaf))
+	}
+	bundle, err := BundleProofs(proofs)
+	if err != nil {
+		return nil, err
+	}
+	return &RangeProof{
+		Start:     startHash,
+		End:       endHash,
+		First:     uint64(from),
+		LeafCount: uint64(len(m.Leafs)),
+		Proofs:    bundle,
+	}, nil
+}
+
+// VerifyRangeProof checks p against root and leafCount for a tree built with opts
+// and returns the hashes of the leaves in the range, in sorted order. The proofs
+// must cover consecutive positions with increasing hashes, each verified at its
+// position as by VerifyIndexedProof, and the first and last of them must lie
+// below and above the range unless they are the first and last leaves of the
+// tree. Trees that sort their pairs are refused.
+func VerifyRangeProof(p *RangeProof, root []byte, leafCount uint64, opts ...Option) ([][]byte, bool, error) {
+	if p == nil || p.Proofs == nil {
+		return nil, false, errors.New("error: empty range proof")
+	}
+	if !newConfiguredTree(opts).sortedPositional() {
+		return nil, false, errors.New("error: range proofs require sorted leaves and unsorted pairs")
+	}
+	if p.LeafCount != leafCount || bytes.Compare(p.Start, p.End) > 0 {
+		return nil, false, nil
+	}
+
+	proofs, err := p.Proofs.Expand()
+	if err != nil {
+		return nil, false, err
+	}
+	if len(proofs) == 0 || p.First+uint64(len(proofs)) > leafCount {
+		return nil, false, nil
+	}
+
+	var inRange [][]byte
+	for k, proof := range proofs {
+		index := p.First + uint64(k)
+		if k > 0 && bytes.Compare(proofs[k-1].LeafHash, proof.LeafHash) >= 0 {
+			return nil, false, nil
+		}
+		ok, err := VerifyIndexedProof(root, int(index), int(leafCount), proof, opts...)
+		if err != nil || !ok {
+			return nil, false, err
+		}
+
+		switch {
+		case bytes.Compare(proof.LeafHash, p.Start) < 0:
+			// only the left neighbour may sort below the range
+			if k != 0 {
+				return nil, false, nil
+			}
+		case bytes.Compare(proof.LeafHash, p.End) > 0:
+			// only the right neighbour may sort above the range
+			if k != len(proofs)-1 {
+				return nil, false, nil
+			}
+		default:
+			inRange = append(inRange, proof.LeafHash)
+		}
+	}
+
+	// without a neighbour, the range must reach the edge of the tree
+	if p.First > 0 && bytes.Compare(proofs[0].LeafHash, p.Start) >= 0 {
+		return nil, false, nil
+	}
+	last := proofs[len(proofs)-1].LeafHash
+	if p.First+uint64(len(proofs)) < leafCount && bytes.Compare(last, p.End) <= 0 {
+		return nil, false, nil
+	}
+	return inRange, true, nil
+}
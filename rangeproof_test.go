@@ -0,0 +1,79 @@
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_RangeProof(t *testing.T) {
+	opts := []Option{WithSortPairs(false)}
+	tree, err := NewTreeWithOptions(testLeaves(20), opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, count := tree.MerkleRoot(), uint64(len(tree.Leafs))
+	low, high := make([]byte, 32), bytes.Repeat([]byte{0xff}, 32)
+	hashAt := func(i int) []byte { return tree.Leafs[i].Hash }
+
+	cases := []struct {
+		start, end []byte
+		want       int
+	}{
+		{hashAt(3), hashAt(9), 7},
+		{low, hashAt(4), 5},
+		{hashAt(15), high, 5},
+		{low, high, 20},
+		{hashAt(6), hashAt(6), 1},
+		{append(hashAt(6), 0), append(hashAt(6), 1), 0},
+	}
+	for _, c := range cases {
+		proof, err := tree.GetRangeProof(c.start, c.end)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leaves, ok, err := VerifyRangeProof(proof, root, count, opts...)
+		if err != nil || !ok {
+			t.Fatalf("range %x..%x does not verify", c.start, c.end)
+		}
+		if len(leaves) != c.want {
+			t.Fatalf("got %d leaves in range, want %d", len(leaves), c.want)
+		}
+	}
+
+	// dropping the right neighbour hides whether the range was complete
+	proof, _ := tree.GetRangeProof(hashAt(3), hashAt(9))
+	proofs, _ := proof.Proofs.Expand()
+	proof.Proofs, _ = BundleProofs(proofs[:len(proofs)-2])
+	if _, ok, _ := VerifyRangeProof(proof, root, count, opts...); ok {
+		t.Fatal("truncated range verifies")
+	}
+
+	// skipping a leaf in the middle breaks the positions
+	proof.Proofs, _ = BundleProofs(append(proofs[:3:3], proofs[4:]...))
+	if _, ok, _ := VerifyRangeProof(proof, root, count, opts...); ok {
+		t.Fatal("range with a missing leaf verifies")
+	}
+
+	// omitting the first leaf of the range and shifting the others into its
+	// position with made-up directions must not verify either
+	proof, _ = tree.GetRangeProof(hashAt(3), hashAt(9))
+	proofs, _ = proof.Proofs.Expand()
+	shifted := append(proofs[:1:1], proofs[2:]...)
+	for k, pr := range shifted {
+		pr.Path = expectedPath(proof.First+uint64(k), count, false)
+	}
+	proof.Proofs, _ = BundleProofs(shifted)
+	if _, ok, _ := VerifyRangeProof(proof, root, count, opts...); ok {
+		t.Fatal("range without its boundary leaf verifies")
+	}
+
+	// sorted pairs do not bind positions
+	sorted, _ := NewTree(testLeaves(20))
+	if _, err := sorted.GetRangeProof(hashAt(3), hashAt(9)); err == nil {
+		t.Fatal("expected error for a tree with sorted pairs")
+	}
+	proof, _ = tree.GetRangeProof(hashAt(3), hashAt(9))
+	if _, _, err := VerifyRangeProof(proof, root, count); err == nil {
+		t.Fatal("expected error for sorted pairs")
+	}
+}
@@ -0,0 +1,183 @@
+package merkletree
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+)
+
+// maxFetch bounds the hashes of a single NodeFetcher call.
+const maxFetch = 1 << 12
+
+// NodeFetcher fetches the nodes of a remote tree in the layout written by
+// Persist: the value of an internal node is its left child hash followed by its
+// right child hash, that of a leaf a one-byte marker.
+type NodeFetcher interface {
+	// FetchNodes returns the values of the nodes with hashes, in their order.
+	FetchNodes(ctx context.Context, hashes [][]byte) ([][]byte, error)
+}
+
+// StoreFetcher returns the NodeFetcher of the trees persisted in s, such as a
+// replica of the store of a remote service.
+func StoreFetcher(s NodeStore) NodeFetcher {
+	return storeFetcher{s}
+}
+
+type storeFetcher struct {
+	s NodeStore
+}
+
+func (f storeFetcher) FetchNodes(ctx context.Context, hashes [][]byte) ([][]byte, error) {
+	values := make([][]byte, len(hashes))
+	for k, hashBz := range hashes {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		value, err := f.s.Get(hashBz)
+		if err != nil {
+			return nil, fmt.Errorf("error: node %x: %w", hashBz, err)
+		}
+		values[k] = value
+	}
+	return values, nil
+}
+
+// Reconciliation lists how the leaves of a tree differ from those of a remote
+// tree, see Reconcile.
+type Reconciliation struct {
+	// Missing holds the hashes of the remote leaves the tree does not have, in
+	// the order they were found. They are not authenticated: a leaf is only a
+	// marker in the remote store, so a remote that reports an internal node as
+	// a leaf hides the leaves under it. Check each content fetched for them
+	// with LeafHashOf before relying on it.
+	Missing [][]byte
+	// Extra holds the leaves of the tree the remote tree does not have, indexed
+	// by their position in the tree
+	Extra []LeafDiff
+	// Fetched is the number of remote nodes fetched
+	Fetched int
+}
+
+// Empty reports whether both trees hold the same leaves.
+func (r *Reconciliation) Empty() bool {
+	return len(r.Missing) == 0 && len(r.Extra) == 0
+}
+
+// Reconcile compares the leaves of the tree, taken as a set, with those of the
+// remote tree with remoteRoot, whose nodes f fetches. The remote tree is walked
+// from its root down, a level per round of fetches, and any remote node whose
+// hash is that of a node anywhere in the tree is not descended into: the tree
+// holds all of its leaves. Only the nodes above the differences are fetched, so
+// the cost depends on where the sets differ rather than on their size. Both
+// trees must hash nodes alike. Every fetched internal node is checked against its
+// hash, but the leaves the remote reports carry no preimage and are taken on
+// trust, see Reconciliation.Missing.
+//
+// Sorted leaves shift when a leaf comes or goes, so subtrees after a difference
+// seldom match; sets that mostly grow at the end reconcile best in trees built
+// WithInsertionOrder.
+func (m *MerkleTree) Reconcile(ctx context.Context, remoteRoot []byte, f NodeFetcher) (*Reconciliation, error) {
+	if m.levelTag != nil {
+		return nil, errors.New("error: reconciliation does not support level tags")
+	}
+	r := &Reconciliation{}
+	if m.Root != nil && bytes.Equal(remoteRoot, m.merkleRoot) {
+		return r, nil
+	}
+
+	// spans maps the hash of every node of the tree to the leaves under it
+	type span struct{ first, end int }
+	spans := make(map[string]span)
+	var index func(n *Node, first int) int
+	index = func(n *Node, first int) int {
+		end := first + 1
+		switch {
+		case n.leaf:
+		case n.Left == n.Right:
+			end = index(n.Left, first)
+		default:
+			end = index(n.Right, index(n.Left, first))
+		}
+		if _, ok := spans[string(n.Hash)]; !ok {
+			spans[string(n.Hash)] = span{first, end}
+		}
+		return end
+	}
+	if m.Root != nil {
+		index(m.Root, 0)
+	}
+
+	held := make([]bool, len(m.Leafs))
+	frontier := [][]byte{remoteRoot}
+	if m.emptyRoot != nil && bytes.Equal(remoteRoot, m.emptyRoot) {
+		frontier = nil
+	}
+	for len(frontier) > 0 {
+		var fetch [][]byte
+		for _, hashBz := range frontier {
+			if s, ok := spans[string(hashBz)]; ok {
+				for i := s.first; i < s.end; i++ {
+					held[i] = true
+				}
+				continue
+			}
+			fetch = append(fetch, hashBz)
+		}
+
+		var next [][]byte
+		for start := 0; start < len(fetch); start += maxFetch {
+			end := start + maxFetch
+			if end > len(fetch) {
+				end = len(fetch)
+			}
+			values, err := f.FetchNodes(ctx, fetch[start:end])
+			if err != nil {
+				return nil, err
+			}
+			if len(values) != end-start {
+				return nil, fmt.Errorf("error: fetched %d nodes for %d hashes", len(values), end-start)
+			}
+			r.Fetched += len(values)
+
+			for k, value := range values {
+				hashBz := fetch[start+k]
+				if bytes.Equal(value, storeLeafMarker) {
+					r.Missing = append(r.Missing, hashBz)
+					continue
+				}
+				if len(value) == 0 || len(value)%2 != 0 {
+					return nil, fmt.Errorf("error: malformed remote node %x", hashBz)
+				}
+				half := len(value) / 2
+				left, right := value[:half], value[half:]
+				computed, err := m.hashPair(0, left, right)
+				if err != nil {
+					return nil, err
+				}
+				if !bytes.Equal(computed, hashBz) {
+					return nil, fmt.Errorf("error: remote node %x does not hash to its children", hashBz)
+				}
+				next = append(next, left)
+				if !bytes.Equal(left, right) {
+					next = append(next, right)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	for i, leaf := range m.Leafs {
+		if !held[i] {
+			r.Extra = append(r.Extra, LeafDiff{Index: i, Leaf: leaf})
+		}
+	}
+	return r, nil
+}
+
+// LeafHashOf returns the hash c has as a leaf of the tree, with the leaf hashing
+// of its options applied. A content fetched for a hash of Reconciliation.Missing
+// is that leaf only if LeafHashOf returns the same hash.
+func (m *MerkleTree) LeafHashOf(c Content) ([]byte, error) {
+	return m.leafHash(c)
+}
@@ -0,0 +1,129 @@
+package merkletree
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"testing"
+)
+
+// leafHashSet returns the sorted leaf hashes of tree.
+func leafHashSet(tree *MerkleTree) []string {
+	var hashes []string
+	for _, leaf := range tree.Leafs {
+		hashes = append(hashes, string(leaf.Hash))
+	}
+	sort.Strings(hashes)
+	return hashes
+}
+
+func Test_Reconcile(t *testing.T) {
+	leaves := testLeaves(120)
+	for _, opts := range [][]Option{nil, {WithInsertionOrder()}, {WithOddNodePolicy(DuplicateLast)}, {WithRFC6962()}} {
+		remote, _ := NewTreeWithOptions(leaves[:100], opts...)
+		store := NewMemNodeStore()
+		if err := remote.PersistTo(store); err != nil {
+			t.Fatal(err)
+		}
+		local, _ := NewTreeWithOptions(append(append([]Content(nil), leaves[5:90]...), leaves[110:]...), opts...)
+
+		r, err := local.Reconcile(context.Background(), remote.MerkleRoot(), StoreFetcher(store))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var missing, extra []string
+		for _, hashBz := range r.Missing {
+			missing = append(missing, string(hashBz))
+		}
+		for _, d := range r.Extra {
+			if d.Leaf != local.Leafs[d.Index] {
+				t.Fatal("expected extra leaves at their positions")
+			}
+			extra = append(extra, string(d.Leaf.Hash))
+		}
+		sort.Strings(missing)
+		sort.Strings(extra)
+
+		wantMissing, _ := NewTreeWithOptions(append(append([]Content(nil), leaves[:5]...), leaves[90:100]...), opts...)
+		wantExtra, _ := NewTreeWithOptions(leaves[110:], opts...)
+		if !equalStrings(missing, leafHashSet(wantMissing)) || !equalStrings(extra, leafHashSet(wantExtra)) {
+			t.Fatalf("unexpected reconciliation: %d missing, %d extra", len(r.Missing), len(r.Extra))
+		}
+
+		r, err = remote.Reconcile(context.Background(), remote.MerkleRoot(), StoreFetcher(store))
+		if err != nil || !r.Empty() || r.Fetched != 0 {
+			t.Fatal("expected nothing to reconcile with the same root")
+		}
+	}
+
+	// an appended log only fetches the nodes above the new leaves
+	remote, _ := NewTreeWithOptions(testLeaves(1024), WithInsertionOrder())
+	local, _ := NewTreeWithOptions(testLeaves(1020), WithInsertionOrder())
+	store := NewMemNodeStore()
+	remote.PersistTo(store)
+	r, err := local.Reconcile(context.Background(), remote.MerkleRoot(), StoreFetcher(store))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.Missing) != 4 || len(r.Extra) != 0 || r.Fetched > 3*11 {
+		t.Fatalf("unexpected reconciliation: %d missing, %d extra, %d fetched", len(r.Missing), len(r.Extra), r.Fetched)
+	}
+
+	// the contents fetched for the missing hashes authenticate them
+	for _, hashBz := range r.Missing {
+		var found bool
+		for _, c := range testLeaves(1024)[1020:] {
+			if leafHash, err := local.LeafHashOf(c); err == nil && bytes.Equal(leafHash, hashBz) {
+				found = true
+			}
+		}
+		if !found {
+			t.Fatal("expected a content for every missing hash")
+		}
+	}
+
+	// a remote that reports an internal node as a leaf hides its subtree, which
+	// no content for that hash can back
+	node := remote.Leafs[1020].Parent.Hash
+	store.Put(node, storeLeafMarker)
+	r, err = local.Reconcile(context.Background(), remote.MerkleRoot(), StoreFetcher(store))
+	if err != nil || len(r.Missing) != 3 {
+		t.Fatalf("expected the forged leaf to hide its subtree, got %d missing", len(r.Missing))
+	}
+	for _, c := range testLeaves(1024)[1020:] {
+		if leafHash, _ := local.LeafHashOf(c); bytes.Equal(leafHash, node) {
+			t.Fatal("a content hashes to the forged leaf")
+		}
+	}
+
+	// a remote node that does not match its hash is rejected
+	value, _ := store.Get(remote.MerkleRoot())
+	forged := append([]byte(nil), value...)
+	forged[0] ^= 1
+	store.Put(remote.MerkleRoot(), forged)
+	if _, err := local.Reconcile(context.Background(), remote.MerkleRoot(), StoreFetcher(store)); err == nil {
+		t.Fatal("expected error for a forged node")
+	}
+	if _, err := local.Reconcile(context.Background(), []byte("missing"), StoreFetcher(store)); err == nil {
+		t.Fatal("expected error for an unknown root")
+	}
+
+	// against an empty remote tree every leaf is extra
+	empty, _ := NewTreeWithOptions(testLeaves(3), WithEmptyRoot(ZeroRoot(32)))
+	r, err = empty.Reconcile(context.Background(), ZeroRoot(32), StoreFetcher(store))
+	if err != nil || len(r.Extra) != 3 || !bytes.Equal(r.Extra[0].Leaf.Hash, empty.Leafs[0].Hash) {
+		t.Fatal("expected every leaf to be extra")
+	}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
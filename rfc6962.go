@@ -0,0 +1,36 @@
+package merkletree
+
+import (
+	"crypto/sha256"
+	"hash"
+)
+
+// RFC 6962 domain separation prefixes.
+const (
+	rfc6962LeafPrefix byte = 0x00
+	rfc6962NodePrefix byte = 0x01
+)
+
+// WithRFC6962 builds the tree defined by RFC 6962 for Certificate Transparency:
+// leaves keep their order, a leaf hash is SHA-256(0x00 || leaf) and a node hash is
+// SHA-256(0x01 || left || right). CalculateHash of each content must return the
+// leaf entry itself, which is hashed by the tree. Add WithHashStrategy after this
+// option for Trillian logs using another hash function.
+//
+// Proofs from GetProofByIndex verify with VerifyIndexedProof and consistency
+// proofs with VerifyConsistencyProof, both given WithRFC6962.
+func WithRFC6962() Option {
+	return func(m *MerkleTree) {
+		WithHashStrategy(sha256.New)(m)
+		WithInsertionOrder()(m)
+		WithOddNodePolicy(PromoteSingle)(m)
+		m.leafPrefix = []byte{rfc6962LeafPrefix}
+		m.nodePrefix = []byte{rfc6962NodePrefix}
+	}
+}
+
+// RFC6962EmptyRoot returns the root RFC 6962 defines for a tree without leaves,
+// the hash of the empty string.
+func RFC6962EmptyRoot(hashStrategy func() hash.Hash) []byte {
+	return EmptyStringRoot(hashStrategy)
+}
@@ -0,0 +1,70 @@
+package merkletree
+
+import (
+	"crypto/sha256"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func Test_RFC6962(t *testing.T) {
+	// test vectors of the Certificate Transparency and Trillian implementations
+	entries := [][]byte{
+		{},
+		{0x00},
+		{0x10},
+		{0x20, 0x21},
+		{0x30, 0x31},
+		{0x40, 0x41, 0x42, 0x43},
+		{0x50, 0x51, 0x52, 0x53, 0x54, 0x55, 0x56, 0x57},
+		{0x60, 0x61, 0x62, 0x63, 0x64, 0x65, 0x66, 0x67, 0x68, 0x69, 0x6a, 0x6b, 0x6c, 0x6d, 0x6e, 0x6f},
+	}
+	roots := []string{
+		"0x6e340b9cffb37a989ca544e6bb780a2c78901d3fb33738768511a30617afa01d",
+		"0xfac54203e7cc696cf0dfcb42c92a1d9dbaf70ad9e621f4bd8d98662f00e3c125",
+		"0xaeb6bcfe274b70a14fb067a5e5578264db0fa9b51af5e0ba159158f329e06e77",
+		"0xd37ee418976dd95753c1c73862b9398fa2a2cf9b4ff0fdfe8b30cd95209614b7",
+		"0x4e3bbb1f7b478dcfe71fb631631519a3bca12c9aefca1612bfce4c13a86264d4",
+		"0x76e67dadbcdf1e10e1b74ddc608abd2f98dfb16fbce75277b5232a127f2087ef",
+		"0xddb89be403809e325750d3d263cd78929c2942b7942a34b77e122c9594a74c8c",
+		"0x5dc9da79a70659a9ad559cb701ded9a2ab9d823aad2f4960cfe370eff4604328",
+	}
+
+	if hexutil.Encode(RFC6962EmptyRoot(sha256.New)) != "0xe3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Fatal("unexpected empty root")
+	}
+
+	var leaves []Content
+	treeRoots := make([][]byte, len(entries)+1)
+	for i, entry := range entries {
+		leaves = append(leaves, hashLeaf(entry))
+		tree, err := NewTreeWithOptions(leaves, WithRFC6962())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := hexutil.Encode(tree.MerkleRoot()); got != roots[i] {
+			t.Fatalf("%d entries: root %s, want %s", i+1, got, roots[i])
+		}
+		treeRoots[i+1] = tree.MerkleRoot()
+
+		for j := range leaves {
+			proof, _ := tree.GetProofByIndex(j)
+			ok, err := VerifyIndexedProof(tree.MerkleRoot(), j, len(leaves), proof, WithRFC6962())
+			if err != nil || !ok {
+				t.Fatalf("%d entries: inclusion proof of %d does not verify", i+1, j)
+			}
+		}
+	}
+
+	tree, _ := NewTreeWithOptions(leaves, WithRFC6962())
+	for oldSize := 1; oldSize <= len(entries); oldSize++ {
+		proof, err := tree.ConsistencyProof(oldSize, len(entries))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ok, err := VerifyConsistencyProof(oldSize, len(entries), treeRoots[oldSize], treeRoots[len(entries)], proof, WithRFC6962())
+		if err != nil || !ok {
+			t.Fatalf("consistency from %d does not verify", oldSize)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package merkletree
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"hash"
+)
+
+// SaltSize is the length of the salts generated by NewSalt.
+const SaltSize = 32
+
+// SaltedContent is a leaf blinded with a random salt: its hash is
+// H(salt || content hash). Without the salt, a leaf cannot be found by hashing
+// guesses of its content and comparing them with the published tree, which
+// matters when the contents come from a small space, as with proof-of-reserves
+// balances or allowlisted addresses. The salt is handed to the owner of the leaf
+// along with its proof.
+type SaltedContent struct {
+	Content      Content
+	Salt         []byte
+	hashStrategy func() hash.Hash
+}
+
+// NewSalt returns SaltSize random bytes from crypto/rand.
+func NewSalt() ([]byte, error) {
+	salt := make([]byte, SaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	return salt, nil
+}
+
+// NewSaltedContent blinds c with salt, generating a fresh one if salt is nil.
+func NewSaltedContent(c Content, salt []byte, hashStrategy func() hash.Hash) (SaltedContent, error) {
+	if c == nil {
+		return SaltedContent{}, errors.New("error: cannot salt nil content")
+	}
+	if salt == nil {
+		var err error
+		if salt, err = NewSalt(); err != nil {
+			return SaltedContent{}, err
+		}
+	}
+	return SaltedContent{Content: c, Salt: salt, hashStrategy: hashStrategy}, nil
+}
+
+// SaltContents blinds every content with a fresh salt.
+func SaltContents(cs []Content, hashStrategy func() hash.Hash) ([]Content, error) {
+	salted := make([]Content, len(cs))
+	for i, c := range cs {
+		s, err := NewSaltedContent(c, nil, hashStrategy)
+		if err != nil {
+			return nil, err
+		}
+		salted[i] = s
+	}
+	return salted, nil
+}
+
+// CalculateHash hashes the salt followed by the hash of the content
+func (s SaltedContent) CalculateHash() ([]byte, error) {
+	contentHash, err := s.Content.CalculateHash()
+	if err != nil {
+		return nil, err
+	}
+	return SaltedLeafHash(s.Salt, contentHash, s.hashStrategy)
+}
+
+// Equals tests the salts and the contents for equality
+func (s SaltedContent) Equals(other Content) (bool, error) {
+	o, ok := other.(SaltedContent)
+	if !ok || !bytes.Equal(s.Salt, o.Salt) {
+		return false, nil
+	}
+	return s.Content.Equals(o.Content)
+}
+
+// SaltedLeafHash returns H(salt || contentHash), the leaf hash of salted content.
+func SaltedLeafHash(salt, contentHash []byte, hashStrategy func() hash.Hash) ([]byte, error) {
+	h := hashStrategy()
+	if _, err := h.Write(append(append([]byte(nil), salt...), contentHash...)); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// NewTreeWithSaltedLeaves blinds every content with a fresh salt and builds a tree
+// over them with hashStrategy. The salted contents are kept in Leafs, from where
+// GetSalt retrieves them.
+func NewTreeWithSaltedLeaves(cs []Content, hashStrategy func() hash.Hash, opts ...Option) (*MerkleTree, error) {
+	salted, err := SaltContents(cs, hashStrategy)
+	if err != nil {
+		return nil, err
+	}
+	return NewTreeWithOptions(salted, append([]Option{WithHashStrategy(hashStrategy)}, opts...)...)
+}
+
+// GetSalt returns the salted leaf holding content, whose salt and proof are to be
+// handed to the owner of content.
+func (m *MerkleTree) GetSalt(content Content) (SaltedContent, error) {
+	for _, leaf := range m.Leafs {
+		s, ok := leaf.C.(SaltedContent)
+		if !ok {
+			continue
+		}
+		ok, err := s.Content.Equals(content)
+		if err != nil {
+			return SaltedContent{}, err
+		}
+		if ok {
+			return s, nil
+		}
+	}
+	return SaltedContent{}, errors.New("error: no salted leaf holds the content")
+}
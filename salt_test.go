@@ -0,0 +1,56 @@
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+func Test_SaltedLeaves(t *testing.T) {
+	leaves := testLeaves(6)
+	tree, err := NewTreeWithSaltedLeaves(leaves, sha3.NewLegacyKeccak256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := NewTreeWithSaltedLeaves(leaves, sha3.NewLegacyKeccak256)
+	if bytes.Equal(tree.MerkleRoot(), again.MerkleRoot()) {
+		t.Fatal("fresh salts produced the same root")
+	}
+
+	for _, leaf := range leaves {
+		salted, err := tree.GetSalt(leaf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(salted.Salt) != SaltSize {
+			t.Fatalf("salt of %d bytes", len(salted.Salt))
+		}
+		if ok, err := tree.VerifyContent(salted); err != nil || !ok {
+			t.Fatal("salted content does not verify")
+		}
+		if ok, _ := tree.VerifyContent(leaf); ok {
+			t.Fatal("unsalted content verifies")
+		}
+
+		ep, err := tree.GetExtendedProof(salted)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(ep.Salt, salted.Salt) {
+			t.Fatal("extended proof does not carry the salt")
+		}
+		ep.Leaf = leaf.(TestLeaf).Bz
+		if ok, err := ep.Verify(); err != nil || !ok {
+			t.Fatal("salted extended proof does not verify")
+		}
+		ep.Salt = make([]byte, SaltSize)
+		if ok, _ := ep.Verify(); ok {
+			t.Fatal("extended proof verifies with a wrong salt")
+		}
+	}
+
+	if _, err := tree.GetSalt(TestLeaf{Bz: []byte("missing")}); err == nil {
+		t.Fatal("expected error for a missing content")
+	}
+}
@@ -0,0 +1,70 @@
+package merkletree
+
+// ProofLengthRange returns the shortest and the longest proof length over all
+// leaves of the tree. Single-node promotion lets leaves sit at different depths.
+func (m *MerkleTree) ProofLengthRange() (min, max int) {
+	for i, leaf := range m.Leafs {
+		l := leaf.proofLength()
+		if i == 0 || l < min {
+			min = l
+		}
+		if l > max {
+			max = l
+		}
+	}
+	return min, max
+}
+
+// IsBalanced reports whether the proofs of all leaves are within one level of each
+// other. Trees with a power-of-two number of leaves are always balanced.
+func (m *MerkleTree) IsBalanced() bool {
+	min, max := m.ProofLengthRange()
+	return max-min <= 1
+}
+
+// proofLength counts the siblings on the path from the leaf n to the root.
+func (n *Node) proofLength() int {
+	l := 0
+	for current := n; current.Parent != nil; current = current.Parent {
+		if !current.single {
+			l++
+		}
+	}
+	return l
+}
+
+// SingleNodes returns the nodes that had no partner on their level and were
+// promoted to the next level without hashing, from the bottom level up.
+func (m *MerkleTree) SingleNodes() []*Node {
+	var singles []*Node
+	level := m.Leafs
+	for len(level) > 1 || (len(level) == 1 && level[0] != m.Root) {
+		var parents []*Node
+		for i, n := range level {
+			if n.single {
+				singles = append(singles, n)
+			}
+			if i%2 == 0 {
+				parents = append(parents, n.Parent)
+			}
+		}
+		level = parents
+	}
+	return singles
+}
+
+// SinglePromotionCount returns how many single-node promotions building a tree of
+// n leaves performs across all levels, i.e. len(tree.SingleNodes()).
+func SinglePromotionCount(n int) int {
+	count := 0
+	for n > 0 {
+		if n%2 == 1 {
+			count++
+		}
+		if n <= 2 {
+			break
+		}
+		n = (n + 1) / 2
+	}
+	return count
+}
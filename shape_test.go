@@ -0,0 +1,45 @@
+package merkletree
+
+import "testing"
+
+func Test_IsBalanced(t *testing.T) {
+	for _, n := range []int{1, 2, 4, 8, 16, 32} {
+		tree, err := NewTree(testLeaves(n))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !tree.IsBalanced() {
+			t.Fatalf("tree with %d leaves should be balanced", n)
+		}
+	}
+
+	// 5 leaves: four leaves have 3 siblings, the promoted one only 1
+	tree, _ := NewTree(testLeaves(5))
+	if min, max := tree.ProofLengthRange(); min != 1 || max != 3 {
+		t.Fatalf("unexpected proof length range %d..%d", min, max)
+	}
+	for _, n := range []int{5, 9, 17} {
+		tree, _ := NewTree(testLeaves(n))
+		if tree.IsBalanced() {
+			t.Fatalf("tree with %d leaves should not be balanced", n)
+		}
+	}
+
+	// 3 leaves: the promoted leaf is one level above the others
+	tree, _ = NewTree(testLeaves(3))
+	if !tree.IsBalanced() {
+		t.Fatal("tree with 3 leaves should be balanced")
+	}
+}
+
+func Test_SinglePromotionCount(t *testing.T) {
+	for n := 1; n <= 32; n++ {
+		tree, err := NewTree(testLeaves(n))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, want := SinglePromotionCount(n), len(tree.SingleNodes()); got != want {
+			t.Fatalf("%d leaves: SinglePromotionCount = %d, tree has %d single nodes", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package merkletree
+
+// WithoutContent builds a slim tree, whose leaves keep only their hash and drop
+// the content they were built from. Trees over large blobs then hold no more than
+// the hashes. Proofs are obtained with GetProofByLeafHash or GetProofByIndex:
+// looking a leaf up by content finds nothing, as for trees restored from hashes,
+// and RebuildTree fails for want of contents. Leaves added later are slim too.
+func WithoutContent() Option {
+	return func(m *MerkleTree) {
+		m.slim = true
+	}
+}
+
+// PruneContent drops the content of every leaf, turning the tree into a slim tree
+// as built WithoutContent. The leaves are shared with the snapshots of the tree,
+// which lose their contents as well.
+func (m *MerkleTree) PruneContent() {
+	m.proofCache.invalidate()
+	m.slim = true
+	for _, leaf := range m.Leafs {
+		leaf.C = nil
+	}
+}
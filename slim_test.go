@@ -0,0 +1,83 @@
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_WithoutContent(t *testing.T) {
+	leaves := testLeaves(9)
+	full, err := NewTreeWithOptions(leaves)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slim, err := NewTreeWithOptions(leaves, WithoutContent())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(slim.MerkleRoot(), full.MerkleRoot()) {
+		t.Fatal("expected a slim tree to have the same root")
+	}
+	for i, leaf := range slim.Leafs {
+		if leaf.C != nil {
+			t.Fatal("expected leaves without content")
+		}
+		proof, err := slim.GetProofByLeafHash(leaf.Hash)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want, _ := full.GetProofByIndex(i)
+		if !bytes.Equal(proof.Root, want.Root) || len(proof.Siblings) != len(want.Siblings) {
+			t.Fatal("expected the proof of the full tree")
+		}
+		if ok, err := VerifyProofWithOptions(slim.MerkleRoot(), proof); err != nil || !ok {
+			t.Fatal("expected the proof to verify")
+		}
+	}
+	if _, err := slim.GetProof(leaves[0]); err != ErrContentNotFound {
+		t.Fatalf("expected ErrContentNotFound, got %v", err)
+	}
+	if ok, err := slim.VerifyTree(); err != nil || !ok {
+		t.Fatal("expected a slim tree to verify")
+	}
+
+	if err := slim.AddLeaf(TestLeaf{Bz: []byte("added")}); err != nil {
+		t.Fatal(err)
+	}
+	for _, leaf := range slim.Leafs {
+		if leaf.C != nil {
+			t.Fatal("expected added leaves without content")
+		}
+	}
+	if err := slim.RebuildTree(); err == nil {
+		t.Fatal("expected error rebuilding without contents")
+	}
+}
+
+func Test_PruneContent(t *testing.T) {
+	leaves := testLeaves(7)
+	tree, err := NewTreeWithOptions(leaves, WithProofCache())
+	if err != nil {
+		t.Fatal(err)
+	}
+	root := tree.MerkleRoot()
+	if _, err := tree.GetProof(leaves[2]); err != nil {
+		t.Fatal(err)
+	}
+
+	tree.PruneContent()
+	if !bytes.Equal(tree.MerkleRoot(), root) {
+		t.Fatal("expected pruning to keep the root")
+	}
+	if _, err := tree.GetProof(leaves[2]); err != ErrContentNotFound {
+		t.Fatalf("expected ErrContentNotFound, got %v", err)
+	}
+	hashBz, _ := leaves[2].CalculateHash()
+	proof, err := tree.GetProofByLeafHash(hashBz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := VerifyProofWithOptions(root, proof); err != nil || !ok {
+		t.Fatal("expected the proof to verify")
+	}
+}
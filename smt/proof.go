@@ -0,0 +1,112 @@
+package smt
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash"
+	"math/bits"
+)
+
+// Proof holds the siblings of the path of a key from the leaf up to the root. A
+// nil sibling is the root of an empty subtree, the zero hash of its height, which
+// most siblings of a sparse tree are.
+type Proof struct {
+	Siblings [][]byte
+}
+
+// VerifyInclusion checks that p shows key holding value in the tree with the
+// given root.
+func VerifyInclusion(root []byte, key Key, value []byte, p *Proof, hashStrategy func() hash.Hash) (bool, error) {
+	leaf, err := leafHash(hashStrategy, value)
+	if err != nil {
+		return false, err
+	}
+	return verify(root, key, leaf, p, hashStrategy)
+}
+
+// VerifyNonInclusion checks that p shows key absent from the tree with the given
+// root.
+func VerifyNonInclusion(root []byte, key Key, p *Proof, hashStrategy func() hash.Hash) (bool, error) {
+	return verify(root, key, make([]byte, hashStrategy().Size()), p, hashStrategy)
+}
+
+func verify(root []byte, key Key, leaf []byte, p *Proof, hashStrategy func() hash.Hash) (bool, error) {
+	if p == nil || len(p.Siblings) != Depth {
+		return false, fmt.Errorf("error: proof must have %d siblings", Depth)
+	}
+	zero := make([]byte, len(leaf))
+	node := leaf
+	for height, sibling := range p.Siblings {
+		if sibling == nil {
+			sibling = zero
+		}
+		left, right := node, sibling
+		if bit(key, height) == 1 {
+			left, right = sibling, node
+		}
+		var err error
+		if node, err = hashPair(hashStrategy, left, right); err != nil {
+			return false, err
+		}
+		if height+1 < Depth {
+			if zero, err = hashPair(hashStrategy, zero, zero); err != nil {
+				return false, err
+			}
+		}
+	}
+	return bytes.Equal(node, root), nil
+}
+
+// MarshalBinary encodes the proof compactly: a 32-byte bitmap whose bit i, the
+// bit i%8 of byte i/8, is set if sibling i is given, followed by the given
+// siblings from the leaf up. Siblings must all have the same size.
+func (p *Proof) MarshalBinary() ([]byte, error) {
+	if len(p.Siblings) != Depth {
+		return nil, fmt.Errorf("error: proof must have %d siblings", Depth)
+	}
+	data := make([]byte, Depth/8)
+	size := -1
+	for height, sibling := range p.Siblings {
+		if sibling == nil {
+			continue
+		}
+		if size >= 0 && len(sibling) != size {
+			return nil, errors.New("error: proof siblings differ in size")
+		}
+		size = len(sibling)
+		data[height/8] |= 1 << uint(height%8)
+		data = append(data, sibling...)
+	}
+	return data, nil
+}
+
+// UnmarshalBinary decodes a proof encoded by MarshalBinary.
+func (p *Proof) UnmarshalBinary(data []byte) error {
+	if len(data) < Depth/8 {
+		return errors.New("error: proof too short")
+	}
+	bitmap, rest := data[:Depth/8], data[Depth/8:]
+	count := 0
+	for _, b := range bitmap {
+		count += bits.OnesCount8(b)
+	}
+	if (count == 0 && len(rest) != 0) || (count > 0 && (len(rest) == 0 || len(rest)%count != 0)) {
+		return errors.New("error: malformed proof")
+	}
+
+	siblings := make([][]byte, Depth)
+	size := 0
+	if count > 0 {
+		size = len(rest) / count
+	}
+	for height := range siblings {
+		if bitmap[height/8]>>uint(height%8)&1 == 0 {
+			continue
+		}
+		siblings[height] = append([]byte(nil), rest[:size]...)
+		rest = rest[size:]
+	}
+	p.Siblings = siblings
+	return nil
+}
@@ -0,0 +1,215 @@
+// Package smt implements a sparse merkle tree over a fixed 256-bit key space.
+// Every key has a leaf slot at depth 256, given by the bits of the key from the
+// most significant one down, and a key commits to the hash of its value while the
+// slots of absent keys hold the zero leaf. Empty subtrees are never stored: their
+// roots, the zero hashes, are computed once per height. Proofs thus show both that
+// a key holds a value and that a key is absent.
+package smt
+
+import (
+	"bytes"
+	"errors"
+	"hash"
+)
+
+// Depth is the depth of the tree, the number of bits of a key.
+const Depth = 256
+
+// KeySize is the size of a key in bytes.
+const KeySize = Depth / 8
+
+// Key is a key of the tree. Keys of another size are usually hashed to 32 bytes.
+type Key [KeySize]byte
+
+// ErrKeyNotFound is returned for keys the tree does not hold.
+var ErrKeyNotFound = errors.New("error: key not found")
+
+// Tree is a sparse merkle tree held in memory. A leaf is H(value), an internal
+// node H(left || right) and the zero leaf 32 zero bytes, so the zero hash of
+// height i+1 is H(z || z) for the zero hash z of height i.
+type Tree struct {
+	hashStrategy func() hash.Hash
+	zeros        [][]byte
+	// nodes holds the hash of every node that is not the root of an empty subtree
+	nodes  map[nodeID][]byte
+	values map[Key][]byte
+}
+
+// nodeID names the node at height above the leaves over the keys starting with
+// prefix. The bits of prefix below height are zero.
+type nodeID struct {
+	height int
+	prefix Key
+}
+
+// New returns an empty tree hashing with hashStrategy.
+func New(hashStrategy func() hash.Hash) (*Tree, error) {
+	zeros, err := ZeroHashes(hashStrategy)
+	if err != nil {
+		return nil, err
+	}
+	return &Tree{
+		hashStrategy: hashStrategy,
+		zeros:        zeros,
+		nodes:        make(map[nodeID][]byte),
+		values:       make(map[Key][]byte),
+	}, nil
+}
+
+// ZeroHashes returns the roots of empty subtrees of every height from the zero
+// leaf up to the root of an empty tree.
+func ZeroHashes(hashStrategy func() hash.Hash) ([][]byte, error) {
+	zeros := make([][]byte, Depth+1)
+	zeros[0] = make([]byte, hashStrategy().Size())
+	for i := 0; i < Depth; i++ {
+		z, err := hashPair(hashStrategy, zeros[i], zeros[i])
+		if err != nil {
+			return nil, err
+		}
+		zeros[i+1] = z
+	}
+	return zeros, nil
+}
+
+// Root returns the root of the tree.
+func (t *Tree) Root() []byte {
+	return t.node(nodeID{height: Depth})
+}
+
+// Len returns the number of keys in the tree.
+func (t *Tree) Len() int {
+	return len(t.values)
+}
+
+// Get returns the value of key.
+func (t *Tree) Get(key Key) ([]byte, error) {
+	value, ok := t.values[key]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return append([]byte(nil), value...), nil
+}
+
+// Has reports whether the tree holds key.
+func (t *Tree) Has(key Key) bool {
+	_, ok := t.values[key]
+	return ok
+}
+
+// Set gives key the value value, which may be empty, and rehashes the path of
+// the key.
+func (t *Tree) Set(key Key, value []byte) error {
+	leaf, err := leafHash(t.hashStrategy, value)
+	if err != nil {
+		return err
+	}
+	if err := t.update(key, leaf); err != nil {
+		return err
+	}
+	t.values[key] = append([]byte(nil), value...)
+	return nil
+}
+
+// Delete removes key from the tree, which puts the zero leaf back in its slot.
+func (t *Tree) Delete(key Key) error {
+	if !t.Has(key) {
+		return ErrKeyNotFound
+	}
+	if err := t.update(key, t.zeros[0]); err != nil {
+		return err
+	}
+	delete(t.values, key)
+	return nil
+}
+
+// Prove returns the proof of key, which shows its value if the tree holds key and
+// its absence otherwise, see VerifyInclusion and VerifyNonInclusion.
+func (t *Tree) Prove(key Key) *Proof {
+	p := &Proof{Siblings: make([][]byte, Depth)}
+	for height := 0; height < Depth; height++ {
+		if sibling, ok := t.nodes[siblingID(key, height)]; ok {
+			p.Siblings[height] = sibling
+		}
+	}
+	return p
+}
+
+// update puts leaf in the slot of key and rehashes its ancestors, leaving the tree
+// unchanged if hashing fails.
+func (t *Tree) update(key Key, leaf []byte) error {
+	hashes := make([][]byte, Depth+1)
+	hashes[0] = leaf
+	for height := 0; height < Depth; height++ {
+		sibling := t.node(siblingID(key, height))
+		left, right := hashes[height], sibling
+		if bit(key, height) == 1 {
+			left, right = sibling, hashes[height]
+		}
+		parent, err := hashPair(t.hashStrategy, left, right)
+		if err != nil {
+			return err
+		}
+		hashes[height+1] = parent
+	}
+
+	for height, h := range hashes {
+		id := nodeID{height: height, prefix: mask(key, height)}
+		if bytes.Equal(h, t.zeros[height]) {
+			delete(t.nodes, id)
+		} else {
+			t.nodes[id] = h
+		}
+	}
+	return nil
+}
+
+// node returns the hash of the node id, a zero hash if its subtree is empty.
+func (t *Tree) node(id nodeID) []byte {
+	if h, ok := t.nodes[id]; ok {
+		return h
+	}
+	return t.zeros[id.height]
+}
+
+// siblingID returns the sibling of the ancestor of key at height.
+func siblingID(key Key, height int) nodeID {
+	prefix := mask(key, height)
+	prefix[KeySize-1-height/8] ^= 1 << uint(height%8)
+	return nodeID{height: height, prefix: prefix}
+}
+
+// bit returns the bit of key that tells whether its ancestor at height is a right
+// child, counting from the least significant bit.
+func bit(key Key, height int) int {
+	return int(key[KeySize-1-height/8]>>uint(height%8)) & 1
+}
+
+// mask returns key with the bits below height cleared.
+func mask(key Key, height int) Key {
+	for i := 0; i < height/8; i++ {
+		key[KeySize-1-i] = 0
+	}
+	if height < Depth {
+		key[KeySize-1-height/8] &^= 1<<uint(height%8) - 1
+	}
+	return key
+}
+
+func leafHash(hashStrategy func() hash.Hash, value []byte) ([]byte, error) {
+	h := hashStrategy()
+	if _, err := h.Write(value); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+func hashPair(hashStrategy func() hash.Hash, left, right []byte) ([]byte, error) {
+	h := hashStrategy()
+	if _, err := h.Write(left); err != nil {
+		return nil, err
+	}
+	if _, err := h.Write(right); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
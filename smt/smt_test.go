@@ -0,0 +1,127 @@
+package smt
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"golang.org/x/crypto/sha3"
+)
+
+func testKey(i int) Key {
+	return sha256.Sum256([]byte(fmt.Sprintf("key-%d", i)))
+}
+
+func Test_SetGetDelete(t *testing.T) {
+	tree, err := New(sha3.NewLegacyKeccak256)
+	if err != nil {
+		t.Fatal(err)
+	}
+	empty := tree.Root()
+	zeros, _ := ZeroHashes(sha3.NewLegacyKeccak256)
+	if !bytes.Equal(empty, zeros[Depth]) {
+		t.Fatal("expected the empty root to be the top zero hash")
+	}
+
+	for i := 0; i < 20; i++ {
+		if err := tree.Set(testKey(i), []byte(fmt.Sprintf("value-%d", i))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if tree.Len() != 20 {
+		t.Fatalf("expected 20 keys, got %d", tree.Len())
+	}
+	value, err := tree.Get(testKey(7))
+	if err != nil || string(value) != "value-7" {
+		t.Fatal("expected the value of the key")
+	}
+	if _, err := tree.Get(testKey(20)); err != ErrKeyNotFound {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+
+	// the root depends on the contents only, not on the order of changes
+	other, _ := New(sha3.NewLegacyKeccak256)
+	for i := 19; i >= 0; i-- {
+		other.Set(testKey(i), []byte("stale"))
+		other.Set(testKey(i), []byte(fmt.Sprintf("value-%d", i)))
+	}
+	if !bytes.Equal(tree.Root(), other.Root()) {
+		t.Fatal("expected the same root for the same contents")
+	}
+
+	for i := 0; i < 20; i++ {
+		if err := tree.Delete(testKey(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(tree.Root(), empty) || len(tree.nodes) != 0 {
+		t.Fatal("expected deleting every key to empty the tree")
+	}
+	if err := tree.Delete(testKey(0)); err != ErrKeyNotFound {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func Test_Proofs(t *testing.T) {
+	tree, _ := New(sha256.New)
+	for i := 0; i < 10; i++ {
+		tree.Set(testKey(i), []byte(fmt.Sprintf("value-%d", i)))
+	}
+	// keys sharing a long prefix have siblings deep down
+	near := testKey(0)
+	near[KeySize-1] ^= 1
+	tree.Set(near, nil)
+	root := tree.Root()
+
+	for i := 0; i < 10; i++ {
+		p := tree.Prove(testKey(i))
+		value := []byte(fmt.Sprintf("value-%d", i))
+		if ok, err := VerifyInclusion(root, testKey(i), value, p, sha256.New); err != nil || !ok {
+			t.Fatal("expected the inclusion proof to verify")
+		}
+		if ok, _ := VerifyInclusion(root, testKey(i), []byte("other"), p, sha256.New); ok {
+			t.Fatal("expected a wrong value to fail")
+		}
+		if ok, _ := VerifyNonInclusion(root, testKey(i), p, sha256.New); ok {
+			t.Fatal("expected a present key to fail non-inclusion")
+		}
+
+		data, err := p.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var decoded Proof
+		if err := decoded.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := VerifyInclusion(root, testKey(i), value, &decoded, sha256.New); err != nil || !ok {
+			t.Fatal("expected the decoded proof to verify")
+		}
+	}
+	if p := tree.Prove(near); p.Siblings[0] == nil {
+		t.Fatal("expected a sibling leaf for a neighbouring key")
+	} else if ok, err := VerifyInclusion(root, near, nil, p, sha256.New); err != nil || !ok {
+		t.Fatal("expected the inclusion proof of an empty value to verify")
+	}
+
+	absent := testKey(10)
+	p := tree.Prove(absent)
+	if ok, err := VerifyNonInclusion(root, absent, p, sha256.New); err != nil || !ok {
+		t.Fatal("expected the non-inclusion proof to verify")
+	}
+	if ok, _ := VerifyInclusion(root, absent, nil, p, sha256.New); ok {
+		t.Fatal("expected an absent key to fail inclusion")
+	}
+	if ok, _ := VerifyNonInclusion(root, testKey(11), p, sha256.New); ok {
+		t.Fatal("expected the proof of another key to fail")
+	}
+
+	var decoded Proof
+	if err := decoded.UnmarshalBinary([]byte{1}); err == nil {
+		t.Fatal("expected error for a truncated proof")
+	}
+	if _, err := VerifyNonInclusion(root, absent, &Proof{}, sha256.New); err == nil {
+		t.Fatal("expected error for a proof of the wrong length")
+	}
+}
@@ -0,0 +1,184 @@
+package merkletree
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Snapshot is a read-only view of a tree as it was at one version. It shares its
+// nodes with the tree: changes to the tree copy the nodes they would otherwise
+// modify, so taking a snapshot is cheap and the snapshot keeps serving proofs
+// against its root while the tree moves on.
+type Snapshot struct {
+	t          *MerkleTree
+	root       *Node
+	merkleRoot []byte
+	leafCount  int
+	version    uint64
+}
+
+// WithRetainedVersions makes the tree keep a snapshot of each of its last n
+// versions, see SnapshotAt.
+func WithRetainedVersions(n int) Option {
+	return func(m *MerkleTree) {
+		m.retainVersions = n
+	}
+}
+
+// Version returns the number of times the tree was built or changed since it was
+// created.
+func (m *MerkleTree) Version() uint64 {
+	return m.version
+}
+
+// Snapshot returns a view of the tree at its current version.
+func (m *MerkleTree) Snapshot() *Snapshot {
+	// the nodes of the snapshot may no longer be changed in place
+	m.epoch++
+	return &Snapshot{
+		t:          m,
+		root:       m.Root,
+		merkleRoot: m.merkleRoot,
+		leafCount:  len(m.Leafs),
+		version:    m.version,
+	}
+}
+
+// SnapshotAt returns the retained snapshot of the given version.
+func (m *MerkleTree) SnapshotAt(version uint64) (*Snapshot, error) {
+	for _, s := range m.versions {
+		if s.version == version {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("error: version %d is not retained", version)
+}
+
+// commit records a change of the tree: it starts a new version, adds its root to
+// the root history, retains a snapshot of it if the tree keeps them and tells
+// the root listeners.
+func (m *MerkleTree) commit() {
+	if m.previewing {
+		return
+	}
+	m.version++
+	if m.rootHistory != nil {
+		m.rootHistory.add(RootRecord{Version: m.version, Root: m.merkleRoot, Time: time.Now()})
+	}
+	if m.retainVersions > 0 {
+		if len(m.versions) == m.retainVersions {
+			copy(m.versions, m.versions[1:])
+			m.versions = m.versions[:len(m.versions)-1]
+		}
+		m.versions = append(m.versions, m.Snapshot())
+	}
+	m.notifyRoot()
+}
+
+// MerkleRoot returns the root of the tree at the version of the snapshot.
+func (s *Snapshot) MerkleRoot() []byte {
+	return s.merkleRoot
+}
+
+// Version returns the version of the tree the snapshot was taken at.
+func (s *Snapshot) Version() uint64 {
+	return s.version
+}
+
+// LeafCount returns the number of leaves of the snapshot.
+func (s *Snapshot) LeafCount() int {
+	return s.leafCount
+}
+
+// GetProofByIndex returns the proof of the leaf at position i, as the tree would
+// have returned it at the version of the snapshot.
+func (s *Snapshot) GetProofByIndex(i int) (*MerkleProof, error) {
+	if i < 0 || i >= s.leafCount {
+		return nil, fmt.Errorf("error: leaf index %d out of range [0, %d)", i, s.leafCount)
+	}
+	leaf, siblings, path := s.descend(i, true)
+	proof := &MerkleProof{LeafHash: leaf.Hash, Root: s.merkleRoot}
+	for j := len(siblings) - 1; j >= 0; j-- {
+		proof.Siblings = append(proof.Siblings, siblings[j])
+		proof.Path = append(proof.Path, path[j])
+	}
+	return proof, nil
+}
+
+// GetProof returns the proof of content in the snapshot.
+func (s *Snapshot) GetProof(content Content) (*MerkleProof, error) {
+	if s.leafCount == 0 {
+		return nil, ErrEmptyTree
+	}
+	hashBz, err := s.t.leafHash(content)
+	if err != nil {
+		return nil, err
+	}
+
+	i := 0
+	if !s.t.unsortedLeaves {
+		i = sort.Search(s.leafCount, func(i int) bool {
+			return bytes.Compare(s.leafAt(i).Hash, hashBz) >= 0
+		})
+	}
+	for ; i < s.leafCount; i++ {
+		leaf := s.leafAt(i)
+		if !bytes.Equal(leaf.Hash, hashBz) {
+			if s.t.unsortedLeaves {
+				continue
+			}
+			break
+		}
+		if leaf.C == nil {
+			continue
+		}
+		ok, err := leaf.C.Equals(content)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return s.GetProofByIndex(i)
+		}
+	}
+	return nil, ErrContentNotFound
+}
+
+func (s *Snapshot) leafAt(i int) *Node {
+	leaf, _, _ := s.descend(i, false)
+	return leaf
+}
+
+// descend walks from the root to the leaf at position i, collecting the siblings
+// and directions of the merkle path from the root down if withPath is set. Node
+// i of a level has the children 2i and 2i+1 on the level below. Parent pointers
+// and the single flag belong to the live tree, so a promoted node is told by its
+// hash, which is that of its only child.
+func (s *Snapshot) descend(i int, withPath bool) (*Node, [][]byte, []int64) {
+	height := 0
+	for n := s.root; !n.leaf; n = n.Left {
+		height++
+	}
+
+	var siblings [][]byte
+	var path []int64
+	n := s.root
+	for ; height > 0; height-- {
+		child := n.Left
+		if (i>>uint(height-1))&1 == 1 {
+			child = n.Right
+		}
+		promoted := n.Left == n.Right && bytes.Equal(n.Hash, n.Left.Hash)
+		if withPath && !promoted {
+			// same direction rule as merklePath
+			if bytes.Equal(n.Left.Hash, child.Hash) {
+				siblings, path = append(siblings, n.Right.Hash), append(path, 1)
+			} else {
+				siblings, path = append(siblings, n.Left.Hash), append(path, 0)
+			}
+		}
+		n = child
+	}
+	return n, siblings, path
+}
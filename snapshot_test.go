@@ -0,0 +1,114 @@
+package merkletree
+
+import (
+	"reflect"
+	"testing"
+)
+
+func Test_Snapshot(t *testing.T) {
+	leaves := testLeaves(11)
+	for _, opts := range incrementalOptions {
+		tree, err := NewTreeWithOptions(leaves, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		snap := tree.Snapshot()
+		var want []*MerkleProof
+		for i := range tree.Leafs {
+			proof, _ := tree.GetProofByIndex(i)
+			want = append(want, proof)
+		}
+
+		// change the tree every possible way
+		cs := append([]Content(nil), leaves...)
+		extra := testLeaves(16)[11:]
+		if err := tree.AddLeaf(extra[0]); err != nil {
+			t.Fatal(err)
+		}
+		cs = append(cs, extra[0])
+		if err := tree.UpdateContent(leaves[3], extra[1]); err != nil {
+			t.Fatal(err)
+		}
+		cs[3] = extra[1]
+		tree.Snapshot()
+		if err := tree.UpdateContent(leaves[4], extra[2]); err != nil {
+			t.Fatal(err)
+		}
+		cs[4] = extra[2]
+		if _, err := tree.RemoveLeaf(leaves[0]); err != nil {
+			t.Fatal(err)
+		}
+		cs = cs[1:]
+		index, _ := tree.GetIndexOf(leaves[1])
+		if err := tree.ApplyBatch(extra[3:], map[int]Content{index: leaves[0]}, nil); err != nil {
+			t.Fatal(err)
+		}
+		cs[0] = leaves[0]
+		checkSameTree(t, tree, append(cs, extra[3:]...), opts)
+
+		if snap.LeafCount() != len(leaves) {
+			t.Fatalf("unexpected leaf count %d", snap.LeafCount())
+		}
+		for i, proof := range want {
+			got, err := snap.GetProofByIndex(i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, proof) {
+				t.Fatalf("proof of leaf %d changed with the tree", i)
+			}
+		}
+		fresh, _ := NewTreeWithOptions(leaves, opts...)
+		for _, c := range leaves {
+			proof, err := snap.GetProof(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			freshProof, _ := fresh.GetProof(c)
+			if !reflect.DeepEqual(proof, freshProof) {
+				t.Fatal("proof of the snapshot differs from the tree it was taken of")
+			}
+		}
+		if _, err := snap.GetProof(extra[0]); err != ErrContentNotFound {
+			t.Fatalf("expected ErrContentNotFound, got %v", err)
+		}
+	}
+}
+
+func Test_RetainedVersions(t *testing.T) {
+	leaves := testLeaves(6)
+	tree, err := NewTreeWithOptions(leaves[:2], WithRetainedVersions(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := map[uint64][]byte{tree.Version(): tree.MerkleRoot()}
+	for _, c := range leaves[2:] {
+		if err := tree.AddLeaf(c); err != nil {
+			t.Fatal(err)
+		}
+		roots[tree.Version()] = tree.MerkleRoot()
+	}
+	if tree.Version() != 5 {
+		t.Fatalf("unexpected version %d", tree.Version())
+	}
+
+	for v := uint64(1); v <= 5; v++ {
+		snap, err := tree.SnapshotAt(v)
+		if v <= 2 {
+			if err == nil {
+				t.Fatalf("expected version %d to be dropped", v)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(snap.MerkleRoot(), roots[v]) || snap.LeafCount() != int(v)+1 {
+			t.Fatalf("unexpected snapshot of version %d", v)
+		}
+		proof, _ := snap.GetProofByIndex(0)
+		if ok, _ := VerifyProofWithOptions(roots[v], proof); !ok {
+			t.Fatalf("proof of version %d does not verify", v)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+)
+
+var (
+	bytes32Type, _      = abi.NewType("bytes32", "", nil)
+	bytes32ArrayType, _ = abi.NewType("bytes32[]", "", nil)
+
+	solidityProofArgs  = abi.Arguments{{Type: bytes32ArrayType}}
+	solidityVerifyArgs = abi.Arguments{{Type: bytes32ArrayType}, {Type: bytes32Type}, {Type: bytes32Type}}
+)
+
+// GetSolidityProof returns the leaf, proof and root of content as bytes32 values
+// ready to pass to the standard sorted-pair keccak256 verifier, e.g. OpenZeppelin's
+// MerkleProof.verify. It fails unless the tree is hashed with keccak256 and every
+// hash involved is 32 bytes long.
+func (m *MerkleTree) GetSolidityProof(content Content) (leaf [32]byte, proof [][32]byte, root [32]byte, err error) {
+	if k, ok := identifyHash(m.hashStrategy); !ok || k.name != "keccak256" {
+		return leaf, nil, root, errors.New("error: solidity proofs require a keccak256 tree")
+	}
+	if m.unsortedPairs {
+		return leaf, nil, root, errors.New("error: solidity proofs require sorted pairs")
+	}
+
+	node, err := m.findLeaf(content)
+	if err != nil {
+		return leaf, nil, root, err
+	}
+
+	if err := copyBytes32(&leaf, node.Hash); err != nil {
+		return leaf, nil, root, err
+	}
+	if err := copyBytes32(&root, m.merkleRoot); err != nil {
+		return leaf, nil, root, err
+	}
+
+	siblings, _ := node.merklePath()
+	proof = make([][32]byte, len(siblings))
+	for i, sibling := range siblings {
+		if err := copyBytes32(&proof[i], sibling); err != nil {
+			return leaf, nil, root, err
+		}
+	}
+
+	// guard against any divergence from the on-chain verification loop
+	computed, err := foldProof(leaf[:], siblings, m.hashStrategy)
+	if err != nil {
+		return leaf, nil, root, err
+	}
+	if !bytes.Equal(computed, root[:]) {
+		return leaf, nil, root, errors.New("error: proof does not fold to the tree root")
+	}
+	return leaf, proof, root, nil
+}
+
+// EncodeSolidityProof ABI-encodes proof as a single bytes32[] argument.
+func EncodeSolidityProof(proof [][32]byte) ([]byte, error) {
+	return solidityProofArgs.Pack(proof)
+}
+
+// EncodeSolidityVerifyArgs ABI-encodes proof, root and leaf in the argument order of
+// OpenZeppelin's MerkleProof.verify(bytes32[] proof, bytes32 root, bytes32 leaf).
+// Prepend the 4-byte selector of the target function to get complete calldata.
+func EncodeSolidityVerifyArgs(proof [][32]byte, root, leaf [32]byte) ([]byte, error) {
+	return solidityVerifyArgs.Pack(proof, root, leaf)
+}
+
+// GetSolidityCalldata returns the ABI-encoded proof of content. With
+// packRootAndLeaf set, root and leaf follow the proof as in
+// EncodeSolidityVerifyArgs, otherwise only the bytes32[] proof is encoded.
+func (m *MerkleTree) GetSolidityCalldata(content Content, packRootAndLeaf bool) ([]byte, error) {
+	leaf, proof, root, err := m.GetSolidityProof(content)
+	if err != nil {
+		return nil, err
+	}
+	if packRootAndLeaf {
+		return EncodeSolidityVerifyArgs(proof, root, leaf)
+	}
+	return EncodeSolidityProof(proof)
+}
+
+func copyBytes32(dst *[32]byte, src []byte) error {
+	if len(src) != 32 {
+		return fmt.Errorf("error: expected 32-byte hash, got %d bytes", len(src))
+	}
+	copy(dst[:], src)
+	return nil
+}
@@ -0,0 +1,95 @@
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"reflect"
+	"testing"
+
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// solidityVerify mirrors OpenZeppelin's MerkleProof.verify:
+//
+//	bytes32 computedHash = leaf;
+//	for (uint256 i = 0; i < proof.length; i++) {
+//	    computedHash = _hashPair(computedHash, proof[i]);
+//	}
+//	return computedHash == root;
+func solidityVerify(proof [][32]byte, root, leaf [32]byte) bool {
+	computedHash := leaf
+	for _, proofElement := range proof {
+		if bytes.Compare(computedHash[:], proofElement[:]) < 0 {
+			computedHash = gethcrypto.Keccak256Hash(computedHash[:], proofElement[:])
+		} else {
+			computedHash = gethcrypto.Keccak256Hash(proofElement[:], computedHash[:])
+		}
+	}
+	return computedHash == root
+}
+
+func Test_GetSolidityProof(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 7, 16, 21} {
+		leaves := testLeaves(n)
+		tree, err := NewTree(leaves)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, c := range leaves {
+			leaf, proof, root, err := tree.GetSolidityProof(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !solidityVerify(proof, root, leaf) {
+				t.Fatalf("%d leaves: solidity verification failed", n)
+			}
+		}
+	}
+
+	sha, _ := NewTreeWithHashStrategy(testLeaves(4), sha256.New)
+	if _, _, _, err := sha.GetSolidityProof(testLeaves(1)[0]); err == nil {
+		t.Fatal("expected error for a sha256 tree")
+	}
+}
+
+func Test_GetSolidityCalldata(t *testing.T) {
+	leaves := testLeaves(6)
+	tree, _ := NewTree(leaves)
+	leaf, proof, root, err := tree.GetSolidityProof(leaves[2])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	encoded, err := tree.GetSolidityCalldata(leaves[2], false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// offset, length, then one word per element
+	if len(encoded) != 32*(2+len(proof)) {
+		t.Fatalf("unexpected encoding length %d", len(encoded))
+	}
+	unpacked, err := solidityProofArgs.Unpack(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(unpacked[0], proof) {
+		t.Fatal("decoded proof mismatch")
+	}
+
+	encoded, err = tree.GetSolidityCalldata(leaves[2], true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unpacked, err = solidityVerifyArgs.Unpack(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(unpacked[0], proof) || unpacked[1] != root || unpacked[2] != leaf {
+		t.Fatal("decoded verify arguments mismatch")
+	}
+
+	if encoded, err := EncodeSolidityProof(nil); err != nil || len(encoded) != 64 {
+		t.Fatal("empty proof should encode as a zero-length array")
+	}
+}
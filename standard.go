@@ -0,0 +1,150 @@
+package merkletree
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"golang.org/x/crypto/sha3"
+)
+
+// StandardLeafHash returns keccak256(keccak256(abi.encode(values...))), the leaf
+// hash of OpenZeppelin's StandardMerkleTree for values of the given Solidity types.
+// Values must be the Go types accounts/abi packs, e.g. common.Address for address
+// and *big.Int for uint256. Hashing twice keeps the 64-byte preimage of a node from
+// being accepted as a leaf.
+func StandardLeafHash(types []string, values []interface{}) ([]byte, error) {
+	args, err := standardArguments(types)
+	if err != nil {
+		return nil, err
+	}
+	encoded, err := args.Pack(values...)
+	if err != nil {
+		return nil, err
+	}
+	return gethcrypto.Keccak256(gethcrypto.Keccak256(encoded)), nil
+}
+
+func standardArguments(types []string) (abi.Arguments, error) {
+	args := make(abi.Arguments, len(types))
+	for i, typ := range types {
+		t, err := abi.NewType(typ, "", nil)
+		if err != nil {
+			return nil, fmt.Errorf("error: invalid leaf type %q: %v", typ, err)
+		}
+		args[i] = abi.Argument{Type: t}
+	}
+	return args, nil
+}
+
+// StandardMerkleTree reproduces the tree of OpenZeppelin's StandardMerkleTree.of,
+// so its root and proofs match those of the JavaScript library and verify with
+// MerkleProof.verify on chain. Leaves are hashed with StandardLeafHash and sorted,
+// and nodes are laid out as a complete binary tree in an array rather than level
+// by level, which gives a different shape from MerkleTree for most leaf counts.
+type StandardMerkleTree struct {
+	types []string
+	// nodes[0] is the root and the children of nodes[i] are nodes[2i+1] and
+	// nodes[2i+2]
+	nodes [][]byte
+	// treeIndex[i] is the position in nodes of the leaf of the i-th value
+	treeIndex []int
+}
+
+// NewStandardMerkleTree builds the tree over values, each a list of values of the
+// given Solidity types.
+func NewStandardMerkleTree(values [][]interface{}, types []string) (*StandardMerkleTree, error) {
+	if len(values) == 0 {
+		return nil, ErrEmptyTree
+	}
+
+	type hashedValue struct {
+		index int
+		hash  []byte
+	}
+	hashed := make([]hashedValue, len(values))
+	for i, v := range values {
+		h, err := StandardLeafHash(types, v)
+		if err != nil {
+			return nil, err
+		}
+		hashed[i] = hashedValue{index: i, hash: h}
+	}
+	sort.SliceStable(hashed, func(i, j int) bool {
+		return bytes.Compare(hashed[i].hash, hashed[j].hash) < 0
+	})
+
+	t := &StandardMerkleTree{
+		types:     types,
+		nodes:     make([][]byte, 2*len(values)-1),
+		treeIndex: make([]int, len(values)),
+	}
+	// the leaves fill the end of the array in reverse order
+	for i, v := range hashed {
+		pos := len(t.nodes) - 1 - i
+		t.nodes[pos] = v.hash
+		t.treeIndex[v.index] = pos
+	}
+	for i := len(t.nodes) - 1 - len(values); i >= 0; i-- {
+		h := sha3.NewLegacyKeccak256()
+		if _, err := h.Write(combineTwoHash(t.nodes[2*i+1], t.nodes[2*i+2])); err != nil {
+			return nil, err
+		}
+		t.nodes[i] = h.Sum(nil)
+	}
+	return t, nil
+}
+
+// MerkleRoot returns the root of the tree.
+func (t *StandardMerkleTree) MerkleRoot() []byte {
+	return t.nodes[0]
+}
+
+// LeafCount returns the number of leaves.
+func (t *StandardMerkleTree) LeafCount() int {
+	return len(t.treeIndex)
+}
+
+// LeafHash returns the leaf hash of the i-th value given to NewStandardMerkleTree.
+func (t *StandardMerkleTree) LeafHash(i int) ([]byte, error) {
+	if i < 0 || i >= len(t.treeIndex) {
+		return nil, fmt.Errorf("error: leaf index %d out of range [0, %d)", i, len(t.treeIndex))
+	}
+	return t.nodes[t.treeIndex[i]], nil
+}
+
+// GetProof returns the proof of the i-th value given to NewStandardMerkleTree, the
+// siblings from the leaf up as returned by the JavaScript getProof.
+func (t *StandardMerkleTree) GetProof(i int) (*MerkleProof, error) {
+	leafHash, err := t.LeafHash(i)
+	if err != nil {
+		return nil, err
+	}
+
+	proof := &MerkleProof{LeafHash: leafHash, Root: t.MerkleRoot()}
+	for pos := t.treeIndex[i]; pos > 0; pos = (pos - 1) / 2 {
+		sibling, direction := pos+1, int64(1) // right sibling
+		if pos%2 == 0 {
+			sibling, direction = pos-1, 0
+		}
+		proof.Siblings = append(proof.Siblings, t.nodes[sibling])
+		proof.Path = append(proof.Path, direction)
+	}
+	return proof, nil
+}
+
+// VerifyStandardProof checks that values of the given Solidity types are a leaf of
+// the StandardMerkleTree with the given root.
+func VerifyStandardProof(root []byte, types []string, values []interface{}, proof [][]byte) (bool, error) {
+	if len(root) == 0 {
+		return false, errors.New("error: empty root")
+	}
+	leafHash, err := StandardLeafHash(types, values)
+	if err != nil {
+		return false, err
+	}
+	return VerifyProof(root, leafHash, proof, sha3.NewLegacyKeccak256)
+}
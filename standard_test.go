@@ -0,0 +1,56 @@
+package merkletree
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+func Test_StandardMerkleTree(t *testing.T) {
+	types := []string{"address", "uint256"}
+	amount := func(s string) *big.Int {
+		v, _ := new(big.Int).SetString(s, 10)
+		return v
+	}
+	// the example of the OpenZeppelin merkle-tree README
+	values := [][]interface{}{
+		{common.HexToAddress("0x1111111111111111111111111111111111111111"), amount("5000000000000000000")},
+		{common.HexToAddress("0x2222222222222222222222222222222222222222"), amount("2500000000000000000")},
+	}
+	tree, err := NewStandardMerkleTree(values, types)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := hexutil.Encode(tree.MerkleRoot()); got != "0xd4dee0beab2d53f2cc83e567171bd2820e49898130a22622b10ead383e90bd77" {
+		t.Fatalf("unexpected root %s", got)
+	}
+
+	for n := 1; n <= 9; n++ {
+		values := make([][]interface{}, n)
+		for i := range values {
+			values[i] = []interface{}{common.BigToAddress(big.NewInt(int64(i + 1))), big.NewInt(int64(100 * i))}
+		}
+		tree, err := NewStandardMerkleTree(values, types)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, v := range values {
+			proof, err := tree.GetProof(i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := VerifyStandardProof(tree.MerkleRoot(), types, v, proof.Siblings); err != nil || !ok {
+				t.Fatalf("%d leaves: proof of %d does not verify", n, i)
+			}
+		}
+		if ok, _ := VerifyStandardProof(tree.MerkleRoot(), types, []interface{}{common.Address{}, big.NewInt(1)}, nil); ok {
+			t.Fatal("absent value verifies")
+		}
+	}
+
+	if _, err := StandardLeafHash([]string{"notatype"}, []interface{}{big.NewInt(1)}); err == nil {
+		t.Fatal("expected error for an invalid type")
+	}
+}
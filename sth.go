@@ -0,0 +1,132 @@
+package merkletree
+
+import (
+	"crypto/ecdsa"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+// SignedTreeHead is a root authenticated by its publisher. It binds the root to
+// the number of leaves and to the time it was published with a secp256k1
+// signature in the 65-byte [R || S || V] form used by Ethereum.
+type SignedTreeHead struct {
+	Root      []byte
+	TreeSize  uint64
+	Timestamp uint64 // seconds since the Unix epoch
+	Signature []byte
+}
+
+// SignTreeHead returns the head of the tree signed by key with the given
+// publication time.
+func (m *MerkleTree) SignTreeHead(key *ecdsa.PrivateKey, timestamp time.Time) (*SignedTreeHead, error) {
+	if len(m.Leafs) == 0 {
+		return nil, ErrEmptyTree
+	}
+
+	sth := &SignedTreeHead{
+		Root:      m.merkleRoot,
+		TreeSize:  uint64(len(m.Leafs)),
+		Timestamp: uint64(timestamp.Unix()),
+	}
+	sig, err := gethcrypto.Sign(sth.SigningHash(), key)
+	if err != nil {
+		return nil, err
+	}
+	sth.Signature = sig
+	return sth, nil
+}
+
+// SigningHash returns the digest covered by the signature:
+// keccak256(root || uint64(treeSize) || uint64(timestamp)) with big-endian
+// integers.
+func (sth *SignedTreeHead) SigningHash() []byte {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], sth.TreeSize)
+	binary.BigEndian.PutUint64(b[8:], sth.Timestamp)
+	return gethcrypto.Keccak256(sth.Root, b[:])
+}
+
+// Signer recovers the address of the key that signed the head.
+func (sth *SignedTreeHead) Signer() (common.Address, error) {
+	pub, err := gethcrypto.SigToPub(sth.SigningHash(), sth.Signature)
+	if err != nil {
+		return common.Address{}, err
+	}
+	return gethcrypto.PubkeyToAddress(*pub), nil
+}
+
+// Verify reports whether the head was signed by the key pub.
+func (sth *SignedTreeHead) Verify(pub *ecdsa.PublicKey) (bool, error) {
+	if len(sth.Signature) != 65 {
+		return false, errors.New("error: signature must be 65 bytes")
+	}
+	// the recovery id is not needed to check against a known key
+	return gethcrypto.VerifySignature(gethcrypto.FromECDSAPub(pub), sth.SigningHash(), sth.Signature[:64]), nil
+}
+
+// sthJSON is the JSON form of a SignedTreeHead, with hashes and signature as
+// 0x-prefixed hex strings.
+type sthJSON struct {
+	Root      hexutil.Bytes `json:"root"`
+	TreeSize  uint64        `json:"tree_size"`
+	Timestamp uint64        `json:"timestamp"`
+	Signature hexutil.Bytes `json:"signature"`
+}
+
+// MarshalJSON encodes the head with hex strings for its root and signature, the
+// form heads are gossiped in between auditors.
+func (sth *SignedTreeHead) MarshalJSON() ([]byte, error) {
+	return json.Marshal(sthJSON{
+		Root:      sth.Root,
+		TreeSize:  sth.TreeSize,
+		Timestamp: sth.Timestamp,
+		Signature: sth.Signature,
+	})
+}
+
+// UnmarshalJSON decodes a head encoded by MarshalJSON.
+func (sth *SignedTreeHead) UnmarshalJSON(data []byte) error {
+	var decoded sthJSON
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	*sth = SignedTreeHead{
+		Root:      decoded.Root,
+		TreeSize:  decoded.TreeSize,
+		Timestamp: decoded.Timestamp,
+		Signature: decoded.Signature,
+	}
+	return nil
+}
+
+// MarshalBinary encodes the head as the uvarint length of the root, the root, the
+// tree size and the timestamp as big-endian uint64s and the signature.
+func (sth *SignedTreeHead) MarshalBinary() ([]byte, error) {
+	data := binary.AppendUvarint(nil, uint64(len(sth.Root)))
+	data = append(data, sth.Root...)
+	data = binary.BigEndian.AppendUint64(data, sth.TreeSize)
+	data = binary.BigEndian.AppendUint64(data, sth.Timestamp)
+	return append(data, sth.Signature...), nil
+}
+
+// UnmarshalBinary decodes a head encoded by MarshalBinary.
+func (sth *SignedTreeHead) UnmarshalBinary(data []byte) error {
+	size, n := binary.Uvarint(data)
+	if n <= 0 || uint64(len(data)-n) < size+16 {
+		return errors.New("error: truncated tree head")
+	}
+	data = data[n:]
+	*sth = SignedTreeHead{
+		Root:      append([]byte(nil), data[:size]...),
+		TreeSize:  binary.BigEndian.Uint64(data[size:]),
+		Timestamp: binary.BigEndian.Uint64(data[size+8:]),
+		Signature: append([]byte(nil), data[size+16:]...),
+	}
+	return nil
+}
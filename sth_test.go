@@ -0,0 +1,85 @@
+package merkletree
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+func Test_SignedTreeHead(t *testing.T) {
+	key, err := gethcrypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tree, _ := NewTree(testLeaves(5))
+
+	sth, err := tree.SignTreeHead(key, time.Unix(1700000000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sth.TreeSize != 5 || sth.Timestamp != 1700000000 {
+		t.Fatalf("unexpected head %+v", sth)
+	}
+
+	if ok, err := sth.Verify(&key.PublicKey); err != nil || !ok {
+		t.Fatal("head does not verify")
+	}
+	signer, err := sth.Signer()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signer != gethcrypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatal("recovered the wrong signer")
+	}
+
+	other, _ := gethcrypto.GenerateKey()
+	if ok, _ := sth.Verify(&other.PublicKey); ok {
+		t.Fatal("head verifies with another key")
+	}
+	sth.TreeSize++
+	if ok, _ := sth.Verify(&key.PublicKey); ok {
+		t.Fatal("tampered head verifies")
+	}
+}
+
+func Test_SignedTreeHeadEncoding(t *testing.T) {
+	key, _ := gethcrypto.GenerateKey()
+	tree, _ := NewTree(testLeaves(5))
+	sth, err := tree.SignTreeHead(key, time.Unix(1700000000, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := json.Marshal(sth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromJSON SignedTreeHead
+	if err := json.Unmarshal(data, &fromJSON); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&fromJSON, sth) {
+		t.Fatal("head changed in a JSON round trip")
+	}
+
+	data, err = sth.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fromBinary SignedTreeHead
+	if err := fromBinary.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&fromBinary, sth) {
+		t.Fatal("head changed in a binary round trip")
+	}
+	if ok, err := fromBinary.Verify(&key.PublicKey); err != nil || !ok {
+		t.Fatal("decoded head does not verify")
+	}
+	if err := fromBinary.UnmarshalBinary(data[:len(sth.Root)]); err == nil {
+		t.Fatal("expected error for a truncated head")
+	}
+}
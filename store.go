@@ -0,0 +1,150 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash"
+)
+
+// storeLeafMarker is the value stored for leaf nodes. Internal nodes store the
+// concatenation of their two child hashes, which is never one byte long.
+var storeLeafMarker = []byte{0}
+
+// Persist writes every node of the tree through put, keyed by the node hash. The
+// value of an internal node is its left child hash followed by its right child
+// hash, the value of a leaf is a one-byte marker. Nodes created by single-node
+// promotion share the hash of their only child and are not written separately.
+// Leaf content is not persisted.
+func (m *MerkleTree) Persist(put func(key, value []byte) error) error {
+	if m.Root == nil {
+		return ErrEmptyTree
+	}
+	return m.Root.persist(put)
+}
+
+func (n *Node) persist(put func(key, value []byte) error) error {
+	if n.leaf {
+		return put(n.Hash, storeLeafMarker)
+	}
+	if n.Left.single {
+		// promoted node, stored as its child
+		return n.Left.persist(put)
+	}
+
+	if err := n.Left.persist(put); err != nil {
+		return err
+	}
+	if err := n.Right.persist(put); err != nil {
+		return err
+	}
+
+	value := make([]byte, 0, len(n.Left.Hash)+len(n.Right.Hash))
+	value = append(value, n.Left.Hash...)
+	value = append(value, n.Right.Hash...)
+	return put(n.Hash, value)
+}
+
+// LoadFromStore returns the tree written by Persist with root, built with the
+// default options and the hash strategy h, nil for keccak256 as in NewTree. The
+// returned tree is held in memory: every stored node is read, and the tree is
+// rebuilt from its leaf hashes and checked against root. Its leaves carry no
+// content. Trees larger than memory, or built with other options, are served
+// from the store by LoadStoredTree instead.
+func LoadFromStore(root []byte, get func(key []byte) ([]byte, error), h func() hash.Hash) (*MerkleTree, error) {
+	var opts []Option
+	if h != nil {
+		opts = append(opts, WithHashStrategy(h))
+	}
+	st, err := LoadStoredTree(root, get, opts...)
+	if err != nil {
+		return nil, err
+	}
+	hashes, err := st.leafHashes()
+	if err != nil {
+		return nil, err
+	}
+	tree, err := NewTreeFromLeafHashes(hashes, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(tree.merkleRoot, root) {
+		return nil, fmt.Errorf("error: stored leaves do not build root %x", root)
+	}
+	return tree, nil
+}
+
+// LoadStoredTree returns the tree written by Persist with root, reading its nodes
+// through get as they are needed, so trees larger than memory can be served. opts
+// must be those the tree was built with. The leaf count is read from the record
+// PersistTo writes, or else worked out from the nodes along the right edge of
+// the tree; with DuplicateLast, trees whose last leaves are equal cannot be told
+// from trees with the last leaf duplicated, so persist those with PersistTo.
+// Only the root node is read up front, and checked against root.
+func LoadStoredTree(root []byte, get func(key []byte) ([]byte, error), opts ...Option) (*StoredTree, error) {
+	t := newConfiguredTree(opts)
+	leafCount, err := storedLeafCount(root, get, t.duplicateOdd)
+	if err != nil {
+		return nil, err
+	}
+	st := newStoredTree(get, root, leafCount, t)
+	if err := st.checkRoot(); err != nil {
+		return nil, err
+	}
+	return st, nil
+}
+
+// storedLeafCount returns the number of leaves of the stored tree with root. The
+// left child of every node on the right edge of a tree is the root of a perfect
+// subtree, so only its height is needed; a node whose children are equal under
+// duplicateOdd pairs its left child with itself.
+func storedLeafCount(root []byte, get func(key []byte) ([]byte, error), duplicateOdd bool) (int, error) {
+	if value, err := get(leafCountKey(root)); err == nil {
+		if leafCount, n := binary.Uvarint(value); n > 0 && leafCount > 0 {
+			return int(leafCount), nil
+		}
+	}
+
+	children := func(hashBz []byte) ([]byte, []byte, error) {
+		value, err := get(hashBz)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error: node %x: %w", hashBz, err)
+		}
+		if bytes.Equal(value, storeLeafMarker) {
+			return nil, nil, nil
+		}
+		if len(value) == 0 || len(value)%2 != 0 {
+			return nil, nil, fmt.Errorf("error: malformed store value for node %x", hashBz)
+		}
+		half := len(value) / 2
+		return value[:half], value[half:], nil
+	}
+
+	count := 0
+	for hashBz := root; ; {
+		left, right, err := children(hashBz)
+		if err != nil {
+			return 0, err
+		}
+		if left == nil {
+			return count + 1, nil
+		}
+		if duplicateOdd && bytes.Equal(left, right) {
+			hashBz = left
+			continue
+		}
+
+		// the height of the perfect left subtree is that of its leftmost leaf
+		height := 1
+		for l := left; ; height++ {
+			if l, _, err = children(l); err != nil {
+				return 0, err
+			}
+			if l == nil {
+				break
+			}
+		}
+		count += 1 << uint(height-1)
+		hashBz = right
+	}
+}
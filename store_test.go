@@ -0,0 +1,182 @@
+package merkletree
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"hash"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func Test_PersistAndLoad(t *testing.T) {
+	layouts := [][]Option{nil, {WithOddNodePolicy(DuplicateLast)}, {WithInsertionOrder()}, {WithRFC6962()}}
+	for _, opts := range layouts {
+		for _, n := range []int{1, 2, 5, 8, 13} {
+			tree, err := NewTreeWithOptions(testLeaves(n), opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			store := make(map[string][]byte)
+			reads := 0
+			err = tree.Persist(func(key, value []byte) error {
+				store[string(key)] = append([]byte(nil), value...)
+				return nil
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			get := func(key []byte) ([]byte, error) {
+				reads++
+				value, ok := store[string(key)]
+				if !ok {
+					return nil, errors.New("not found")
+				}
+				return value, nil
+			}
+			loaded, err := LoadStoredTree(tree.MerkleRoot(), get, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(loaded.MerkleRoot(), tree.MerkleRoot()) {
+				t.Fatalf("%d leaves: loaded root mismatch", n)
+			}
+			if loaded.LeafCount() != n {
+				t.Fatalf("%d leaves: loaded %d leaves", n, loaded.LeafCount())
+			}
+			if reads > 16 {
+				t.Fatalf("%d leaves: loading read %d nodes", n, reads)
+			}
+
+			for i, leaf := range tree.Leafs {
+				proof, err := loaded.GetProofByIndex(i)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if !bytes.Equal(proof.LeafHash, leaf.Hash) {
+					t.Fatalf("%d leaves: leaf %d differs", n, i)
+				}
+				if ok, err := loaded.VerifyProof(proof); err != nil || !ok {
+					t.Fatalf("%d leaves: proof of leaf %d does not verify", n, i)
+				}
+			}
+
+			if _, err := LoadStoredTree([]byte("missing"), get, opts...); err == nil {
+				t.Fatal("expected error for unknown root")
+			}
+		}
+	}
+
+	// the leaf count recorded by PersistTo settles trees whose last leaves are
+	// equal, which DuplicateLast hashes like a duplicated last leaf
+	opts := []Option{WithOddNodePolicy(DuplicateLast)}
+	leaves := append(testLeaves(3), testLeaves(3)[2])
+	tree, err := NewTreeWithOptions(leaves, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := NewMemNodeStore()
+	if err := tree.PersistTo(store); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := LoadStoredTree(tree.MerkleRoot(), store.Get, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.LeafCount() != 4 {
+		t.Fatalf("expected 4 leaves, got %d", loaded.LeafCount())
+	}
+
+	// a root with a leaf count record but no node fails up front
+	wrong := tree.Leafs[0].Hash
+	store.Put(leafCountKey(wrong), []byte{4})
+	if _, err := LoadStoredTree(wrong, store.Get, opts...); err == nil {
+		t.Fatal("expected error for a root that is not the stored root node")
+	}
+	if _, err := OpenStoredTree(store, wrong, opts...); err == nil {
+		t.Fatal("expected error for a root that is not the stored root node")
+	}
+	if _, err := LoadStoredTree([]byte("missing"), func(key []byte) ([]byte, error) {
+		if bytes.Equal(key, leafCountKey([]byte("missing"))) {
+			return []byte{4}, nil
+		}
+		return store.Get(key)
+	}, opts...); err == nil {
+		t.Fatal("expected error for a missing root node")
+	}
+}
+
+func Test_LoadFromStore(t *testing.T) {
+	for _, n := range []int{1, 2, 7, 16} {
+		for _, h := range []func() hash.Hash{nil, sha256.New} {
+			tree, err := NewTreeWithOptions(testLeaves(n))
+			if h != nil {
+				tree, err = NewTreeWithHashStrategy(testLeaves(n), h)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			store := NewMemNodeStore()
+			if err := tree.Persist(store.Put); err != nil {
+				t.Fatal(err)
+			}
+
+			loaded, err := LoadFromStore(tree.MerkleRoot(), store.Get, h)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(loaded.MerkleRoot(), tree.MerkleRoot()) || len(loaded.Leafs) != n {
+				t.Fatalf("%d leaves: loaded another tree", n)
+			}
+			if ok, err := loaded.VerifyTree(); err != nil || !ok {
+				t.Fatalf("%d leaves: loaded tree does not verify", n)
+			}
+			for i, leaf := range tree.Leafs {
+				if !bytes.Equal(loaded.Leafs[i].Hash, leaf.Hash) {
+					t.Fatalf("%d leaves: leaf %d differs", n, i)
+				}
+			}
+
+			if _, err := LoadFromStore([]byte("missing"), store.Get, h); err == nil {
+				t.Fatal("expected error for unknown root")
+			}
+		}
+	}
+}
+
+func Test_StoredTreeRoot(t *testing.T) {
+	tree, _ := NewTreeWithOptions(testLeaves(9))
+	store := NewMemNodeStore()
+	if err := tree.PersistTo(store); err != nil {
+		t.Fatal(err)
+	}
+	var reads int64
+	get := func(key []byte) ([]byte, error) {
+		atomic.AddInt64(&reads, 1)
+		return store.Get(key)
+	}
+	loaded, err := LoadStoredTree(tree.MerkleRoot(), get)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// the root was checked on opening and is only read afterwards
+	opened := atomic.LoadInt64(&reads)
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !bytes.Equal(loaded.MerkleRoot(), tree.MerkleRoot()) {
+				t.Error("loaded root mismatch")
+			}
+		}()
+	}
+	wg.Wait()
+	if atomic.LoadInt64(&reads) != opened {
+		t.Fatal("expected MerkleRoot to read no nodes")
+	}
+}
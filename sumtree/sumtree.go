@@ -0,0 +1,187 @@
+// Package sumtree implements a merkle sum tree, the tree of proofs of liabilities
+// and reserves: every node carries the sum of the balances below it next to its
+// hash, and the hash of an internal node commits to the sums of both children.
+// A proof hands a user the hashes and sums of the siblings of their leaf, from
+// which they recompute the root and the total the tree declares, so that no
+// balance can be left out or counted as negative without changing them.
+package sumtree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"math/bits"
+)
+
+// ErrEmpty is returned when building a tree without entries.
+var ErrEmpty = errors.New("error: sum tree without entries")
+
+// Entry is a balance owed to the holder of ID, which should be a commitment such
+// as the hash of an account id and a nonce known only to its holder.
+type Entry struct {
+	ID      []byte
+	Balance uint64
+}
+
+// Sibling is a node of a proof.
+type Sibling struct {
+	Hash []byte
+	Sum  uint64
+}
+
+// Proof shows that a leaf is part of the tree. Siblings are ordered from the leaf
+// up to the root, and Path holds 1 where the sibling is the right child and 0
+// where it is the left one. Levels where the node is promoted have no sibling.
+type Proof struct {
+	LeafHash []byte
+	Balance  uint64
+	Siblings []Sibling
+	Path     []int64
+}
+
+type node struct {
+	hash []byte
+	sum  uint64
+}
+
+// Tree is a merkle sum tree over entries kept in the order they were given. The
+// last node of an odd level is promoted to the level above unchanged. A leaf is
+// H(0x00 || ID || balance) and an internal node
+// H(0x01 || left hash || left sum || right hash || right sum), with sums as
+// big-endian uint64s.
+type Tree struct {
+	hashStrategy func() hash.Hash
+	// levels[0] holds the leaves and the last level the root
+	levels [][]node
+}
+
+// New builds the tree over entries. It fails if the balances overflow a uint64.
+func New(entries []Entry, hashStrategy func() hash.Hash) (*Tree, error) {
+	if len(entries) == 0 {
+		return nil, ErrEmpty
+	}
+	leaves := make([]node, len(entries))
+	for i, e := range entries {
+		h, err := LeafHash(e, hashStrategy)
+		if err != nil {
+			return nil, err
+		}
+		leaves[i] = node{hash: h, sum: e.Balance}
+	}
+
+	t := &Tree{hashStrategy: hashStrategy, levels: [][]node{leaves}}
+	for level := leaves; len(level) > 1; {
+		parents := make([]node, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				parents = append(parents, level[i])
+				break
+			}
+			parent, err := parentOf(hashStrategy, level[i], level[i+1])
+			if err != nil {
+				return nil, err
+			}
+			parents = append(parents, parent)
+		}
+		t.levels = append(t.levels, parents)
+		level = parents
+	}
+	return t, nil
+}
+
+// Root returns the hash of the root.
+func (t *Tree) Root() []byte {
+	return t.root().hash
+}
+
+// Total returns the sum of every balance, the liabilities the root commits to.
+func (t *Tree) Total() uint64 {
+	return t.root().sum
+}
+
+// LeafCount returns the number of entries of the tree.
+func (t *Tree) LeafCount() int {
+	return len(t.levels[0])
+}
+
+func (t *Tree) root() node {
+	return t.levels[len(t.levels)-1][0]
+}
+
+// GetProof returns the proof of the entry at index.
+func (t *Tree) GetProof(index int) (*Proof, error) {
+	if index < 0 || index >= t.LeafCount() {
+		return nil, fmt.Errorf("error: leaf index %d out of range [0, %d)", index, t.LeafCount())
+	}
+	leaf := t.levels[0][index]
+	p := &Proof{LeafHash: leaf.hash, Balance: leaf.sum}
+	for _, level := range t.levels[:len(t.levels)-1] {
+		sibling := index ^ 1
+		if sibling < len(level) {
+			p.Siblings = append(p.Siblings, Sibling{Hash: level[sibling].hash, Sum: level[sibling].sum})
+			p.Path = append(p.Path, int64(1-index&1))
+		}
+		index /= 2
+	}
+	return p, nil
+}
+
+// VerifyProof checks that p shows entry in the tree with the given root and
+// total.
+func VerifyProof(root []byte, total uint64, entry Entry, p *Proof, hashStrategy func() hash.Hash) (bool, error) {
+	if len(p.Path) != len(p.Siblings) {
+		return false, errors.New("error: proof directions do not match its siblings")
+	}
+	leafHash, err := LeafHash(entry, hashStrategy)
+	if err != nil {
+		return false, err
+	}
+	if p.Balance != entry.Balance || !bytes.Equal(p.LeafHash, leafHash) {
+		return false, nil
+	}
+
+	current := node{hash: leafHash, sum: entry.Balance}
+	for i, s := range p.Siblings {
+		sibling := node{hash: s.Hash, sum: s.Sum}
+		left, right := current, sibling
+		if p.Path[i] == 0 {
+			left, right = sibling, current
+		}
+		if current, err = parentOf(hashStrategy, left, right); err != nil {
+			// a sum overflowing is no proof
+			return false, nil
+		}
+	}
+	return current.sum == total && bytes.Equal(current.hash, root), nil
+}
+
+// LeafHash returns the hash of the leaf of e.
+func LeafHash(e Entry, hashStrategy func() hash.Hash) ([]byte, error) {
+	var balance [8]byte
+	binary.BigEndian.PutUint64(balance[:], e.Balance)
+	h := hashStrategy()
+	if _, err := h.Write(append(append([]byte{0}, e.ID...), balance[:]...)); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// parentOf returns the parent of two nodes. It fails if their sums overflow.
+func parentOf(hashStrategy func() hash.Hash, left, right node) (node, error) {
+	sum, carry := bits.Add64(left.sum, right.sum, 0)
+	if carry != 0 {
+		return node{}, errors.New("error: sum overflows uint64")
+	}
+	data := make([]byte, 0, 1+len(left.hash)+len(right.hash)+16)
+	data = append(data, 1)
+	data = append(binary.BigEndian.AppendUint64(append(data, left.hash...), left.sum), right.hash...)
+	data = binary.BigEndian.AppendUint64(data, right.sum)
+
+	h := hashStrategy()
+	if _, err := h.Write(data); err != nil {
+		return node{}, err
+	}
+	return node{hash: h.Sum(nil), sum: sum}, nil
+}
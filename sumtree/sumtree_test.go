@@ -0,0 +1,68 @@
+package sumtree
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"math"
+	"testing"
+)
+
+func testEntries(n int) []Entry {
+	entries := make([]Entry, n)
+	for i := range entries {
+		entries[i] = Entry{ID: []byte(fmt.Sprintf("user-%d", i)), Balance: uint64(i*100 + 1)}
+	}
+	return entries
+}
+
+func Test_SumTree(t *testing.T) {
+	for n := 1; n <= 17; n++ {
+		entries := testEntries(n)
+		tree, err := New(entries, sha256.New)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var total uint64
+		for _, e := range entries {
+			total += e.Balance
+		}
+		if tree.Total() != total || tree.LeafCount() != n {
+			t.Fatalf("expected a total of %d, got %d", total, tree.Total())
+		}
+
+		for i, e := range entries {
+			p, err := tree.GetProof(i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := VerifyProof(tree.Root(), total, e, p, sha256.New); err != nil || !ok {
+				t.Fatalf("expected the proof of entry %d of %d to verify", i, n)
+			}
+			if ok, _ := VerifyProof(tree.Root(), total+1, e, p, sha256.New); ok {
+				t.Fatal("expected another total to fail")
+			}
+			cheated := Entry{ID: e.ID, Balance: e.Balance - 1}
+			if ok, _ := VerifyProof(tree.Root(), total, cheated, p, sha256.New); ok {
+				t.Fatal("expected another balance to fail")
+			}
+			if len(p.Siblings) > 0 {
+				// shifting a sibling sum changes the root
+				p.Siblings[0].Sum++
+				if ok, _ := VerifyProof(tree.Root(), total, e, p, sha256.New); ok {
+					t.Fatal("expected a changed sibling sum to fail")
+				}
+			}
+		}
+		if _, err := tree.GetProof(n); err == nil {
+			t.Fatal("expected error for an index out of range")
+		}
+	}
+
+	if _, err := New(nil, sha256.New); err != ErrEmpty {
+		t.Fatalf("expected ErrEmpty, got %v", err)
+	}
+	overflow := []Entry{{ID: []byte("a"), Balance: math.MaxUint64}, {ID: []byte("b"), Balance: 1}}
+	if _, err := New(overflow, sha256.New); err == nil {
+		t.Fatal("expected error for overflowing balances")
+	}
+}
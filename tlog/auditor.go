@@ -0,0 +1,78 @@
+package tlog
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/smartbch/merkletree"
+)
+
+// ErrInconsistentHead is returned by Auditor.Observe for a validly signed head
+// that does not extend the heads seen before it, which is evidence of a log
+// presenting different views to different clients.
+var ErrInconsistentHead = errors.New("error: tree head is inconsistent with the last verified head")
+
+// Auditor follows the heads of one log, gossiped by its clients or fetched from
+// the log itself, and checks that each extends the previous one. It is safe for
+// concurrent use.
+type Auditor struct {
+	mu   sync.Mutex
+	pub  *ecdsa.PublicKey
+	head *merkletree.SignedTreeHead
+}
+
+// NewAuditor returns an auditor of the log whose heads are signed by pub.
+func NewAuditor(pub *ecdsa.PublicKey) *Auditor {
+	return &Auditor{pub: pub}
+}
+
+// Head returns the largest head verified so far, or nil.
+func (a *Auditor) Head() *merkletree.SignedTreeHead {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.head
+}
+
+// Observe verifies head and moves the auditor to it if it is larger than the
+// last verified head. proof must prove the last verified head consistent with
+// head when head is larger; it is ignored otherwise. A head of the same size
+// must have the same root, and a smaller head is only checked for its signature
+// as the auditor cannot prove it consistent.
+func (a *Auditor) Observe(head *merkletree.SignedTreeHead, proof *ConsistencyProof) error {
+	ok, err := head.Verify(a.pub)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.New("error: tree head has an invalid signature")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	switch {
+	case a.head == nil:
+	case head.TreeSize == a.head.TreeSize:
+		if !bytes.Equal(head.Root, a.head.Root) {
+			return ErrInconsistentHead
+		}
+		return nil
+	case head.TreeSize < a.head.TreeSize:
+		return nil
+	default:
+		if proof == nil {
+			return fmt.Errorf("error: missing consistency proof from %d to %d entries", a.head.TreeSize, head.TreeSize)
+		}
+		ok, err := VerifyConsistency(a.head, head, proof)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInconsistentHead
+		}
+	}
+	a.head = head
+	return nil
+}
@@ -0,0 +1,87 @@
+package tlog
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/smartbch/merkletree"
+)
+
+// InclusionProof proves that the entry at LeafIndex is in the log at TreeSize
+// entries. Its JSON form follows the get-proof-by-hash response of RFC 6962.
+type InclusionProof struct {
+	LeafIndex uint64          `json:"leaf_index"`
+	TreeSize  uint64          `json:"tree_size"`
+	AuditPath []hexutil.Bytes `json:"audit_path"`
+}
+
+// ConsistencyProof proves that the log at First entries is a prefix of the log
+// at Second entries. Its JSON form follows the get-sth-consistency response of
+// RFC 6962.
+type ConsistencyProof struct {
+	First  uint64          `json:"first"`
+	Second uint64          `json:"second"`
+	Path   []hexutil.Bytes `json:"consistency"`
+}
+
+// VerifyInclusion checks that p proves entry e in the log at the root given for
+// p.TreeSize entries, following RFC 9162 section 2.1.3.2.
+func VerifyInclusion(root []byte, e []byte, p *InclusionProof) bool {
+	if p.LeafIndex >= p.TreeSize {
+		return false
+	}
+	fn, sn := p.LeafIndex, p.TreeSize-1
+	r := LeafHash(e)
+	for _, s := range p.AuditPath {
+		if sn == 0 {
+			return false
+		}
+		if fn&1 == 1 || fn == sn {
+			r = nodeHash(s, r)
+			for fn&1 == 0 && fn != 0 {
+				fn, sn = fn>>1, sn>>1
+			}
+		} else {
+			r = nodeHash(r, s)
+		}
+		fn, sn = fn>>1, sn>>1
+	}
+	return sn == 0 && bytes.Equal(r, root)
+}
+
+// VerifyHeadInclusion checks that p proves entry e in the log at head, whose
+// signature the caller verified.
+func VerifyHeadInclusion(head *merkletree.SignedTreeHead, e []byte, p *InclusionProof) bool {
+	return p.TreeSize == head.TreeSize && VerifyInclusion(head.Root, e, p)
+}
+
+// VerifyConsistency checks that p proves the log at newHead extends the log at
+// oldHead.
+func VerifyConsistency(oldHead, newHead *merkletree.SignedTreeHead, p *ConsistencyProof) (bool, error) {
+	if p.First != oldHead.TreeSize || p.Second != newHead.TreeSize {
+		return false, errors.New("error: consistency proof is for other tree sizes")
+	}
+	if oldHead.TreeSize > newHead.TreeSize {
+		return false, fmt.Errorf("error: log shrank from %d to %d entries", oldHead.TreeSize, newHead.TreeSize)
+	}
+	return merkletree.VerifyConsistencyProof(int(oldHead.TreeSize), int(newHead.TreeSize),
+		oldHead.Root, newHead.Root, fromHex(p.Path), merkletree.WithRFC6962())
+}
+
+func toHex(path [][]byte) []hexutil.Bytes {
+	out := make([]hexutil.Bytes, len(path))
+	for i, p := range path {
+		out[i] = p
+	}
+	return out
+}
+
+func fromHex(path []hexutil.Bytes) [][]byte {
+	out := make([][]byte, len(path))
+	for i, p := range path {
+		out[i] = p
+	}
+	return out
+}
@@ -0,0 +1,137 @@
+// Package tlog is an append-only transparency log in the manner of Certificate
+// Transparency (RFC 6962): entries are appended to a merkle tree, the log signs
+// heads that commit to its size and root, and it proves that an entry is in the
+// log at a given size and that any head extends an earlier one. Proofs and heads
+// encode to JSON and binary so auditors can gossip them.
+package tlog
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/smartbch/merkletree"
+)
+
+// ErrEmptyLog is returned for a head of a log without entries.
+var ErrEmptyLog = errors.New("error: log has no entries")
+
+// entry is the content of a log leaf. Under RFC 6962 a content hands the tree
+// the entry itself, which is hashed with the leaf prefix.
+type entry = merkletree.HashContent
+
+// Log is an append-only log of entries. It is safe for concurrent use.
+type Log struct {
+	mu   sync.RWMutex
+	tree *merkletree.MerkleTree
+	key  *ecdsa.PrivateKey
+}
+
+// New returns an empty log whose heads are signed with key.
+func New(key *ecdsa.PrivateKey) (*Log, error) {
+	tree, err := merkletree.NewTreeWithOptions(nil,
+		merkletree.WithRFC6962(),
+		merkletree.WithEmptyRoot(merkletree.RFC6962EmptyRoot(sha256.New)))
+	if err != nil {
+		return nil, err
+	}
+	return &Log{tree: tree, key: key}, nil
+}
+
+// Append adds e to the end of the log and returns its index.
+func (l *Log) Append(e []byte) (uint64, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := l.tree.AddLeaf(entry(append([]byte(nil), e...))); err != nil {
+		return 0, err
+	}
+	return uint64(len(l.tree.Leafs) - 1), nil
+}
+
+// Size returns the number of entries in the log.
+func (l *Log) Size() uint64 {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return uint64(len(l.tree.Leafs))
+}
+
+// Root returns the root of the log at its current size.
+func (l *Log) Root() []byte {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.tree.MerkleRoot()
+}
+
+// Entry returns the entry at index.
+func (l *Log) Entry(index uint64) ([]byte, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if index >= uint64(len(l.tree.Leafs)) {
+		return nil, fmt.Errorf("error: entry index %d out of range [0, %d)", index, len(l.tree.Leafs))
+	}
+	return append([]byte(nil), l.tree.Leafs[index].C.(entry)...), nil
+}
+
+// Head returns a head over the current size of the log, signed at the current
+// time.
+func (l *Log) Head() (*merkletree.SignedTreeHead, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if len(l.tree.Leafs) == 0 {
+		return nil, ErrEmptyLog
+	}
+	return l.tree.SignTreeHead(l.key, time.Now())
+}
+
+// InclusionProof returns the audit path of the entry at index in the log as it
+// was at treeSize entries, so it verifies against any head of that size.
+func (l *Log) InclusionProof(index, treeSize uint64) (*InclusionProof, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if treeSize > uint64(len(l.tree.Leafs)) || index >= treeSize {
+		return nil, fmt.Errorf("error: invalid inclusion of entry %d in %d of %d entries", index, treeSize, len(l.tree.Leafs))
+	}
+	path, err := l.tree.InclusionProof(int(index), int(treeSize))
+	if err != nil {
+		return nil, err
+	}
+	return &InclusionProof{
+		LeafIndex: index,
+		TreeSize:  treeSize,
+		AuditPath: toHex(path),
+	}, nil
+}
+
+// ConsistencyProof returns the proof that the log at first entries is a prefix of
+// the log at second entries.
+func (l *Log) ConsistencyProof(first, second uint64) (*ConsistencyProof, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if second > uint64(len(l.tree.Leafs)) {
+		return nil, fmt.Errorf("error: invalid consistency range %d to %d for %d entries", first, second, len(l.tree.Leafs))
+	}
+	path, err := l.tree.ConsistencyProof(int(first), int(second))
+	if err != nil {
+		return nil, err
+	}
+	return &ConsistencyProof{First: first, Second: second, Path: toHex(path)}, nil
+}
+
+// LeafHash returns the RFC 6962 hash of a log entry.
+func LeafHash(e []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x00})
+	h.Write(e)
+	return h.Sum(nil)
+}
+
+func nodeHash(left, right []byte) []byte {
+	h := sha256.New()
+	h.Write([]byte{0x01})
+	h.Write(left)
+	h.Write(right)
+	return h.Sum(nil)
+}
@@ -0,0 +1,237 @@
+package tlog
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+	"github.com/smartbch/merkletree"
+)
+
+func testLog(t *testing.T, n int) *Log {
+	t.Helper()
+	key, err := gethcrypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := New(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		index, err := l.Append(testEntry(i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if index != uint64(i) {
+			t.Fatalf("expected index %d, got %d", i, index)
+		}
+	}
+	return l
+}
+
+func testEntry(i int) []byte {
+	return []byte(fmt.Sprintf("entry-%d", i))
+}
+
+func Test_Log(t *testing.T) {
+	l := testLog(t, 0)
+	if _, err := l.Head(); err != ErrEmptyLog {
+		t.Fatalf("expected ErrEmptyLog, got %v", err)
+	}
+	if !reflect.DeepEqual(l.Root(), merkletree.RFC6962EmptyRoot(sha256.New)) {
+		t.Fatal("expected the RFC 6962 empty root")
+	}
+
+	l = testLog(t, 7)
+	var cs []merkletree.Content
+	for i := 0; i < 7; i++ {
+		cs = append(cs, merkletree.HashContent(testEntry(i)))
+	}
+	tree, err := merkletree.NewTreeWithOptions(cs, merkletree.WithRFC6962())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Size() != 7 || !reflect.DeepEqual(l.Root(), tree.MerkleRoot()) {
+		t.Fatal("log root differs from an RFC 6962 tree")
+	}
+	e, err := l.Entry(3)
+	if err != nil || string(e) != "entry-3" {
+		t.Fatal("unexpected entry")
+	}
+	if _, err := l.Entry(7); err == nil {
+		t.Fatal("expected error for an index out of range")
+	}
+	head, err := l.Head()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.TreeSize != 7 {
+		t.Fatalf("unexpected head size %d", head.TreeSize)
+	}
+	if ok, _ := head.Verify(&l.key.PublicKey); !ok {
+		t.Fatal("head does not verify")
+	}
+}
+
+func Test_InclusionProof(t *testing.T) {
+	const n = 13
+	l := testLog(t, 0)
+	var roots [][]byte
+	for i := 0; i < n; i++ {
+		l.Append(testEntry(i))
+		roots = append(roots, l.Root())
+	}
+
+	for size := uint64(1); size <= n; size++ {
+		for index := uint64(0); index < size; index++ {
+			p, err := l.InclusionProof(index, size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !VerifyInclusion(roots[size-1], testEntry(int(index)), p) {
+				t.Fatalf("proof of entry %d in %d entries does not verify", index, size)
+			}
+			if VerifyInclusion(roots[size-1], testEntry(int(index)+1), p) {
+				t.Fatal("proof verifies another entry")
+			}
+		}
+	}
+
+	// the current size matches the proofs of the tree
+	p, _ := l.InclusionProof(4, n)
+	proof, _ := l.tree.GetProofByIndex(4)
+	if !reflect.DeepEqual(fromHex(p.AuditPath), proof.Siblings) {
+		t.Fatal("audit path differs from the merkle path of the tree")
+	}
+
+	if _, err := l.InclusionProof(3, 3); err == nil {
+		t.Fatal("expected error for an index past the tree size")
+	}
+	if _, err := l.InclusionProof(0, n+1); err == nil {
+		t.Fatal("expected error for a tree size past the log")
+	}
+
+	head, _ := l.Head()
+	p, _ = l.InclusionProof(5, head.TreeSize)
+	if !VerifyHeadInclusion(head, testEntry(5), p) {
+		t.Fatal("proof does not verify against the head")
+	}
+	p.TreeSize--
+	if VerifyHeadInclusion(head, testEntry(5), p) {
+		t.Fatal("proof of another size verifies against the head")
+	}
+}
+
+func Test_ConsistencyProof(t *testing.T) {
+	l := testLog(t, 0)
+	var heads []*merkletree.SignedTreeHead
+	for i := 0; i < 10; i++ {
+		l.Append(testEntry(i))
+		head, err := l.Head()
+		if err != nil {
+			t.Fatal(err)
+		}
+		heads = append(heads, head)
+	}
+
+	for _, old := range heads {
+		for _, cur := range heads[old.TreeSize-1:] {
+			p, err := l.ConsistencyProof(old.TreeSize, cur.TreeSize)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok, err := VerifyConsistency(old, cur, p); err != nil || !ok {
+				t.Fatalf("consistency from %d to %d does not verify", old.TreeSize, cur.TreeSize)
+			}
+		}
+	}
+
+	p, _ := l.ConsistencyProof(3, 8)
+	if _, err := VerifyConsistency(heads[3], heads[7], p); err == nil {
+		t.Fatal("expected error for a proof of other sizes")
+	}
+	if _, err := l.ConsistencyProof(3, 11); err == nil {
+		t.Fatal("expected error for a size past the log")
+	}
+}
+
+func Test_Auditor(t *testing.T) {
+	l := testLog(t, 3)
+	a := NewAuditor(&l.key.PublicKey)
+	first, _ := l.Head()
+	if err := a.Observe(first, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	l.Append(testEntry(3))
+	l.Append(testEntry(4))
+	second, _ := l.Head()
+	if err := a.Observe(second, nil); err == nil {
+		t.Fatal("expected error without a consistency proof")
+	}
+	p, _ := l.ConsistencyProof(3, 5)
+	if err := a.Observe(second, p); err != nil {
+		t.Fatal(err)
+	}
+	if a.Head() != second {
+		t.Fatal("expected the auditor to move to the larger head")
+	}
+	if err := a.Observe(first, nil); err != nil || a.Head() != second {
+		t.Fatal("expected a smaller head to be accepted without moving the auditor")
+	}
+
+	// forks of the log signed with the same key
+	fork := testLog(t, 0)
+	fork.key = l.key
+	for i := 0; i < 6; i++ {
+		fork.Append(testEntry(i + 100))
+	}
+	forked, _ := fork.Head()
+	forkProof, _ := fork.ConsistencyProof(5, 6)
+	if err := a.Observe(forked, forkProof); err != ErrInconsistentHead {
+		t.Fatalf("expected ErrInconsistentHead, got %v", err)
+	}
+	sameSize := testLog(t, 4)
+	sameSize.key = l.key
+	sameSize.Append([]byte("other"))
+	split, _ := sameSize.Head()
+	if err := a.Observe(split, nil); err != ErrInconsistentHead {
+		t.Fatalf("expected ErrInconsistentHead, got %v", err)
+	}
+
+	other := testLog(t, 5)
+	foreign, _ := other.Head()
+	if err := a.Observe(foreign, nil); err == nil {
+		t.Fatal("expected error for a head signed by another key")
+	}
+}
+
+func Test_ProofEncoding(t *testing.T) {
+	l := testLog(t, 9)
+	p, _ := l.InclusionProof(6, 9)
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded InclusionProof
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !VerifyInclusion(l.Root(), testEntry(6), &decoded) {
+		t.Fatal("decoded inclusion proof does not verify")
+	}
+
+	c, _ := l.ConsistencyProof(4, 9)
+	data, _ = json.Marshal(c)
+	var decodedConsistency ConsistencyProof
+	if err := json.Unmarshal(data, &decodedConsistency); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(&decodedConsistency, c) {
+		t.Fatal("consistency proof changed in a JSON round trip")
+	}
+}
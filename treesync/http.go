@@ -0,0 +1,214 @@
+package treesync
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common/hexutil"
+)
+
+// maxBodySize bounds the requests accepted by Handler.
+const maxBodySize = 4 << 20
+
+type headResponse struct {
+	Root      hexutil.Bytes `json:"root"`
+	LeafCount int           `json:"leafCount"`
+}
+
+type nodesRequest struct {
+	Root      hexutil.Bytes `json:"root"`
+	Positions []Position    `json:"positions"`
+}
+
+type leavesRequest struct {
+	Root    hexutil.Bytes `json:"root"`
+	Indexes []int         `json:"indexes"`
+}
+
+type hashesResponse struct {
+	Hashes []hexutil.Bytes `json:"hashes"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Handler serves t over HTTP with JSON bodies, for NewHTTPTransport:
+//
+//	GET  /head    {"root": "0x…", "leafCount": n}
+//	POST /nodes   {"root": "0x…", "positions": [{"level": l, "index": j}, …]}
+//	POST /leaves  {"root": "0x…", "indexes": [i, …]}
+//
+// /nodes and /leaves answer with {"hashes": ["0x…", …]}, holding node hashes or
+// encoded leaves. ErrRootChanged is answered with 409 Conflict, other errors
+// with {"error": "…"} and a 4xx status.
+func Handler(t Transport) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/head", func(w http.ResponseWriter, r *http.Request) {
+		if !allowMethod(w, r, http.MethodGet) {
+			return
+		}
+		head, err := t.Head(r.Context())
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, headResponse{Root: head.Root, LeafCount: head.LeafCount})
+	})
+	mux.HandleFunc("/nodes", func(w http.ResponseWriter, r *http.Request) {
+		var req nodesRequest
+		if !readRequest(w, r, &req) {
+			return
+		}
+		hashes, err := t.Nodes(r.Context(), req.Root, req.Positions)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, toHashesResponse(hashes))
+	})
+	mux.HandleFunc("/leaves", func(w http.ResponseWriter, r *http.Request) {
+		var req leavesRequest
+		if !readRequest(w, r, &req) {
+			return
+		}
+		leaves, err := t.Leaves(r.Context(), req.Root, req.Indexes)
+		if err != nil {
+			writeError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, toHashesResponse(leaves))
+	})
+	return mux
+}
+
+func toHashesResponse(hashes [][]byte) hashesResponse {
+	resp := hashesResponse{Hashes: make([]hexutil.Bytes, len(hashes))}
+	for k, hashBz := range hashes {
+		resp.Hashes[k] = hashBz
+	}
+	return resp
+}
+
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeJSON(w, http.StatusMethodNotAllowed, errorResponse{Error: "method not allowed"})
+	return false
+}
+
+func readRequest(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if !allowMethod(w, r, http.MethodPost) {
+		return false
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize)).Decode(v); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: "invalid request: " + err.Error()})
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	if err == ErrRootChanged {
+		status = http.StatusConflict
+	}
+	writeJSON(w, status, errorResponse{Error: err.Error()})
+}
+
+// httpTransport is the Transport of a source served by Handler.
+type httpTransport struct {
+	url    string
+	client *http.Client
+}
+
+// NewHTTPTransport returns the Transport of the source that Handler serves at
+// url. client may be nil for http.DefaultClient.
+func NewHTTPTransport(url string, client *http.Client) Transport {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &httpTransport{url: strings.TrimSuffix(url, "/"), client: client}
+}
+
+func (h *httpTransport) Head(ctx context.Context) (*Head, error) {
+	var resp headResponse
+	if err := h.do(ctx, http.MethodGet, "/head", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &Head{Root: resp.Root, LeafCount: resp.LeafCount}, nil
+}
+
+func (h *httpTransport) Nodes(ctx context.Context, root []byte, positions []Position) ([][]byte, error) {
+	var resp hashesResponse
+	if err := h.do(ctx, http.MethodPost, "/nodes", nodesRequest{Root: root, Positions: positions}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.hashes(), nil
+}
+
+func (h *httpTransport) Leaves(ctx context.Context, root []byte, indexes []int) ([][]byte, error) {
+	var resp hashesResponse
+	if err := h.do(ctx, http.MethodPost, "/leaves", leavesRequest{Root: root, Indexes: indexes}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.hashes(), nil
+}
+
+func (r hashesResponse) hashes() [][]byte {
+	hashes := make([][]byte, len(r.Hashes))
+	for k, hashBz := range r.Hashes {
+		hashes[k] = hashBz
+	}
+	return hashes
+}
+
+// do sends body, if any, as JSON to path and decodes the answer into v.
+func (h *httpTransport) do(ctx context.Context, method, path string, body, v interface{}) error {
+	var rd io.Reader
+	if body != nil {
+		bz, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		rd = bytes.NewReader(bz)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, h.url+path, rd)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return json.NewDecoder(resp.Body).Decode(v)
+	case http.StatusConflict:
+		return ErrRootChanged
+	}
+	var e errorResponse
+	if json.NewDecoder(resp.Body).Decode(&e) != nil || e.Error == "" {
+		return fmt.Errorf("error: source answered %s", resp.Status)
+	}
+	return errors.New(e.Error)
+}
@@ -0,0 +1,309 @@
+package treesync
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/smartbch/merkletree"
+)
+
+// Result tells what a Sync did.
+type Result struct {
+	// Root is the root of the replica after the sync
+	Root []byte
+	// Rounds is the number of requests made through the transport
+	Rounds int
+	// Nodes and Leaves are the numbers of node hashes and leaf contents fetched
+	Nodes  int
+	Leaves int
+	// Added, Updated and Removed count the leaf changes applied to the replica
+	Added   int
+	Updated int
+	Removed int
+}
+
+// Sync brings replica up to the tree of the source behind t. Both trees must
+// have been built with the same options; codec decodes the leaf contents the
+// source sends. The changes are applied in a single transaction once their root
+// matches that of the source, so a failed sync leaves replica as it was.
+//
+// Trees kept in insertion order only transfer the leaves at differing positions.
+// Sorted leaves move whenever a leaf before them comes or goes, which makes the
+// comparison descend into every subtree after the first change, but only the
+// leaves the replica does not hold are fetched.
+func Sync(ctx context.Context, replica *merkletree.MerkleTree, t Transport, codec Codec) (*Result, error) {
+	head, err := t.Head(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res := &Result{Rounds: 1}
+	if bytes.Equal(head.Root, replica.MerkleRoot()) {
+		res.Root = head.Root
+		return res, nil
+	}
+
+	s := &syncer{ctx: ctx, t: t, head: head, res: res, replica: replica}
+	s.local = levelCounts(len(replica.Leafs))
+	s.remote = levelCounts(head.LeafCount)
+	diffs, err := s.diffLeaves()
+	if err != nil {
+		return nil, err
+	}
+
+	tx := replica.Begin()
+	if replica.SortsLeaves() {
+		err = s.stageSorted(tx, diffs, codec)
+	} else {
+		err = s.stageOrdered(tx, diffs, codec)
+	}
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	root, err := tx.PreviewRoot()
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if !bytes.Equal(root, head.Root) {
+		tx.Rollback()
+		return nil, fmt.Errorf("error: synced tree has root %x, not %x of the source", root, head.Root)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	res.Root = root
+	return res, nil
+}
+
+// leafDiff is a leaf position where the trees hold different hashes, nil where
+// a tree has no leaf.
+type leafDiff struct {
+	index  int
+	local  []byte
+	remote []byte
+}
+
+type syncer struct {
+	ctx     context.Context
+	t       Transport
+	head    *Head
+	res     *Result
+	replica *merkletree.MerkleTree
+	// local and remote hold the node counts of each level of both trees
+	local, remote []int
+}
+
+// levelCounts returns the number of nodes on each level of a tree with leafCount
+// leaves, leaves first, or nil for an empty tree.
+func levelCounts(leafCount int) []int {
+	if leafCount == 0 {
+		return nil
+	}
+	var counts []int
+	for count := leafCount; ; count = (count + 1) / 2 {
+		counts = append(counts, count)
+		if count == 1 {
+			return counts
+		}
+	}
+}
+
+// diffLeaves walks both trees from the top down, a level at a time, and returns
+// the leaf positions where they differ in ascending order. The root of the lower
+// tree stands in for the missing nodes above it, the way Diff lifts it, so that
+// a tree that grew still matches the subtrees it shares with its older version.
+func (s *syncer) diffLeaves() ([]leafDiff, error) {
+	top := len(s.local)
+	if len(s.remote) > top {
+		top = len(s.remote)
+	}
+
+	var diffs []leafDiff
+	frontier := []int{0}
+	for level := top - 1; level >= 0 && len(frontier) > 0; level-- {
+		remote, err := s.remoteNodes(level, frontier)
+		if err != nil {
+			return nil, err
+		}
+		var next []int
+		for k, j := range frontier {
+			local, err := s.localNode(level, j)
+			if err != nil {
+				return nil, err
+			}
+			if (local == nil && remote[k] == nil) || (local != nil && bytes.Equal(local, remote[k])) {
+				continue
+			}
+			if level == 0 {
+				diffs = append(diffs, leafDiff{index: j, local: local, remote: remote[k]})
+			} else {
+				next = append(next, 2*j, 2*j+1)
+			}
+		}
+		frontier = next
+	}
+	return diffs, nil
+}
+
+// localNode returns the hash of node j of level in the replica, nil if there is
+// none.
+func (s *syncer) localNode(level, j int) ([]byte, error) {
+	if len(s.local) == 0 {
+		return nil, nil
+	}
+	if level >= len(s.local)-1 {
+		if j != 0 {
+			return nil, nil
+		}
+		return s.replica.MerkleRoot(), nil
+	}
+	if j >= s.local[level] {
+		return nil, nil
+	}
+	return s.replica.NodeHash(level, j)
+}
+
+// remoteNodes returns the hashes of the nodes js of level in the source, nil
+// where there is none. The root is known from the head; the others are fetched
+// in batches.
+func (s *syncer) remoteNodes(level int, js []int) ([][]byte, error) {
+	hashes := make([][]byte, len(js))
+	var positions []Position
+	var at []int
+	for k, j := range js {
+		switch {
+		case len(s.remote) == 0:
+		case level >= len(s.remote)-1:
+			if j == 0 {
+				hashes[k] = s.head.Root
+			}
+		case j < s.remote[level]:
+			positions = append(positions, Position{Level: level, Index: j})
+			at = append(at, k)
+		}
+	}
+
+	for start := 0; start < len(positions); start += maxBatch {
+		end := start + maxBatch
+		if end > len(positions) {
+			end = len(positions)
+		}
+		fetched, err := s.t.Nodes(s.ctx, s.head.Root, positions[start:end])
+		if err != nil {
+			return nil, err
+		}
+		if len(fetched) != end-start {
+			return nil, fmt.Errorf("error: source returned %d nodes for %d positions", len(fetched), end-start)
+		}
+		s.res.Rounds++
+		s.res.Nodes += len(fetched)
+		for k, hashBz := range fetched {
+			hashes[at[start+k]] = hashBz
+		}
+	}
+	return hashes, nil
+}
+
+// fetchLeaves returns the decoded contents of the leaves of the source at
+// indexes.
+func (s *syncer) fetchLeaves(indexes []int, codec Codec) ([]merkletree.Content, error) {
+	cs := make([]merkletree.Content, 0, len(indexes))
+	for start := 0; start < len(indexes); start += maxBatch {
+		end := start + maxBatch
+		if end > len(indexes) {
+			end = len(indexes)
+		}
+		leaves, err := s.t.Leaves(s.ctx, s.head.Root, indexes[start:end])
+		if err != nil {
+			return nil, err
+		}
+		if len(leaves) != end-start {
+			return nil, fmt.Errorf("error: source returned %d leaves for %d indexes", len(leaves), end-start)
+		}
+		s.res.Rounds++
+		s.res.Leaves += len(leaves)
+		for _, b := range leaves {
+			c, err := codec.Decode(b)
+			if err != nil {
+				return nil, err
+			}
+			cs = append(cs, c)
+		}
+	}
+	return cs, nil
+}
+
+// stageOrdered stages the changes of a tree in insertion order, where a leaf
+// differs from the source at its position: it is replaced, appended past the end
+// of the replica or removed past the end of the source.
+func (s *syncer) stageOrdered(tx *merkletree.Tx, diffs []leafDiff, codec Codec) error {
+	var indexes []int
+	for _, d := range diffs {
+		if d.remote != nil {
+			indexes = append(indexes, d.index)
+		}
+	}
+	cs, err := s.fetchLeaves(indexes, codec)
+	if err != nil {
+		return err
+	}
+
+	k := 0
+	for _, d := range diffs {
+		switch {
+		case d.remote == nil:
+			tx.Remove(d.index)
+			s.res.Removed++
+		case d.local == nil:
+			tx.Add(cs[k])
+			s.res.Added++
+			k++
+		default:
+			tx.Update(d.index, cs[k])
+			s.res.Updated++
+			k++
+		}
+	}
+	return nil
+}
+
+// stageSorted stages the changes of a tree with sorted leaves, where the leaves
+// at differing positions are matched by hash: those only the replica holds are
+// removed and those only the source holds are fetched and added.
+func (s *syncer) stageSorted(tx *merkletree.Tx, diffs []leafDiff, codec Codec) error {
+	// counts of the differing hashes of the replica, less those of the source
+	held := make(map[string]int)
+	for _, d := range diffs {
+		if d.local != nil {
+			held[string(d.local)]++
+		}
+	}
+	var indexes []int
+	for _, d := range diffs {
+		if d.remote == nil {
+			continue
+		}
+		if held[string(d.remote)] > 0 {
+			held[string(d.remote)]--
+			continue
+		}
+		indexes = append(indexes, d.index)
+	}
+	for _, d := range diffs {
+		if d.local != nil && held[string(d.local)] > 0 {
+			held[string(d.local)]--
+			tx.Remove(d.index)
+			s.res.Removed++
+		}
+	}
+
+	cs, err := s.fetchLeaves(indexes, codec)
+	if err != nil {
+		return err
+	}
+	tx.Add(cs...)
+	s.res.Added += len(cs)
+	return nil
+}
@@ -0,0 +1,152 @@
+// Package treesync replicates a tree from a source to a replica by anti-entropy:
+// the replica compares its node hashes with those of the source top-down, one
+// level per round trip, descends only into subtrees whose hashes differ and
+// fetches the contents of the differing leaves alone. Bringing a replica up to
+// date therefore moves data in proportion to the change, not to the tree.
+//
+// Nodes are addressed by their position in the layout of MerkleTree.NodeHash.
+// How requests reach the source is up to a Transport: a Source answers them in
+// process, and Handler and NewHTTPTransport carry them over HTTP.
+package treesync
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/smartbch/merkletree"
+)
+
+// maxBatch bounds the positions of a single request.
+const maxBatch = 1 << 14
+
+// ErrRootChanged is returned by a Transport whose source no longer holds the
+// tree a request names. Syncing again starts over from the new tree.
+var ErrRootChanged = errors.New("error: source tree changed during sync")
+
+// Head describes the tree of a source.
+type Head struct {
+	Root      []byte
+	LeafCount int
+}
+
+// Position addresses node Index of Level, level 0 holding the leaves.
+type Position struct {
+	Level int `json:"level"`
+	Index int `json:"index"`
+}
+
+// Transport carries the requests of a replica to a source. The requests after
+// Head name the root it returned and fail with ErrRootChanged once the source
+// moved on.
+type Transport interface {
+	// Head returns the root and leaf count of the tree of the source.
+	Head(ctx context.Context) (*Head, error)
+	// Nodes returns the hashes of the nodes at positions.
+	Nodes(ctx context.Context, root []byte, positions []Position) ([][]byte, error)
+	// Leaves returns the encoded contents of the leaves at indexes.
+	Leaves(ctx context.Context, root []byte, indexes []int) ([][]byte, error)
+}
+
+// Codec turns the leaf contents of a tree into bytes for a Transport and back.
+type Codec interface {
+	Encode(c merkletree.Content) ([]byte, error)
+	Decode(b []byte) (merkletree.Content, error)
+}
+
+// BytesCodec is the Codec of trees over merkletree.ByteContent.
+var BytesCodec Codec = bytesCodec{}
+
+type bytesCodec struct{}
+
+func (bytesCodec) Encode(c merkletree.Content) ([]byte, error) {
+	b, ok := c.(merkletree.ByteContent)
+	if !ok {
+		return nil, fmt.Errorf("error: content of type %T is not ByteContent", c)
+	}
+	return b, nil
+}
+
+func (bytesCodec) Decode(b []byte) (merkletree.Content, error) {
+	return merkletree.ByteContent(append([]byte(nil), b...)), nil
+}
+
+// Source is the Transport of a frozen tree in process, which may be swapped for
+// a newer one while replicas sync from it.
+type Source struct {
+	tree  atomic.Pointer[merkletree.FrozenTree]
+	codec Codec
+}
+
+var _ Transport = (*Source)(nil)
+
+// NewSource returns a source of tree, whose contents codec encodes.
+func NewSource(tree *merkletree.FrozenTree, codec Codec) *Source {
+	s := &Source{codec: codec}
+	s.tree.Store(tree)
+	return s
+}
+
+// SetTree makes the source serve tree from now on. Replicas syncing from the
+// previous tree get ErrRootChanged.
+func (s *Source) SetTree(tree *merkletree.FrozenTree) {
+	s.tree.Store(tree)
+}
+
+// Head implements Transport.
+func (s *Source) Head(ctx context.Context) (*Head, error) {
+	tree := s.tree.Load()
+	return &Head{Root: tree.MerkleRoot(), LeafCount: tree.LeafCount()}, nil
+}
+
+// Nodes implements Transport.
+func (s *Source) Nodes(ctx context.Context, root []byte, positions []Position) ([][]byte, error) {
+	tree, err := s.treeWithRoot(root, len(positions))
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([][]byte, len(positions))
+	for k, p := range positions {
+		if hashes[k], err = tree.NodeHash(p.Level, p.Index); err != nil {
+			return nil, err
+		}
+	}
+	return hashes, nil
+}
+
+// Leaves implements Transport.
+func (s *Source) Leaves(ctx context.Context, root []byte, indexes []int) ([][]byte, error) {
+	tree, err := s.treeWithRoot(root, len(indexes))
+	if err != nil {
+		return nil, err
+	}
+	leaves := make([][]byte, len(indexes))
+	for k, i := range indexes {
+		if i < 0 || i >= tree.LeafCount() {
+			return nil, fmt.Errorf("error: leaf index %d out of range [0, %d)", i, tree.LeafCount())
+		}
+		c := tree.Content(i)
+		if c == nil {
+			return nil, fmt.Errorf("error: leaf %d has no content", i)
+		}
+		if leaves[k], err = s.codec.Encode(c); err != nil {
+			return nil, err
+		}
+	}
+	return leaves, nil
+}
+
+// treeWithRoot returns the current tree if its root is root, for a request of n
+// positions.
+func (s *Source) treeWithRoot(root []byte, n int) (*merkletree.FrozenTree, error) {
+	if n > maxBatch {
+		return nil, fmt.Errorf("error: %d positions exceed the limit of %d per request", n, maxBatch)
+	}
+	tree := s.tree.Load()
+	if !bytes.Equal(tree.MerkleRoot(), root) {
+		return nil, ErrRootChanged
+	}
+	return tree, nil
+}
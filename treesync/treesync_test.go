@@ -0,0 +1,172 @@
+package treesync
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/smartbch/merkletree"
+)
+
+func testContents(from, to int) []merkletree.Content {
+	var cs []merkletree.Content
+	for i := from; i < to; i++ {
+		cs = append(cs, merkletree.ByteContent(fmt.Sprintf("entry-%d", i)))
+	}
+	return cs
+}
+
+func newTree(t *testing.T, cs []merkletree.Content, opts []merkletree.Option) *merkletree.MerkleTree {
+	t.Helper()
+	tree, err := merkletree.NewTreeWithOptions(cs, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tree
+}
+
+func checkSynced(t *testing.T, replica *merkletree.MerkleTree, res *Result, source *merkletree.MerkleTree) {
+	t.Helper()
+	if !bytes.Equal(replica.MerkleRoot(), source.MerkleRoot()) || !bytes.Equal(res.Root, source.MerkleRoot()) {
+		t.Fatal("replica does not have the root of the source")
+	}
+	if ok, err := replica.VerifyTree(); err != nil || !ok {
+		t.Fatal("replica does not verify")
+	}
+}
+
+func Test_SyncOrdered(t *testing.T) {
+	layouts := [][]merkletree.Option{
+		{merkletree.WithInsertionOrder()},
+		{merkletree.WithInsertionOrder(), merkletree.WithOddNodePolicy(merkletree.DuplicateLast)},
+	}
+	for _, opts := range layouts {
+		cs := testContents(0, 200)
+		source := newTree(t, cs, opts)
+
+		// the replica lags behind and has a stale entry
+		stale := append([]merkletree.Content(nil), cs[:150]...)
+		stale[7] = merkletree.ByteContent("stale")
+		replica := newTree(t, stale, opts)
+		res, err := Sync(context.Background(), replica, NewSource(source.Freeze(), BytesCodec), BytesCodec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkSynced(t, replica, res, source)
+		if res.Added != 50 || res.Updated != 1 || res.Removed != 0 || res.Leaves != 51 {
+			t.Fatalf("unexpected result %+v", res)
+		}
+
+		// the replica is ahead of the source
+		replica = newTree(t, testContents(0, 230), opts)
+		res, err = Sync(context.Background(), replica, NewSource(source.Freeze(), BytesCodec), BytesCodec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkSynced(t, replica, res, source)
+		if res.Removed != 30 || res.Leaves != 0 {
+			t.Fatalf("unexpected result %+v", res)
+		}
+
+		// in sync already
+		res, err = Sync(context.Background(), replica, NewSource(source.Freeze(), BytesCodec), BytesCodec)
+		if err != nil || res.Rounds != 1 || res.Nodes != 0 {
+			t.Fatalf("expected a single round, got %+v", res)
+		}
+	}
+}
+
+func Test_SyncSorted(t *testing.T) {
+	cs := testContents(0, 300)
+	source := newTree(t, cs, nil)
+
+	replica := newTree(t, append(testContents(3, 290), testContents(1000, 1004)...), nil)
+	res, err := Sync(context.Background(), replica, NewSource(source.Freeze(), BytesCodec), BytesCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSynced(t, replica, res, source)
+	if res.Added != 13 || res.Removed != 4 || res.Leaves != 13 {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if index, err := replica.GetIndexOf(cs[0]); err != nil || replica.Leafs[index].C == nil {
+		t.Fatal("expected the synced content in the replica")
+	}
+}
+
+func Test_SyncEmpty(t *testing.T) {
+	opts := []merkletree.Option{merkletree.WithInsertionOrder(), merkletree.WithEmptyRoot(merkletree.ZeroRoot(32))}
+	source := newTree(t, testContents(0, 17), opts)
+	replica := newTree(t, nil, opts)
+	res, err := Sync(context.Background(), replica, NewSource(source.Freeze(), BytesCodec), BytesCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSynced(t, replica, res, source)
+
+	empty := newTree(t, nil, opts)
+	if _, err := Sync(context.Background(), replica, NewSource(empty.Freeze(), BytesCodec), BytesCodec); err != nil {
+		t.Fatal(err)
+	}
+	if len(replica.Leafs) != 0 || !bytes.Equal(replica.MerkleRoot(), merkletree.ZeroRoot(32)) {
+		t.Fatal("expected an empty replica")
+	}
+}
+
+func Test_SyncHTTP(t *testing.T) {
+	opts := []merkletree.Option{merkletree.WithInsertionOrder()}
+	source := newTree(t, testContents(0, 1000), opts)
+	srv := httptest.NewServer(Handler(NewSource(source.Freeze(), BytesCodec)))
+	defer srv.Close()
+
+	replica := newTree(t, testContents(0, 990), opts)
+	res, err := Sync(context.Background(), replica, NewHTTPTransport(srv.URL, srv.Client()), BytesCodec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSynced(t, replica, res, source)
+	if res.Leaves != 10 || res.Nodes > 4*11 {
+		t.Fatalf("expected only the new leaves to be transferred, got %+v", res)
+	}
+}
+
+// movingTransport swaps the tree of its source after answering the head.
+type movingTransport struct {
+	*Source
+	next *merkletree.FrozenTree
+}
+
+func (m *movingTransport) Head(ctx context.Context) (*Head, error) {
+	head, err := m.Source.Head(ctx)
+	m.SetTree(m.next)
+	return head, err
+}
+
+func Test_SyncRootChanged(t *testing.T) {
+	opts := []merkletree.Option{merkletree.WithInsertionOrder()}
+	source := newTree(t, testContents(0, 40), opts)
+	next := newTree(t, testContents(0, 41), opts)
+	replica := newTree(t, testContents(0, 30), opts)
+	root := replica.MerkleRoot()
+
+	src := NewSource(source.Freeze(), BytesCodec)
+	srv := httptest.NewServer(Handler(&movingTransport{Source: src, next: next.Freeze()}))
+	defer srv.Close()
+	if _, err := Sync(context.Background(), replica, NewHTTPTransport(srv.URL, nil), BytesCodec); err != ErrRootChanged {
+		t.Fatalf("expected ErrRootChanged, got %v", err)
+	}
+	if !bytes.Equal(replica.MerkleRoot(), root) {
+		t.Fatal("expected the replica to be unchanged")
+	}
+
+	// a source with other options cannot be matched
+	sorted := newTree(t, testContents(0, 40), nil)
+	if _, err := Sync(context.Background(), replica, NewSource(sorted.Freeze(), BytesCodec), BytesCodec); err == nil {
+		t.Fatal("expected error for a source with other options")
+	}
+	if !bytes.Equal(replica.MerkleRoot(), root) {
+		t.Fatal("expected the replica to be unchanged")
+	}
+}
@@ -0,0 +1,121 @@
+package merkletree
+
+import (
+	"errors"
+)
+
+// Tx stages changes to a tree so that the root they lead to can be looked at
+// before they are applied or dropped. Positions refer to the leaves of the tree
+// when the transaction began, as in ApplyBatch, and the tree may not be changed
+// by other means while the transaction is open.
+type Tx struct {
+	m        *MerkleTree
+	version  uint64
+	adds     []Content
+	updates  map[int]Content
+	removals []int
+	// preview is the root of the staged changes once computed
+	preview []byte
+	closed  bool
+}
+
+// parentLink is the parent and single flag a node had before a change that is
+// going to be undone, see Tx.PreviewRoot.
+type parentLink struct {
+	n      *Node
+	parent *Node
+	single bool
+}
+
+// Begin starts a transaction on the tree.
+func (m *MerkleTree) Begin() *Tx {
+	return &Tx{m: m, version: m.version, updates: make(map[int]Content)}
+}
+
+// Add stages the addition of cs.
+func (tx *Tx) Add(cs ...Content) {
+	tx.adds = append(tx.adds, cs...)
+	tx.preview = nil
+}
+
+// Update stages the replacement of the leaf at index with one holding c.
+func (tx *Tx) Update(index int, c Content) {
+	tx.updates[index] = c
+	tx.preview = nil
+}
+
+// Remove stages the removal of the leaf at index.
+func (tx *Tx) Remove(index int) {
+	tx.removals = append(tx.removals, index)
+	tx.preview = nil
+}
+
+// PreviewRoot returns the root the tree would have once the staged changes are
+// committed, leaving the tree as it is. The changes are applied as by Commit and
+// undone: the nodes they would modify are copied, as for a snapshot, and the
+// parent links they touch are put back.
+func (tx *Tx) PreviewRoot() ([]byte, error) {
+	if err := tx.check(); err != nil {
+		return nil, err
+	}
+	if tx.preview != nil {
+		return tx.preview, nil
+	}
+
+	m := tx.m
+	saved := *m
+	leafs := append([]*Node(nil), m.Leafs...)
+	m.epoch++
+	m.previewing = true
+	// the cache is shared with the saved tree, which the preview must not empty
+	m.proofCache = nil
+	err := m.ApplyBatch(tx.adds, tx.updates, tx.removals)
+	root := m.merkleRoot
+
+	for i := len(m.undo) - 1; i >= 0; i-- {
+		link := m.undo[i]
+		link.n.Parent, link.n.single = link.parent, link.single
+	}
+	*m = saved
+	m.Leafs = leafs
+	if err != nil {
+		return nil, err
+	}
+	tx.preview = root
+	return root, nil
+}
+
+// Commit applies the staged changes to the tree and closes the transaction. The
+// tree is left unchanged if they are invalid.
+func (tx *Tx) Commit() error {
+	if err := tx.check(); err != nil {
+		return err
+	}
+	if err := tx.m.ApplyBatch(tx.adds, tx.updates, tx.removals); err != nil {
+		return err
+	}
+	tx.closed = true
+	return nil
+}
+
+// Rollback drops the staged changes and closes the transaction.
+func (tx *Tx) Rollback() {
+	tx.closed = true
+}
+
+func (tx *Tx) check() error {
+	if tx.closed {
+		return errors.New("error: transaction is closed")
+	}
+	if tx.m.version != tx.version {
+		return errors.New("error: tree changed since the transaction began")
+	}
+	return nil
+}
+
+// keepLink records the parent link of n if the current change is to be undone.
+func (m *MerkleTree) keepLink(n *Node) {
+	if m.previewing {
+		m.undo = append(m.undo, parentLink{n: n, parent: n.Parent, single: n.single})
+	}
+}
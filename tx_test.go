@@ -0,0 +1,92 @@
+package merkletree
+
+import (
+	"bytes"
+	"testing"
+)
+
+func Test_Tx(t *testing.T) {
+	leaves := testLeaves(16)
+	for _, opts := range incrementalOptions {
+		tree, err := NewTreeWithOptions(leaves[:10], opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		tx := tree.Begin()
+		tx.Add(leaves[10], leaves[11])
+		tx.Update(2, leaves[12])
+		tx.Update(7, leaves[13])
+		tx.Remove(4)
+		preview, err := tx.PreviewRoot()
+		if err != nil {
+			t.Fatal(err)
+		}
+		// the preview leaves the tree as it was
+		checkSameTree(t, tree, leaves[:10], opts)
+		if again, _ := tx.PreviewRoot(); !bytes.Equal(again, preview) {
+			t.Fatal("expected the same preview twice")
+		}
+
+		if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(tree.MerkleRoot(), preview) {
+			t.Fatal("committed root differs from the preview")
+		}
+		if err := tx.Commit(); err == nil {
+			t.Fatal("expected error committing twice")
+		}
+
+		root := tree.MerkleRoot()
+		tx = tree.Begin()
+		tx.Add(leaves[14])
+		if _, err := tx.PreviewRoot(); err != nil {
+			t.Fatal(err)
+		}
+		tx.Rollback()
+		if _, err := tx.PreviewRoot(); err == nil {
+			t.Fatal("expected error using a rolled back transaction")
+		}
+		if !bytes.Equal(tree.MerkleRoot(), root) {
+			t.Fatal("rolled back transaction changed the tree")
+		}
+	}
+
+	tree, _ := NewTreeWithOptions(leaves[:4], WithInsertionOrder())
+	tx := tree.Begin()
+	tx.Update(1, leaves[5])
+	tx.Remove(1)
+	if _, err := tx.PreviewRoot(); err == nil {
+		t.Fatal("expected error for an invalid transaction")
+	}
+	if err := tx.Commit(); err == nil {
+		t.Fatal("expected error for an invalid transaction")
+	}
+	checkSameTree(t, tree, leaves[:4], []Option{WithInsertionOrder()})
+
+	tx = tree.Begin()
+	tx.Add(leaves[6])
+	if err := tree.AddLeaf(leaves[7]); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx.Commit(); err == nil {
+		t.Fatal("expected error after the tree changed")
+	}
+
+	// a preview rolled back keeps the cached proofs of the tree
+	tree, _ = NewTreeWithOptions(leaves[:8], WithProofCache())
+	if _, err := tree.GetProof(leaves[3]); err != nil {
+		t.Fatal(err)
+	}
+	tx = tree.Begin()
+	tx.Add(leaves[8])
+	tx.Update(1, leaves[9])
+	if _, err := tx.PreviewRoot(); err != nil {
+		t.Fatal(err)
+	}
+	tx.Rollback()
+	if len(tree.proofCache.entries) != 1 {
+		t.Fatal("expected the preview to leave the proof cache alone")
+	}
+}
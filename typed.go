@@ -0,0 +1,112 @@
+package merkletree
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Leaf is a leaf of a TypedTree: the item, its position in the tree and its leaf
+// hash.
+type Leaf[T any] struct {
+	Item  T
+	Index int
+	Hash  []byte
+}
+
+// TypedTree is a tree over items of type T, built with NewTreeOf. It adapts the
+// items to Content with the functions given there, so callers deal with their own
+// type only.
+type TypedTree[T any] struct {
+	tree   *MerkleTree
+	hashFn func(T) ([]byte, error)
+	eq     func(a, b T) bool
+}
+
+// typedContent adapts an item to Content.
+type typedContent[T any] struct {
+	item   T
+	hashFn func(T) ([]byte, error)
+	eq     func(a, b T) bool
+}
+
+func (c typedContent[T]) CalculateHash() ([]byte, error) {
+	return c.hashFn(c.item)
+}
+
+func (c typedContent[T]) Equals(other Content) (bool, error) {
+	o, ok := other.(typedContent[T])
+	return ok && c.eq(c.item, o.item), nil
+}
+
+// NewTreeOf builds a tree over items, hashing each with hashFn and comparing them
+// with eq. opts apply as in NewTreeWithOptions.
+func NewTreeOf[T any](items []T, hashFn func(T) ([]byte, error), eq func(a, b T) bool, opts ...Option) (*TypedTree[T], error) {
+	if hashFn == nil || eq == nil {
+		return nil, errors.New("error: typed trees need a hash and an equality function")
+	}
+
+	t := &TypedTree[T]{hashFn: hashFn, eq: eq}
+	cs := make([]Content, len(items))
+	for i, item := range items {
+		cs[i] = t.content(item)
+	}
+	tree, err := NewTreeWithOptions(cs, opts...)
+	if err != nil {
+		return nil, err
+	}
+	t.tree = tree
+	return t, nil
+}
+
+func (t *TypedTree[T]) content(item T) typedContent[T] {
+	return typedContent[T]{item: item, hashFn: t.hashFn, eq: t.eq}
+}
+
+// Tree returns the underlying tree.
+func (t *TypedTree[T]) Tree() *MerkleTree {
+	return t.tree
+}
+
+// MerkleRoot returns the root of the tree.
+func (t *TypedTree[T]) MerkleRoot() []byte {
+	return t.tree.MerkleRoot()
+}
+
+// Leaves returns the leaves in tree order.
+func (t *TypedTree[T]) Leaves() []Leaf[T] {
+	leaves := make([]Leaf[T], len(t.tree.Leafs))
+	for i, n := range t.tree.Leafs {
+		leaves[i] = Leaf[T]{Item: n.C.(typedContent[T]).item, Index: i, Hash: n.Hash}
+	}
+	return leaves
+}
+
+// LeafAt returns the leaf at position i.
+func (t *TypedTree[T]) LeafAt(i int) (Leaf[T], error) {
+	if i < 0 || i >= len(t.tree.Leafs) {
+		return Leaf[T]{}, fmt.Errorf("error: leaf index %d out of range [0, %d)", i, len(t.tree.Leafs))
+	}
+	n := t.tree.Leafs[i]
+	return Leaf[T]{Item: n.C.(typedContent[T]).item, Index: i, Hash: n.Hash}, nil
+}
+
+// Find returns the leaf holding item. It returns ErrContentNotFound if item is not
+// in the tree.
+func (t *TypedTree[T]) Find(item T) (Leaf[T], error) {
+	i, err := t.tree.GetIndexOf(t.content(item))
+	if err != nil {
+		return Leaf[T]{}, err
+	}
+	return t.LeafAt(i)
+}
+
+// GetProof returns the inclusion proof of item.
+func (t *TypedTree[T]) GetProof(item T) (*MerkleProof, error) {
+	return t.tree.GetProof(t.content(item))
+}
+
+// VerifyItem reports whether item is in the tree and its path hashes up to the
+// root.
+func (t *TypedTree[T]) VerifyItem(item T) (bool, error) {
+	return t.tree.VerifyContent(t.content(item))
+}
@@ -0,0 +1,63 @@
+package merkletree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	gethcrypto "github.com/ethereum/go-ethereum/crypto"
+)
+
+type account struct {
+	Name    string
+	Balance uint64
+}
+
+func Test_NewTreeOf(t *testing.T) {
+	hashAccount := func(a account) ([]byte, error) {
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], a.Balance)
+		return gethcrypto.Keccak256([]byte(a.Name), b[:]), nil
+	}
+	eq := func(a, b account) bool { return a == b }
+
+	accounts := []account{{"alice", 10}, {"bob", 20}, {"carol", 30}, {"dave", 40}, {"erin", 50}}
+	tree, err := NewTreeOf(accounts, hashAccount, eq)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, a := range accounts {
+		leaf, err := tree.Find(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if leaf.Item != a {
+			t.Fatalf("found %v, want %v", leaf.Item, a)
+		}
+		h, _ := hashAccount(a)
+		if !bytes.Equal(leaf.Hash, h) || !bytes.Equal(tree.Leaves()[leaf.Index].Hash, h) {
+			t.Fatal("leaf hash does not match")
+		}
+
+		proof, err := tree.GetProof(a)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ok, err := proof.Verify(); err != nil || !ok {
+			t.Fatal("proof does not verify")
+		}
+		if ok, err := tree.VerifyItem(a); err != nil || !ok {
+			t.Fatal("item does not verify")
+		}
+	}
+
+	if _, err := tree.Find(account{"mallory", 10}); err != ErrContentNotFound {
+		t.Fatalf("got %v, want ErrContentNotFound", err)
+	}
+
+	ordered, _ := NewTreeOf(accounts, hashAccount, eq, WithInsertionOrder())
+	if leaf, _ := ordered.LeafAt(1); leaf.Item != accounts[1] {
+		t.Fatal("insertion order was not kept")
+	}
+}
@@ -0,0 +1,10 @@
+// Package verkle is an experimental Verkle tree: a 256-ary trie whose nodes commit
+// to their children with KZG polynomial commitments over the bn256 curve instead
+// of hashing them. A proof opens one commitment per level of the path of a key,
+// and every opening is a single curve point whatever the width of the node, where
+// a merkle proof of a 256-ary node would carry its 255 siblings.
+//
+// The package is built only with the verkle build tag, its format may change and
+// it has not been audited. Commitments need a trusted setup; NewInsecureSetup
+// derives one from a known secret and is only fit for tests and prototypes.
+package verkle
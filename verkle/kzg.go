@@ -0,0 +1,124 @@
+//go:build verkle
+
+package verkle
+
+import (
+	"errors"
+	"math/big"
+
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+)
+
+// Width is the number of children of a node, the number of evaluations of the
+// polynomial it commits to.
+const Width = 256
+
+// Setup is the reference string of the commitments: the Lagrange basis of the
+// evaluation domain 0, 1, ..., Width-1 at the secret point tau in G1, and tau in
+// G2 for verifiers.
+type Setup struct {
+	lagrange [Width]*bn256.G1
+	tauG2    *bn256.G2
+	// weights[i] is 1 / prod_{j != i} (i - j)
+	weights [Width]*big.Int
+}
+
+// NewInsecureSetup returns the setup of the secret tau. Anyone who knows tau can
+// open a commitment to any value, so tau must come from a trusted setup ceremony
+// and be destroyed for the tree to be sound.
+func NewInsecureSetup(tau *big.Int) (*Setup, error) {
+	tau = new(big.Int).Mod(tau, bn256.Order)
+	if tau.Cmp(big.NewInt(Width)) < 0 {
+		return nil, errors.New("error: tau must lie outside of the evaluation domain")
+	}
+
+	s := &Setup{tauG2: new(bn256.G2).ScalarBaseMult(tau)}
+	// L_i(tau) = A(tau) * weights[i] / (tau - i), with A(X) = prod_j (X - j)
+	a := big.NewInt(1)
+	for j := 0; j < Width; j++ {
+		a.Mul(a, new(big.Int).Sub(tau, big.NewInt(int64(j))))
+		a.Mod(a, bn256.Order)
+	}
+	for i := 0; i < Width; i++ {
+		d := big.NewInt(1)
+		for j := 0; j < Width; j++ {
+			if j != i {
+				d.Mul(d, big.NewInt(int64(i-j)))
+				d.Mod(d, bn256.Order)
+			}
+		}
+		s.weights[i] = d.ModInverse(d, bn256.Order)
+
+		l := new(big.Int).Sub(tau, big.NewInt(int64(i)))
+		l.ModInverse(l, bn256.Order)
+		l.Mul(l, a)
+		l.Mul(l, s.weights[i])
+		l.Mod(l, bn256.Order)
+		s.lagrange[i] = new(bn256.G1).ScalarBaseMult(l)
+	}
+	return s, nil
+}
+
+// commit returns the commitment to the polynomial taking the given values on the
+// domain, nil values standing for zero.
+func (s *Setup) commit(values *[Width]*big.Int) *bn256.G1 {
+	c := zeroG1()
+	for i, v := range values {
+		if v != nil && v.Sign() != 0 {
+			c.Add(c, new(bn256.G1).ScalarMult(s.lagrange[i], v))
+		}
+	}
+	return c
+}
+
+// open returns the proof that the polynomial taking the given values on the
+// domain takes values[z] at z: the commitment to the quotient
+// q(X) = (f(X) - f(z)) / (X - z).
+func (s *Setup) open(values *[Width]*big.Int, z int) *bn256.G1 {
+	var q [Width]*big.Int
+	at := func(i int) *big.Int {
+		if values[i] == nil {
+			return new(big.Int)
+		}
+		return values[i]
+	}
+
+	// q(i) = (f(i) - f(z)) / (i - z) off z, and q(z) = f'(z), which is
+	// -sum_{j != z} q(j) * weights[j] / weights[z]
+	fz := at(z)
+	qz := new(big.Int)
+	for i := 0; i < Width; i++ {
+		if i == z {
+			continue
+		}
+		d := new(big.Int).Sub(at(i), fz)
+		if d.Sign() == 0 {
+			continue
+		}
+		inv := new(big.Int).Mod(big.NewInt(int64(i-z)), bn256.Order)
+		q[i] = d.Mul(d, inv.ModInverse(inv, bn256.Order))
+		q[i].Mod(q[i], bn256.Order)
+		qz.Add(qz, new(big.Int).Mul(q[i], s.weights[i]))
+	}
+	qz.Neg(qz)
+	qz.Mul(qz, new(big.Int).ModInverse(s.weights[z], bn256.Order))
+	q[z] = qz.Mod(qz, bn256.Order)
+	return s.commit(&q)
+}
+
+// verify checks that proof opens the commitment c to y at z.
+func (s *Setup) verify(c *bn256.G1, z int, y *big.Int, proof *bn256.G1) bool {
+	// e(c - y*G1, G2) == e(proof, tau*G2 - z*G2)
+	lhs := new(bn256.G1).ScalarBaseMult(y)
+	lhs.Neg(lhs)
+	lhs.Add(lhs, c)
+	rhs := new(bn256.G2).ScalarBaseMult(big.NewInt(int64(z)))
+	rhs.Neg(rhs)
+	rhs.Add(rhs, s.tauG2)
+	negProof := new(bn256.G1).Neg(proof)
+	return bn256.PairingCheck([]*bn256.G1{lhs, negProof}, []*bn256.G2{new(bn256.G2).ScalarBaseMult(big.NewInt(1)), rhs})
+}
+
+func zeroG1() *bn256.G1 {
+	return new(bn256.G1).ScalarBaseMult(new(big.Int))
+}
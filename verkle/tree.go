@@ -0,0 +1,308 @@
+//go:build verkle
+
+package verkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"math/big"
+
+	bn256 "github.com/ethereum/go-ethereum/crypto/bn256/cloudflare"
+)
+
+// KeySize is the size of a key in bytes. Byte d of a key picks the child of the
+// node at depth d on its path.
+const KeySize = 32
+
+// Key is a key of the tree.
+type Key [KeySize]byte
+
+// ErrKeyNotFound is returned for keys the tree does not hold.
+var ErrKeyNotFound = errors.New("error: key not found")
+
+// Tree is a Verkle tree held in memory. A branch commits to the values of its
+// children: zero for an empty slot, sha256(key || sha256(value)) for a leaf and
+// the sha256 of the commitment of a branch, all taken modulo the group order. A
+// leaf sits right below the first branch at which its key parts from every other
+// key, so the shape of the tree only depends on its keys.
+type Tree struct {
+	setup *Setup
+	root  *branch
+	count int
+}
+
+type node interface{}
+
+type branch struct {
+	children   [Width]node
+	values     [Width]*big.Int
+	commitment *bn256.G1
+	// count is the number of children that are not empty
+	count int
+}
+
+type leaf struct {
+	key       Key
+	value     []byte
+	valueHash []byte
+}
+
+// Proof opens the commitments on the path of a key, the root first, each at the
+// byte of the key for its depth. The opening of the last branch shows the leaf of
+// the key, an empty slot, or for an absent key the leaf of another key given by
+// OtherKey and OtherValueHash.
+type Proof struct {
+	// Commitments holds the commitments of the branches below the root
+	Commitments [][]byte
+	Openings    [][]byte
+	// OtherKey and OtherValueHash describe the leaf found in place of an absent key
+	OtherKey       []byte
+	OtherValueHash []byte
+}
+
+// New returns an empty tree committing with setup.
+func New(setup *Setup) *Tree {
+	return &Tree{setup: setup, root: newBranch()}
+}
+
+func newBranch() *branch {
+	return &branch{commitment: zeroG1()}
+}
+
+// Root returns the commitment of the root, a marshalled bn256 G1 point.
+func (t *Tree) Root() []byte {
+	return t.root.commitment.Marshal()
+}
+
+// Len returns the number of keys in the tree.
+func (t *Tree) Len() int {
+	return t.count
+}
+
+// Get returns the value of key.
+func (t *Tree) Get(key Key) ([]byte, error) {
+	b := t.root
+	for depth := 0; depth < KeySize; depth++ {
+		switch c := b.children[key[depth]].(type) {
+		case *branch:
+			b = c
+			continue
+		case *leaf:
+			if c.key == key {
+				return append([]byte(nil), c.value...), nil
+			}
+		}
+		break
+	}
+	return nil, ErrKeyNotFound
+}
+
+// Set gives key the value value and updates the commitments on its path, with
+// one scalar multiplication for each.
+func (t *Tree) Set(key Key, value []byte) {
+	h := sha256.Sum256(value)
+	l := &leaf{key: key, value: append([]byte(nil), value...), valueHash: h[:]}
+
+	var path []*branch
+	var indexes []byte
+	b := t.root
+	for depth := 0; ; depth++ {
+		i := key[depth]
+		path, indexes = append(path, b), append(indexes, i)
+		switch c := b.children[i].(type) {
+		case *branch:
+			b = c
+			continue
+		case *leaf:
+			if c.key != key {
+				// push the other leaf down a level, its key shares this byte
+				nb := newBranch()
+				t.setChild(nb, c.key[depth+1], c)
+				b.children[i] = nb
+				b = nb
+				continue
+			}
+		case nil:
+			t.count++
+		}
+		t.setChild(b, i, l)
+		break
+	}
+	t.updatePath(path, indexes, len(path)-2)
+}
+
+// Delete removes key from the tree. A branch left with a single leaf hands it to
+// its parent, so the tree keeps the shape of its keys.
+func (t *Tree) Delete(key Key) error {
+	var path []*branch
+	var indexes []byte
+	b := t.root
+	for depth := 0; ; depth++ {
+		i := key[depth]
+		path, indexes = append(path, b), append(indexes, i)
+		c, ok := b.children[i].(*branch)
+		if !ok {
+			if l, ok := b.children[i].(*leaf); !ok || l.key != key {
+				return ErrKeyNotFound
+			}
+			break
+		}
+		b = c
+	}
+
+	k := len(path) - 1
+	t.setChild(path[k], indexes[k], nil)
+	for ; k > 0 && path[k].count == 1; k-- {
+		var only node
+		for _, c := range path[k].children {
+			if c != nil {
+				only = c
+				break
+			}
+		}
+		l, ok := only.(*leaf)
+		if !ok {
+			break
+		}
+		t.setChild(path[k-1], indexes[k-1], l)
+	}
+	t.updatePath(path, indexes, k-1)
+	t.count--
+	return nil
+}
+
+// Prove returns the proof of key, which shows its value if the tree holds key and
+// its absence otherwise, see VerifyInclusion and VerifyNonInclusion.
+func (t *Tree) Prove(key Key) *Proof {
+	p := &Proof{}
+	b := t.root
+	for depth := 0; ; depth++ {
+		i := key[depth]
+		p.Openings = append(p.Openings, t.setup.open(&b.values, int(i)).Marshal())
+		switch c := b.children[i].(type) {
+		case *branch:
+			p.Commitments = append(p.Commitments, c.commitment.Marshal())
+			b = c
+			continue
+		case *leaf:
+			if c.key != key {
+				p.OtherKey = append([]byte(nil), c.key[:]...)
+				p.OtherValueHash = c.valueHash
+			}
+		}
+		return p
+	}
+}
+
+// VerifyInclusion checks that p shows key holding value in the tree with the
+// given root.
+func VerifyInclusion(setup *Setup, root []byte, key Key, value []byte, p *Proof) (bool, error) {
+	if p.OtherKey != nil {
+		return false, nil
+	}
+	h := sha256.Sum256(value)
+	return verify(setup, root, key, leafValue(key[:], h[:]), p)
+}
+
+// VerifyNonInclusion checks that p shows key absent from the tree with the given
+// root.
+func VerifyNonInclusion(setup *Setup, root []byte, key Key, p *Proof) (bool, error) {
+	if p.OtherKey == nil {
+		return verify(setup, root, key, new(big.Int), p)
+	}
+	depth := len(p.Openings)
+	if len(p.OtherKey) != KeySize || depth > KeySize || bytes.Equal(p.OtherKey, key[:]) ||
+		!bytes.Equal(p.OtherKey[:depth], key[:depth]) {
+		return false, nil
+	}
+	return verify(setup, root, key, leafValue(p.OtherKey, p.OtherValueHash), p)
+}
+
+// verify checks that the openings of p lead from root down the path of key to a
+// slot holding last.
+func verify(setup *Setup, root []byte, key Key, last *big.Int, p *Proof) (bool, error) {
+	if len(p.Openings) == 0 || len(p.Openings) > KeySize || len(p.Commitments) != len(p.Openings)-1 {
+		return false, fmt.Errorf("error: proof with %d openings and %d commitments", len(p.Openings), len(p.Commitments))
+	}
+	c, err := unmarshalG1(root)
+	if err != nil {
+		return false, err
+	}
+	for depth, enc := range p.Openings {
+		opening, err := unmarshalG1(enc)
+		if err != nil {
+			return false, err
+		}
+		y := last
+		if depth < len(p.Commitments) {
+			y = scalar(p.Commitments[depth])
+		}
+		if !setup.verify(c, int(key[depth]), y, opening) {
+			return false, nil
+		}
+		if depth < len(p.Commitments) {
+			if c, err = unmarshalG1(p.Commitments[depth]); err != nil {
+				return false, err
+			}
+		}
+	}
+	return true, nil
+}
+
+// updatePath brings the values of the children on path up to date, from the
+// branch at from up to the root.
+func (t *Tree) updatePath(path []*branch, indexes []byte, from int) {
+	for k := from; k >= 0; k-- {
+		t.setChild(path[k], indexes[k], path[k].children[indexes[k]])
+	}
+}
+
+// setChild puts child in slot i of b and moves the commitment of b by the change
+// of the value of the slot.
+func (t *Tree) setChild(b *branch, i byte, child node) {
+	if b.children[i] == nil && child != nil {
+		b.count++
+	} else if b.children[i] != nil && child == nil {
+		b.count--
+	}
+	b.children[i] = child
+
+	var v *big.Int
+	switch c := child.(type) {
+	case nil:
+		v = new(big.Int)
+	case *leaf:
+		v = leafValue(c.key[:], c.valueHash)
+	case *branch:
+		v = scalar(c.commitment.Marshal())
+	}
+	delta := new(big.Int).Set(v)
+	if old := b.values[i]; old != nil {
+		delta.Sub(delta, old)
+	}
+	if delta.Mod(delta, bn256.Order).Sign() != 0 {
+		b.commitment.Add(b.commitment, new(bn256.G1).ScalarMult(t.setup.lagrange[i], delta))
+	}
+	b.values[i] = v
+}
+
+func leafValue(key, valueHash []byte) *big.Int {
+	return scalar(append(append([]byte(nil), key...), valueHash...))
+}
+
+// scalar maps data to a field element by hashing it.
+func scalar(data []byte) *big.Int {
+	h := sha256.Sum256(data)
+	v := new(big.Int).SetBytes(h[:])
+	return v.Mod(v, bn256.Order)
+}
+
+func unmarshalG1(data []byte) (*bn256.G1, error) {
+	p := new(bn256.G1)
+	if _, err := p.Unmarshal(data); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,141 @@
+//go:build verkle
+
+package verkle
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+var testSetup *Setup
+
+func setup(t *testing.T) *Setup {
+	if testSetup == nil {
+		s, err := NewInsecureSetup(big.NewInt(0).SetBytes([]byte("verkle test secret")))
+		if err != nil {
+			t.Fatal(err)
+		}
+		testSetup = s
+	}
+	return testSetup
+}
+
+func testKey(i int) Key {
+	return sha256.Sum256([]byte(fmt.Sprintf("key-%d", i)))
+}
+
+func Test_SetGetDelete(t *testing.T) {
+	s := setup(t)
+	tree := New(s)
+	empty := tree.Root()
+
+	keys := []Key{testKey(0), testKey(1), testKey(2), testKey(3)}
+	// keys sharing their first bytes nest branches
+	k := testKey(0)
+	k[KeySize-1] ^= 1
+	keys = append(keys, k)
+	k[1] ^= 1
+	keys = append(keys, k)
+	for i, k := range keys {
+		tree.Set(k, []byte(fmt.Sprintf("value-%d", i)))
+	}
+	if tree.Len() != len(keys) {
+		t.Fatalf("expected %d keys, got %d", len(keys), tree.Len())
+	}
+	for i, k := range keys {
+		value, err := tree.Get(k)
+		if err != nil || string(value) != fmt.Sprintf("value-%d", i) {
+			t.Fatal("expected the value of the key")
+		}
+	}
+	if _, err := tree.Get(testKey(4)); err != ErrKeyNotFound {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+
+	// the root only depends on the contents
+	other := New(s)
+	for i := len(keys) - 1; i >= 0; i-- {
+		other.Set(keys[i], []byte("stale"))
+		other.Set(keys[i], []byte(fmt.Sprintf("value-%d", i)))
+	}
+	if !bytes.Equal(tree.Root(), other.Root()) {
+		t.Fatal("expected the same root for the same contents")
+	}
+	if err := other.Delete(keys[4]); err != nil {
+		t.Fatal(err)
+	}
+	want := New(s)
+	for i, k := range keys {
+		if i != 4 {
+			want.Set(k, []byte(fmt.Sprintf("value-%d", i)))
+		}
+	}
+	if !bytes.Equal(other.Root(), want.Root()) {
+		t.Fatal("expected a delete to give the root of a tree built without the key")
+	}
+
+	for _, k := range keys {
+		if err := tree.Delete(k); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if !bytes.Equal(tree.Root(), empty) || tree.Len() != 0 {
+		t.Fatal("expected deleting every key to empty the tree")
+	}
+	if err := tree.Delete(keys[0]); err != ErrKeyNotFound {
+		t.Fatalf("expected ErrKeyNotFound, got %v", err)
+	}
+}
+
+func Test_Proofs(t *testing.T) {
+	s := setup(t)
+	tree := New(s)
+	keys := []Key{testKey(0), testKey(1), testKey(2)}
+	k := testKey(0)
+	k[2] ^= 1
+	keys = append(keys, k)
+	for i, k := range keys {
+		tree.Set(k, []byte(fmt.Sprintf("value-%d", i)))
+	}
+	root := tree.Root()
+
+	for i, k := range keys {
+		p := tree.Prove(k)
+		value := []byte(fmt.Sprintf("value-%d", i))
+		if ok, err := VerifyInclusion(s, root, k, value, p); err != nil || !ok {
+			t.Fatal("expected the inclusion proof to verify")
+		}
+		if ok, _ := VerifyInclusion(s, root, k, []byte("other"), p); ok {
+			t.Fatal("expected a wrong value to fail")
+		}
+		if ok, _ := VerifyNonInclusion(s, root, k, p); ok {
+			t.Fatal("expected a present key to fail non-inclusion")
+		}
+	}
+	if p := tree.Prove(keys[3]); len(p.Openings) != 3 {
+		t.Fatalf("expected a nested key to open 3 branches, got %d", len(p.Openings))
+	}
+
+	// an empty slot and a slot holding another key
+	absent := testKey(3)
+	near := keys[1]
+	near[KeySize-1] ^= 1
+	for _, k := range []Key{absent, near} {
+		p := tree.Prove(k)
+		if ok, err := VerifyNonInclusion(s, root, k, p); err != nil || !ok {
+			t.Fatal("expected the non-inclusion proof to verify")
+		}
+		if ok, _ := VerifyInclusion(s, root, k, nil, p); ok {
+			t.Fatal("expected an absent key to fail inclusion")
+		}
+	}
+	if ok, _ := VerifyNonInclusion(s, root, near, tree.Prove(absent)); ok {
+		t.Fatal("expected the proof of another key to fail")
+	}
+	if _, err := VerifyInclusion(s, root, keys[0], nil, &Proof{}); err == nil {
+		t.Fatal("expected error for a proof without openings")
+	}
+}